@@ -5,21 +5,25 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	_ "github.com/libopenstorage/stork/drivers/volume/aws"
 	_ "github.com/libopenstorage/stork/drivers/volume/azure"
+	_ "github.com/libopenstorage/stork/drivers/volume/csi"
 	_ "github.com/libopenstorage/stork/drivers/volume/gcp"
 	_ "github.com/libopenstorage/stork/drivers/volume/portworx"
 	"github.com/libopenstorage/stork/pkg/applicationmanager"
 	"github.com/libopenstorage/stork/pkg/clusterdomains"
 	"github.com/libopenstorage/stork/pkg/controller"
+	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/dbg"
 	"github.com/libopenstorage/stork/pkg/extender"
 	"github.com/libopenstorage/stork/pkg/groupsnapshot"
 	"github.com/libopenstorage/stork/pkg/initializer"
 	"github.com/libopenstorage/stork/pkg/migration"
 	"github.com/libopenstorage/stork/pkg/monitor"
+	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/libopenstorage/stork/pkg/pvcwatcher"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
@@ -75,9 +79,9 @@ func main() {
 			Name:  "verbose",
 			Usage: "Enable verbose logging",
 		},
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:  "driver,d",
-			Usage: "Storage driver name",
+			Usage: "Storage driver name. Can be specified multiple times to use more than one driver",
 		},
 		cli.BoolTFlag{
 			Name:  "leader-elect",
@@ -101,6 +105,50 @@ func main() {
 			Name:  "extender",
 			Usage: "Enable scheduler extender for hyperconvergence (default: true)",
 		},
+		cli.StringFlag{
+			Name:  "extender-listen-address",
+			Value: ":8099",
+			Usage: "Address on which the scheduler extender listens for requests",
+		},
+		cli.StringFlag{
+			Name:  "extender-strategy",
+			Value: string(extender.PackStrategy),
+			Usage: "Strategy used by the scheduler extender to score nodes for pods (pack, spread or sizeWeighted)",
+		},
+		cli.IntFlag{
+			Name:  "extender-node-score",
+			Value: 100,
+			Usage: "Score for nodes that have data for a volume",
+		},
+		cli.IntFlag{
+			Name:  "extender-rack-score",
+			Value: 50,
+			Usage: "Score for nodes in the same rack as a node that has data for a volume",
+		},
+		cli.IntFlag{
+			Name:  "extender-zone-score",
+			Value: 25,
+			Usage: "Score for nodes in the same zone as a node that has data for a volume",
+		},
+		cli.IntFlag{
+			Name:  "extender-region-score",
+			Value: 10,
+			Usage: "Score for nodes in the same region as a node that has data for a volume",
+		},
+		cli.IntFlag{
+			Name:  "extender-default-score",
+			Value: 5,
+			Usage: "Score for nodes that don't have data for any volume",
+		},
+		cli.DurationFlag{
+			Name:  "extender-node-cache-ttl",
+			Value: 10 * time.Second,
+			Usage: "Time for which the scheduler extender caches the nodes returned by the storage drivers (negative to disable)",
+		},
+		cli.StringFlag{
+			Name:  "extender-config-map",
+			Usage: "Name of the config map in the admin namespace used to update the scheduler extender config at runtime",
+		},
 		cli.BoolTFlag{
 			Name:  "health-monitor",
 			Usage: "Enable health monitoring of the storage driver (default: true)",
@@ -110,14 +158,54 @@ func main() {
 			Value: 120,
 			Usage: "The interval in seconds to monitor the health of the storage driver (min: 30)",
 		},
+		cli.DurationFlag{
+			Name:  "health-monitor-grace-period",
+			Usage: "Time for which a storage node has to be failing before pods on it are fenced by the health monitor",
+		},
+		cli.IntFlag{
+			Name:  "health-monitor-failure-threshold",
+			Value: 1,
+			Usage: "Number of consecutive checks for which a storage node has to be failing before pods on it are fenced by the health monitor",
+		},
+		cli.IntFlag{
+			Name:  "health-monitor-max-evictions",
+			Usage: "Maximum number of pods deleted by the health monitor in one interval (default: no limit)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-ignore-degraded",
+			Usage: "Don't fence pods on storage nodes that are degraded (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "health-monitor-dry-run",
+			Usage: "Only record events for the pods and volume attachments that the health monitor would delete (default: false)",
+		},
+		cli.StringFlag{
+			Name:  "health-monitor-config-map",
+			Usage: "Name of the config map in the admin namespace used to update the health monitor fencing policy at runtime",
+		},
 		cli.BoolTFlag{
 			Name:  "migration-controller",
 			Usage: "Start the migration controller (default: true)",
 		},
+		cli.IntFlag{
+			Name:  "max-concurrent-migrations",
+			Value: 0,
+			Usage: "Maximum number of migrations that can run at the same time, no limit if 0",
+		},
 		cli.BoolTFlag{
 			Name:  "application-controller",
 			Usage: "Start the controllers for managing applications (default: true)",
 		},
+		cli.StringFlag{
+			Name:   "file-key-provider-root",
+			EnvVar: crypto.FileKeyProviderRootEnv,
+			Usage:  "Directory that the key directories for BackupLocations using the file key provider need to be under. The file key provider is disabled if it isn't set",
+		},
+		cli.StringFlag{
+			Name:   "file-backup-location-root",
+			EnvVar: objectstore.FileBackupLocationRootEnv,
+			Usage:  "Directory that the paths for filesystem BackupLocations need to be under. Filesystem BackupLocations are disabled if it isn't set",
+		},
 		cli.BoolFlag{
 			Name:  "app-initializer",
 			Usage: "EXPERIMENTAL: Enable application initializer to update scheduler name automatically (default: false)",
@@ -151,12 +239,14 @@ func run(c *cli.Context) {
 	dbg.Init(c.App.Name, debugFilePath)
 
 	log.Infof("Starting stork version %v", version.Version)
-	driverName := c.String("driver")
+	driverNames := c.StringSlice("driver")
 
 	verbose := c.Bool("verbose")
 	if verbose {
 		log.SetLevel(log.DebugLevel)
 	}
+	crypto.SetFileKeyProviderRoot(c.String("file-key-provider-root"))
+	objectstore.SetFileBackupLocationRoot(c.String("file-backup-location-root"))
 
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -172,9 +262,9 @@ func run(c *cli.Context) {
 	eventBroadcaster.StartRecordingToSink(&core_v1.EventSinkImpl{Interface: core_v1.New(k8sClient.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(legacyscheme.Scheme, api_v1.EventSource{Component: eventComponentName})
 
-	var d volume.Driver
-	if driverName != "" {
-		d, err = volume.Get(driverName)
+	drivers := make([]volume.Driver, 0)
+	for _, driverName := range driverNames {
+		d, err := volume.Get(driverName)
 		if err != nil {
 			log.Fatalf("Error getting Stork Driver %v: %v", driverName, err)
 		}
@@ -182,11 +272,27 @@ func run(c *cli.Context) {
 		if err = d.Init(nil); err != nil {
 			log.Fatalf("Error initializing Stork Driver %v: %v", driverName, err)
 		}
+		drivers = append(drivers, d)
+	}
 
+	if len(drivers) != 0 {
 		if c.Bool("extender") {
 			ext = &extender.Extender{
-				Driver:   d,
-				Recorder: recorder,
+				Drivers:    drivers,
+				Recorder:   recorder,
+				KubeClient: k8sClient,
+				Config: extender.Config{
+					ListenAddress:       c.String("extender-listen-address"),
+					Strategy:            extender.ScoringStrategy(c.String("extender-strategy")),
+					NodePriorityScore:   extender.Score(c.Int("extender-node-score")),
+					RackPriorityScore:   extender.Score(c.Int("extender-rack-score")),
+					ZonePriorityScore:   extender.Score(c.Int("extender-zone-score")),
+					RegionPriorityScore: extender.Score(c.Int("extender-region-score")),
+					DefaultScore:        extender.Score(c.Int("extender-default-score")),
+					NodeCacheTTL:        c.Duration("extender-node-cache-ttl"),
+				},
+				ConfigMapName:      c.String("extender-config-map"),
+				ConfigMapNamespace: getAdminNamespace(c),
 			}
 
 			if err = ext.Start(); err != nil {
@@ -195,7 +301,7 @@ func run(c *cli.Context) {
 		}
 	}
 	webhook = &webhookadmission.Controller{
-		Driver:   d,
+		Drivers:  drivers,
 		Recorder: recorder,
 	}
 	if err := webhook.Start(); err != nil {
@@ -203,7 +309,7 @@ func run(c *cli.Context) {
 	}
 
 	runFunc := func(_ <-chan struct{}) {
-		runStork(d, recorder, c)
+		runStork(drivers, recorder, k8sClient, c)
 	}
 
 	if c.BoolT("leader-elect") {
@@ -258,7 +364,22 @@ func run(c *cli.Context) {
 	}
 }
 
-func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
+// getAdminNamespace returns the admin namespace, falling back to the
+// deprecated migration admin namespace if it isn't set
+func getAdminNamespace(c *cli.Context) string {
+	adminNamespace := c.String("admin-namespace")
+	if adminNamespace == "" {
+		adminNamespace = c.String("migration-admin-namespace")
+	}
+	return adminNamespace
+}
+
+func runStork(
+	drivers []volume.Driver,
+	recorder record.EventRecorder,
+	k8sClient clientset.Interface,
+	c *cli.Context,
+) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	if err := controller.Init(); err != nil {
@@ -270,31 +391,42 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 	}
 
 	resourceCollector := resourcecollector.ResourceCollector{
-		Driver: d,
+		Drivers: drivers,
 	}
 	if err := resourceCollector.Init(nil); err != nil {
 		log.Fatalf("Error initializing ResourceCollector: %v", err)
 	}
-	adminNamespace := c.String("admin-namespace")
-	if adminNamespace == "" {
-		adminNamespace = c.String("migration-admin-namespace")
+	if err := resourceCollector.CreateCRD(); err != nil {
+		log.Fatalf("Error creating CRD for ResourceCollectorConfig: %v", err)
 	}
+	adminNamespace := getAdminNamespace(c)
 
 	initializer := &initializer.Initializer{
-		Driver: d,
+		Drivers: drivers,
 	}
 	monitor := &monitor.Monitor{
-		Driver:      d,
+		Drivers:     drivers,
 		IntervalSec: c.Int64("health-monitor-interval"),
+		Recorder:    recorder,
+		Policy: monitor.FencingPolicy{
+			GracePeriod:             c.Duration("health-monitor-grace-period"),
+			FailureThreshold:        c.Int("health-monitor-failure-threshold"),
+			MaxEvictionsPerInterval: c.Int("health-monitor-max-evictions"),
+			IgnoreDegradedNodes:     c.Bool("health-monitor-ignore-degraded"),
+			DryRun:                  c.Bool("health-monitor-dry-run"),
+		},
+		ConfigMapName:      c.String("health-monitor-config-map"),
+		ConfigMapNamespace: adminNamespace,
+		KubeClient:         k8sClient,
 	}
 	snapshot := &snapshot.Snapshot{
-		Driver:   d,
+		Drivers:  drivers,
 		Recorder: recorder,
 	}
 	if err := schedule.Init(); err != nil {
 		log.Fatalf("Error initializing schedule: %v", err)
 	}
-	if d != nil {
+	if len(drivers) != 0 {
 		if c.Bool("app-initializer") {
 			if err := initializer.Start(); err != nil {
 				log.Fatalf("Error starting initializer: %v", err)
@@ -313,7 +445,7 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 			}
 
 			groupsnapshotInst := groupsnapshot.GroupSnapshot{
-				Driver:   d,
+				Drivers:  drivers,
 				Recorder: recorder,
 			}
 			if err := groupsnapshotInst.Init(); err != nil {
@@ -321,7 +453,7 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 			}
 		}
 		pvcWatcher := pvcwatcher.PVCWatcher{
-			Driver:   d,
+			Drivers:  drivers,
 			Recorder: recorder,
 		}
 		if c.Bool("pvc-watcher") {
@@ -332,9 +464,10 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 
 		if c.Bool("migration-controller") {
 			migration := migration.Migration{
-				Driver:            d,
-				Recorder:          recorder,
-				ResourceCollector: resourceCollector,
+				Drivers:                 drivers,
+				Recorder:                recorder,
+				ResourceCollector:       resourceCollector,
+				MaxConcurrentMigrations: c.Int("max-concurrent-migrations"),
 			}
 			if err := migration.Init(adminNamespace); err != nil {
 				log.Fatalf("Error initializing migration: %v", err)
@@ -342,8 +475,9 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 		}
 
 		if c.Bool("cluster-domain-controllers") {
+			// Cluster domains are only managed by the primary driver
 			clusterDomains := clusterdomains.ClusterDomains{
-				Driver:   d,
+				Driver:   drivers[0],
 				Recorder: recorder,
 			}
 			if err := clusterDomains.Init(); err != nil {
@@ -354,7 +488,7 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 
 	if c.Bool("application-controller") {
 		appManager := applicationmanager.ApplicationManager{
-			Driver:            d,
+			Drivers:           drivers,
 			Recorder:          recorder,
 			ResourceCollector: resourceCollector,
 		}
@@ -391,8 +525,10 @@ func runStork(d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
 				log.Warnf("Error stopping app-initializer: %v", err)
 			}
 		}
-		if err := d.Stop(); err != nil {
-			log.Warnf("Error stopping driver: %v", err)
+		for _, d := range drivers {
+			if err := d.Stop(); err != nil {
+				log.Warnf("Error stopping driver %v: %v", d.String(), err)
+			}
 		}
 		if err := webhook.Stop(); err != nil {
 			log.Warnf("error stopping webhook controller %v", err)
@@ -43,10 +43,12 @@ type aws struct {
 	client *ec2.EC2
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
+	storkvolume.MigrateVolumesNotSupported
 	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.NodeStatusWatchNotSupported
 }
 
 func (a *aws) Init(_ interface{}) error {
@@ -471,11 +473,11 @@ func (a *aws) GetClusterID() (string, error) {
 }
 
 func (a *aws) GetNodes() ([]*storkvolume.NodeInfo, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetNodesFromLabels()
 }
 
 func (a *aws) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetPodVolumesWithTopology(a, podSpec, namespace)
 }
 
 func (a *aws) GetSnapshotPlugin() snapshotVolume.Plugin {
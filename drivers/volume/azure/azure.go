@@ -49,10 +49,12 @@ type azure struct {
 	snapshotClient compute.SnapshotsClient
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
+	storkvolume.MigrateVolumesNotSupported
 	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.NodeStatusWatchNotSupported
 }
 
 func (a *azure) Init(_ interface{}) error {
@@ -406,11 +408,11 @@ func (a *azure) GetClusterID() (string, error) {
 }
 
 func (a *azure) GetNodes() ([]*storkvolume.NodeInfo, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetNodesFromLabels()
 }
 
 func (a *azure) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetPodVolumesWithTopology(a, podSpec, namespace)
 }
 
 func (a *azure) GetSnapshotPlugin() snapshotVolume.Plugin {
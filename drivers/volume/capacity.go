@@ -0,0 +1,74 @@
+package volume
+
+import (
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+)
+
+// IsPVCWaitingForFirstConsumer returns true if the PVC hasn't been bound yet
+// and uses a storage class with the WaitForFirstConsumer binding mode. These
+// PVCs are only provisioned once a pod using them has been scheduled.
+func IsPVCWaitingForFirstConsumer(pvc *v1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.VolumeName != "" || pvc.Status.Phase == v1.ClaimBound {
+		return false, nil
+	}
+	storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+	if storageClassName == "" {
+		return false, nil
+	}
+	storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+	if err != nil {
+		return false, err
+	}
+	return storageClass.VolumeBindingMode != nil &&
+		*storageClass.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer, nil
+}
+
+// GetWaitForFirstConsumerPVCs returns the PVCs used by the pod that are owned
+// by the driver and will only be provisioned once the pod has been scheduled
+func GetWaitForFirstConsumerPVCs(driver Driver, podSpec *v1.PodSpec, namespace string) ([]*v1.PersistentVolumeClaim, error) {
+	var pvcs []*v1.PersistentVolumeClaim
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !driver.OwnsPVC(pvc) {
+			continue
+		}
+		waiting, err := IsPVCWaitingForFirstConsumer(pvc)
+		if err != nil {
+			return nil, err
+		}
+		if waiting {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+// GetRequestedCapacity returns the total storage in bytes requested by the
+// PVCs
+func GetRequestedCapacity(pvcs []*v1.PersistentVolumeClaim) uint64 {
+	var requested uint64
+	for _, pvc := range pvcs {
+		if size, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+			requested += uint64(size.Value())
+		}
+	}
+	return requested
+}
+
+// HasCapacity returns true if the node has enough free capacity for the
+// requested size in bytes. Nodes for which the driver doesn't report the
+// capacity are assumed to have enough capacity.
+func (n *NodeInfo) HasCapacity(requested uint64) bool {
+	return n.TotalCapacity == 0 || n.FreeCapacity >= requested
+}
@@ -0,0 +1,837 @@
+package csi
+
+import (
+	"fmt"
+	"strings"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
+)
+
+const (
+	// driverName is the name of the csi driver implementation
+	driverName = "csi"
+	// pvcProvisionerAnnotation is the annotation on PVC which has the
+	// provisioner name
+	pvcProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+	// snapshotClassAnnotation can be set on ApplicationBackups and
+	// ApplicationClones to choose the VolumeSnapshotClass to be used. The
+	// default snapshot class is used if it isn't specified
+	snapshotClassAnnotation = "stork.libopenstorage.org/csiSnapshotClass"
+	// stagingPVCAnnotation is added to PVs provisioned by stork from a data
+	// source, pointing to the staging PVC that was used to provision them
+	stagingPVCAnnotation = "stork.libopenstorage.org/csiStagingPVC"
+	// storkSnapshotProvisionerName is the provisioner used by stork for
+	// restoring snapshots, it isn't a CSI driver
+	storkSnapshotProvisionerName = "stork-snapshot"
+	// inTreeProvisionerPrefix is the prefix used by all in-tree provisioners
+	inTreeProvisionerPrefix = "kubernetes.io/"
+
+	snapshotAPIGroup     = "snapshot.storage.k8s.io"
+	snapshotKind         = "VolumeSnapshot"
+	snapshotContentKind  = "VolumeSnapshotContent"
+	pvcKind              = "PersistentVolumeClaim"
+	snapshotNamePrefix   = "stork-backup-"
+	restorePVCNamePrefix = "stork-restore-"
+	clonePVCNamePrefix   = "stork-clone-"
+
+	// Options stored with the backup for every volume so that the volume
+	// can be provisioned again during restore
+	storageClassOption = "storageClassName"
+	sizeOption         = "size"
+	accessModesOption  = "accessModes"
+)
+
+var (
+	snapshotResource        = schema.GroupVersionResource{Group: snapshotAPIGroup, Version: "v1alpha1", Resource: "volumesnapshots"}
+	snapshotContentResource = schema.GroupVersionResource{Group: snapshotAPIGroup, Version: "v1alpha1", Resource: "volumesnapshotcontents"}
+	pvcResource             = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	pvResource              = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+)
+
+// stagingPVCError is returned when the volume for a staging PVC can't be
+// provisioned or released. Unlike other errors it won't go away on a retry.
+type stagingPVCError struct {
+	msg string
+}
+
+func (e *stagingPVCError) Error() string {
+	return e.msg
+}
+
+type csi struct {
+	dynamicInterface dynamic.Interface
+	storkvolume.ClusterPairNotSupported
+	storkvolume.MigrationNotSupported
+	storkvolume.MigrateVolumesNotSupported
+	storkvolume.GroupSnapshotNotSupported
+	storkvolume.ClusterDomainsNotSupported
+	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.NodeStatusWatchNotSupported
+}
+
+// Init initializes the driver. A dynamic interface can be passed in to be
+// used for the CSI snapshot and PVC objects, otherwise one is created from
+// the in-cluster config.
+func (c *csi) Init(config interface{}) error {
+	if dynamicInterface, ok := config.(dynamic.Interface); ok {
+		c.dynamicInterface = dynamicInterface
+		return nil
+	}
+
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("error getting cluster config: %v", err)
+	}
+	c.dynamicInterface, err = dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("error getting dynamic client: %v", err)
+	}
+	return nil
+}
+
+func (c *csi) String() string {
+	return driverName
+}
+
+func (c *csi) Stop() error {
+	return nil
+}
+
+func (c *csi) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	// The PV is the most reliable source of information if the PVC has
+	// already been bound
+	if pvc.Spec.VolumeName != "" {
+		pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err == nil {
+			return c.OwnsPV(pv)
+		}
+		logrus.Warnf("Error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+	}
+
+	provisioner := ""
+	// Check for the provisioner in the PVC annotation. If not populated
+	// try getting the provisioner from the Storage class.
+	if val, ok := pvc.Annotations[pvcProvisionerAnnotation]; ok {
+		provisioner = val
+	} else {
+		storageClassName := k8shelper.GetPersistentVolumeClaimClass(pvc)
+		if storageClassName != "" {
+			storageClass, err := k8s.Instance().GetStorageClass(storageClassName)
+			if err == nil {
+				provisioner = storageClass.Provisioner
+			} else {
+				logrus.Warnf("Error getting storageclass %v for pvc %v: %v", storageClassName, pvc.Name, err)
+			}
+		}
+	}
+
+	if !isCsiProvisioner(provisioner) {
+		logrus.Debugf("Provisioner in Storageclass not CSI: %v", provisioner)
+		return false
+	}
+	return true
+}
+
+func (c *csi) OwnsPV(pv *v1.PersistentVolume) bool {
+	if pv.Spec.CSI == nil {
+		return false
+	}
+	// Portworx CSI volumes are managed by the portworx driver
+	return !isPortworxProvisioner(pv.Spec.CSI.Driver)
+}
+
+// isCsiProvisioner returns true for provisioners that are expected to be CSI
+// drivers. In-tree provisioners, stork's own snapshot provisioner and
+// provisioners handled by other stork drivers are skipped.
+func isCsiProvisioner(provisioner string) bool {
+	return provisioner != "" &&
+		!strings.HasPrefix(provisioner, inTreeProvisionerPrefix) &&
+		provisioner != storkSnapshotProvisionerName &&
+		!isPortworxProvisioner(provisioner)
+}
+
+func isPortworxProvisioner(provisioner string) bool {
+	return provisioner == snapv1.PortworxCsiProvisionerName ||
+		provisioner == snapv1.PortworxCsiDeprecatedProvisionerName
+}
+
+func (c *csi) InspectVolume(volumeID string) (*storkvolume.Info, error) {
+	pv, err := k8s.Instance().GetPersistentVolume(volumeID)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, &errors.ErrNotFound{
+				ID:   volumeID,
+				Type: "Volume",
+			}
+		}
+		return nil, err
+	}
+	if !c.OwnsPV(pv) {
+		return nil, &errors.ErrNotFound{
+			ID:   volumeID,
+			Type: "Volume",
+		}
+	}
+
+	info := &storkvolume.Info{
+		VolumeID:   pv.Spec.CSI.VolumeHandle,
+		VolumeName: pv.Name,
+		Labels:     pv.Labels,
+	}
+	if size, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+		info.Size = uint64(size.Value()) / (1024 * 1024 * 1024)
+	}
+	return info, nil
+}
+
+func (c *csi) GetClusterID() (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func (c *csi) GetNodes() ([]*storkvolume.NodeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+func (c *csi) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+func (c *csi) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return nil
+}
+
+func (c *csi) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	return "", &errors.ErrNotSupported{}
+}
+
+func (c *csi) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	var csiTemplates []v1.PersistentVolumeClaim
+	for _, t := range templates {
+		if c.OwnsPVC(&t) {
+			csiTemplates = append(csiTemplates, t)
+		}
+	}
+	return csiTemplates, nil
+}
+
+// UpdateMigratedPersistentVolumeSpec points the PV to the volume that was
+// provisioned by stork for it. The volume handle is picked up from the PV that
+// was pre-bound when the volume was restored or cloned.
+func (c *csi) UpdateMigratedPersistentVolumeSpec(
+	pv *v1.PersistentVolume,
+) (*v1.PersistentVolume, error) {
+	existingPV, err := k8s.Instance().GetPersistentVolume(pv.Name)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return pv, nil
+		}
+		return nil, err
+	}
+	if existingPV.Spec.CSI != nil {
+		pv.Spec.CSI = existingPV.Spec.CSI
+		pv.Spec.NodeAffinity = existingPV.Spec.NodeAffinity
+	}
+	return pv, nil
+}
+
+func (c *csi) StartBackup(backup *storkapi.ApplicationBackup,
+	pvcs []v1.PersistentVolumeClaim,
+) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
+	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
+
+	for _, pvc := range pvcs {
+		if pvc.DeletionTimestamp != nil {
+			log.ApplicationBackupLog(backup).Warnf("Ignoring PVC %v which is being deleted", pvc.Name)
+			continue
+		}
+		pvName, err := k8s.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
+		if err != nil {
+			return nil, fmt.Errorf("error getting PV name for PVC (%v/%v): %v", pvc.Namespace, pvc.Name, err)
+		}
+
+		volumeInfo := &storkapi.ApplicationBackupVolumeInfo{}
+		volumeInfo.PersistentVolumeClaim = pvc.Name
+		volumeInfo.Namespace = pvc.Namespace
+		volumeInfo.DriverName = driverName
+		volumeInfo.Volume = pvName
+		volumeInfo.Options = getPVCOptions(&pvc)
+		volumeInfo.BackupID = snapshotNamePrefix + string(uuid.NewUUID())
+
+		if err := c.createVolumeSnapshot(
+			volumeInfo.BackupID,
+			pvc.Namespace,
+			pvc.Name,
+			backup.Annotations[snapshotClassAnnotation],
+		); err != nil {
+			return nil, err
+		}
+		volumeInfos = append(volumeInfos, volumeInfo)
+	}
+	return volumeInfos, nil
+}
+
+func (c *csi) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
+	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
+
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
+		snapshot, err := c.dynamicInterface.Resource(snapshotResource).Namespace(vInfo.Namespace).Get(vInfo.BackupID, metav1.GetOptions{})
+		if err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, err
+			}
+			vInfo.Status = storkapi.ApplicationBackupStatusFailed
+			vInfo.Reason = fmt.Sprintf("VolumeSnapshot %v not found", vInfo.BackupID)
+			volumeInfos = append(volumeInfos, vInfo)
+			continue
+		}
+
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		errorMessage, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+		switch {
+		case ready:
+			vInfo.Status = storkapi.ApplicationBackupStatusSuccessful
+			vInfo.Reason = "Backup successful for volume"
+		case errorMessage != "":
+			vInfo.Status = storkapi.ApplicationBackupStatusFailed
+			vInfo.Reason = fmt.Sprintf("Backup failed for volume: %v", errorMessage)
+		default:
+			vInfo.Status = storkapi.ApplicationBackupStatusInProgress
+			vInfo.Reason = "Volume backup in progress"
+		}
+		volumeInfos = append(volumeInfos, vInfo)
+	}
+	return volumeInfos, nil
+}
+
+func (c *csi) CancelBackup(backup *storkapi.ApplicationBackup) error {
+	return c.DeleteBackup(backup)
+}
+
+func (c *csi) DeleteBackup(backup *storkapi.ApplicationBackup) error {
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
+		err := c.dynamicInterface.Resource(snapshotResource).Namespace(vInfo.Namespace).Delete(vInfo.BackupID, &metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// getRestoreNamespace returns the namespace that volumes from the given
+// namespace are restored to
+func getRestoreNamespace(restore *storkapi.ApplicationRestore, namespace string) string {
+	if restoreNamespace, ok := restore.Spec.NamespaceMapping[namespace]; ok && restoreNamespace != "" {
+		return restoreNamespace
+	}
+	return namespace
+}
+
+// StartRestore creates a staging PVC in the restore namespace from the
+// VolumeSnapshot of every volume. Until the volume has been provisioned the
+// name of the staging PVC is stored as the RestoreVolume, it is replaced by the
+// name of the provisioned PV once the PVC has been bound.
+func (c *csi) StartRestore(
+	restore *storkapi.ApplicationRestore,
+	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
+) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	for _, backupVolumeInfo := range volumeBackupInfos {
+		volumeInfo := &storkapi.ApplicationRestoreVolumeInfo{}
+		volumeInfo.PersistentVolumeClaim = backupVolumeInfo.PersistentVolumeClaim
+		volumeInfo.SourceNamespace = backupVolumeInfo.Namespace
+		volumeInfo.SourceVolume = backupVolumeInfo.Volume
+		volumeInfo.DriverName = driverName
+		volumeInfo.RestoreVolume = restorePVCNamePrefix + string(uuid.NewUUID())
+		volumeInfo.Status = storkapi.ApplicationRestoreStatusInProgress
+		volumeInfos = append(volumeInfos, volumeInfo)
+
+		if err := c.startVolumeRestore(restore, volumeInfo, backupVolumeInfo); err != nil {
+			// Clean up the volumes that were already started since the
+			// restore won't be tracking them
+			for _, vInfo := range volumeInfos {
+				if err := c.cleanupRestoreVolume(restore, vInfo); err != nil {
+					log.ApplicationRestoreLog(restore).Warnf("Error cleaning up restore of volume %v: %v", vInfo.SourceVolume, err)
+				}
+			}
+			return nil, err
+		}
+	}
+	return volumeInfos, nil
+}
+
+func (c *csi) startVolumeRestore(
+	restore *storkapi.ApplicationRestore,
+	volumeInfo *storkapi.ApplicationRestoreVolumeInfo,
+	backupVolumeInfo *storkapi.ApplicationBackupVolumeInfo,
+) error {
+	// The data source needs to be in the same namespace as the PVC, so the
+	// snapshot is copied to the restore namespace if required
+	namespace := getRestoreNamespace(restore, backupVolumeInfo.Namespace)
+	snapshotName := backupVolumeInfo.BackupID
+	if namespace != backupVolumeInfo.Namespace {
+		if err := c.copyVolumeSnapshot(
+			backupVolumeInfo.BackupID,
+			backupVolumeInfo.Namespace,
+			volumeInfo.RestoreVolume,
+			namespace,
+		); err != nil {
+			return err
+		}
+		snapshotName = volumeInfo.RestoreVolume
+	}
+
+	dataSource := map[string]interface{}{
+		"apiGroup": snapshotAPIGroup,
+		"kind":     snapshotKind,
+		"name":     snapshotName,
+	}
+	return c.createStagingPVC(
+		volumeInfo.RestoreVolume,
+		namespace,
+		backupVolumeInfo.Options,
+		dataSource,
+	)
+}
+
+// cleanupRestoreVolume deletes the staging PVC and the copy of the snapshot
+// created to restore a volume
+func (c *csi) cleanupRestoreVolume(
+	restore *storkapi.ApplicationRestore,
+	vInfo *storkapi.ApplicationRestoreVolumeInfo,
+) error {
+	namespace := getRestoreNamespace(restore, vInfo.SourceNamespace)
+	err := c.dynamicInterface.Resource(pvcResource).Namespace(namespace).Delete(vInfo.RestoreVolume, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting PVC %v/%v: %v", namespace, vInfo.RestoreVolume, err)
+	}
+	return c.deleteVolumeSnapshotCopy(vInfo.RestoreVolume, namespace)
+}
+
+func (c *csi) CancelRestore(restore *storkapi.ApplicationRestore) error {
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.DriverName != driverName ||
+			vInfo.Status == storkapi.ApplicationRestoreStatusSuccessful {
+			continue
+		}
+		if err := c.cleanupRestoreVolume(restore, vInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csi) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
+		if vInfo.Status == storkapi.ApplicationRestoreStatusSuccessful ||
+			vInfo.Status == storkapi.ApplicationRestoreStatusFailed {
+			volumeInfos = append(volumeInfos, vInfo)
+			continue
+		}
+
+		namespace := getRestoreNamespace(restore, vInfo.SourceNamespace)
+		claimRef := &v1.ObjectReference{
+			Kind:      pvcKind,
+			Namespace: namespace,
+			Name:      vInfo.PersistentVolumeClaim,
+		}
+		pvName, err := c.releaseStagingPVC(vInfo.RestoreVolume, namespace, claimRef)
+		if err != nil {
+			// Errors other than failures to provision the volume are
+			// returned so that the status is checked again
+			if _, ok := err.(*stagingPVCError); !ok {
+				return nil, err
+			}
+			if err := c.cleanupRestoreVolume(restore, vInfo); err != nil {
+				return nil, err
+			}
+			vInfo.Status = storkapi.ApplicationRestoreStatusFailed
+			vInfo.Reason = fmt.Sprintf("Restore failed for volume: %v", err)
+		} else if pvName == "" {
+			vInfo.Status = storkapi.ApplicationRestoreStatusInProgress
+			vInfo.Reason = "Volume restore in progress: waiting for volume to be provisioned"
+		} else {
+			if err := c.deleteVolumeSnapshotCopy(vInfo.RestoreVolume, namespace); err != nil {
+				return nil, err
+			}
+			vInfo.RestoreVolume = pvName
+			vInfo.Status = storkapi.ApplicationRestoreStatusSuccessful
+			vInfo.Reason = "Restore successful for volume"
+		}
+		volumeInfos = append(volumeInfos, vInfo)
+	}
+
+	return volumeInfos, nil
+}
+
+// CreateVolumeClones starts the clones of the volumes by creating a staging
+// PVC using the source PVC as the data source. The clones are completed in
+// GetCloneStatus.
+func (c *csi) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
+	for _, vInfo := range clone.Status.Volumes {
+		if vInfo.DriverName != driverName ||
+			vInfo.Status != storkapi.ApplicationCloneStatusInitial {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(vInfo.PersistentVolumeClaim, clone.Spec.SourceNamespace)
+		if err != nil {
+			return fmt.Errorf("error getting PVC %v/%v: %v", clone.Spec.SourceNamespace, vInfo.PersistentVolumeClaim, err)
+		}
+
+		dataSource := map[string]interface{}{
+			"kind": pvcKind,
+			"name": pvc.Name,
+		}
+		if err := c.createStagingPVC(
+			clonePVCNamePrefix+vInfo.CloneVolume,
+			clone.Spec.SourceNamespace,
+			getPVCOptions(pvc),
+			dataSource,
+		); err != nil {
+			return fmt.Errorf("error creating clone for volume %v: %v", vInfo.Volume, err)
+		}
+		vInfo.Status = storkapi.ApplicationCloneStatusInProgress
+		vInfo.Reason = "Volume clone in progress: waiting for volume to be provisioned"
+	}
+	return nil
+}
+
+// GetCloneStatus checks if the staging PVCs for the clones have been bound.
+// Once a clone has been provisioned the PV is pre-bound to the PVC in the
+// destination namespace.
+func (c *csi) GetCloneStatus(clone *storkapi.ApplicationClone) error {
+	for _, vInfo := range clone.Status.Volumes {
+		if vInfo.DriverName != driverName ||
+			vInfo.Status != storkapi.ApplicationCloneStatusInProgress {
+			continue
+		}
+		stagingPVCName := clonePVCNamePrefix + vInfo.CloneVolume
+		claimRef := &v1.ObjectReference{
+			Kind:      pvcKind,
+			Namespace: clone.Spec.DestinationNamespace,
+			Name:      vInfo.PersistentVolumeClaim,
+		}
+		pvName, err := c.releaseStagingPVC(stagingPVCName, clone.Spec.SourceNamespace, claimRef)
+		if err != nil {
+			if _, ok := err.(*stagingPVCError); !ok {
+				return err
+			}
+			deleteErr := c.dynamicInterface.Resource(pvcResource).Namespace(clone.Spec.SourceNamespace).Delete(stagingPVCName, &metav1.DeleteOptions{})
+			if deleteErr != nil && !k8serrors.IsNotFound(deleteErr) {
+				return fmt.Errorf("error deleting PVC %v/%v: %v", clone.Spec.SourceNamespace, stagingPVCName, deleteErr)
+			}
+			vInfo.Status = storkapi.ApplicationCloneStatusFailed
+			vInfo.Reason = fmt.Sprintf("Clone failed for volume: %v", err)
+		} else if pvName != "" {
+			vInfo.CloneVolume = pvName
+			vInfo.Status = storkapi.ApplicationCloneStatusSuccessful
+			vInfo.Reason = "Volume cloned succesfully"
+		}
+	}
+	return nil
+}
+
+// getPVCOptions returns the options required to provision a PVC like the
+// given one
+func getPVCOptions(pvc *v1.PersistentVolumeClaim) map[string]string {
+	accessModes := make([]string, 0)
+	for _, mode := range pvc.Spec.AccessModes {
+		accessModes = append(accessModes, string(mode))
+	}
+	options := map[string]string{
+		storageClassOption: k8shelper.GetPersistentVolumeClaimClass(pvc),
+		accessModesOption:  strings.Join(accessModes, ","),
+	}
+	if size, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+		options[sizeOption] = size.String()
+	}
+	return options
+}
+
+func (c *csi) createVolumeSnapshot(name, namespace, pvcName, snapshotClass string) error {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"kind": pvcKind,
+			"name": pvcName,
+		},
+	}
+	if snapshotClass != "" {
+		spec["snapshotClassName"] = snapshotClass
+	}
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": snapshotResource.GroupVersion().String(),
+			"kind":       snapshotKind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}
+	_, err := c.dynamicInterface.Resource(snapshotResource).Namespace(namespace).Create(snapshot)
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating VolumeSnapshot for PVC (%v/%v): %v", namespace, pvcName, err)
+	}
+	return nil
+}
+
+// copyVolumeSnapshot creates a copy of a VolumeSnapshot in another namespace.
+// A VolumeSnapshotContent is created for the copy pointing to the same CSI
+// snapshot. It retains the snapshot when deleted since the snapshot is still
+// owned by the original VolumeSnapshot.
+func (c *csi) copyVolumeSnapshot(name, namespace, copyName, copyNamespace string) error {
+	snapshot, err := c.dynamicInterface.Resource(snapshotResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting VolumeSnapshot %v/%v: %v", namespace, name, err)
+	}
+	contentName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "snapshotContentName")
+	if contentName == "" {
+		return fmt.Errorf("VolumeSnapshot %v/%v isn't bound to a VolumeSnapshotContent", namespace, name)
+	}
+	content, err := c.dynamicInterface.Resource(snapshotContentResource).Get(contentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting VolumeSnapshotContent %v: %v", contentName, err)
+	}
+	source, found, err := unstructured.NestedMap(content.Object, "spec", "csiVolumeSnapshotSource")
+	if err != nil || !found {
+		return fmt.Errorf("VolumeSnapshotContent %v doesn't have a CSI snapshot source", contentName)
+	}
+	snapshotClass, _, _ := unstructured.NestedString(snapshot.Object, "spec", "snapshotClassName")
+
+	contentSpec := map[string]interface{}{
+		"csiVolumeSnapshotSource": source,
+		"deletionPolicy":          "Retain",
+		"volumeSnapshotRef": map[string]interface{}{
+			"kind":      snapshotKind,
+			"name":      copyName,
+			"namespace": copyNamespace,
+		},
+	}
+	snapshotSpec := map[string]interface{}{
+		"snapshotContentName": copyName,
+	}
+	if snapshotClass != "" {
+		contentSpec["snapshotClassName"] = snapshotClass
+		snapshotSpec["snapshotClassName"] = snapshotClass
+	}
+	contentCopy := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": snapshotContentResource.GroupVersion().String(),
+			"kind":       snapshotContentKind,
+			"metadata": map[string]interface{}{
+				"name": copyName,
+			},
+			"spec": contentSpec,
+		},
+	}
+	_, err = c.dynamicInterface.Resource(snapshotContentResource).Create(contentCopy)
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating VolumeSnapshotContent %v: %v", copyName, err)
+	}
+	snapshotCopy := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": snapshotResource.GroupVersion().String(),
+			"kind":       snapshotKind,
+			"metadata": map[string]interface{}{
+				"name":      copyName,
+				"namespace": copyNamespace,
+			},
+			"spec": snapshotSpec,
+		},
+	}
+	_, err = c.dynamicInterface.Resource(snapshotResource).Namespace(copyNamespace).Create(snapshotCopy)
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating VolumeSnapshot %v/%v: %v", copyNamespace, copyName, err)
+	}
+	return nil
+}
+
+// deleteVolumeSnapshotCopy deletes a VolumeSnapshot created by
+// copyVolumeSnapshot along with its VolumeSnapshotContent
+func (c *csi) deleteVolumeSnapshotCopy(name, namespace string) error {
+	err := c.dynamicInterface.Resource(snapshotResource).Namespace(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting VolumeSnapshot %v/%v: %v", namespace, name, err)
+	}
+	err = c.dynamicInterface.Resource(snapshotContentResource).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting VolumeSnapshotContent %v: %v", name, err)
+	}
+	return nil
+}
+
+// createStagingPVC creates a PVC that is provisioned from the given data
+// source. The PVC is created through the dynamic interface since the
+// dataSource field isn't part of the vendored PVC spec.
+func (c *csi) createStagingPVC(
+	name string,
+	namespace string,
+	options map[string]string,
+	dataSource map[string]interface{},
+) error {
+	pvc := &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       pvcKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if storageClass := options[storageClassOption]; storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	for _, mode := range strings.Split(options[accessModesOption], ",") {
+		if mode != "" {
+			pvc.Spec.AccessModes = append(pvc.Spec.AccessModes, v1.PersistentVolumeAccessMode(mode))
+		}
+	}
+	if len(pvc.Spec.AccessModes) == 0 {
+		pvc.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+	}
+	size, err := resource.ParseQuantity(options[sizeOption])
+	if err != nil {
+		return fmt.Errorf("invalid size %q for volume: %v", options[sizeOption], err)
+	}
+	pvc.Spec.Resources.Requests = v1.ResourceList{
+		v1.ResourceStorage: size,
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pvc)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedMap(content, dataSource, "spec", "dataSource"); err != nil {
+		return err
+	}
+	_, err = c.dynamicInterface.Resource(pvcResource).Namespace(namespace).Create(&unstructured.Unstructured{Object: content})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating PVC %v/%v: %v", namespace, name, err)
+	}
+	return nil
+}
+
+// releaseStagingPVC hands over the PV provisioned for a staging PVC to the
+// given claim and deletes the staging PVC. Returns the name of the PV once it
+// has been released or an empty string if the PVC hasn't been bound yet.
+func (c *csi) releaseStagingPVC(
+	name string,
+	namespace string,
+	claimRef *v1.ObjectReference,
+) (string, error) {
+	stagingPVC := fmt.Sprintf("%v/%v", namespace, name)
+	obj, err := c.dynamicInterface.Resource(pvcResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+		// The staging PVC could have been deleted after the PV was released
+		pvName, err := c.getReleasedPVName(stagingPVC)
+		if err != nil {
+			return "", err
+		}
+		if pvName == "" {
+			return "", &stagingPVCError{msg: fmt.Sprintf("PVC %v not found", stagingPVC)}
+		}
+		return pvName, nil
+	}
+
+	var pvc v1.PersistentVolumeClaim
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &pvc); err != nil {
+		return "", err
+	}
+	switch pvc.Status.Phase {
+	case v1.ClaimBound:
+	case v1.ClaimLost:
+		// The PVC is marked as lost once the PV has been pre-bound to the
+		// new claim, so it only needs to be deleted
+		if pvc.Spec.VolumeName == "" {
+			return "", &stagingPVCError{msg: fmt.Sprintf("PVC %v lost its volume", stagingPVC)}
+		}
+	default:
+		return "", nil
+	}
+
+	pvObj, err := c.dynamicInterface.Resource(pvResource).Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	var pv v1.PersistentVolume
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(pvObj.UnstructuredContent(), &pv); err != nil {
+		return "", err
+	}
+	// Pre-bind the PV to the new claim so that it can't be used by any other
+	// PVC once the staging PVC has been deleted
+	if pv.Annotations[stagingPVCAnnotation] != stagingPVC {
+		if pv.Annotations == nil {
+			pv.Annotations = make(map[string]string)
+		}
+		pv.Annotations[stagingPVCAnnotation] = stagingPVC
+		pv.Spec.ClaimRef = claimRef
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pv)
+		if err != nil {
+			return "", err
+		}
+		pvObj.SetUnstructuredContent(content)
+		if _, err := c.dynamicInterface.Resource(pvResource).Update(pvObj); err != nil {
+			return "", fmt.Errorf("error updating claim for PV %v: %v", pv.Name, err)
+		}
+	}
+
+	err = c.dynamicInterface.Resource(pvcResource).Namespace(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return "", fmt.Errorf("error deleting PVC %v: %v", stagingPVC, err)
+	}
+	return pv.Name, nil
+}
+
+// getReleasedPVName returns the name of the PV that was released from the
+// given staging PVC
+func (c *csi) getReleasedPVName(stagingPVC string) (string, error) {
+	pvs, err := c.dynamicInterface.Resource(pvResource).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, pv := range pvs.Items {
+		if pv.GetAnnotations()[stagingPVCAnnotation] == stagingPVC {
+			return pv.GetName(), nil
+		}
+	}
+	return "", nil
+}
+
+func init() {
+	if err := storkvolume.Register(driverName, &csi{}); err != nil {
+		logrus.Panicf("Error registering csi volume driver: %v", err)
+	}
+}
@@ -0,0 +1,677 @@
+// +build unittest
+
+package csi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testCSIDriver     = "test.csi.driver"
+	testStorageClass  = "csi-sc"
+	ebsStorageClass   = "ebs-sc"
+	sourceNamespace   = "csi-source"
+	destNamespace     = "csi-dest"
+	testPVCName       = "csi-pvc"
+	testPVName        = "pvc-csi-source"
+	testVolumeHandle  = "source-handle"
+	testVolumeSizeStr = "2Gi"
+)
+
+var driver *csi
+var fakeDynamic *fakeDynamicClient
+
+// fakeDynamicClient is an in-memory dynamic client. It also acts as the CSI
+// snapshot controller and external provisioner, marking snapshots as ready
+// and provisioning PVs for PVCs created from a data source.
+type fakeDynamicClient struct {
+	sync.Mutex
+	objects map[string]*unstructured.Unstructured
+	// getError is returned for all Get calls if set
+	getError error
+}
+
+type fakeResourceClient struct {
+	client    *fakeDynamicClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+func newFakeDynamicClient() *fakeDynamicClient {
+	return &fakeDynamicClient{
+		objects: make(map[string]*unstructured.Unstructured),
+	}
+}
+
+func (f *fakeDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeResourceClient{client: f, resource: resource}
+}
+
+func (r *fakeResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &fakeResourceClient{client: r.client, resource: r.resource, namespace: namespace}
+}
+
+func (r *fakeResourceClient) prefix() string {
+	return fmt.Sprintf("%v/%v/", r.resource.String(), r.namespace)
+}
+
+func (r *fakeResourceClient) Create(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	r.client.Lock()
+	defer r.client.Unlock()
+	key := r.prefix() + obj.GetName()
+	if _, ok := r.client.objects[key]; ok {
+		return nil, k8serrors.NewAlreadyExists(r.resource.GroupResource(), obj.GetName())
+	}
+	r.client.objects[key] = obj.DeepCopy()
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResourceClient) Update(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	r.client.Lock()
+	defer r.client.Unlock()
+	key := r.prefix() + obj.GetName()
+	if _, ok := r.client.objects[key]; !ok {
+		return nil, k8serrors.NewNotFound(r.resource.GroupResource(), obj.GetName())
+	}
+	r.client.objects[key] = obj.DeepCopy()
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResourceClient) UpdateStatus(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return r.Update(obj)
+}
+
+func (r *fakeResourceClient) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	r.client.Lock()
+	defer r.client.Unlock()
+	key := r.prefix() + name
+	if _, ok := r.client.objects[key]; !ok {
+		return k8serrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	delete(r.client.objects, key)
+	return nil
+}
+
+func (r *fakeResourceClient) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) Get(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.client.Lock()
+	defer r.client.Unlock()
+	if r.client.getError != nil {
+		return nil, r.client.getError
+	}
+	obj, ok := r.client.objects[r.prefix()+name]
+	if !ok {
+		return nil, k8serrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResourceClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.client.Lock()
+	defer r.client.Unlock()
+	list := &unstructured.UnstructuredList{}
+	for key, obj := range r.client.objects {
+		if strings.HasPrefix(key, r.prefix()) {
+			list.Items = append(list.Items, *obj.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeResourceClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// sync marks all snapshots as ready and binds all pending PVCs that have a
+// data source to newly provisioned PVs
+func (f *fakeDynamicClient) sync(t *testing.T) {
+	for _, namespace := range []string{sourceNamespace, destNamespace} {
+		f.syncNamespace(t, namespace)
+	}
+}
+
+func (f *fakeDynamicClient) syncNamespace(t *testing.T, namespace string) {
+	snapshots, err := f.Resource(snapshotResource).Namespace(namespace).List(metav1.ListOptions{})
+	require.NoError(t, err, "Error listing snapshots")
+	for _, snapshot := range snapshots.Items {
+		if contentName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "snapshotContentName"); contentName == "" {
+			content := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": snapshotContentResource.GroupVersion().String(),
+					"kind":       snapshotContentKind,
+					"metadata": map[string]interface{}{
+						"name": "snapcontent-" + snapshot.GetName(),
+					},
+					"spec": map[string]interface{}{
+						"csiVolumeSnapshotSource": map[string]interface{}{
+							"driver":         testCSIDriver,
+							"snapshotHandle": "handle-" + snapshot.GetName(),
+						},
+						"deletionPolicy": "Delete",
+					},
+				},
+			}
+			_, err = f.Resource(snapshotContentResource).Create(content)
+			require.NoError(t, err, "Error creating snapshot content")
+			err = unstructured.SetNestedField(snapshot.Object, content.GetName(), "spec", "snapshotContentName")
+			require.NoError(t, err, "Error setting snapshot content")
+		}
+		err = unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse")
+		require.NoError(t, err, "Error setting snapshot status")
+		_, err = f.Resource(snapshotResource).Namespace(namespace).Update(&snapshot)
+		require.NoError(t, err, "Error updating snapshot")
+	}
+
+	pvcs, err := f.Resource(pvcResource).Namespace(namespace).List(metav1.ListOptions{})
+	require.NoError(t, err, "Error listing PVCs")
+	for _, obj := range pvcs.Items {
+		if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "dataSource"); !found {
+			continue
+		}
+		var pvc v1.PersistentVolumeClaim
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pvc)
+		require.NoError(t, err, "Error converting PVC")
+		if pvc.Status.Phase == v1.ClaimBound {
+			continue
+		}
+
+		pv := &v1.PersistentVolume{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolume",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "pvc-" + string(uuid.NewUUID()),
+			},
+			Spec: v1.PersistentVolumeSpec{
+				Capacity: pvc.Spec.Resources.Requests,
+				PersistentVolumeSource: v1.PersistentVolumeSource{
+					CSI: &v1.CSIPersistentVolumeSource{
+						Driver:       testCSIDriver,
+						VolumeHandle: "handle-" + pvc.Name,
+					},
+				},
+				ClaimRef: &v1.ObjectReference{
+					Kind:      pvcKind,
+					Namespace: pvc.Namespace,
+					Name:      pvc.Name,
+				},
+			},
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pv)
+		require.NoError(t, err, "Error converting PV")
+		_, err = f.Resource(pvResource).Create(&unstructured.Unstructured{Object: content})
+		require.NoError(t, err, "Error creating PV")
+
+		pvc.Spec.VolumeName = pv.Name
+		pvc.Status.Phase = v1.ClaimBound
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&pvc)
+		require.NoError(t, err, "Error converting PVC")
+		obj.SetUnstructuredContent(content)
+		_, err = f.Resource(pvcResource).Namespace(namespace).Update(&obj)
+		require.NoError(t, err, "Error updating PVC")
+	}
+}
+
+func getFakePV(t *testing.T, name string) *v1.PersistentVolume {
+	obj, err := fakeDynamic.Resource(pvResource).Get(name, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting PV")
+	var pv v1.PersistentVolume
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pv)
+	require.NoError(t, err, "Error converting PV")
+	return &pv
+}
+
+func TestCSI(t *testing.T) {
+	t.Run("setup", setup)
+	t.Run("ownershipTest", ownershipTest)
+	t.Run("backupTest", backupTest)
+	t.Run("backupFailureTest", backupFailureTest)
+	t.Run("restoreTest", restoreTest)
+	t.Run("restoreFailureTest", restoreFailureTest)
+	t.Run("cancelRestoreTest", cancelRestoreTest)
+	t.Run("cloneTest", cloneTest)
+	t.Run("cloneFailureTest", cloneFailureTest)
+	t.Run("updateMigratedPersistentVolumeSpecTest", updateMigratedPersistentVolumeSpecTest)
+}
+
+func setup(t *testing.T) {
+	fakeKubeClient := kubernetes.NewSimpleClientset()
+	k8s.Instance().SetClient(fakeKubeClient, nil, nil, nil, nil, nil, nil, nil)
+
+	fakeDynamic = newFakeDynamicClient()
+	driver = &csi{}
+	err := driver.Init(fakeDynamic)
+	require.NoError(t, err, "Error initializing csi driver")
+
+	_, err = k8s.Instance().CreateStorageClass(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: testStorageClass},
+		Provisioner: testCSIDriver,
+	})
+	require.NoError(t, err, "Error creating storage class")
+	_, err = k8s.Instance().CreateStorageClass(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: ebsStorageClass},
+		Provisioner: "kubernetes.io/aws-ebs",
+	})
+	require.NoError(t, err, "Error creating storage class")
+
+	_, err = k8s.Instance().CreatePersistentVolume(&v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: testPVName},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       testCSIDriver,
+					VolumeHandle: testVolumeHandle,
+				},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating PV")
+
+	storageClass := testStorageClass
+	_, err = k8s.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testPVCName,
+			Namespace: sourceNamespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			VolumeName:       testPVName,
+			AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse(testVolumeSizeStr),
+				},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating PVC")
+}
+
+func ownershipTest(t *testing.T) {
+	csiStorageClass := testStorageClass
+	require.True(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		Spec: v1.PersistentVolumeClaimSpec{StorageClassName: &csiStorageClass},
+	}), "CSI PVC should be owned by csi driver")
+
+	ebsStorageClassName := ebsStorageClass
+	require.False(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		Spec: v1.PersistentVolumeClaimSpec{StorageClassName: &ebsStorageClassName},
+	}), "In-tree PVC shouldn't be owned by csi driver")
+
+	require.True(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{pvcProvisionerAnnotation: testCSIDriver},
+		},
+	}), "PVC with CSI provisioner annotation should be owned by csi driver")
+
+	require.False(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{pvcProvisionerAnnotation: snapv1.PortworxCsiProvisionerName},
+		},
+	}), "Portworx CSI PVC shouldn't be owned by csi driver")
+
+	require.False(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{pvcProvisionerAnnotation: storkSnapshotProvisionerName},
+		},
+	}), "Stork snapshot PVC shouldn't be owned by csi driver")
+
+	require.True(t, driver.OwnsPVC(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{pvcProvisionerAnnotation: "kubernetes.io/aws-ebs"},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{VolumeName: testPVName},
+	}), "Bound PVC should be owned based on the PV")
+
+	require.False(t, driver.OwnsPV(&v1.PersistentVolume{
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: snapv1.PortworxCsiDeprecatedProvisionerName},
+			},
+		},
+	}), "Portworx CSI PV shouldn't be owned by csi driver")
+
+	require.False(t, driver.OwnsPV(&v1.PersistentVolume{
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				AWSElasticBlockStore: &v1.AWSElasticBlockStoreVolumeSource{VolumeID: "vol-1"},
+			},
+		},
+	}), "In-tree PV shouldn't be owned by csi driver")
+
+	templates, err := driver.GetVolumeClaimTemplates([]v1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi"},
+			Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &csiStorageClass},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ebs"},
+			Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &ebsStorageClassName},
+		},
+	})
+	require.NoError(t, err, "Error getting volume claim templates")
+	require.Len(t, templates, 1, "Only CSI templates should be returned")
+	require.Equal(t, "csi", templates[0].Name)
+
+	info, err := driver.InspectVolume(testPVName)
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, testVolumeHandle, info.VolumeID)
+	require.Equal(t, testPVName, info.VolumeName)
+}
+
+func newTestBackup(t *testing.T) *storkapi.ApplicationBackup {
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(testPVCName, sourceNamespace)
+	require.NoError(t, err, "Error getting PVC")
+
+	backup := &storkapi.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "csi-backup",
+			Namespace:   sourceNamespace,
+			Annotations: map[string]string{snapshotClassAnnotation: "csi-snapclass"},
+		},
+	}
+	volumeInfos, err := driver.StartBackup(backup, []v1.PersistentVolumeClaim{*pvc})
+	require.NoError(t, err, "Error starting backup")
+	require.Len(t, volumeInfos, 1, "Backup should have one volume")
+	backup.Status.Volumes = volumeInfos
+	return backup
+}
+
+func backupTest(t *testing.T) {
+	backup := newTestBackup(t)
+	vInfo := backup.Status.Volumes[0]
+	require.Equal(t, driverName, vInfo.DriverName)
+	require.Equal(t, testPVName, vInfo.Volume)
+	require.Equal(t, testStorageClass, vInfo.Options[storageClassOption])
+	require.Equal(t, testVolumeSizeStr, vInfo.Options[sizeOption])
+	require.Equal(t, string(v1.ReadWriteOnce), vInfo.Options[accessModesOption])
+
+	snapshot, err := fakeDynamic.Resource(snapshotResource).Namespace(sourceNamespace).Get(vInfo.BackupID, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting VolumeSnapshot")
+	source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "name")
+	require.Equal(t, testPVCName, source, "Snapshot should be for the PVC")
+	class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "snapshotClassName")
+	require.Equal(t, "csi-snapclass", class, "Snapshot class should be picked up from the annotation")
+
+	volumeInfos, err := driver.GetBackupStatus(backup)
+	require.NoError(t, err, "Error getting backup status")
+	require.Equal(t, storkapi.ApplicationBackupStatusInProgress, volumeInfos[0].Status)
+
+	fakeDynamic.sync(t)
+	volumeInfos, err = driver.GetBackupStatus(backup)
+	require.NoError(t, err, "Error getting backup status")
+	require.Equal(t, storkapi.ApplicationBackupStatusSuccessful, volumeInfos[0].Status)
+
+	err = driver.DeleteBackup(backup)
+	require.NoError(t, err, "Error deleting backup")
+	_, err = fakeDynamic.Resource(snapshotResource).Namespace(sourceNamespace).Get(vInfo.BackupID, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "VolumeSnapshot should have been deleted")
+
+	// Deleting again should be a no-op
+	err = driver.DeleteBackup(backup)
+	require.NoError(t, err, "Error deleting backup again")
+}
+
+func backupFailureTest(t *testing.T) {
+	backup := newTestBackup(t)
+	vInfo := backup.Status.Volumes[0]
+	snapshot, err := fakeDynamic.Resource(snapshotResource).Namespace(sourceNamespace).Get(vInfo.BackupID, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting VolumeSnapshot")
+	err = unstructured.SetNestedField(snapshot.Object, "snapshot failed", "status", "error", "message")
+	require.NoError(t, err, "Error setting snapshot error")
+	_, err = fakeDynamic.Resource(snapshotResource).Namespace(sourceNamespace).Update(snapshot)
+	require.NoError(t, err, "Error updating VolumeSnapshot")
+
+	volumeInfos, err := driver.GetBackupStatus(backup)
+	require.NoError(t, err, "Error getting backup status")
+	require.Equal(t, storkapi.ApplicationBackupStatusFailed, volumeInfos[0].Status)
+	require.Contains(t, volumeInfos[0].Reason, "snapshot failed")
+
+	err = driver.CancelBackup(backup)
+	require.NoError(t, err, "Error cancelling backup")
+	volumeInfos, err = driver.GetBackupStatus(backup)
+	require.NoError(t, err, "Error getting backup status")
+	require.Equal(t, storkapi.ApplicationBackupStatusFailed, volumeInfos[0].Status)
+}
+
+func newTestRestore(t *testing.T) *storkapi.ApplicationRestore {
+	backup := newTestBackup(t)
+	fakeDynamic.sync(t)
+
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "csi-restore",
+			Namespace: sourceNamespace,
+		},
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{sourceNamespace: destNamespace},
+		},
+	}
+	volumeInfos, err := driver.StartRestore(restore, backup.Status.Volumes)
+	require.NoError(t, err, "Error starting restore")
+	require.Len(t, volumeInfos, 1, "Restore should have one volume")
+	restore.Status.Volumes = volumeInfos
+	return restore
+}
+
+func restoreTest(t *testing.T) {
+	restore := newTestRestore(t)
+	stagingPVCName := restore.Status.Volumes[0].RestoreVolume
+
+	stagingPVC, err := fakeDynamic.Resource(pvcResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.NoError(t, err, "Staging PVC should be created in the restore namespace")
+	dataSource, _, _ := unstructured.NestedStringMap(stagingPVC.Object, "spec", "dataSource")
+	require.Equal(t, snapshotKind, dataSource["kind"])
+	require.Equal(t, stagingPVCName, dataSource["name"], "Data source should be the copy of the snapshot")
+
+	snapshotCopy, err := fakeDynamic.Resource(snapshotResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.NoError(t, err, "Snapshot should be copied to the restore namespace")
+	contentName, _, _ := unstructured.NestedString(snapshotCopy.Object, "spec", "snapshotContentName")
+	require.Equal(t, stagingPVCName, contentName)
+	contentCopy, err := fakeDynamic.Resource(snapshotContentResource).Get(contentName, metav1.GetOptions{})
+	require.NoError(t, err, "Error getting snapshot content copy")
+	deletionPolicy, _, _ := unstructured.NestedString(contentCopy.Object, "spec", "deletionPolicy")
+	require.Equal(t, "Retain", deletionPolicy, "Snapshot content copy shouldn't delete the snapshot")
+	snapshotHandle, _, _ := unstructured.NestedString(contentCopy.Object, "spec", "csiVolumeSnapshotSource", "snapshotHandle")
+	require.True(t, strings.HasPrefix(snapshotHandle, "handle-"+snapshotNamePrefix),
+		"Snapshot content copy should point to the CSI snapshot from the backup")
+	refNamespace, _, _ := unstructured.NestedString(contentCopy.Object, "spec", "volumeSnapshotRef", "namespace")
+	require.Equal(t, destNamespace, refNamespace)
+
+	volumeInfos, err := driver.GetRestoreStatus(restore)
+	require.NoError(t, err, "Error getting restore status")
+	require.Equal(t, storkapi.ApplicationRestoreStatusInProgress, volumeInfos[0].Status)
+
+	fakeDynamic.sync(t)
+	volumeInfos, err = driver.GetRestoreStatus(restore)
+	require.NoError(t, err, "Error getting restore status")
+	require.Equal(t, storkapi.ApplicationRestoreStatusSuccessful, volumeInfos[0].Status)
+	require.Equal(t, testPVName, volumeInfos[0].SourceVolume)
+	require.NotEqual(t, stagingPVCName, volumeInfos[0].RestoreVolume, "RestoreVolume should be updated to the PV name")
+
+	pv := getFakePV(t, volumeInfos[0].RestoreVolume)
+	require.Equal(t, destNamespace, pv.Spec.ClaimRef.Namespace, "PV should be pre-bound to the restored PVC")
+	require.Equal(t, testPVCName, pv.Spec.ClaimRef.Name, "PV should be pre-bound to the restored PVC")
+	_, err = fakeDynamic.Resource(pvcResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Staging PVC should have been deleted")
+	_, err = fakeDynamic.Resource(snapshotResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Snapshot copy should have been deleted")
+	_, err = fakeDynamic.Resource(snapshotContentResource).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Snapshot content copy should have been deleted")
+
+	// The PV should be found again if the status wasn't persisted
+	restore.Status.Volumes[0].RestoreVolume = stagingPVCName
+	restore.Status.Volumes[0].Status = storkapi.ApplicationRestoreStatusInProgress
+	retryInfos, err := driver.GetRestoreStatus(restore)
+	require.NoError(t, err, "Error getting restore status")
+	require.Equal(t, storkapi.ApplicationRestoreStatusSuccessful, retryInfos[0].Status)
+	require.Equal(t, pv.Name, retryInfos[0].RestoreVolume)
+}
+
+func restoreFailureTest(t *testing.T) {
+	restore := newTestRestore(t)
+	stagingPVCName := restore.Status.Volumes[0].RestoreVolume
+
+	// Transient errors should be returned without failing the volume
+	fakeDynamic.getError = fmt.Errorf("connection refused")
+	_, err := driver.GetRestoreStatus(restore)
+	fakeDynamic.getError = nil
+	require.Error(t, err, "Transient errors should be returned")
+	require.Equal(t, storkapi.ApplicationRestoreStatusInProgress, restore.Status.Volumes[0].Status)
+
+	err = fakeDynamic.Resource(pvcResource).Namespace(destNamespace).Delete(stagingPVCName, &metav1.DeleteOptions{})
+	require.NoError(t, err, "Error deleting staging PVC")
+	volumeInfos, err := driver.GetRestoreStatus(restore)
+	require.NoError(t, err, "Error getting restore status")
+	require.Equal(t, storkapi.ApplicationRestoreStatusFailed, volumeInfos[0].Status)
+	require.Contains(t, volumeInfos[0].Reason, "not found")
+	_, err = fakeDynamic.Resource(snapshotResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Snapshot copy should have been deleted")
+	_, err = fakeDynamic.Resource(snapshotContentResource).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Snapshot content copy should have been deleted")
+}
+
+func cancelRestoreTest(t *testing.T) {
+	restore := newTestRestore(t)
+	stagingPVCName := restore.Status.Volumes[0].RestoreVolume
+
+	err := driver.CancelRestore(restore)
+	require.NoError(t, err, "Error cancelling restore")
+	_, err = fakeDynamic.Resource(pvcResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Staging PVC should have been deleted")
+	_, err = fakeDynamic.Resource(snapshotResource).Namespace(destNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Snapshot copy should have been deleted")
+}
+
+func newTestClone() *storkapi.ApplicationClone {
+	return &storkapi.ApplicationClone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "csi-clone",
+			Namespace: sourceNamespace,
+		},
+		Spec: storkapi.ApplicationCloneSpec{
+			SourceNamespace:      sourceNamespace,
+			DestinationNamespace: destNamespace,
+		},
+		Status: storkapi.ApplicationCloneStatus{
+			Volumes: []*storkapi.ApplicationCloneVolumeInfo{
+				{
+					PersistentVolumeClaim: testPVCName,
+					Volume:                testPVName,
+					CloneVolume:           "pvc-" + string(uuid.NewUUID()),
+					DriverName:            driverName,
+				},
+				{
+					PersistentVolumeClaim: "other",
+					Volume:                "other",
+					DriverName:            "other",
+				},
+			},
+		},
+	}
+}
+
+func cloneTest(t *testing.T) {
+	clone := newTestClone()
+	stagingPVCName := clonePVCNamePrefix + clone.Status.Volumes[0].CloneVolume
+
+	err := driver.CreateVolumeClones(clone)
+	require.NoError(t, err, "Error creating volume clones")
+	vInfo := clone.Status.Volumes[0]
+	require.Equal(t, storkapi.ApplicationCloneStatusInProgress, vInfo.Status)
+	require.Equal(t, storkapi.ApplicationCloneStatusInitial, clone.Status.Volumes[1].Status,
+		"Volumes from other drivers shouldn't be updated")
+	_, err = fakeDynamic.Resource(pvcResource).Namespace(sourceNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.NoError(t, err, "Staging PVC should have been created")
+
+	err = driver.GetCloneStatus(clone)
+	require.NoError(t, err, "Error getting clone status")
+	require.Equal(t, storkapi.ApplicationCloneStatusInProgress, vInfo.Status)
+
+	fakeDynamic.sync(t)
+	err = driver.GetCloneStatus(clone)
+	require.NoError(t, err, "Error getting clone status")
+	require.Equal(t, storkapi.ApplicationCloneStatusSuccessful, vInfo.Status)
+	require.Equal(t, storkapi.ApplicationCloneStatusInitial, clone.Status.Volumes[1].Status,
+		"Volumes from other drivers shouldn't be updated")
+
+	pv := getFakePV(t, vInfo.CloneVolume)
+	require.Equal(t, "handle-"+stagingPVCName, pv.Spec.CSI.VolumeHandle)
+	require.Equal(t, destNamespace, pv.Spec.ClaimRef.Namespace, "PV should be pre-bound to the cloned PVC")
+	require.Equal(t, testPVCName, pv.Spec.ClaimRef.Name, "PV should be pre-bound to the cloned PVC")
+	_, err = fakeDynamic.Resource(pvcResource).Namespace(sourceNamespace).Get(stagingPVCName, metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "Staging PVC should have been deleted")
+}
+
+func cloneFailureTest(t *testing.T) {
+	clone := newTestClone()
+	stagingPVCName := clonePVCNamePrefix + clone.Status.Volumes[0].CloneVolume
+
+	err := driver.CreateVolumeClones(clone)
+	require.NoError(t, err, "Error creating volume clones")
+
+	fakeDynamic.getError = fmt.Errorf("connection refused")
+	err = driver.GetCloneStatus(clone)
+	fakeDynamic.getError = nil
+	require.Error(t, err, "Transient errors should be returned")
+	require.Equal(t, storkapi.ApplicationCloneStatusInProgress, clone.Status.Volumes[0].Status)
+
+	err = fakeDynamic.Resource(pvcResource).Namespace(sourceNamespace).Delete(stagingPVCName, &metav1.DeleteOptions{})
+	require.NoError(t, err, "Error deleting staging PVC")
+	err = driver.GetCloneStatus(clone)
+	require.NoError(t, err, "Error getting clone status")
+	require.Equal(t, storkapi.ApplicationCloneStatusFailed, clone.Status.Volumes[0].Status)
+	require.Contains(t, clone.Status.Volumes[0].Reason, "not found")
+}
+
+func updateMigratedPersistentVolumeSpecTest(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: testPVName},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       testCSIDriver,
+					VolumeHandle: "stale-handle",
+				},
+			},
+		},
+	}
+	pv, err := driver.UpdateMigratedPersistentVolumeSpec(pv)
+	require.NoError(t, err, "Error updating PV spec")
+	require.Equal(t, testVolumeHandle, pv.Spec.CSI.VolumeHandle, "Volume handle should be updated from existing PV")
+
+	pv.Name = "missing-pv"
+	pv.Spec.CSI.VolumeHandle = "unchanged-handle"
+	pv, err = driver.UpdateMigratedPersistentVolumeSpec(pv)
+	require.NoError(t, err, "Error updating PV spec")
+	require.Equal(t, "unchanged-handle", pv.Spec.CSI.VolumeHandle, "Volume handle shouldn't change if PV doesn't exist")
+}
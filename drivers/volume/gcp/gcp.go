@@ -44,10 +44,12 @@ type gcp struct {
 	service   *compute.Service
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
+	storkvolume.MigrateVolumesNotSupported
 	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.NodeStatusWatchNotSupported
 }
 
 func (g *gcp) Init(_ interface{}) error {
@@ -425,11 +427,11 @@ func (g *gcp) GetClusterID() (string, error) {
 }
 
 func (g *gcp) GetNodes() ([]*storkvolume.NodeInfo, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetNodesFromLabels()
 }
 
 func (g *gcp) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvolume.Info, error) {
-	return nil, &errors.ErrNotSupported{}
+	return storkvolume.GetPodVolumesWithTopology(g, podSpec, namespace)
 }
 
 func (g *gcp) GetSnapshotPlugin() snapshotVolume.Plugin {
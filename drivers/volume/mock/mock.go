@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
 	storkvolume "github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/errors"
 	"github.com/pborman/uuid"
+	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	k8shelper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
@@ -27,12 +29,19 @@ const (
 	ZoneLabel = "mock/zone"
 	// RegionLabel Label used for the mock driver to set region information
 	RegionLabel = "mock/region"
+	// nodeWatchBufferSize is the number of status updates buffered for each
+	// watcher
+	nodeWatchBufferSize = 10
 )
 
+// watchersLock protects the node status watchers for all mock drivers
+var watchersLock sync.Mutex
+
 // Driver Mock driver for tests
 type Driver struct {
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
+	storkvolume.MigrateVolumesNotSupported
 	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.BackupRestoreNotSupported
@@ -43,10 +52,25 @@ type Driver struct {
 	pvcs           map[string]*v1.PersistentVolumeClaim
 	interfaceError error
 	clusterID      string
+	name           string
+	storageClass   string
+	nodeWatchers   []chan *storkvolume.NodeInfo
+}
+
+// NewDriver Returns a mock driver with the given name which owns volumes
+// using the given storage class. Can be used to test with multiple drivers.
+func NewDriver(name string, storageClass string) *Driver {
+	return &Driver{
+		name:         name,
+		storageClass: storageClass,
+	}
 }
 
 // String Returns the name for the driver
 func (m Driver) String() string {
+	if m.name != "" {
+		return m.name
+	}
 	return driverName
 }
 
@@ -107,6 +131,9 @@ func (m *Driver) CreateCluster(numNodes int, nodes *v1.NodeList) error {
 
 // GetStorageClassName Returns the storageclass name to be used by tests
 func (m *Driver) GetStorageClassName() string {
+	if m.storageClass != "" {
+		return m.storageClass
+	}
 	return mockStorageClassName
 }
 
@@ -154,6 +181,28 @@ func (m *Driver) ProvisionVolume(
 	return nil
 }
 
+// ProvisionZonalVolume Provision a volume in the mock driver that can be
+// accessed from any node in the given zones
+func (m *Driver) ProvisionZonalVolume(
+	volumeName string,
+	zones []string,
+	region string,
+	size uint64,
+) error {
+	if _, ok := m.volumes[volumeName]; ok {
+		return fmt.Errorf("volume %v already exists", volumeName)
+	}
+
+	m.volumes[volumeName] = &storkvolume.Info{
+		VolumeID:   volumeName,
+		VolumeName: volumeName,
+		Size:       size,
+		Zones:      zones,
+		Region:     region,
+	}
+	return nil
+}
+
 // UpdateNodeStatus Update status for a node
 func (m *Driver) UpdateNodeStatus(
 	nodeIndex int,
@@ -163,6 +212,53 @@ func (m *Driver) UpdateNodeStatus(
 		return fmt.Errorf("node %v not found", nodeIndex)
 	}
 	m.nodes[nodeIndex].Status = nodeStatus
+
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+	for _, watcher := range m.nodeWatchers {
+		node := *m.nodes[nodeIndex]
+		select {
+		case watcher <- &node:
+		default:
+			logrus.Warnf("Dropping status update for node %v, watcher is full", node.StorageID)
+		}
+	}
+	return nil
+}
+
+// WatchNodeStatus Returns a channel on which nodes are sent when their status
+// is updated with UpdateNodeStatus
+func (m *Driver) WatchNodeStatus(stopChannel <-chan struct{}) (<-chan *storkvolume.NodeInfo, error) {
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+	nodeChannel := make(chan *storkvolume.NodeInfo, nodeWatchBufferSize)
+	m.nodeWatchers = append(m.nodeWatchers, nodeChannel)
+	go func() {
+		<-stopChannel
+		watchersLock.Lock()
+		defer watchersLock.Unlock()
+		for i, watcher := range m.nodeWatchers {
+			if watcher == nodeChannel {
+				m.nodeWatchers = append(m.nodeWatchers[:i], m.nodeWatchers[i+1:]...)
+				break
+			}
+		}
+		close(nodeChannel)
+	}()
+	return nodeChannel, nil
+}
+
+// UpdateNodeCapacity Update the free and total capacity in bytes for a node
+func (m *Driver) UpdateNodeCapacity(
+	nodeIndex int,
+	freeCapacity uint64,
+	totalCapacity uint64,
+) error {
+	if len(m.nodes) <= nodeIndex {
+		return fmt.Errorf("node %v not found", nodeIndex)
+	}
+	m.nodes[nodeIndex].FreeCapacity = freeCapacity
+	m.nodes[nodeIndex].TotalCapacity = totalCapacity
 	return nil
 }
 
@@ -217,10 +313,15 @@ func (m Driver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvo
 		if volume.PersistentVolumeClaim != nil {
 			pvc, ok := m.pvcs[volume.PersistentVolumeClaim.ClaimName]
 			if !ok {
-				logrus.Debugf("PVCs: %+v", m.pvcs)
-				return nil, &errors.ErrNotFound{
-					ID:   volume.PersistentVolumeClaim.ClaimName,
-					Type: "PVC",
+				// Check if the PVC was created for another driver
+				var err error
+				pvc, err = k8s.Instance().GetPersistentVolumeClaim(volume.PersistentVolumeClaim.ClaimName, namespace)
+				if err != nil {
+					logrus.Debugf("PVCs: %+v", m.pvcs)
+					return nil, &errors.ErrNotFound{
+						ID:   volume.PersistentVolumeClaim.ClaimName,
+						Type: "PVC",
+					}
 				}
 			}
 
@@ -230,8 +331,8 @@ func (m Driver) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*storkvo
 				continue
 			}
 
-			// Assume all mock volumes have the same storageclass
-			if storageClassName != mockStorageClassName {
+			// Assume all volumes for a mock driver have the same storageclass
+			if storageClassName != m.GetStorageClassName() {
 				continue
 			}
 
@@ -0,0 +1,52 @@
+package volume
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WatchNodeStatusByPolling can be used by drivers that don't have an API to
+// watch for changes to the status of nodes. The nodes are fetched at the given
+// interval and only the nodes whose status has changed are sent on the
+// returned channel. Nodes that aren't online when the watch is started are
+// sent on the first poll.
+func WatchNodeStatusByPolling(
+	driver Driver,
+	interval time.Duration,
+	stopChannel <-chan struct{},
+) <-chan *NodeInfo {
+	nodeChannel := make(chan *NodeInfo)
+	go func() {
+		defer close(nodeChannel)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		statuses := make(map[string]NodeStatus)
+		for {
+			nodes, err := driver.GetNodes()
+			if err != nil {
+				logrus.Errorf("Error getting nodes for driver %v to watch status: %v", driver.String(), err)
+			}
+			for _, node := range nodes {
+				previous, ok := statuses[node.StorageID]
+				statuses[node.StorageID] = node.Status
+				if (ok && previous == node.Status) || (!ok && node.Status == NodeOnline) {
+					continue
+				}
+				select {
+				case nodeChannel <- node:
+				case <-stopChannel:
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stopChannel:
+				return
+			}
+		}
+	}()
+	return nodeChannel
+}
@@ -92,6 +92,11 @@ const (
 	// pxRackLabelKey Label for rack information
 	pxRackLabelKey = "px/rack"
 
+	// nodeStatusPollInterval is the interval at which the status of the
+	// nodes is checked to watch for changes, since the cluster API doesn't
+	// support watches
+	nodeStatusPollInterval = 10 * time.Second
+
 	snapshotDataNamePrefix = "k8s-volume-snapshot"
 	readySnapshotMsg       = "Snapshot created successfully and it is ready"
 	pvNamePrefix           = "pvc-"
@@ -147,6 +152,9 @@ type cloudSnapStatus struct {
 	status         api.CloudBackupStatusType
 	msg            string
 	cloudSnapID    string
+	bytesTotal     uint64
+	bytesDone      uint64
+	etaSeconds     int64
 }
 
 // snapshot annotation constants
@@ -501,6 +509,10 @@ func (p *portworx) getNodeLabels(nodeInfo *storkvolume.NodeInfo) (map[string]str
 	return node.Labels, nil
 }
 
+func (p *portworx) WatchNodeStatus(stopChannel <-chan struct{}) (<-chan *storkvolume.NodeInfo, error) {
+	return storkvolume.WatchNodeStatusByPolling(p, nodeStatusPollInterval, stopChannel), nil
+}
+
 func (p *portworx) GetNodes() ([]*storkvolume.NodeInfo, error) {
 	cluster, err := p.clusterManager.Enumerate()
 	if err != nil {
@@ -519,6 +531,12 @@ func (p *portworx) GetNodes() ([]*storkvolume.NodeInfo, error) {
 		}
 		nodeInfo.IPs = append(nodeInfo.IPs, n.MgmtIp)
 		nodeInfo.IPs = append(nodeInfo.IPs, n.DataIp)
+		for _, pool := range n.Pools {
+			nodeInfo.TotalCapacity += pool.TotalSize
+			if pool.TotalSize > pool.Used {
+				nodeInfo.FreeCapacity += pool.TotalSize - pool.Used
+			}
+		}
 
 		labels, err := p.getNodeLabels(nodeInfo)
 		if err == nil {
@@ -628,6 +646,15 @@ func (p *portworx) GetPodVolumes(podSpec *v1.PodSpec, namespace string) ([]*stor
 			}
 
 			if pvc.Status.Phase == v1.ClaimPending {
+				// Volumes using WaitForFirstConsumer are only
+				// provisioned after the pod has been scheduled
+				waiting, err := storkvolume.IsPVCWaitingForFirstConsumer(pvc)
+				if err != nil {
+					return nil, err
+				}
+				if waiting {
+					continue
+				}
 				return nil, &storkvolume.ErrPVCPending{
 					Name: volume.PersistentVolumeClaim.ClaimName,
 				}
@@ -1722,6 +1749,9 @@ func (p *portworx) getCloudSnapStatus(volDriver volume.VolumeDriver, op api.Clou
 			sourceVolumeID: csStatus.SrcVolumeID,
 			status:         api.CloudBackupStatusActive,
 			cloudSnapID:    csStatus.ID,
+			bytesTotal:     csStatus.BytesTotal,
+			bytesDone:      csStatus.BytesDone,
+			etaSeconds:     csStatus.EtaSeconds,
 			msg: fmt.Sprintf("cloudsnap %s id: %s for %s has started and is active.",
 				op, csStatus.ID, taskID),
 		}
@@ -1742,6 +1772,8 @@ func (p *portworx) getCloudSnapStatus(volDriver volume.VolumeDriver, op api.Clou
 		terminal:       true,
 		status:         api.CloudBackupStatusDone,
 		cloudSnapID:    csStatus.ID,
+		bytesTotal:     csStatus.BytesTotal,
+		bytesDone:      csStatus.BytesTotal,
 		msg:            fmt.Sprintf("cloudsnap %s id: %s for %s done.", op, csStatus.ID, taskID),
 	}
 }
@@ -1946,10 +1978,17 @@ func (p *portworx) DeletePair(pair *storkapi.ClusterPair) error {
 }
 
 func (p *portworx) StartMigration(migration *storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
-	volDriver, err := p.getUserVolDriver(migration.Annotations)
+	volumeInfos, err := p.GetMigrationVolumes(migration)
 	if err != nil {
 		return nil, err
 	}
+	if err := p.StartVolumeMigration(migration, volumeInfos); err != nil {
+		return nil, err
+	}
+	return volumeInfos, nil
+}
+
+func (p *portworx) GetMigrationVolumes(migration *storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
 	ok, msg, err := p.ensureNodesHaveMinVersion("2.0")
 	if err != nil {
 		return nil, err
@@ -1967,10 +2006,6 @@ func (p *portworx) StartMigration(migration *storkapi.Migration) ([]*storkapi.Mi
 		return nil, fmt.Errorf("namespaces for migration cannot be empty")
 	}
 
-	clusterPair, err := k8s.Instance().GetClusterPair(migration.Spec.ClusterPair, migration.Namespace)
-	if err != nil {
-		return nil, fmt.Errorf("error getting clusterpair: %v", err)
-	}
 	volumeInfos := make([]*storkapi.MigrationVolumeInfo, 0)
 	for _, namespace := range migration.Spec.Namespaces {
 		pvcList, err := k8s.Instance().GetPersistentVolumeClaims(namespace, migration.Spec.Selectors)
@@ -1981,39 +2016,63 @@ func (p *portworx) StartMigration(migration *storkapi.Migration) ([]*storkapi.Mi
 			if !p.OwnsPVC(&pvc) {
 				continue
 			}
-			volumeInfo := &storkapi.MigrationVolumeInfo{
-				PersistentVolumeClaim: pvc.Name,
-				Namespace:             pvc.Namespace,
-			}
-			volumeInfos = append(volumeInfos, volumeInfo)
-
 			volume, err := k8s.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
 			if err != nil {
 				return nil, fmt.Errorf("error getting volume for PVC: %v", err)
 			}
-			volumeInfo.Volume = volume
-			taskID := p.getMigrationTaskID(migration, volumeInfo)
-			_, err = volDriver.CloudMigrateStart(&api.CloudMigrateStartRequest{
-				TaskId:    taskID,
-				Operation: api.CloudMigrate_MigrateVolume,
-				ClusterId: clusterPair.Status.RemoteStorageID,
-				TargetId:  volume,
+			volumeInfos = append(volumeInfos, &storkapi.MigrationVolumeInfo{
+				PersistentVolumeClaim: pvc.Name,
+				Namespace:             pvc.Namespace,
+				DriverName:            driverName,
+				Volume:                volume,
+				Status:                storkapi.MigrationStatusPending,
 			})
-			if err != nil {
-				if _, ok := err.(*ost_errors.ErrExists); !ok {
-					return nil, fmt.Errorf("error starting migration for volume: %v", err)
-				}
-			}
-			volumeInfo.Status = storkapi.MigrationStatusInProgress
-			volumeInfo.Reason = "Volume migration has started. Backup in progress."
 		}
 	}
 
 	return volumeInfos, nil
 }
 
+func (p *portworx) StartVolumeMigration(
+	migration *storkapi.Migration,
+	volumeInfos []*storkapi.MigrationVolumeInfo,
+) error {
+	volDriver, err := p.getUserVolDriver(migration.Annotations)
+	if err != nil {
+		return err
+	}
+	clusterPair, err := k8s.Instance().GetClusterPair(migration.Spec.ClusterPair, migration.Namespace)
+	if err != nil {
+		return fmt.Errorf("error getting clusterpair: %v", err)
+	}
+	for _, volumeInfo := range volumeInfos {
+		taskID := p.getMigrationTaskID(migration, volumeInfo)
+		_, err = volDriver.CloudMigrateStart(&api.CloudMigrateStartRequest{
+			TaskId:    taskID,
+			Operation: api.CloudMigrate_MigrateVolume,
+			ClusterId: clusterPair.Status.RemoteStorageID,
+			TargetId:  volumeInfo.Volume,
+		})
+		if err != nil {
+			if _, ok := err.(*ost_errors.ErrExists); !ok {
+				return fmt.Errorf("error starting migration for volume: %v", err)
+			}
+		}
+		volumeInfo.Status = storkapi.MigrationStatusInProgress
+		volumeInfo.Reason = "Volume migration has started. Backup in progress."
+	}
+	return nil
+}
+
+// getMigrationTaskID returns the task ID for the migration of the volume. The
+// cluster pair is only added when migrating to multiple destinations so that
+// the IDs for migrations to a single destination don't change.
 func (p *portworx) getMigrationTaskID(migration *storkapi.Migration, volumeInfo *storkapi.MigrationVolumeInfo) string {
-	return string(migration.UID) + "-" + volumeInfo.Namespace + "-" + volumeInfo.PersistentVolumeClaim
+	taskID := string(migration.UID) + "-" + volumeInfo.Namespace + "-" + volumeInfo.PersistentVolumeClaim
+	if len(migration.Spec.ClusterPairs) != 0 {
+		taskID = taskID + "-" + migration.Spec.ClusterPair
+	}
+	return taskID
 }
 
 func (p *portworx) getBackupRestoreTaskID(operationUID types.UID, namespace string, pvc string) string {
@@ -2044,12 +2103,19 @@ func (p *portworx) GetMigrationStatus(migration *storkapi.Migration) ([]*storkap
 		return nil, fmt.Errorf("migration status not found for remote cluster %v", clusterPair.Status.RemoteStorageID)
 	}
 
+	volumeInfos := make([]*storkapi.MigrationVolumeInfo, 0)
 	for _, vInfo := range migration.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
 		found := false
 		for _, mInfo := range clusterInfo.List {
 			taskID := p.getMigrationTaskID(migration, vInfo)
 			if taskID == mInfo.TaskId {
 				found = true
+				vInfo.TotalBytes = mInfo.BytesTotal
+				vInfo.BytesTransferred = mInfo.BytesDone
+				vInfo.ETASeconds = mInfo.EtaSeconds
 				if mInfo.Status == api.CloudMigrate_Failed || mInfo.Status == api.CloudMigrate_Canceled {
 					vInfo.Status = storkapi.MigrationStatusFailed
 					vInfo.Reason = fmt.Sprintf("Migration %v failed for volume: %v", mInfo.CurrentStage, mInfo.ErrorReason)
@@ -2057,6 +2123,8 @@ func (p *portworx) GetMigrationStatus(migration *storkapi.Migration) ([]*storkap
 					mInfo.Status == api.CloudMigrate_Complete {
 					vInfo.Status = storkapi.MigrationStatusSuccessful
 					vInfo.Reason = "Migration successful for volume"
+					vInfo.BytesTransferred = vInfo.TotalBytes
+					vInfo.ETASeconds = 0
 				} else if mInfo.Status == api.CloudMigrate_InProgress {
 					vInfo.Reason = fmt.Sprintf("Volume migration has started. %v in progress. BytesDone: %v BytesTotal: %v ETA: %v seconds",
 						mInfo.CurrentStage.String(),
@@ -2073,9 +2141,10 @@ func (p *portworx) GetMigrationStatus(migration *storkapi.Migration) ([]*storkap
 			vInfo.Status = storkapi.MigrationStatusFailed
 			vInfo.Reason = "Unable to find migration status for volume"
 		}
+		volumeInfos = append(volumeInfos, vInfo)
 	}
 
-	return migration.Status.Volumes, nil
+	return volumeInfos, nil
 }
 
 func (p *portworx) CancelMigration(migration *storkapi.Migration) error {
@@ -2084,6 +2153,9 @@ func (p *portworx) CancelMigration(migration *storkapi.Migration) error {
 		return err
 	}
 	for _, volumeInfo := range migration.Status.Volumes {
+		if volumeInfo.DriverName != driverName {
+			continue
+		}
 		taskID := p.getMigrationTaskID(migration, volumeInfo)
 		err := volDriver.CloudMigrateCancel(&api.CloudMigrateCancelRequest{
 			TaskId: taskID,
@@ -2513,6 +2585,9 @@ func (p *portworx) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*stork
 		}
 		taskID := p.getBackupRestoreTaskID(backup.UID, vInfo.Namespace, vInfo.PersistentVolumeClaim)
 		csStatus := p.getCloudSnapStatus(volDriver, api.CloudBackupOp, taskID)
+		vInfo.TotalBytes = csStatus.bytesTotal
+		vInfo.BytesTransferred = csStatus.bytesDone
+		vInfo.ETASeconds = csStatus.etaSeconds
 		if isCloudsnapStatusActive(csStatus.status) {
 			vInfo.Status = storkapi.ApplicationBackupStatusInProgress
 			vInfo.Reason = "Volume backup in progress"
@@ -2645,6 +2720,9 @@ func (p *portworx) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*st
 		}
 		taskID := p.getBackupRestoreTaskID(restore.UID, vInfo.SourceNamespace, vInfo.PersistentVolumeClaim)
 		csStatus := p.getCloudSnapStatus(volDriver, api.CloudRestoreOp, taskID)
+		vInfo.TotalBytes = csStatus.bytesTotal
+		vInfo.BytesTransferred = csStatus.bytesDone
+		vInfo.ETASeconds = csStatus.etaSeconds
 		if isCloudsnapStatusActive(csStatus.status) {
 			vInfo.Status = storkapi.ApplicationRestoreStatusInProgress
 			vInfo.Reason = "Volume restore in progress"
@@ -2682,6 +2760,9 @@ func (p *portworx) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
 		return err
 	}
 	for _, vInfo := range clone.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
 		locator := &api.VolumeLocator{
 			Name: vInfo.CloneVolume,
 			VolumeLabels: map[string]string{
@@ -2708,11 +2789,21 @@ func (p *portworx) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
 	}
 	// Update the status for all the volumes only once we are all done
 	for _, vInfo := range clone.Status.Volumes {
+		if vInfo.DriverName != driverName {
+			continue
+		}
 		vInfo.Status = storkapi.ApplicationCloneStatusSuccessful
 		vInfo.Reason = "Volume cloned succesfully"
 	}
 	return nil
 }
+
+// GetCloneStatus doesn't need to do anything since clones are created
+// synchronously in CreateVolumeClones
+func (p *portworx) GetCloneStatus(clone *storkapi.ApplicationClone) error {
+	return nil
+}
+
 func (p *portworx) createGroupLocalSnapFromPVCs(groupSnap *storkapi.GroupVolumeSnapshot, volNames []string, options map[string]string) (
 	*storkvolume.GroupSnapshotCreateResponse, error) {
 	volDriver, err := p.getUserVolDriver(groupSnap.Annotations)
@@ -0,0 +1,160 @@
+package volume
+
+import (
+	"strings"
+
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// labelTopologyZone is the GA label used for the zone of nodes and
+	// volumes
+	labelTopologyZone = "topology.kubernetes.io/zone"
+	// labelTopologyRegion is the GA label used for the region of nodes and
+	// volumes
+	labelTopologyRegion = "topology.kubernetes.io/region"
+	// zoneKeySuffix and regionKeySuffix are used to identify topology keys in
+	// PV node affinity, since CSI drivers use their own keys
+	// (for eg topology.ebs.csi.aws.com/zone)
+	zoneKeySuffix   = "/zone"
+	regionKeySuffix = "/region"
+)
+
+// GetNodesFromLabels returns the nodes in the cluster with the zone and region
+// populated from the Kubernetes node labels. Can be used by drivers for which
+// volumes can be attached to any node in a zone.
+func GetNodesFromLabels() ([]*NodeInfo, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInfos []*NodeInfo
+	for _, node := range nodes.Items {
+		nodeInfo := &NodeInfo{
+			StorageID:   node.Name,
+			SchedulerID: node.Name,
+			Zone:        getLabelValue(node.Labels, labelTopologyZone, kubeletapis.LabelZoneFailureDomain),
+			Region:      getLabelValue(node.Labels, labelTopologyRegion, kubeletapis.LabelZoneRegion),
+			// The volumes aren't tied to the health of the node, so the
+			// driver is considered to be online on all nodes. Node failures
+			// are handled by Kubernetes.
+			Status: NodeOnline,
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeHostName:
+				nodeInfo.Hostname = strings.ToLower(address.Address)
+			case v1.NodeInternalIP:
+				nodeInfo.IPs = append(nodeInfo.IPs, address.Address)
+			}
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+// GetPodVolumesWithTopology returns the volumes used by the pod that are owned
+// by the driver, with the zones and region populated from the PVs. PVCs that
+// haven't been bound yet are skipped since the scheduler will pick the zone
+// for them, as are PVs without any topology information.
+func GetPodVolumesWithTopology(driver Driver, podSpec *v1.PodSpec, namespace string) ([]*Info, error) {
+	var volumes []*Info
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(
+			volume.PersistentVolumeClaim.ClaimName,
+			namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !driver.OwnsPVC(pvc) || pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		pv, err := k8s.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		zones, region := GetPVTopology(pv)
+		// Nothing to schedule on if there is no topology information for
+		// the volume
+		if len(zones) == 0 && region == "" {
+			continue
+		}
+		volumeInfo := &Info{
+			VolumeID:   pv.Name,
+			VolumeName: pv.Name,
+			Labels:     pv.Labels,
+			Zones:      zones,
+			Region:     region,
+		}
+		if size, ok := pv.Spec.Capacity[v1.ResourceStorage]; ok {
+			volumeInfo.Size = uint64(size.Value()) / (1024 * 1024 * 1024)
+		}
+		volumes = append(volumes, volumeInfo)
+	}
+	return volumes, nil
+}
+
+// GetPVTopology returns the zones and region for a PV. The node affinity of
+// the PV is used if present, otherwise the zone and region labels are used.
+func GetPVTopology(pv *v1.PersistentVolume) ([]string, string) {
+	var zones []string
+	region := ""
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expr := range term.MatchExpressions {
+				if expr.Operator != v1.NodeSelectorOpIn {
+					continue
+				}
+				if strings.HasSuffix(expr.Key, zoneKeySuffix) {
+					zones = appendUnique(zones, expr.Values...)
+				} else if strings.HasSuffix(expr.Key, regionKeySuffix) && len(expr.Values) > 0 {
+					region = expr.Values[0]
+				}
+			}
+		}
+	}
+
+	if len(zones) == 0 {
+		// Regional volumes have all the zones in the label
+		zoneLabel := getLabelValue(pv.Labels, labelTopologyZone, kubeletapis.LabelZoneFailureDomain)
+		if zoneLabel != "" {
+			zones = appendUnique(zones, strings.Split(zoneLabel, kubeletapis.LabelMultiZoneDelimiter)...)
+		}
+	}
+	if region == "" {
+		region = getLabelValue(pv.Labels, labelTopologyRegion, kubeletapis.LabelZoneRegion)
+	}
+	return zones, region
+}
+
+func getLabelValue(labels map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
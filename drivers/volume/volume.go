@@ -74,6 +74,20 @@ type Driver interface {
 	ClonePluginInterface
 	// SnapshotRestorePluginInterface Interface to do in-place restore of volumes
 	SnapshotRestorePluginInterface
+	// NodeStatusWatchPluginInterface Interface to watch for changes to the
+	// status of nodes
+	NodeStatusWatchPluginInterface
+	// MigrateVolumesPluginInterface Interface to migrate a limited number of
+	// volumes at a time
+	MigrateVolumesPluginInterface
+}
+
+// NodeStatusWatchPluginInterface Interface to watch for changes to the status
+// of the storage nodes
+type NodeStatusWatchPluginInterface interface {
+	// WatchNodeStatus returns a channel on which nodes are sent when their
+	// status changes. The channel is closed once the stop channel is closed.
+	WatchNodeStatus(stopChannel <-chan struct{}) (<-chan *NodeInfo, error)
 }
 
 // GroupSnapshotCreateResponse is the response for the group snapshot operation
@@ -114,6 +128,17 @@ type MigratePluginInterface interface {
 	UpdateMigratedPersistentVolumeSpec(*v1.PersistentVolume) (*v1.PersistentVolume, error)
 }
 
+// MigrateVolumesPluginInterface Interface to migrate the volumes for a
+// migration in batches instead of starting all of them in StartMigration
+type MigrateVolumesPluginInterface interface {
+	// GetMigrationVolumes returns the volumes that need to be migrated for the
+	// spec without starting the migration for any of them
+	GetMigrationVolumes(*storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error)
+	// StartVolumeMigration starts the migration of the volumes returned by
+	// GetMigrationVolumes and updates their status
+	StartVolumeMigration(*storkapi.Migration, []*storkapi.MigrationVolumeInfo) error
+}
+
 // ClusterDomainsPluginInterface Interface to manage cluster domains
 type ClusterDomainsPluginInterface interface {
 	// GetClusterDomains returns all the cluster domains and their status
@@ -164,7 +189,14 @@ type SnapshotRestorePluginInterface interface {
 
 // ClonePluginInterface Interface to clone volumes
 type ClonePluginInterface interface {
+	// CreateVolumeClones starts the clones for volumes owned by the driver
+	// that haven't been started yet. Drivers that clone asynchronously mark
+	// the volumes as in progress and update them in GetCloneStatus.
 	CreateVolumeClones(*storkapi.ApplicationClone) error
+	// GetCloneStatus updates the status of the volume clones owned by the
+	// driver. An error is returned if the status couldn't be checked and
+	// should be retried.
+	GetCloneStatus(*storkapi.ApplicationClone) error
 }
 
 // Info Information about a volume
@@ -183,6 +215,11 @@ type Info struct {
 	Labels map[string]string
 	// VolumeSourceRef is a optional reference to the source of the volume
 	VolumeSourceRef interface{}
+	// Zones is a list of zones from which the volume can be accessed. Used
+	// by drivers which don't have data for the volume on specific nodes
+	Zones []string
+	// Region is the region where the volume is located
+	Region string
 }
 
 // NodeStatus Status of driver on a node
@@ -216,6 +253,12 @@ type NodeInfo struct {
 	Region string
 	// Status of the node
 	Status NodeStatus
+	// TotalCapacity is the total storage capacity of the node in bytes. Set
+	// to 0 if the driver doesn't report the capacity.
+	TotalCapacity uint64
+	// FreeCapacity is the storage capacity of the node in bytes that is
+	// available for new volumes
+	FreeCapacity uint64
 }
 
 var (
@@ -270,6 +313,48 @@ func GetPVDriver(pv *v1.PersistentVolume) (string, error) {
 	}
 }
 
+// GetPVCDriverFromList gets the driver from the given list which owns the PVC.
+// Returns ErrNotFound if the PVC is not owned by any of the drivers
+func GetPVCDriverFromList(drivers []Driver, pvc *v1.PersistentVolumeClaim) (Driver, error) {
+	for _, d := range drivers {
+		if d.OwnsPVC(pvc) {
+			return d, nil
+		}
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   pvc.Name,
+		Type: "VolumeDriver",
+	}
+}
+
+// GetPVDriverFromList gets the driver from the given list which owns the PV.
+// Returns ErrNotFound if the PV is not owned by any of the drivers
+func GetPVDriverFromList(drivers []Driver, pv *v1.PersistentVolume) (Driver, error) {
+	for _, d := range drivers {
+		if d.OwnsPV(pv) {
+			return d, nil
+		}
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   pv.Name,
+		Type: "VolumeDriver",
+	}
+}
+
+// GetDriverFromList returns the driver with the given name from the list.
+// Returns ErrNotFound if the driver isn't in the list
+func GetDriverFromList(drivers []Driver, name string) (Driver, error) {
+	for _, d := range drivers {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   name,
+		Type: "VolumeDriver",
+	}
+}
+
 // ClusterPairNotSupported to be used by drivers that don't support pairing
 type ClusterPairNotSupported struct{}
 
@@ -308,6 +393,20 @@ func (m *MigrationNotSupported) UpdateMigratedPersistentVolumeSpec(
 	return nil, &errors.ErrNotSupported{}
 }
 
+// MigrateVolumesNotSupported to be used by drivers that can't migrate the
+// volumes for a migration in batches
+type MigrateVolumesNotSupported struct{}
+
+// GetMigrationVolumes returns ErrNotSupported
+func (m *MigrateVolumesNotSupported) GetMigrationVolumes(*storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+// StartVolumeMigration returns ErrNotSupported
+func (m *MigrateVolumesNotSupported) StartVolumeMigration(*storkapi.Migration, []*storkapi.MigrationVolumeInfo) error {
+	return &errors.ErrNotSupported{}
+}
+
 // GroupSnapshotNotSupported to be used by drivers that don't support group snapshots
 type GroupSnapshotNotSupported struct{}
 
@@ -396,6 +495,20 @@ func (c *CloneNotSupported) CreateVolumeClones(*storkapi.ApplicationClone) error
 	return &errors.ErrNotSupported{}
 }
 
+// GetCloneStatus returns ErrNotSupported
+func (c *CloneNotSupported) GetCloneStatus(*storkapi.ApplicationClone) error {
+	return &errors.ErrNotSupported{}
+}
+
+// NodeStatusWatchNotSupported to be used by drivers that can't watch for
+// changes to the status of nodes
+type NodeStatusWatchNotSupported struct{}
+
+// WatchNodeStatus returns ErrNotSupported
+func (n *NodeStatusWatchNotSupported) WatchNodeStatus(<-chan struct{}) (<-chan *NodeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
 // SnapshotRestoreNotSupported to be used by drivers that don't support
 // volume snapshot restore
 type SnapshotRestoreNotSupported struct{}
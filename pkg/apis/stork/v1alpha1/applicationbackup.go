@@ -30,6 +30,13 @@ type ApplicationBackupSpec struct {
 	PreExecRule    string                             `json:"preExecRule"`
 	PostExecRule   string                             `json:"postExecRule"`
 	ReclaimPolicy  ApplicationBackupReclaimPolicyType `json:"reclaimPolicy"`
+	// IncludeResourceTypes are backed up in addition to the resource types
+	// that are backed up by default or included by the
+	// ResourceCollectorConfig
+	IncludeResourceTypes []ResourceType `json:"includeResourceTypes"`
+	// ExcludeResourceTypes aren't backed up. Overrides the resource types
+	// included by the ResourceCollectorConfig or IncludeResourceTypes.
+	ExcludeResourceTypes []ResourceType `json:"excludeResourceTypes"`
 }
 
 // ApplicationBackupReclaimPolicyType is the reclaim policy for the application backup
@@ -53,6 +60,10 @@ type ApplicationBackupStatus struct {
 	BackupPath       string                           `json:"backupPath"`
 	TriggerTimestamp metav1.Time                      `json:"triggerTimestamp"`
 	FinishTimestamp  metav1.Time                      `json:"finishTimestamp"`
+	// EncryptionProvider is the key provider that wrapped the data key for
+	// the backup when it was taken. It is empty if the backup wasn't
+	// encrypted with a data key, so a missing data key can be detected.
+	EncryptionProvider string `json:"encryptionProvider,omitempty"`
 }
 
 // ApplicationBackupResourceInfo is the info for the backup of a resource
@@ -73,6 +84,12 @@ type ApplicationBackupVolumeInfo struct {
 	Status                ApplicationBackupStatusType `json:"status"`
 	Reason                string                      `json:"reason"`
 	Options               map[string]string           `jons:"options"`
+	// TotalBytes is the number of bytes being transferred for the volume
+	TotalBytes uint64 `json:"totalBytes"`
+	// BytesTransferred is the number of bytes transferred so far
+	BytesTransferred uint64 `json:"bytesTransferred"`
+	// ETASeconds is the estimated time in seconds for the transfer to complete
+	ETASeconds int64 `json:"etaSeconds"`
 }
 
 // ApplicationBackupStatusType is the status of the application backup
@@ -17,6 +17,14 @@ type ApplicationBackupScheduleSpec struct {
 	SchedulePolicyName string                        `json:"schedulePolicyName"`
 	Suspend            *bool                         `json:"suspend"`
 	ReclaimPolicy      ReclaimPolicyType             `json:"reclaimPolicy"`
+	// StartingDeadlineSeconds is the deadline for starting a run after the
+	// time it was scheduled for. Runs that can't be started before the
+	// deadline are recorded as missed. Defaults to one hour for daily,
+	// weekly and monthly policies and no deadline for interval policies.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds"`
+	// ConcurrencyPolicy specifies how a run is handled when a previous run
+	// is still in progress. Defaults to Forbid.
+	ConcurrencyPolicy ConcurrencyPolicyType `json:"concurrencyPolicy"`
 }
 
 // ApplicationBackupTemplateSpec describes the data a ApplicationBackup should have when created
@@ -28,6 +36,10 @@ type ApplicationBackupTemplateSpec struct {
 // ApplicationBackupScheduleStatus is the status of a applicationbackup schedule
 type ApplicationBackupScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledApplicationBackupStatus `json:"items"`
+	// MissedRuns are the most recent runs that weren't started
+	MissedRuns []*ScheduleMissedRun `json:"missedRuns"`
+	// LastSuccessfulTime is the time the last successful run finished
+	LastSuccessfulTime meta.Time `json:"lastSuccessfulTime"`
 }
 
 // ScheduledApplicationBackupStatus keeps track of the applicationbackup that was triggered by a
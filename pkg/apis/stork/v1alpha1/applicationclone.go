@@ -63,6 +63,7 @@ type ApplicationCloneVolumeInfo struct {
 	PersistentVolumeClaim string                     `json:"persistentVolumeClaim"`
 	Volume                string                     `json:"volume"`
 	CloneVolume           string                     `json:"cloneVolume"`
+	DriverName            string                     `json:"driverName"`
 	Status                ApplicationCloneStatusType `json:"status"`
 	Reason                string                     `json:"reason"`
 }
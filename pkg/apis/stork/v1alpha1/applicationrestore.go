@@ -31,6 +31,24 @@ type ApplicationRestoreSpec struct {
 	Selectors        map[string]string                   `json:"selectors"`
 	EncryptionKey    *corev1.EnvVarSource                `json:"encryptionKey"`
 	ReplacePolicy    ApplicationRestoreReplacePolicyType `json:"replacePolicy"`
+	// IncludeResources restricts the restore to the resources in the backup
+	// that match any of the filters. Everything in the backup is restored if
+	// empty.
+	IncludeResources []RestoreResourceFilter `json:"includeResources"`
+	// ExcludeResources aren't restored, even if they match IncludeResources
+	ExcludeResources []RestoreResourceFilter `json:"excludeResources"`
+}
+
+// RestoreResourceFilter selects resources in a backup by their type, name and
+// namespace. PersistentVolumes are selected along with their
+// PersistentVolumeClaims.
+type RestoreResourceFilter struct {
+	ResourceType `json:",inline"`
+	// Name of the resources. Matches all names if empty.
+	Name string `json:"name"`
+	// Namespace of the resources in the backup, before NamespaceMapping is
+	// applied. Matches all namespaces if empty.
+	Namespace string `json:"namespace"`
 }
 
 // ApplicationRestoreReplacePolicyType is the replace policy for the application restore
@@ -76,6 +94,12 @@ type ApplicationRestoreVolumeInfo struct {
 	Zones                 []string                     `json:"zones"`
 	Status                ApplicationRestoreStatusType `json:"status"`
 	Reason                string                       `json:"reason"`
+	// TotalBytes is the number of bytes being transferred for the volume
+	TotalBytes uint64 `json:"totalBytes"`
+	// BytesTransferred is the number of bytes transferred so far
+	BytesTransferred uint64 `json:"bytesTransferred"`
+	// ETASeconds is the estimated time in seconds for the transfer to complete
+	ETASeconds int64 `json:"etaSeconds"`
 }
 
 // ApplicationRestoreStatusType is the status of the application restore
@@ -32,7 +32,8 @@ type BackupLocation struct {
 // through the SecretConfig
 type BackupLocationItem struct {
 	Type BackupLocationType `json:"type"`
-	// Path is either the bucket or any other path for the backup location
+	// Path is either the bucket or any other path for the backup location.
+	// For file and nfs backup locations it is the directory to store backups in
 	Path          string        `json:"path"`
 	EncryptionKey string        `json:"encryptionKey"`
 	S3Config      *S3Config     `json:"s3Config,omitempty"`
@@ -40,6 +41,44 @@ type BackupLocationItem struct {
 	GoogleConfig  *GoogleConfig `json:"googleConfig,omitempty"`
 	SecretConfig  string        `json:"secretConfig"`
 	Sync          bool          `json:"sync"`
+	// SigningKey is used to sign the manifest with the checksums of the
+	// objects for each backup. Backups are only restored or synced if the
+	// signature matches when it is set.
+	SigningKey string `json:"signingKey,omitempty"`
+	// EncryptionConfig configures the key provider for the key-encryption
+	// key that wraps the data key for each backup. If it isn't specified the
+	// key-encryption key is derived from EncryptionKey.
+	EncryptionConfig *EncryptionConfig `json:"encryptionConfig,omitempty"`
+}
+
+// EncryptionConfig specifies the key provider used to encrypt backups
+type EncryptionConfig struct {
+	// Provider is the name of the key provider. Defaults to passphrase which
+	// uses EncryptionKey.
+	Provider string `json:"provider"`
+	// KeyID is the ID of the key-encryption key that new data keys are
+	// wrapped with, for providers that need one
+	KeyID string `json:"keyID"`
+	// PreviousEncryptionKeys are older values of EncryptionKey that are
+	// needed to restore backups until their data keys have been rotated
+	PreviousEncryptionKeys []string `json:"previousEncryptionKeys,omitempty"`
+	// Options are specific to the key provider
+	Options map[string]string `json:"options,omitempty"`
+	// PreviousProviders are key providers that were configured before
+	// Provider. Data keys wrapped by them can still be unwrapped, and are
+	// wrapped with Provider when the data keys are rotated.
+	PreviousProviders []KeyProviderConfig `json:"previousProviders,omitempty"`
+}
+
+// KeyProviderConfig specifies a key provider that was previously used to
+// encrypt backups. The passphrase provider uses PreviousEncryptionKeys.
+type KeyProviderConfig struct {
+	// Provider is the name of the key provider
+	Provider string `json:"provider"`
+	// KeyID is the ID of the key-encryption key, for providers that need one
+	KeyID string `json:"keyID"`
+	// Options are specific to the key provider
+	Options map[string]string `json:"options,omitempty"`
 }
 
 // BackupLocationType is the type of the backup location
@@ -52,6 +91,13 @@ const (
 	BackupLocationAzure BackupLocationType = "azure"
 	// BackupLocationGoogle stores the backup in Google Cloud Storage
 	BackupLocationGoogle BackupLocationType = "google"
+	// BackupLocationFile stores the backup in a directory on the filesystem.
+	// Path should be an absolute path to a directory that is mounted in the
+	// stork pods, under the root configured for filesystem backupLocations.
+	BackupLocationFile BackupLocationType = "file"
+	// BackupLocationNFS stores the backup on an NFS share. This is the same as
+	// BackupLocationFile with Path being where the share is mounted.
+	BackupLocationNFS BackupLocationType = "nfs"
 )
 
 // S3Config speficies the config required to connect to an S3-compliant
@@ -100,6 +146,19 @@ func (bl *BackupLocation) UpdateFromSecret(client kubernetes.Interface) error {
 		if val, ok := secretConfig.Data["encryptionKey"]; ok && val != nil {
 			bl.Location.EncryptionKey = strings.TrimSuffix(string(val), "\n")
 		}
+		if val, ok := secretConfig.Data["signingKey"]; ok && val != nil {
+			bl.Location.SigningKey = strings.TrimSuffix(string(val), "\n")
+		}
+		if val, ok := secretConfig.Data["previousEncryptionKeys"]; ok && val != nil {
+			if bl.Location.EncryptionConfig == nil {
+				bl.Location.EncryptionConfig = &EncryptionConfig{}
+			}
+			for _, key := range strings.Split(string(val), "\n") {
+				if key != "" {
+					bl.Location.EncryptionConfig.PreviousEncryptionKeys = append(bl.Location.EncryptionConfig.PreviousEncryptionKeys, key)
+				}
+			}
+		}
 		if val, ok := secretConfig.Data["path"]; ok && val != nil {
 			bl.Location.Path = strings.TrimSuffix(string(val), "\n")
 		}
@@ -111,6 +170,9 @@ func (bl *BackupLocation) UpdateFromSecret(client kubernetes.Interface) error {
 		return bl.getMergedAzureConfig(client)
 	case BackupLocationGoogle:
 		return bl.getMergedGoogleConfig(client)
+	case BackupLocationFile, BackupLocationNFS:
+		// Only the path is required which has already been merged above
+		return nil
 	default:
 		return fmt.Errorf("Invalid BackupLocation type %v", bl.Location.Type)
 	}
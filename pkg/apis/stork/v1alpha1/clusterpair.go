@@ -10,6 +10,9 @@ const (
 	ClusterPairResourceName = "clusterpair"
 	// ClusterPairResourcePlural is plural for "clusterpair" resource
 	ClusterPairResourcePlural = "clusterpairs"
+	// ClusterPairConfigSecretKey is the key in the config secret with the
+	// kubeconfig for the remote cluster
+	ClusterPairConfigSecretKey = "kubeconfig"
 )
 
 // +genclient
@@ -27,6 +30,11 @@ type ClusterPair struct {
 type ClusterPairSpec struct {
 	Config  api.Config        `json:"config"`
 	Options map[string]string `json:"options"`
+	// ConfigSecret is the name of a secret in the same namespace with the
+	// kubeconfig for the remote cluster, used instead of Config if set. The
+	// secret is read every time the remote cluster is accessed so that
+	// rotated credentials are picked up without re-creating the pair.
+	ConfigSecret string `json:"configSecret"`
 }
 
 // ClusterPairStatusType is the status of the pair
@@ -61,6 +69,16 @@ type ClusterPairStatus struct {
 	// ID of the remote storage which is paired
 	// +optional
 	RemoteStorageID string `json:"remoteStorageId"`
+	// Reason for the scheduler status
+	// +optional
+	SchedulerReason string `json:"schedulerReason"`
+	// Time when the connectivity to the remote cluster was last validated
+	// +optional
+	LastValidated meta.Time `json:"lastValidated"`
+	// Time when the credentials for the remote cluster expire, if they have
+	// an expiry
+	// +optional
+	CredentialsExpiry *meta.Time `json:"credentialsExpiry,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
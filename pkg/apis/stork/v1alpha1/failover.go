@@ -0,0 +1,114 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FailoverResourceName is name for "failover" resource
+	FailoverResourceName = "failover"
+	// FailoverResourcePlural is plural for "failover" resource
+	FailoverResourcePlural = "failovers"
+	// FailoverShortName is the short name for failover
+	FailoverShortName = "fo"
+)
+
+// FailoverSpec is the spec used to fail over apps to the destination cluster
+// of a cluster pair, or to fail them back to the source cluster
+type FailoverSpec struct {
+	// ClusterPair is used to migrate the apps to the destination cluster
+	ClusterPair string `json:"clusterPair"`
+	// Namespaces with the apps to fail over
+	Namespaces []string `json:"namespaces"`
+	// NamespaceMapping maps the namespaces on the source cluster to the
+	// namespaces on the destination cluster
+	NamespaceMapping map[string]string `json:"namespaceMapping"`
+	// IncludeVolumes is used to migrate the volumes in the final migration
+	IncludeVolumes *bool `json:"includeVolumes"`
+	// ClusterDomain is the cluster domain of the source cluster that is
+	// deactivated after the final migration. Not deactivated if empty.
+	ClusterDomain string `json:"clusterDomain"`
+	// Failback is the name of a completed failover in the same namespace. If
+	// set, the successful steps of that failover are reversed and the other
+	// fields are filled in from it.
+	Failback string `json:"failback"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Failover represents a planned failover of apps to the destination cluster
+// of a cluster pair, or a failback of a previous failover
+type Failover struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            FailoverSpec   `json:"spec"`
+	Status          FailoverStatus `json:"status"`
+}
+
+// FailoverStatus is the status of a failover operation
+type FailoverStatus struct {
+	Status FailoverStatusType `json:"status"`
+	Reason string             `json:"reason"`
+	// Steps are the steps that have been performed, in order
+	Steps []*FailoverStepInfo `json:"steps"`
+	// MigrationName is the name of the final migration
+	MigrationName string `json:"migrationName"`
+}
+
+// FailoverStepInfo is the outcome of a step of a failover
+type FailoverStepInfo struct {
+	Step      FailoverStepType   `json:"step"`
+	Status    FailoverStatusType `json:"status"`
+	Reason    string             `json:"reason"`
+	Timestamp meta.Time          `json:"timestamp"`
+}
+
+// FailoverStatusType is the status of a failover or of one of its steps
+type FailoverStatusType string
+
+const (
+	// FailoverStatusInitial is the initial status when a failover is created
+	FailoverStatusInitial FailoverStatusType = ""
+	// FailoverStatusInProgress is the status when a failover is in progress
+	FailoverStatusInProgress FailoverStatusType = "InProgress"
+	// FailoverStatusFailed is the status when a failover has failed
+	FailoverStatusFailed FailoverStatusType = "Failed"
+	// FailoverStatusSuccessful is the status when a failover has completed
+	// successfully
+	FailoverStatusSuccessful FailoverStatusType = "Successful"
+)
+
+// FailoverStepType is a step of a failover
+type FailoverStepType string
+
+const (
+	// FailoverStepScaleDownSource scales down the apps on the source cluster
+	FailoverStepScaleDownSource FailoverStepType = "ScaleDownSource"
+	// FailoverStepMigrate runs the final migration to the destination cluster
+	FailoverStepMigrate FailoverStepType = "Migrate"
+	// FailoverStepDeactivateClusterDomain deactivates the cluster domain of
+	// the source cluster
+	FailoverStepDeactivateClusterDomain FailoverStepType = "DeactivateClusterDomain"
+	// FailoverStepActivateDestination scales up the apps on the destination
+	// cluster
+	FailoverStepActivateDestination FailoverStepType = "ActivateDestination"
+	// FailoverStepDeactivateDestination scales down the apps on the
+	// destination cluster during a failback
+	FailoverStepDeactivateDestination FailoverStepType = "DeactivateDestination"
+	// FailoverStepActivateClusterDomain activates the cluster domain of the
+	// source cluster during a failback
+	FailoverStepActivateClusterDomain FailoverStepType = "ActivateClusterDomain"
+	// FailoverStepScaleUpSource scales the apps on the source cluster back up
+	// during a failback
+	FailoverStepScaleUpSource FailoverStepType = "ScaleUpSource"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FailoverList is a list of failovers
+type FailoverList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []Failover `json:"items"`
+}
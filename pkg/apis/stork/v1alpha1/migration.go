@@ -13,7 +13,13 @@ const (
 
 // MigrationSpec is the spec used to migrate apps between clusterpairs
 type MigrationSpec struct {
-	ClusterPair           string            `json:"clusterPair"`
+	ClusterPair string `json:"clusterPair"`
+	// ClusterPairs are additional cluster pairs to migrate to. The
+	// applications are quiesced and the resources are collected once and
+	// then migrated to all the destination clusters in parallel.
+	ClusterPairs []string `json:"clusterPairs"`
+	// AdminClusterPair is used for the cluster scoped resources on the
+	// destination cluster of ClusterPair. It isn't used for ClusterPairs.
 	AdminClusterPair      string            `json:"adminClusterPair"`
 	Namespaces            []string          `json:"namespaces"`
 	IncludeResources      *bool             `json:"includeResources"`
@@ -23,6 +29,58 @@ type MigrationSpec struct {
 	Selectors             map[string]string `json:"selectors"`
 	PreExecRule           string            `json:"preExecRule"`
 	PostExecRule          string            `json:"postExecRule"`
+	// NamespaceMapping maps the namespaces being migrated to the namespaces
+	// they should be created in on the destination cluster. Namespaces
+	// without a mapping are migrated to a namespace with the same name.
+	NamespaceMapping map[string]string `json:"namespaceMapping"`
+	// Transforms are applied to the resources before they are created on
+	// the destination cluster
+	Transforms []ResourceTransform `json:"transforms"`
+	// Priority of the migration when it is waiting to start because the
+	// maximum number of migrations are already running. Migrations with a
+	// higher priority are started first.
+	Priority int32 `json:"priority"`
+	// MaxConcurrentVolumes is the maximum number of volumes that are migrated
+	// at the same time. All volumes are migrated at once if not set or if the
+	// driver doesn't support it.
+	MaxConcurrentVolumes int `json:"maxConcurrentVolumes"`
+	// MaxBandwidthMBps is a hint for the maximum bandwidth in MB/s to use
+	// when migrating volumes. None of the volume drivers support limiting
+	// the bandwidth yet, so volumes are migrated without a limit and a
+	// warning is added to the status if it is set.
+	MaxBandwidthMBps int64 `json:"maxBandwidthMBps"`
+	// Verify checks that the resources on the destination cluster match the
+	// resources that were migrated and that the migrated PVCs are bound
+	Verify *bool `json:"verify"`
+	// VerifyApplications starts the migrated applications on the destination
+	// cluster during verification to check that they become ready.
+	// Applications that weren't started by the migration are scaled down
+	// again afterwards. Ignored if Verify isn't set.
+	VerifyApplications *bool `json:"verifyApplications"`
+	// IncludeResourceTypes are migrated in addition to the resource types
+	// that are migrated by default or included by the ResourceCollectorConfig
+	IncludeResourceTypes []ResourceType `json:"includeResourceTypes"`
+	// ExcludeResourceTypes aren't migrated. Overrides the resource types
+	// included by the ResourceCollectorConfig or IncludeResourceTypes.
+	ExcludeResourceTypes []ResourceType `json:"excludeResourceTypes"`
+}
+
+// ResourceTransform is a JSON patch that is applied to the resources that
+// match the group, version, kind and selectors
+type ResourceTransform struct {
+	// Group of the resources to transform, use "core" for the core group.
+	// Matches all groups if empty.
+	Group string `json:"group"`
+	// Version of the resources to transform. Matches all versions if empty.
+	Version string `json:"version"`
+	// Kind of the resources to transform. Matches all kinds if empty.
+	Kind string `json:"kind"`
+	// Selectors are the labels that the resources need to have to be
+	// transformed
+	Selectors map[string]string `json:"selectors"`
+	// Patch is a JSON patch document (RFC 6902) with the operations to apply
+	// to the resources
+	Patch string `json:"patch"`
 }
 
 // MigrationStatus is the status of a migration operation
@@ -32,6 +90,58 @@ type MigrationStatus struct {
 	Resources       []*MigrationResourceInfo `json:"resources"`
 	Volumes         []*MigrationVolumeInfo   `json:"volumes"`
 	FinishTimestamp meta.Time                `json:"finishTimestamp"`
+	// Verification is the summary of the verification of the resources
+	// migrated to each namespace on the destination cluster
+	Verification []*MigrationNamespaceVerification `json:"verification"`
+	// VerifyStartTimestamp is when the verification of the resources
+	// started. PVCs and applications that aren't ready by the verification
+	// timeouts from this time are failed.
+	VerifyStartTimestamp meta.Time `json:"verifyStartTimestamp"`
+	// Destinations is the status of the migration to each of the
+	// destination clusters
+	Destinations []*MigrationDestinationInfo `json:"destinations"`
+	// TotalBytes is the number of bytes being transferred for all the
+	// volumes
+	TotalBytes uint64 `json:"totalBytes"`
+	// BytesTransferred is the number of bytes transferred so far for all the
+	// volumes
+	BytesTransferred uint64 `json:"bytesTransferred"`
+	// ETASeconds is the estimated time in seconds for the transfer of all
+	// the volumes to complete
+	ETASeconds int64 `json:"etaSeconds"`
+	// Warnings are the options from the spec that couldn't be honored by
+	// the volume drivers
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// MigrationDestinationInfo is the status of the migration to one of the
+// destination clusters
+type MigrationDestinationInfo struct {
+	ClusterPair string              `json:"clusterPair"`
+	Status      MigrationStatusType `json:"status"`
+	Reason      string              `json:"reason"`
+	// StateError is the error from saving the state of the migrated
+	// resources on the destination cluster for a migration created by a
+	// schedule. All the resources are migrated again by the next migration
+	// if the state couldn't be saved.
+	StateError string `json:"stateError,omitempty"`
+}
+
+// MigrationNamespaceVerification is the summary of the verification of the
+// resources migrated to a namespace
+type MigrationNamespaceVerification struct {
+	// ClusterPair for the destination cluster that the namespace was
+	// verified on
+	ClusterPair string `json:"clusterPair"`
+	Namespace   string `json:"namespace"`
+	// Expected is the number of resources that were migrated to the namespace
+	Expected int `json:"expected"`
+	// Found is the number of resources that were found on the destination
+	// cluster
+	Found int `json:"found"`
+	// Verified is the number of resources that matched the source
+	Verified  int       `json:"verified"`
+	Timestamp meta.Time `json:"timestamp"`
 }
 
 // MigrationResourceInfo is the info for the migration of a resource
@@ -41,6 +151,13 @@ type MigrationResourceInfo struct {
 	meta.GroupVersionKind `json:",inline"`
 	Status                MigrationStatusType `json:"status"`
 	Reason                string              `json:"reason"`
+	// VerifyStatus is the result of verifying the resource on the
+	// destination cluster, empty if it wasn't verified
+	VerifyStatus MigrationStatusType `json:"verifyStatus"`
+	VerifyReason string              `json:"verifyReason"`
+	// ClusterPair for the destination cluster that the resource was
+	// migrated to
+	ClusterPair string `json:"clusterPair"`
 }
 
 // MigrationVolumeInfo is the info for the migration of a volume
@@ -48,8 +165,18 @@ type MigrationVolumeInfo struct {
 	PersistentVolumeClaim string              `json:"persistentVolumeClaim"`
 	Namespace             string              `json:"namespace"`
 	Volume                string              `json:"volume"`
+	DriverName            string              `json:"driverName"`
 	Status                MigrationStatusType `json:"status"`
 	Reason                string              `json:"reason"`
+	// ClusterPair for the destination cluster that the volume is being
+	// migrated to
+	ClusterPair string `json:"clusterPair"`
+	// TotalBytes is the number of bytes being transferred for the volume
+	TotalBytes uint64 `json:"totalBytes"`
+	// BytesTransferred is the number of bytes transferred so far
+	BytesTransferred uint64 `json:"bytesTransferred"`
+	// ETASeconds is the estimated time in seconds for the transfer to complete
+	ETASeconds int64 `json:"etaSeconds"`
 }
 
 // +genclient
@@ -83,6 +210,12 @@ const (
 	MigrationStatusSuccessful MigrationStatusType = "Successful"
 	// MigrationStatusPurged for when migration objects has been deleted
 	MigrationStatusPurged MigrationStatusType = "Purged"
+	// MigrationStatusSkipped for when a resource wasn't migrated since it
+	// hasn't changed since the last migration
+	MigrationStatusSkipped MigrationStatusType = "Skipped"
+	// MigrationStatusDrifted for when a resource was modified on the
+	// destination since the last migration and has been migrated again
+	MigrationStatusDrifted MigrationStatusType = "Drifted"
 )
 
 // MigrationStageType is the stage of the migration
@@ -99,6 +232,9 @@ const (
 	MigrationStageVolumes MigrationStageType = "Volumes"
 	// MigrationStageApplications for when applications are being migrated
 	MigrationStageApplications MigrationStageType = "Applications"
+	// MigrationStageVerify for when the migrated resources are being verified
+	// on the destination cluster
+	MigrationStageVerify MigrationStageType = "Verify"
 	// MigrationStageFinal is the final stage for migration
 	MigrationStageFinal MigrationStageType = "Final"
 )
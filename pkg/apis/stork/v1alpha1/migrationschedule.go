@@ -16,6 +16,14 @@ type MigrationScheduleSpec struct {
 	Template           MigrationTemplateSpec `json:"template"`
 	SchedulePolicyName string                `json:"schedulePolicyName"`
 	Suspend            *bool                 `json:"suspend"`
+	// StartingDeadlineSeconds is the deadline for starting a run after the
+	// time it was scheduled for. Runs that can't be started before the
+	// deadline are recorded as missed. Defaults to one hour for daily,
+	// weekly and monthly policies and no deadline for interval policies.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds"`
+	// ConcurrencyPolicy specifies how a run is handled when a previous run
+	// is still in progress. Defaults to Forbid.
+	ConcurrencyPolicy ConcurrencyPolicyType `json:"concurrencyPolicy"`
 }
 
 // MigrationTemplateSpec describes the data a Migration should have when created
@@ -27,6 +35,10 @@ type MigrationTemplateSpec struct {
 // MigrationScheduleStatus is the status of a migration schedule
 type MigrationScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledMigrationStatus `json:"items"`
+	// MissedRuns are the most recent runs that weren't started
+	MissedRuns []*ScheduleMissedRun `json:"missedRuns"`
+	// LastSuccessfulTime is the time the last successful run finished
+	LastSuccessfulTime meta.Time `json:"lastSuccessfulTime"`
 }
 
 // ScheduledMigrationStatus keeps track of the migration that was triggered by a
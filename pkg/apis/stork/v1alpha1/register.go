@@ -63,6 +63,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ApplicationBackupScheduleList{},
 		&DataExport{},
 		&DataExportList{},
+		&Failover{},
+		&FailoverList{},
+		&ResourceCollectorConfig{},
+		&ResourceCollectorConfigList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -0,0 +1,57 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ResourceCollectorConfigResourceName is name for "resourcecollectorconfig" resource
+	ResourceCollectorConfigResourceName = "resourcecollectorconfig"
+	// ResourceCollectorConfigResourcePlural is plural for "resourcecollectorconfig" resource
+	ResourceCollectorConfigResourcePlural = "resourcecollectorconfigs"
+)
+
+// ResourceType selects resources with a group, version and kind
+type ResourceType struct {
+	// Group of the resources, use "core" for the core group. Matches all
+	// groups if empty.
+	Group string `json:"group"`
+	// Version of the resources. Matches all versions if empty.
+	Version string `json:"version"`
+	// Kind of the resources. Matches all kinds if empty.
+	Kind string `json:"kind"`
+}
+
+// ResourceCollectorConfigSpec is the spec for the resource types that are
+// collected for migrations and backups
+type ResourceCollectorConfigSpec struct {
+	// IncludeResourceTypes are collected in addition to the resource types
+	// that are collected by default
+	IncludeResourceTypes []ResourceType `json:"includeResourceTypes"`
+	// ExcludeResourceTypes aren't collected, even if they are collected by
+	// default or included
+	ExcludeResourceTypes []ResourceType `json:"excludeResourceTypes"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ResourceCollectorConfig is the cluster-wide config for the resource types
+// that are collected. The include and exclude lists from all the configs are
+// merged.
+type ResourceCollectorConfig struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            ResourceCollectorConfigSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ResourceCollectorConfigList is a list of ResourceCollectorConfigs
+type ResourceCollectorConfigList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []ResourceCollectorConfig `json:"items"`
+}
@@ -49,6 +49,30 @@ func GetValidSchedulePolicyTypes() []SchedulePolicyType {
 	return []SchedulePolicyType{SchedulePolicyTypeInterval, SchedulePolicyTypeDaily, SchedulePolicyTypeWeekly, SchedulePolicyTypeMonthly}
 }
 
+// ConcurrencyPolicyType specifies how a run of a schedule is handled when a
+// previous run is still in progress
+type ConcurrencyPolicyType string
+
+const (
+	// ConcurrencyPolicyAllow allows runs to be started while previous ones
+	// are still in progress
+	ConcurrencyPolicyAllow ConcurrencyPolicyType = "Allow"
+	// ConcurrencyPolicyForbid waits for the previous runs to complete before
+	// starting a new one. This is the default.
+	ConcurrencyPolicyForbid ConcurrencyPolicyType = "Forbid"
+	// ConcurrencyPolicyReplace cancels the runs in progress and starts a new
+	// one
+	ConcurrencyPolicyReplace ConcurrencyPolicyType = "Replace"
+)
+
+// ScheduleMissedRun is a run of a schedule that wasn't started
+type ScheduleMissedRun struct {
+	PolicyType SchedulePolicyType `json:"policyType"`
+	// ScheduledTime is the time the run was scheduled for
+	ScheduledTime meta.Time `json:"scheduledTime"`
+	Reason        string    `json:"reason"`
+}
+
 // Days is a map of valid Day strings
 var Days = map[string]time.Weekday{
 	"Sunday":    time.Sunday,
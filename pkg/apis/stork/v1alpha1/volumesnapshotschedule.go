@@ -20,6 +20,14 @@ type VolumeSnapshotScheduleSpec struct {
 	ReclaimPolicy      ReclaimPolicyType          `json:"reclaimPolicy"`
 	PreExecRule        string                     `json:"preExecRule"`
 	PostExecRule       string                     `json:"postExecRule"`
+	// StartingDeadlineSeconds is the deadline for starting a run after the
+	// time it was scheduled for. Runs that can't be started before the
+	// deadline are recorded as missed. Defaults to one hour for daily,
+	// weekly and monthly policies and no deadline for interval policies.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds"`
+	// ConcurrencyPolicy specifies how a run is handled when a previous run
+	// is still in progress. Defaults to Forbid.
+	ConcurrencyPolicy ConcurrencyPolicyType `json:"concurrencyPolicy"`
 }
 
 // VolumeSnapshotTemplateSpec describes the data a VolumeSnapshot should have when created
@@ -43,6 +51,10 @@ const (
 // VolumeSnapshotScheduleStatus is the status of a volumesnapshot schedule
 type VolumeSnapshotScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledVolumeSnapshotStatus `json:"items"`
+	// MissedRuns are the most recent runs that weren't started
+	MissedRuns []*ScheduleMissedRun `json:"missedRuns"`
+	// LastSuccessfulTime is the time the last successful run finished
+	LastSuccessfulTime meta.Time `json:"lastSuccessfulTime"`
 }
 
 // ScheduledVolumeSnapshotStatus keeps track of the volumesnapshot that was triggered by a
@@ -174,6 +174,11 @@ func (in *ApplicationBackupScheduleSpec) DeepCopyInto(out *ApplicationBackupSche
 		*out = new(bool)
 		**out = **in
 	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	return
 }
 
@@ -211,6 +216,18 @@ func (in *ApplicationBackupScheduleStatus) DeepCopyInto(out *ApplicationBackupSc
 			(*out)[key] = outVal
 		}
 	}
+	if in.MissedRuns != nil {
+		in, out := &in.MissedRuns, &out.MissedRuns
+		*out = make([]*ScheduleMissedRun, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ScheduleMissedRun)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	in.LastSuccessfulTime.DeepCopyInto(&out.LastSuccessfulTime)
 	return
 }
 
@@ -239,6 +256,16 @@ func (in *ApplicationBackupSpec) DeepCopyInto(out *ApplicationBackupSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.IncludeResourceTypes != nil {
+		in, out := &in.IncludeResourceTypes, &out.IncludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeResourceTypes != nil {
+		in, out := &in.ExcludeResourceTypes, &out.ExcludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -593,6 +620,16 @@ func (in *ApplicationRestoreSpec) DeepCopyInto(out *ApplicationRestoreSpec) {
 		*out = new(v1.EnvVarSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.IncludeResources != nil {
+		in, out := &in.IncludeResources, &out.IncludeResources
+		*out = make([]RestoreResourceFilter, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeResources != nil {
+		in, out := &in.ExcludeResources, &out.ExcludeResources
+		*out = make([]RestoreResourceFilter, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -727,6 +764,11 @@ func (in *BackupLocationItem) DeepCopyInto(out *BackupLocationItem) {
 		*out = new(GoogleConfig)
 		**out = **in
 	}
+	if in.EncryptionConfig != nil {
+		in, out := &in.EncryptionConfig, &out.EncryptionConfig
+		*out = new(EncryptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -969,7 +1011,7 @@ func (in *ClusterPair) DeepCopyInto(out *ClusterPair) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -1051,6 +1093,11 @@ func (in *ClusterPairSpec) DeepCopy() *ClusterPairSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPairStatus) DeepCopyInto(out *ClusterPairStatus) {
 	*out = *in
+	in.LastValidated.DeepCopyInto(&out.LastValidated)
+	if in.CredentialsExpiry != nil {
+		in, out := &in.CredentialsExpiry, &out.CredentialsExpiry
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -1201,6 +1248,41 @@ func (in *DataExportSpec) DeepCopy() *DataExportSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionConfig) DeepCopyInto(out *EncryptionConfig) {
+	*out = *in
+	if in.PreviousEncryptionKeys != nil {
+		in, out := &in.PreviousEncryptionKeys, &out.PreviousEncryptionKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PreviousProviders != nil {
+		in, out := &in.PreviousProviders, &out.PreviousProviders
+		*out = make([]KeyProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionConfig.
+func (in *EncryptionConfig) DeepCopy() *EncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExportStatus) DeepCopyInto(out *ExportStatus) {
 	*out = *in
@@ -1217,6 +1299,144 @@ func (in *ExportStatus) DeepCopy() *ExportStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Failover) DeepCopyInto(out *Failover) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Failover.
+func (in *Failover) DeepCopy() *Failover {
+	if in == nil {
+		return nil
+	}
+	out := new(Failover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Failover) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverList) DeepCopyInto(out *FailoverList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Failover, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverList.
+func (in *FailoverList) DeepCopy() *FailoverList {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FailoverList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverSpec) DeepCopyInto(out *FailoverSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceMapping != nil {
+		in, out := &in.NamespaceMapping, &out.NamespaceMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IncludeVolumes != nil {
+		in, out := &in.IncludeVolumes, &out.IncludeVolumes
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverSpec.
+func (in *FailoverSpec) DeepCopy() *FailoverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverStatus) DeepCopyInto(out *FailoverStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]*FailoverStepInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(FailoverStepInfo)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverStatus.
+func (in *FailoverStatus) DeepCopy() *FailoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverStepInfo) DeepCopyInto(out *FailoverStepInfo) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverStepInfo.
+func (in *FailoverStepInfo) DeepCopy() *FailoverStepInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverStepInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GoogleConfig) DeepCopyInto(out *GoogleConfig) {
 	*out = *in
@@ -1366,6 +1586,29 @@ func (in *IntervalPolicy) DeepCopy() *IntervalPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyProviderConfig) DeepCopyInto(out *KeyProviderConfig) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyProviderConfig.
+func (in *KeyProviderConfig) DeepCopy() *KeyProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Migration) DeepCopyInto(out *Migration) {
 	*out = *in
@@ -1394,6 +1637,22 @@ func (in *Migration) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationDestinationInfo) DeepCopyInto(out *MigrationDestinationInfo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationDestinationInfo.
+func (in *MigrationDestinationInfo) DeepCopy() *MigrationDestinationInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationDestinationInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationList) DeepCopyInto(out *MigrationList) {
 	*out = *in
@@ -1427,6 +1686,23 @@ func (in *MigrationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationNamespaceVerification) DeepCopyInto(out *MigrationNamespaceVerification) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationNamespaceVerification.
+func (in *MigrationNamespaceVerification) DeepCopy() *MigrationNamespaceVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationNamespaceVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationResourceInfo) DeepCopyInto(out *MigrationResourceInfo) {
 	*out = *in
@@ -1514,6 +1790,11 @@ func (in *MigrationScheduleSpec) DeepCopyInto(out *MigrationScheduleSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	return
 }
 
@@ -1551,6 +1832,18 @@ func (in *MigrationScheduleStatus) DeepCopyInto(out *MigrationScheduleStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.MissedRuns != nil {
+		in, out := &in.MissedRuns, &out.MissedRuns
+		*out = make([]*ScheduleMissedRun, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ScheduleMissedRun)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	in.LastSuccessfulTime.DeepCopyInto(&out.LastSuccessfulTime)
 	return
 }
 
@@ -1567,6 +1860,11 @@ func (in *MigrationScheduleStatus) DeepCopy() *MigrationScheduleStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 	*out = *in
+	if in.ClusterPairs != nil {
+		in, out := &in.ClusterPairs, &out.ClusterPairs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Namespaces != nil {
 		in, out := &in.Namespaces, &out.Namespaces
 		*out = make([]string, len(*in))
@@ -1599,6 +1897,40 @@ func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.NamespaceMapping != nil {
+		in, out := &in.NamespaceMapping, &out.NamespaceMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]ResourceTransform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VerifyApplications != nil {
+		in, out := &in.VerifyApplications, &out.VerifyApplications
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IncludeResourceTypes != nil {
+		in, out := &in.IncludeResourceTypes, &out.IncludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeResourceTypes != nil {
+		in, out := &in.ExcludeResourceTypes, &out.ExcludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1638,6 +1970,34 @@ func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
 		}
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = make([]*MigrationNamespaceVerification, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(MigrationNamespaceVerification)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	in.VerifyStartTimestamp.DeepCopyInto(&out.VerifyStartTimestamp)
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]*MigrationDestinationInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(MigrationDestinationInfo)
+				**out = **in
+			}
+		}
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1717,6 +2077,148 @@ func (in *PVCSelectorSpec) DeepCopy() *PVCSelectorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCollectorConfig) DeepCopyInto(out *ResourceCollectorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCollectorConfig.
+func (in *ResourceCollectorConfig) DeepCopy() *ResourceCollectorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCollectorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResourceCollectorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCollectorConfigList) DeepCopyInto(out *ResourceCollectorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ResourceCollectorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCollectorConfigList.
+func (in *ResourceCollectorConfigList) DeepCopy() *ResourceCollectorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCollectorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResourceCollectorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCollectorConfigSpec) DeepCopyInto(out *ResourceCollectorConfigSpec) {
+	*out = *in
+	if in.IncludeResourceTypes != nil {
+		in, out := &in.IncludeResourceTypes, &out.IncludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeResourceTypes != nil {
+		in, out := &in.ExcludeResourceTypes, &out.ExcludeResourceTypes
+		*out = make([]ResourceType, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCollectorConfigSpec.
+func (in *ResourceCollectorConfigSpec) DeepCopy() *ResourceCollectorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCollectorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceTransform) DeepCopyInto(out *ResourceTransform) {
+	*out = *in
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceTransform.
+func (in *ResourceTransform) DeepCopy() *ResourceTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceType) DeepCopyInto(out *ResourceType) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceType.
+func (in *ResourceType) DeepCopy() *ResourceType {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreResourceFilter) DeepCopyInto(out *RestoreResourceFilter) {
+	*out = *in
+	out.ResourceType = in.ResourceType
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreResourceFilter.
+func (in *RestoreResourceFilter) DeepCopy() *RestoreResourceFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreResourceFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreVolumeInfo) DeepCopyInto(out *RestoreVolumeInfo) {
 	*out = *in
@@ -1859,6 +2361,23 @@ func (in *S3Config) DeepCopy() *S3Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleMissedRun) DeepCopyInto(out *ScheduleMissedRun) {
+	*out = *in
+	in.ScheduledTime.DeepCopyInto(&out.ScheduledTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleMissedRun.
+func (in *ScheduleMissedRun) DeepCopy() *ScheduleMissedRun {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleMissedRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SchedulePolicy) DeepCopyInto(out *SchedulePolicy) {
 	*out = *in
@@ -2190,6 +2709,11 @@ func (in *VolumeSnapshotScheduleSpec) DeepCopyInto(out *VolumeSnapshotScheduleSp
 		*out = new(bool)
 		**out = **in
 	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	return
 }
 
@@ -2227,6 +2751,18 @@ func (in *VolumeSnapshotScheduleStatus) DeepCopyInto(out *VolumeSnapshotSchedule
 			(*out)[key] = outVal
 		}
 	}
+	if in.MissedRuns != nil {
+		in, out := &in.MissedRuns, &out.MissedRuns
+		*out = make([]*ScheduleMissedRun, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ScheduleMissedRun)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	in.LastSuccessfulTime.DeepCopyInto(&out.LastSuccessfulTime)
 	return
 }
 
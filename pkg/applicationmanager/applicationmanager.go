@@ -23,7 +23,7 @@ const (
 
 // ApplicationManager maintains all controllers for application level operations
 type ApplicationManager struct {
-	Driver            volume.Driver
+	Drivers           []volume.Driver
 	Recorder          record.EventRecorder
 	ResourceCollector resourcecollector.ResourceCollector
 }
@@ -50,7 +50,7 @@ func (a *ApplicationManager) Init(adminNamespace string, stopChannel chan os.Sig
 	}
 
 	cloneController := &controllers.ApplicationCloneController{
-		Driver:            a.Driver,
+		Drivers:           a.Drivers,
 		Recorder:          a.Recorder,
 		ResourceCollector: a.ResourceCollector,
 	}
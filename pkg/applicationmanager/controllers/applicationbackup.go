@@ -13,7 +13,6 @@ import (
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
-	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
@@ -38,7 +37,6 @@ const (
 	validateCRDInterval time.Duration = 5 * time.Second
 	validateCRDTimeout  time.Duration = 1 * time.Minute
 
-	resourceObjectName = "resources.json"
 	metadataObjectName = "metadata.json"
 
 	backupCancelBackoffInitialDelay = 5 * time.Second
@@ -526,10 +524,13 @@ func (a *ApplicationBackupController) getObjectPath(
 }
 
 // Uploads the given data to the backup location specified in the backup object
+// and records its checksum in the manifest for the backup
 func (a *ApplicationBackupController) uploadObject(
 	backup *stork_api.ApplicationBackup,
 	objectName string,
 	data []byte,
+	encryption *objectstore.BackupEncryption,
+	checksums *objectstore.BackupManifest,
 ) error {
 	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
@@ -540,10 +541,8 @@ func (a *ApplicationBackupController) uploadObject(
 		return err
 	}
 
-	if backupLocation.Location.EncryptionKey != "" {
-		if data, err = crypto.Encrypt(data, backupLocation.Location.EncryptionKey); err != nil {
-			return err
-		}
+	if data, err = encryption.Encrypt(data); err != nil {
+		return err
 	}
 
 	objectPath := a.getObjectPath(backup)
@@ -565,38 +564,95 @@ func (a *ApplicationBackupController) uploadObject(
 		log.ApplicationBackupLog(backup).Errorf("Error closing writer for objectstore: %v", err)
 		return err
 	}
+	checksums.Add(objectName, data)
 	return nil
 }
 
-// Convert the list of objects to json and upload to the backup location
+// Generate the data key for the backup and upload it wrapped with the key
+// provider for the backup location. Returns nil if the backup location
+// doesn't have encryption configured.
+func (a *ApplicationBackupController) newBackupEncryption(
+	backup *stork_api.ApplicationBackup,
+) (*objectstore.BackupEncryption, error) {
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	return objectstore.NewBackupEncryption(bucket, a.getObjectPath(backup), backupLocation)
+}
+
+// Upload the objects to the backup location in compressed chunks so that the
+// whole backup doesn't need to be serialized in memory. The objects are
+// written one at a time and released from the slice once they have been
+// written.
 func (a *ApplicationBackupController) uploadResources(
 	backup *stork_api.ApplicationBackup,
 	objects []runtime.Unstructured,
+	encryption *objectstore.BackupEncryption,
+	checksums *objectstore.BackupManifest,
 ) error {
-	jsonBytes, err := json.MarshalIndent(objects, "", " ")
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		return err
 	}
-	// TODO: Encrypt if requested
-	return a.uploadObject(backup, resourceObjectName, jsonBytes)
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	writer := objectstore.NewResourceWriter(bucket, a.getObjectPath(backup), encryption, checksums)
+	for i := range objects {
+		if err := writer.Write(objects[i]); err != nil {
+			return err
+		}
+		objects[i] = nil
+	}
+	return writer.Close()
 }
 
 // Upload the backup object which should have all the required metadata
 func (a *ApplicationBackupController) uploadMetadata(
 	backup *stork_api.ApplicationBackup,
+	encryption *objectstore.BackupEncryption,
+	checksums *objectstore.BackupManifest,
 ) error {
 	jsonBytes, err := json.MarshalIndent(backup, "", " ")
 	if err != nil {
 		return err
 	}
 
-	return a.uploadObject(backup, metadataObjectName, jsonBytes)
+	return a.uploadObject(backup, metadataObjectName, jsonBytes, encryption, checksums)
+}
+
+// Upload the manifest with the checksums of all the objects for the backup.
+// This needs to be uploaded last.
+func (a *ApplicationBackupController) uploadBackupManifest(
+	backup *stork_api.ApplicationBackup,
+	checksums *objectstore.BackupManifest,
+) error {
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	return objectstore.UploadBackupManifest(bucket, a.getObjectPath(backup), backupLocation.Location.SigningKey, checksums)
 }
 
 func (a *ApplicationBackupController) backupResources(
 	backup *stork_api.ApplicationBackup,
 ) error {
-	allObjects, err := a.ResourceCollector.GetResources(backup.Spec.Namespaces, backup.Spec.Selectors, true)
+	allObjects, err := a.ResourceCollector.GetResources(
+		backup.Spec.Namespaces,
+		backup.Spec.Selectors,
+		backup.Spec.IncludeResourceTypes,
+		backup.Spec.ExcludeResourceTypes,
+		true)
 	if err != nil {
 		log.ApplicationBackupLog(backup).Errorf("Error getting resources: %v", err)
 		return err
@@ -640,8 +696,19 @@ func (a *ApplicationBackupController) backupResources(
 		return err
 	}
 
+	encryption, err := a.newBackupEncryption(backup)
+	if err != nil {
+		a.Recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			fmt.Sprintf("Error creating data key: %v", err))
+		log.ApplicationBackupLog(backup).Errorf("Error creating data key: %v", err)
+		return err
+	}
+
 	// Upload the resources to the backup location
-	if err = a.uploadResources(backup, allObjects); err != nil {
+	checksums := objectstore.NewBackupManifest()
+	if err = a.uploadResources(backup, allObjects, encryption, checksums); err != nil {
 		a.Recorder.Event(backup,
 			v1.EventTypeWarning,
 			string(stork_api.ApplicationBackupStatusFailed),
@@ -650,12 +717,13 @@ func (a *ApplicationBackupController) backupResources(
 		return err
 	}
 	backup.Status.BackupPath = a.getObjectPath(backup)
+	backup.Status.EncryptionProvider = encryption.Provider()
 	backup.Status.Stage = stork_api.ApplicationBackupStageFinal
 	backup.Status.FinishTimestamp = metav1.Now()
 	backup.Status.Status = stork_api.ApplicationBackupStatusSuccessful
 
 	// Upload the metadata for the backup to the backup location
-	if err = a.uploadMetadata(backup); err != nil {
+	if err = a.uploadMetadata(backup, encryption, checksums); err != nil {
 		a.Recorder.Event(backup,
 			v1.EventTypeWarning,
 			string(stork_api.ApplicationBackupStatusFailed),
@@ -664,6 +732,16 @@ func (a *ApplicationBackupController) backupResources(
 		return err
 	}
 
+	// Upload the checksums for all the objects that were uploaded
+	if err = a.uploadBackupManifest(backup, checksums); err != nil {
+		a.Recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			fmt.Sprintf("Error uploading backup manifest: %v", err))
+		log.ApplicationBackupLog(backup).Errorf("Error uploading backup manifest: %v", err)
+		return err
+	}
+
 	if err = sdk.Update(backup); err != nil {
 		return err
 	}
@@ -713,13 +791,21 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 
 	objectPath := backup.Status.BackupPath
 	if objectPath != "" {
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, resourceObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		if err = objectstore.DeleteResources(bucket, objectPath); err != nil {
 			return fmt.Errorf("error deleting resources for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 
 		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, metadataObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting metadata for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
+
+		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, objectstore.BackupManifestName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("error deleting manifest for backup %v/%v: %v", backup.Namespace, backup.Name, err)
+		}
+
+		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, objectstore.DataKeyObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("error deleting data key for backup %v/%v: %v", backup.Namespace, backup.Name, err)
+		}
 	}
 
 	return nil
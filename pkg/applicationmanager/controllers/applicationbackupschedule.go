@@ -94,6 +94,17 @@ func (s *ApplicationBackupScheduleController) Handle(ctx context.Context, event
 
 			// Start a backup for a policy if required
 			if start {
+				if schedule.GetConcurrencyPolicy(backupSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyReplace {
+					if err := s.replaceApplicationBackups(backupSchedule); err != nil {
+						msg := fmt.Sprintf("Error replacing backups in progress for schedule(%v): %v", policyType, err)
+						s.Recorder.Event(backupSchedule,
+							v1.EventTypeWarning,
+							string(stork_api.ApplicationBackupStatusFailed),
+							msg)
+						log.ApplicationBackupScheduleLog(backupSchedule).Error(msg)
+						return err
+					}
+				}
 				err := s.startApplicationBackup(backupSchedule, policyType)
 				if err != nil {
 					msg := fmt.Sprintf("Error triggering backup for schedule(%v): %v", policyType, err)
@@ -164,6 +175,7 @@ func (s *ApplicationBackupScheduleController) updateApplicationBackupStatus(back
 				if s.isApplicationBackupComplete(backup.Status) {
 					backup.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
 					if pendingApplicationBackupStatus == stork_api.ApplicationBackupStatusSuccessful {
+						backupSchedule.Status.LastSuccessfulTime = backup.FinishTimestamp
 						s.Recorder.Event(backupSchedule,
 							v1.EventTypeNormal,
 							string(stork_api.ApplicationBackupStatusSuccessful),
@@ -195,18 +207,14 @@ func (s *ApplicationBackupScheduleController) isApplicationBackupComplete(status
 }
 
 func (s *ApplicationBackupScheduleController) shouldStartApplicationBackup(backupSchedule *stork_api.ApplicationBackupSchedule) (stork_api.SchedulePolicyType, bool, error) {
-	// Don't trigger a new backup if one is already in progress
-	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
-		policyApplicationBackup, present := backupSchedule.Status.Items[policyType]
-		if present {
-			for _, backup := range policyApplicationBackup {
-				if !s.isApplicationBackupComplete(backup.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
-				}
-			}
-		}
-	}
+	// Don't trigger a new backup if one is already in progress, unless the
+	// concurrency policy allows it
+	inProgress := s.getInProgressApplicationBackups(backupSchedule)
+	forbidden := len(inProgress) != 0 &&
+		schedule.GetConcurrencyPolicy(backupSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyForbid
 
+	missedUpdated := false
+	triggerPolicyType := stork_api.SchedulePolicyTypeInvalid
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		var latestApplicationBackupTimestamp meta.Time
 		policyApplicationBackup, present := backupSchedule.Status.Items[policyType]
@@ -217,19 +225,87 @@ func (s *ApplicationBackupScheduleController) shouldStartApplicationBackup(backu
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, err := schedule.CheckTrigger(
 			backupSchedule.Spec.SchedulePolicyName,
 			policyType,
 			latestApplicationBackupTimestamp,
+			backupSchedule.Spec.StartingDeadlineSeconds,
 		)
 		if err != nil {
 			return stork_api.SchedulePolicyTypeInvalid, false, err
 		}
-		if trigger {
-			return policyType, true, nil
+		// Runs scheduled before the schedule was created weren't missed
+		if trigger.Missed && !trigger.ScheduledTime.Before(backupSchedule.CreationTimestamp.Time) {
+			reason := fmt.Sprintf("Backup wasn't started within the starting deadline of %v", trigger.Deadline)
+			if forbidden {
+				reason = fmt.Sprintf("%v since previous backups were still in progress: %v", reason, strings.Join(inProgress, ", "))
+			}
+			var updated bool
+			backupSchedule.Status.MissedRuns, updated = schedule.AddMissedRun(
+				backupSchedule.Status.MissedRuns,
+				policyType,
+				trigger.ScheduledTime,
+				reason)
+			if updated {
+				msg := fmt.Sprintf("Missed backup for schedule(%v) at %v: %v", policyType, trigger.ScheduledTime, reason)
+				s.Recorder.Event(backupSchedule,
+					v1.EventTypeWarning,
+					"Missed",
+					msg)
+				log.ApplicationBackupScheduleLog(backupSchedule).Warn(msg)
+				missedUpdated = true
+			}
+		}
+		if trigger.Required && !forbidden && triggerPolicyType == stork_api.SchedulePolicyTypeInvalid {
+			triggerPolicyType = policyType
+		}
+	}
+
+	if missedUpdated {
+		if err := sdk.Update(backupSchedule); err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+	}
+	return triggerPolicyType, triggerPolicyType != stork_api.SchedulePolicyTypeInvalid, nil
+}
+
+// getInProgressApplicationBackups returns the names of the backups triggered by
+// the schedule that haven't completed
+func (s *ApplicationBackupScheduleController) getInProgressApplicationBackups(backupSchedule *stork_api.ApplicationBackupSchedule) []string {
+	inProgress := make([]string, 0)
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		for _, backup := range backupSchedule.Status.Items[policyType] {
+			if !s.isApplicationBackupComplete(backup.Status) {
+				inProgress = append(inProgress, backup.Name)
+			}
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+	return inProgress
+}
+
+// replaceApplicationBackups deletes the backups triggered by the schedule that
+// are still in progress so that a new one can be started in their place
+func (s *ApplicationBackupScheduleController) replaceApplicationBackups(backupSchedule *stork_api.ApplicationBackupSchedule) error {
+	for _, policyApplicationBackup := range backupSchedule.Status.Items {
+		for _, backup := range policyApplicationBackup {
+			if s.isApplicationBackupComplete(backup.Status) {
+				continue
+			}
+			err := k8s.Instance().DeleteApplicationBackup(backup.Name, backupSchedule.Namespace)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error deleting backup %v: %v", backup.Name, err)
+			}
+			backup.Status = stork_api.ApplicationBackupStatusFailed
+			backup.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+			msg := fmt.Sprintf("Scheduled backup (%v) replaced by a new backup", backup.Name)
+			s.Recorder.Event(backupSchedule,
+				v1.EventTypeWarning,
+				"Replaced",
+				msg)
+			log.ApplicationBackupScheduleLog(backupSchedule).Info(msg)
+		}
+	}
+	return nil
 }
 
 func (s *ApplicationBackupScheduleController) formatApplicationBackupName(backupSchedule *stork_api.ApplicationBackupSchedule, policyType stork_api.SchedulePolicyType) string {
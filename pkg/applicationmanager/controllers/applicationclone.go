@@ -34,7 +34,7 @@ const (
 
 // ApplicationCloneController reconciles applicationclone objects
 type ApplicationCloneController struct {
-	Driver            volume.Driver
+	Drivers           []volume.Driver
 	Recorder          record.EventRecorder
 	ResourceCollector resourcecollector.ResourceCollector
 	dynamicInterface  dynamic.Interface
@@ -252,7 +252,8 @@ func (a *ApplicationCloneController) generateCloneVolumeNames(clone *stork_api.A
 
 	volumeInfos := make([]*stork_api.ApplicationCloneVolumeInfo, 0)
 	for _, pvc := range pvcList.Items {
-		if !a.Driver.OwnsPVC(&pvc) {
+		driver, err := volume.GetPVCDriverFromList(a.Drivers, &pvc)
+		if err != nil {
 			continue
 		}
 		volume, err := k8s.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
@@ -264,7 +265,8 @@ func (a *ApplicationCloneController) generateCloneVolumeNames(clone *stork_api.A
 			PersistentVolumeClaim: pvc.Name,
 			Volume:                volume,
 			CloneVolume:           pvNamePrefix + string(uuid.NewUUID()),
-			Status:                stork_api.ApplicationCloneStatusInProgress,
+			DriverName:            driver.String(),
+			Status:                stork_api.ApplicationCloneStatusInitial,
 		}
 		volumeInfos = append(volumeInfos, volumeInfo)
 	}
@@ -272,6 +274,67 @@ func (a *ApplicationCloneController) generateCloneVolumeNames(clone *stork_api.A
 	return sdk.Update(clone)
 }
 
+// getDriversForClone returns the drivers that own the volumes being cloned.
+// If the driver for a volume wasn't recorded, which is the case for clones
+// started by older versions, it is looked up from the drivers and recorded in
+// the volume info.
+func (a *ApplicationCloneController) getDriversForClone(clone *stork_api.ApplicationClone) ([]volume.Driver, error) {
+	drivers := make([]volume.Driver, 0)
+	found := make(map[string]bool)
+	for _, vInfo := range clone.Status.Volumes {
+		if vInfo.DriverName == "" {
+			driver, err := a.getVolumeDriver(clone, vInfo)
+			if err != nil {
+				return nil, fmt.Errorf("error getting driver for volume %v: %v", vInfo.Volume, err)
+			}
+			vInfo.DriverName = driver.String()
+		}
+		if found[vInfo.DriverName] {
+			continue
+		}
+		driver, err := volume.GetDriverFromList(a.Drivers, vInfo.DriverName)
+		if err != nil {
+			log.ApplicationCloneLog(clone).Warnf("Driver %v not configured for volume %v", vInfo.DriverName, vInfo.Volume)
+			continue
+		}
+		found[vInfo.DriverName] = true
+		drivers = append(drivers, driver)
+	}
+	return drivers, nil
+}
+
+// getVolumeDriver returns the driver that owns the source PVC for the volume
+// being cloned. The only driver is returned if just one is configured.
+func (a *ApplicationCloneController) getVolumeDriver(
+	clone *stork_api.ApplicationClone,
+	vInfo *stork_api.ApplicationCloneVolumeInfo,
+) (volume.Driver, error) {
+	if len(a.Drivers) == 1 {
+		return a.Drivers[0], nil
+	}
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(vInfo.PersistentVolumeClaim, clone.Spec.SourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return volume.GetPVCDriverFromList(a.Drivers, pvc)
+}
+
+// hasCloneVolumesWithStatus returns true if any of the volumes being cloned
+// by the given driver have the given status. Volumes from all drivers are
+// checked if driverName is empty.
+func hasCloneVolumesWithStatus(
+	clone *stork_api.ApplicationClone,
+	driverName string,
+	status stork_api.ApplicationCloneStatusType,
+) bool {
+	for _, vInfo := range clone.Status.Volumes {
+		if (driverName == "" || vInfo.DriverName == driverName) && vInfo.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *ApplicationCloneController) cloneVolumes(clone *stork_api.ApplicationClone, terminationChannel chan bool) error {
 	defer func() {
 		if terminationChannel != nil {
@@ -296,9 +359,20 @@ func (a *ApplicationCloneController) cloneVolumes(clone *stork_api.ApplicationCl
 	// Start clone of the volumes if it hasn't started yet
 	if clone.Status.Stage == stork_api.ApplicationCloneStageVolumes &&
 		clone.Status.Status == stork_api.ApplicationCloneStatusInProgress {
-		if err := a.Driver.CreateVolumeClones(clone); err != nil {
+		drivers, err := a.getDriversForClone(clone)
+		if err != nil {
 			return err
 		}
+		started := false
+		for _, driver := range drivers {
+			if !hasCloneVolumesWithStatus(clone, driver.String(), stork_api.ApplicationCloneStatusInitial) {
+				continue
+			}
+			if err := driver.CreateVolumeClones(clone); err != nil {
+				return err
+			}
+			started = true
+		}
 
 		// Terminate any background rules that were started
 		if terminationChannel != nil {
@@ -306,8 +380,14 @@ func (a *ApplicationCloneController) cloneVolumes(clone *stork_api.ApplicationCl
 			terminationChannel = nil
 		}
 
-		// Run any post exec rules once clone is triggered
-		if clone.Spec.PostExecRule != "" {
+		if !started {
+			for _, driver := range drivers {
+				if err := driver.GetCloneStatus(clone); err != nil {
+					return fmt.Errorf("error getting status of clones from %v driver: %v", driver.String(), err)
+				}
+			}
+		} else if clone.Spec.PostExecRule != "" {
+			// Run any post exec rules once clone is triggered
 			if err := a.runPostExecRule(clone); err != nil {
 				message := fmt.Sprintf("Error running PostExecRule: %v", err)
 				log.ApplicationCloneLog(clone).Errorf(message)
@@ -326,6 +406,13 @@ func (a *ApplicationCloneController) cloneVolumes(clone *stork_api.ApplicationCl
 				return fmt.Errorf("%v", message)
 			}
 		}
+
+		// Check the status again on the next reconcile if any of the clones
+		// are still in progress
+		if hasCloneVolumesWithStatus(clone, "", stork_api.ApplicationCloneStatusInitial) ||
+			hasCloneVolumesWithStatus(clone, "", stork_api.ApplicationCloneStatusInProgress) {
+			return sdk.Update(clone)
+		}
 	}
 
 	// Skip checking status if no volumes are being cloned up
@@ -482,7 +569,11 @@ func (a *ApplicationCloneController) preparePVResource(
 		return err
 	}
 
-	_, err := a.Driver.UpdateMigratedPersistentVolumeSpec(&pv)
+	driver, err := volume.GetPVDriverFromList(a.Drivers, &pv)
+	if err != nil {
+		return err
+	}
+	_, err = driver.UpdateMigratedPersistentVolumeSpec(&pv)
 	if err != nil {
 		return err
 	}
@@ -647,7 +738,7 @@ func (a *ApplicationCloneController) applyResources(
 func (a *ApplicationCloneController) cloneResources(
 	clone *stork_api.ApplicationClone,
 ) error {
-	allObjects, err := a.ResourceCollector.GetResources([]string{clone.Spec.SourceNamespace}, clone.Spec.Selectors, false)
+	allObjects, err := a.ResourceCollector.GetResources([]string{clone.Spec.SourceNamespace}, clone.Spec.Selectors, nil, nil, false)
 	if err != nil {
 		log.ApplicationCloneLog(clone).Errorf("Error getting resources: %v", err)
 		return err
@@ -2,16 +2,13 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"path/filepath"
 	"reflect"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
-	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
@@ -22,7 +19,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -150,7 +146,20 @@ func (a *ApplicationRestoreController) Handle(ctx context.Context, event sdk.Eve
 
 		switch restore.Status.Stage {
 		case storkapi.ApplicationRestoreStageInitial:
-			// Make sure the namespaces exist
+			// Make sure the backup hasn't been tampered with before restoring
+			// anything from it
+			if err := a.verifyBackup(restore); err != nil {
+				message := fmt.Sprintf("Error verifying backup: %v", err)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.Recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.FinishTimestamp = metav1.Now()
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				return sdk.Update(restore)
+			}
 			fallthrough
 		case storkapi.ApplicationRestoreStageVolumes:
 			err := a.restoreVolumes(restore)
@@ -213,6 +222,7 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 		if err != nil {
 			return fmt.Errorf("error getting backup spec for restore: %v", err)
 		}
+		pvcGVK := schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
 		backupVolumeInfoMappings := make(map[string][]*storkapi.ApplicationBackupVolumeInfo)
 		for _, namespace := range backup.Spec.Namespaces {
 			if _, ok := restore.Spec.NamespaceMapping[namespace]; !ok {
@@ -222,6 +232,16 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 				if volumeBackup.Namespace != namespace {
 					continue
 				}
+				// Only restore the volumes for the PVCs selected for the
+				// restore
+				if !resourcecollector.RestoreResourceSelected(
+					restore.Spec.IncludeResources,
+					restore.Spec.ExcludeResources,
+					pvcGVK,
+					volumeBackup.Namespace,
+					volumeBackup.PersistentVolumeClaim) {
+					continue
+				}
 				if backupVolumeInfoMappings[volumeBackup.DriverName] == nil {
 					backupVolumeInfoMappings[volumeBackup.DriverName] = make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 				}
@@ -334,54 +354,79 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 	return nil
 }
 
-func (a *ApplicationRestoreController) downloadObject(
-	backup *storkapi.ApplicationBackup,
-	backupLocation string,
-	namespace string,
-	objectName string,
-) ([]byte, error) {
-	restoreLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, namespace)
+// Verify the checksums, and signature if a signing key is configured, for all
+// the objects in the backup
+func (a *ApplicationRestoreController) verifyBackup(
+	restore *storkapi.ApplicationRestore,
+) error {
+	backup, err := k8s.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("error getting backup: %v", err)
 	}
-	bucket, err := objectstore.GetBucket(restoreLocation)
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, restore.Namespace)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	objectPath := backup.Status.BackupPath
-	data, err := bucket.ReadAll(context.TODO(), filepath.Join(objectPath, objectName))
+	bucket, err := objectstore.GetBucket(backupLocation)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if restoreLocation.Location.EncryptionKey != "" {
-		if data, err = crypto.Decrypt(data, restoreLocation.Location.EncryptionKey); err != nil {
-			return nil, err
-		}
+	manifest, err := objectstore.VerifyBackup(bucket, backup.Status.BackupPath, backupLocation.Location.SigningKey)
+	if err != nil {
+		return err
 	}
-
-	return data, nil
+	if manifest == nil {
+		log.ApplicationRestoreLog(restore).Warnf("Backup %v doesn't have a manifest, skipping verification", backup.Name)
+	}
+	return nil
 }
 
-func (a *ApplicationRestoreController) downloadResources(
+// Calls objectFunc for each object in the backup. Only one chunk of objects is
+// read into memory at a time. Backups in the legacy format are read at once
+// and sorted since backups taken by older versions might not be in the order
+// in which they need to be applied. Each object is checked against the backup
+// manifest as it is read since it could have changed after the backup was
+// verified.
+func (a *ApplicationRestoreController) readResources(
+	restore *storkapi.ApplicationRestore,
 	backup *storkapi.ApplicationBackup,
-	backupLocation string,
-	namespace string,
-) ([]runtime.Unstructured, error) {
-	data, err := a.downloadObject(backup, backupLocation, namespace, resourceObjectName)
+	objectFunc func(runtime.Unstructured) error,
+) error {
+	restoreLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(restoreLocation)
+	if err != nil {
+		return err
+	}
+	encryption, err := objectstore.GetBackupEncryption(bucket, backup.Status.BackupPath, restoreLocation, backup)
+	if err != nil {
+		return err
+	}
+	checksums, err := objectstore.LoadBackupManifest(bucket, backup.Status.BackupPath, restoreLocation.Location.SigningKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	objects := make([]*unstructured.Unstructured, 0)
-	if err = json.Unmarshal(data, &objects); err != nil {
-		return nil, err
+	legacy, err := objectstore.IsLegacyResources(bucket, backup.Status.BackupPath)
+	if err != nil {
+		return err
+	}
+	if !legacy {
+		return objectstore.ReadResources(bucket, backup.Status.BackupPath, encryption, checksums, objectFunc)
+	}
+	objects, err := objectstore.DownloadResources(bucket, backup.Status.BackupPath, encryption, checksums)
+	if err != nil {
+		return err
 	}
-	runtimeObjects := make([]runtime.Unstructured, 0)
+	resourcecollector.SortResources(objects)
 	for _, o := range objects {
-		runtimeObjects = append(runtimeObjects, o)
+		if err := objectFunc(o); err != nil {
+			return err
+		}
 	}
-	return runtimeObjects, nil
+	return nil
 }
 
 func (a *ApplicationRestoreController) updateResourceStatus(
@@ -437,7 +482,6 @@ func (a *ApplicationRestoreController) updateResourceStatus(
 
 func (a *ApplicationRestoreController) getPVNameMappings(
 	restore *storkapi.ApplicationRestore,
-	objects []runtime.Unstructured,
 ) (map[string]string, error) {
 	pvNameMappings := make(map[string]string)
 	for _, vInfo := range restore.Status.Volumes {
@@ -452,90 +496,138 @@ func (a *ApplicationRestoreController) getPVNameMappings(
 	return pvNameMappings, nil
 }
 
+// Returns false if the object shouldn't be restored, otherwise prepares it
+// to be applied
+func (a *ApplicationRestoreController) prepareResource(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	pvNameMappings map[string]string,
+) (bool, error) {
+	selected, err := resourcecollector.RestoreObjectSelected(
+		object,
+		restore.Spec.IncludeResources,
+		restore.Spec.ExcludeResources)
+	if err != nil || !selected {
+		return false, err
+	}
+	err = a.ResourceCollector.PrepareResourceForApply(
+		object,
+		restore.Spec.NamespaceMapping,
+		pvNameMappings)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reads the objects from the backup one at a time and applies them, so that
+// all the objects from the backup don't need to be in memory
 func (a *ApplicationRestoreController) applyResources(
 	restore *storkapi.ApplicationRestore,
-	objects []runtime.Unstructured,
+	backup *storkapi.ApplicationBackup,
 ) error {
-	pvNameMappings, err := a.getPVNameMappings(restore, objects)
+	pvNameMappings, err := a.getPVNameMappings(restore)
 	if err != nil {
 		return err
 	}
 
-	for _, o := range objects {
-		err = a.ResourceCollector.PrepareResourceForApply(
-			o,
-			restore.Spec.NamespaceMapping,
-			pvNameMappings)
-		if err != nil {
-			return err
-		}
-	}
-
 	// First delete the existing objects if they exist and replace policy is set
 	// to Delete
 	if restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
-		err = a.ResourceCollector.DeleteResources(
-			a.dynamicInterface,
-			objects)
+		err = a.readResources(restore, backup, func(o runtime.Unstructured) error {
+			selected, err := a.prepareResource(restore, o, pvNameMappings)
+			if err != nil || !selected {
+				return err
+			}
+			return a.ResourceCollector.DeleteResources(
+				a.dynamicInterface,
+				[]runtime.Unstructured{o})
+		})
 		if err != nil {
 			return err
 		}
 	}
 
-	for _, o := range objects {
-		metadata, err := meta.Accessor(o)
-		if err != nil {
-			return err
-		}
-		objectType, err := meta.TypeAccessor(o)
-		if err != nil {
+	// Errors for the CRDs that couldn't be established, custom resources of
+	// their kinds can't be applied
+	crdErrors := make(map[schema.GroupKind]error)
+	return a.readResources(restore, backup, func(o runtime.Unstructured) error {
+		selected, err := a.prepareResource(restore, o, pvNameMappings)
+		if err != nil || !selected {
 			return err
 		}
+		return a.applyResource(restore, o, crdErrors)
+	})
+}
 
-		log.ApplicationRestoreLog(restore).Infof("Applying %v %v", objectType.GetKind(), metadata.GetName())
-		retained := false
-
-		err = a.ResourceCollector.ApplyResource(
-			a.dynamicInterface,
-			o)
-		if err != nil && errors.IsAlreadyExists(err) {
-			switch restore.Spec.ReplacePolicy {
-			case storkapi.ApplicationRestoreReplacePolicyDelete:
-				log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
-			case storkapi.ApplicationRestoreReplacePolicyRetain:
-				log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
-				retained = true
-				err = nil
-			}
+func (a *ApplicationRestoreController) applyResource(
+	restore *storkapi.ApplicationRestore,
+	o runtime.Unstructured,
+	crdErrors map[schema.GroupKind]error,
+) error {
+	metadata, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+	objectType, err := meta.TypeAccessor(o)
+	if err != nil {
+		return err
+	}
+	if crdErr, ok := crdErrors[o.GetObjectKind().GroupVersionKind().GroupKind()]; ok {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", crdErr))
+	}
+
+	log.ApplicationRestoreLog(restore).Infof("Applying %v %v", objectType.GetKind(), metadata.GetName())
+	retained := false
+
+	err = a.ResourceCollector.ApplyResource(
+		a.dynamicInterface,
+		o)
+	if err != nil && errors.IsAlreadyExists(err) {
+		switch restore.Spec.ReplacePolicy {
+		case storkapi.ApplicationRestoreReplacePolicyDelete:
+			log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
+		case storkapi.ApplicationRestoreReplacePolicyRetain:
+			log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
+			retained = true
+			err = nil
 		}
-
-		if err != nil {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusFailed,
-				fmt.Sprintf("Error applying resource: %v", err)); err != nil {
-				return err
-			}
-		} else if retained {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusRetained,
-				"Resource restore skipped as it was already present and ReplacePolicy is set to Retain"); err != nil {
-				return err
-			}
-		} else {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusSuccessful,
-				"Resource restored successfully"); err != nil {
-				return err
+	}
+	// Wait for the CRD to be established before the custom resources using
+	// it are applied
+	if err == nil && resourcecollector.IsCRD(o) {
+		if err = resourcecollector.WaitForCRDEstablished(a.dynamicInterface, metadata.GetName()); err != nil {
+			groupKind, gkErr := resourcecollector.GetCRDGroupKind(o)
+			if gkErr != nil {
+				return gkErr
 			}
+			err = fmt.Errorf("CustomResourceDefinition %v wasn't established: %v", metadata.GetName(), err)
+			crdErrors[groupKind] = err
 		}
 	}
-	return nil
+
+	if err != nil {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", err))
+	} else if retained {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusRetained,
+			"Resource restore skipped as it was already present and ReplacePolicy is set to Retain")
+	}
+	return a.updateResourceStatus(
+		restore,
+		o,
+		storkapi.ApplicationRestoreStatusSuccessful,
+		"Resource restored successfully")
 }
 
 func (a *ApplicationRestoreController) restoreResources(
@@ -547,13 +639,8 @@ func (a *ApplicationRestoreController) restoreResources(
 		return err
 	}
 
-	objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
-		return err
-	}
-
-	if err := a.applyResources(restore, objects); err != nil {
+	if err := a.applyResources(restore, backup); err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error restoring resources: %v", err)
 		return err
 	}
 
@@ -3,31 +3,54 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
-	"github.com/libopenstorage/stork/pkg/crypto"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
 	"gocloud.dev/blob"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 )
 
+const (
+	// BackupLocationRotateDataKeysAnnotation requests the data keys for all
+	// the backups in a BackupLocation to be wrapped with the current
+	// key-encryption key. It is removed once the keys have been rotated.
+	BackupLocationRotateDataKeysAnnotation = "stork.libopenstorage.org/rotateDataKeys"
+
+	dataKeysRotatedEventReason        = "DataKeysRotated"
+	dataKeysRotationFailedEventReason = "DataKeysRotationFailed"
+)
+
 // BackupSyncController reconciles applicationbackup objects
 type BackupSyncController struct {
 	Recorder     record.EventRecorder
 	SyncInterval time.Duration
 	stopChannel  chan os.Signal
+	storkClient  storkclientset.Interface
 }
 
 // Init Initializes the backup sync controller
 func (b *BackupSyncController) Init(stopChannel chan os.Signal) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("error getting cluster config: %v", err)
+	}
+	b.storkClient, err = storkclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	b.stopChannel = stopChannel
 	go b.startBackupSync()
 	return nil
@@ -43,6 +66,9 @@ func (b *BackupSyncController) startBackupSync() {
 				continue
 			}
 			for _, backupLocation := range backupLocations.Items {
+				if _, ok := backupLocation.Annotations[BackupLocationRotateDataKeysAnnotation]; ok {
+					b.rotateDataKeys(&backupLocation)
+				}
 				err := b.syncBackupsFromLocation(&backupLocation)
 				if err != nil {
 					log.BackupLocationLog(&backupLocation).Errorf("Error syncing backups from location: %v", err)
@@ -100,17 +126,24 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 					log.BackupLocationLog(location).Errorf("Error syncing backup %v: %v", backupName, err)
 					continue
 				}
-				if location.Location.EncryptionKey != "" {
-					if data, err = crypto.Decrypt(data, location.Location.EncryptionKey); err != nil {
-						log.BackupLocationLog(location).Errorf("Error decrypting backup %v during sync: %v", backupName, err)
-						continue
-					}
+				encryption, err := objectstore.GetBackupEncryption(bucket, object.Key, location, nil)
+				if err != nil {
+					log.BackupLocationLog(location).Errorf("Error getting data key for backup %v during sync: %v", backupName, err)
+					continue
+				}
+				if data, err = encryption.Decrypt(data); err != nil {
+					log.BackupLocationLog(location).Errorf("Error decrypting backup %v during sync: %v", backupName, err)
+					continue
 				}
 				backupInfo := storkv1.ApplicationBackup{}
 				if err = json.Unmarshal(data, &backupInfo); err != nil {
 					log.BackupLocationLog(location).Errorf("Error parsing backup %v during sync: %v", backupName, err)
 					continue
 				}
+				if backupInfo.Status.EncryptionProvider != "" && encryption.Provider() == "" {
+					log.BackupLocationLog(location).Errorf("Error syncing backup %v: data key for backup is missing", backupName)
+					continue
+				}
 
 				localBackupInfo, err := k8s.Instance().GetApplicationBackup(backupInfo.Name, backupInfo.Namespace)
 				if err == nil {
@@ -133,6 +166,13 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 					continue
 				}
 
+				// Only sync backups that match their checksums, and signature
+				// if a signing key is configured
+				if _, err := objectstore.VerifyBackup(bucket, object.Key, location.Location.SigningKey); err != nil {
+					log.BackupLocationLog(location).Errorf("Error verifying backup %v during sync: %v", backupName, err)
+					continue
+				}
+
 				backupInfo.Name = syncedBackupName
 				backupInfo.UID = ""
 				backupInfo.ResourceVersion = ""
@@ -150,6 +190,40 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 	return nil
 }
 
+// rotateDataKeys wraps the data keys for the backups in the location with
+// the current key-encryption key. The annotation requesting the rotation is
+// left in place if it fails so that it is retried on the next sync.
+func (b *BackupSyncController) rotateDataKeys(location *storkv1.BackupLocation) {
+	bucket, err := objectstore.GetBucket(location)
+	if err == nil {
+		var rotated int
+		rotated, err = objectstore.RotateDataKeys(bucket, location)
+		if err == nil {
+			msg := fmt.Sprintf("Rotated data keys for %v backups", rotated)
+			log.BackupLocationLog(location).Info(msg)
+			b.Recorder.Event(location, v1.EventTypeNormal, dataKeysRotatedEventReason, msg)
+			err = b.removeRotateDataKeysAnnotation(location)
+		}
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Error rotating data keys: %v", err)
+		log.BackupLocationLog(location).Error(msg)
+		b.Recorder.Event(location, v1.EventTypeWarning, dataKeysRotationFailedEventReason, msg)
+	}
+}
+
+// removeRotateDataKeysAnnotation removes the annotation from the object that
+// is stored, since location has the fields from its secret merged into it
+func (b *BackupSyncController) removeRotateDataKeysAnnotation(location *storkv1.BackupLocation) error {
+	stored, err := b.storkClient.StorkV1alpha1().BackupLocations(location.Namespace).Get(location.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	delete(stored.Annotations, BackupLocationRotateDataKeysAnnotation)
+	_, err = b.storkClient.StorkV1alpha1().BackupLocations(location.Namespace).Update(stored)
+	return err
+}
+
 func (b *BackupSyncController) getSyncedBackupName(backup *storkv1.ApplicationBackup) string {
 	// For scheduled backups use the original name
 	if _, ok := backup.Annotations[ApplicationBackupScheduleNameAnnotation]; ok {
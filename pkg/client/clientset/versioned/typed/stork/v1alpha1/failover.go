@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	scheme "github.com/libopenstorage/stork/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// FailoversGetter has a method to return a FailoverInterface.
+// A group's client should implement this interface.
+type FailoversGetter interface {
+	Failovers(namespace string) FailoverInterface
+}
+
+// FailoverInterface has methods to work with Failover resources.
+type FailoverInterface interface {
+	Create(*v1alpha1.Failover) (*v1alpha1.Failover, error)
+	Update(*v1alpha1.Failover) (*v1alpha1.Failover, error)
+	UpdateStatus(*v1alpha1.Failover) (*v1alpha1.Failover, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.Failover, error)
+	List(opts v1.ListOptions) (*v1alpha1.FailoverList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.Failover, err error)
+	FailoverExpansion
+}
+
+// failovers implements FailoverInterface
+type failovers struct {
+	client rest.Interface
+	ns     string
+}
+
+// newFailovers returns a Failovers
+func newFailovers(c *StorkV1alpha1Client, namespace string) *failovers {
+	return &failovers{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the failover, and returns the corresponding failover object, and an error if there is any.
+func (c *failovers) Get(name string, options v1.GetOptions) (result *v1alpha1.Failover, err error) {
+	result = &v1alpha1.Failover{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("failovers").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Failovers that match those selectors.
+func (c *failovers) List(opts v1.ListOptions) (result *v1alpha1.FailoverList, err error) {
+	result = &v1alpha1.FailoverList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("failovers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested failovers.
+func (c *failovers) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("failovers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a failover and creates it.  Returns the server's representation of the failover, and an error, if there is any.
+func (c *failovers) Create(failover *v1alpha1.Failover) (result *v1alpha1.Failover, err error) {
+	result = &v1alpha1.Failover{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("failovers").
+		Body(failover).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a failover and updates it. Returns the server's representation of the failover, and an error, if there is any.
+func (c *failovers) Update(failover *v1alpha1.Failover) (result *v1alpha1.Failover, err error) {
+	result = &v1alpha1.Failover{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("failovers").
+		Name(failover.Name).
+		Body(failover).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *failovers) UpdateStatus(failover *v1alpha1.Failover) (result *v1alpha1.Failover, err error) {
+	result = &v1alpha1.Failover{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("failovers").
+		Name(failover.Name).
+		SubResource("status").
+		Body(failover).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the failover and deletes it. Returns an error if one occurs.
+func (c *failovers) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("failovers").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *failovers) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("failovers").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched failover.
+func (c *failovers) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.Failover, err error) {
+	result = &v1alpha1.Failover{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("failovers").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeFailovers implements FailoverInterface
+type FakeFailovers struct {
+	Fake *FakeStorkV1alpha1
+	ns   string
+}
+
+var failoversResource = schema.GroupVersionResource{Group: "stork.libopenstorage.org", Version: "v1alpha1", Resource: "failovers"}
+
+var failoversKind = schema.GroupVersionKind{Group: "stork.libopenstorage.org", Version: "v1alpha1", Kind: "Failover"}
+
+// Get takes name of the failover, and returns the corresponding failover object, and an error if there is any.
+func (c *FakeFailovers) Get(name string, options v1.GetOptions) (result *v1alpha1.Failover, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(failoversResource, c.ns, name), &v1alpha1.Failover{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Failover), err
+}
+
+// List takes label and field selectors, and returns the list of Failovers that match those selectors.
+func (c *FakeFailovers) List(opts v1.ListOptions) (result *v1alpha1.FailoverList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(failoversResource, failoversKind, c.ns, opts), &v1alpha1.FailoverList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.FailoverList{ListMeta: obj.(*v1alpha1.FailoverList).ListMeta}
+	for _, item := range obj.(*v1alpha1.FailoverList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested failovers.
+func (c *FakeFailovers) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(failoversResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a failover and creates it.  Returns the server's representation of the failover, and an error, if there is any.
+func (c *FakeFailovers) Create(failover *v1alpha1.Failover) (result *v1alpha1.Failover, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(failoversResource, c.ns, failover), &v1alpha1.Failover{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Failover), err
+}
+
+// Update takes the representation of a failover and updates it. Returns the server's representation of the failover, and an error, if there is any.
+func (c *FakeFailovers) Update(failover *v1alpha1.Failover) (result *v1alpha1.Failover, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(failoversResource, c.ns, failover), &v1alpha1.Failover{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Failover), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeFailovers) UpdateStatus(failover *v1alpha1.Failover) (*v1alpha1.Failover, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(failoversResource, "status", c.ns, failover), &v1alpha1.Failover{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Failover), err
+}
+
+// Delete takes name of the failover and deletes it. Returns an error if one occurs.
+func (c *FakeFailovers) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(failoversResource, c.ns, name), &v1alpha1.Failover{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeFailovers) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(failoversResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.FailoverList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched failover.
+func (c *FakeFailovers) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.Failover, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(failoversResource, c.ns, name, data, subresources...), &v1alpha1.Failover{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Failover), err
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeResourceCollectorConfigs implements ResourceCollectorConfigInterface
+type FakeResourceCollectorConfigs struct {
+	Fake *FakeStorkV1alpha1
+}
+
+var resourcecollectorconfigsResource = schema.GroupVersionResource{Group: "stork.libopenstorage.org", Version: "v1alpha1", Resource: "resourcecollectorconfigs"}
+
+var resourcecollectorconfigsKind = schema.GroupVersionKind{Group: "stork.libopenstorage.org", Version: "v1alpha1", Kind: "ResourceCollectorConfig"}
+
+// Get takes name of the resourceCollectorConfig, and returns the corresponding resourceCollectorConfig object, and an error if there is any.
+func (c *FakeResourceCollectorConfigs) Get(name string, options v1.GetOptions) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(resourcecollectorconfigsResource, name), &v1alpha1.ResourceCollectorConfig{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResourceCollectorConfig), err
+}
+
+// List takes label and field selectors, and returns the list of ResourceCollectorConfigs that match those selectors.
+func (c *FakeResourceCollectorConfigs) List(opts v1.ListOptions) (result *v1alpha1.ResourceCollectorConfigList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(resourcecollectorconfigsResource, resourcecollectorconfigsKind, opts), &v1alpha1.ResourceCollectorConfigList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.ResourceCollectorConfigList{ListMeta: obj.(*v1alpha1.ResourceCollectorConfigList).ListMeta}
+	for _, item := range obj.(*v1alpha1.ResourceCollectorConfigList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested resourceCollectorConfigs.
+func (c *FakeResourceCollectorConfigs) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(resourcecollectorconfigsResource, opts))
+}
+
+// Create takes the representation of a resourceCollectorConfig and creates it.  Returns the server's representation of the resourceCollectorConfig, and an error, if there is any.
+func (c *FakeResourceCollectorConfigs) Create(resourceCollectorConfig *v1alpha1.ResourceCollectorConfig) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(resourcecollectorconfigsResource, resourceCollectorConfig), &v1alpha1.ResourceCollectorConfig{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResourceCollectorConfig), err
+}
+
+// Update takes the representation of a resourceCollectorConfig and updates it. Returns the server's representation of the resourceCollectorConfig, and an error, if there is any.
+func (c *FakeResourceCollectorConfigs) Update(resourceCollectorConfig *v1alpha1.ResourceCollectorConfig) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(resourcecollectorconfigsResource, resourceCollectorConfig), &v1alpha1.ResourceCollectorConfig{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResourceCollectorConfig), err
+}
+
+// Delete takes name of the resourceCollectorConfig and deletes it. Returns an error if one occurs.
+func (c *FakeResourceCollectorConfigs) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(resourcecollectorconfigsResource, name), &v1alpha1.ResourceCollectorConfig{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeResourceCollectorConfigs) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(resourcecollectorconfigsResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.ResourceCollectorConfigList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched resourceCollectorConfig.
+func (c *FakeResourceCollectorConfigs) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(resourcecollectorconfigsResource, name, data, subresources...), &v1alpha1.ResourceCollectorConfig{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ResourceCollectorConfig), err
+}
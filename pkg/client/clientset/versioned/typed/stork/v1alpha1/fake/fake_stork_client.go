@@ -64,6 +64,10 @@ func (c *FakeStorkV1alpha1) DataExports(namespace string) v1alpha1.DataExportInt
 	return &FakeDataExports{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) Failovers(namespace string) v1alpha1.FailoverInterface {
+	return &FakeFailovers{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) GroupVolumeSnapshots(namespace string) v1alpha1.GroupVolumeSnapshotInterface {
 	return &FakeGroupVolumeSnapshots{c, namespace}
 }
@@ -76,6 +80,10 @@ func (c *FakeStorkV1alpha1) MigrationSchedules(namespace string) v1alpha1.Migrat
 	return &FakeMigrationSchedules{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) ResourceCollectorConfigs() v1alpha1.ResourceCollectorConfigInterface {
+	return &FakeResourceCollectorConfigs{c}
+}
+
 func (c *FakeStorkV1alpha1) Rules(namespace string) v1alpha1.RuleInterface {
 	return &FakeRules{c, namespace}
 }
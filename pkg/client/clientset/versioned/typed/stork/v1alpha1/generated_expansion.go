@@ -36,12 +36,16 @@ type ClusterPairExpansion interface{}
 
 type DataExportExpansion interface{}
 
+type FailoverExpansion interface{}
+
 type GroupVolumeSnapshotExpansion interface{}
 
 type MigrationExpansion interface{}
 
 type MigrationScheduleExpansion interface{}
 
+type ResourceCollectorConfigExpansion interface{}
+
 type RuleExpansion interface{}
 
 type SchedulePolicyExpansion interface{}
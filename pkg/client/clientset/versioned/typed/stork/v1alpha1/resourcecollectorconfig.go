@@ -0,0 +1,147 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	scheme "github.com/libopenstorage/stork/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ResourceCollectorConfigsGetter has a method to return a ResourceCollectorConfigInterface.
+// A group's client should implement this interface.
+type ResourceCollectorConfigsGetter interface {
+	ResourceCollectorConfigs() ResourceCollectorConfigInterface
+}
+
+// ResourceCollectorConfigInterface has methods to work with ResourceCollectorConfig resources.
+type ResourceCollectorConfigInterface interface {
+	Create(*v1alpha1.ResourceCollectorConfig) (*v1alpha1.ResourceCollectorConfig, error)
+	Update(*v1alpha1.ResourceCollectorConfig) (*v1alpha1.ResourceCollectorConfig, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.ResourceCollectorConfig, error)
+	List(opts v1.ListOptions) (*v1alpha1.ResourceCollectorConfigList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ResourceCollectorConfig, err error)
+	ResourceCollectorConfigExpansion
+}
+
+// resourceCollectorConfigs implements ResourceCollectorConfigInterface
+type resourceCollectorConfigs struct {
+	client rest.Interface
+}
+
+// newResourceCollectorConfigs returns a ResourceCollectorConfigs
+func newResourceCollectorConfigs(c *StorkV1alpha1Client) *resourceCollectorConfigs {
+	return &resourceCollectorConfigs{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the resourceCollectorConfig, and returns the corresponding resourceCollectorConfig object, and an error if there is any.
+func (c *resourceCollectorConfigs) Get(name string, options v1.GetOptions) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	result = &v1alpha1.ResourceCollectorConfig{}
+	err = c.client.Get().
+		Resource("resourcecollectorconfigs").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ResourceCollectorConfigs that match those selectors.
+func (c *resourceCollectorConfigs) List(opts v1.ListOptions) (result *v1alpha1.ResourceCollectorConfigList, err error) {
+	result = &v1alpha1.ResourceCollectorConfigList{}
+	err = c.client.Get().
+		Resource("resourcecollectorconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested resourceCollectorConfigs.
+func (c *resourceCollectorConfigs) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Resource("resourcecollectorconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a resourceCollectorConfig and creates it.  Returns the server's representation of the resourceCollectorConfig, and an error, if there is any.
+func (c *resourceCollectorConfigs) Create(resourceCollectorConfig *v1alpha1.ResourceCollectorConfig) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	result = &v1alpha1.ResourceCollectorConfig{}
+	err = c.client.Post().
+		Resource("resourcecollectorconfigs").
+		Body(resourceCollectorConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a resourceCollectorConfig and updates it. Returns the server's representation of the resourceCollectorConfig, and an error, if there is any.
+func (c *resourceCollectorConfigs) Update(resourceCollectorConfig *v1alpha1.ResourceCollectorConfig) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	result = &v1alpha1.ResourceCollectorConfig{}
+	err = c.client.Put().
+		Resource("resourcecollectorconfigs").
+		Name(resourceCollectorConfig.Name).
+		Body(resourceCollectorConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the resourceCollectorConfig and deletes it. Returns an error if one occurs.
+func (c *resourceCollectorConfigs) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("resourcecollectorconfigs").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *resourceCollectorConfigs) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Resource("resourcecollectorconfigs").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched resourceCollectorConfig.
+func (c *resourceCollectorConfigs) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ResourceCollectorConfig, err error) {
+	result = &v1alpha1.ResourceCollectorConfig{}
+	err = c.client.Patch(pt).
+		Resource("resourcecollectorconfigs").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
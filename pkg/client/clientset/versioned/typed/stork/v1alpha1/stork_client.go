@@ -36,9 +36,11 @@ type StorkV1alpha1Interface interface {
 	ClusterDomainsStatusesGetter
 	ClusterPairsGetter
 	DataExportsGetter
+	FailoversGetter
 	GroupVolumeSnapshotsGetter
 	MigrationsGetter
 	MigrationSchedulesGetter
+	ResourceCollectorConfigsGetter
 	RulesGetter
 	SchedulePoliciesGetter
 	VolumeSnapshotRestoresGetter
@@ -86,6 +88,10 @@ func (c *StorkV1alpha1Client) DataExports(namespace string) DataExportInterface
 	return newDataExports(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) Failovers(namespace string) FailoverInterface {
+	return newFailovers(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) GroupVolumeSnapshots(namespace string) GroupVolumeSnapshotInterface {
 	return newGroupVolumeSnapshots(c, namespace)
 }
@@ -98,6 +104,10 @@ func (c *StorkV1alpha1Client) MigrationSchedules(namespace string) MigrationSche
 	return newMigrationSchedules(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) ResourceCollectorConfigs() ResourceCollectorConfigInterface {
+	return newResourceCollectorConfigs(c)
+}
+
 func (c *StorkV1alpha1Client) Rules(namespace string) RuleInterface {
 	return newRules(c, namespace)
 }
@@ -42,12 +42,16 @@ type Interface interface {
 	ClusterPairs() ClusterPairInformer
 	// DataExports returns a DataExportInformer.
 	DataExports() DataExportInformer
+	// Failovers returns a FailoverInformer.
+	Failovers() FailoverInformer
 	// GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 	GroupVolumeSnapshots() GroupVolumeSnapshotInformer
 	// Migrations returns a MigrationInformer.
 	Migrations() MigrationInformer
 	// MigrationSchedules returns a MigrationScheduleInformer.
 	MigrationSchedules() MigrationScheduleInformer
+	// ResourceCollectorConfigs returns a ResourceCollectorConfigInformer.
+	ResourceCollectorConfigs() ResourceCollectorConfigInformer
 	// Rules returns a RuleInformer.
 	Rules() RuleInformer
 	// SchedulePolicies returns a SchedulePolicyInformer.
@@ -114,6 +118,11 @@ func (v *version) DataExports() DataExportInformer {
 	return &dataExportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// Failovers returns a FailoverInformer.
+func (v *version) Failovers() FailoverInformer {
+	return &failoverInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 func (v *version) GroupVolumeSnapshots() GroupVolumeSnapshotInformer {
 	return &groupVolumeSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -129,6 +138,11 @@ func (v *version) MigrationSchedules() MigrationScheduleInformer {
 	return &migrationScheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ResourceCollectorConfigs returns a ResourceCollectorConfigInformer.
+func (v *version) ResourceCollectorConfigs() ResourceCollectorConfigInformer {
+	return &resourceCollectorConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Rules returns a RuleInformer.
 func (v *version) Rules() RuleInformer {
 	return &ruleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -82,6 +82,14 @@ type DataExportListerExpansion interface{}
 // DataExportNamespaceLister.
 type DataExportNamespaceListerExpansion interface{}
 
+// FailoverListerExpansion allows custom methods to be added to
+// FailoverLister.
+type FailoverListerExpansion interface{}
+
+// FailoverNamespaceListerExpansion allows custom methods to be added to
+// FailoverNamespaceLister.
+type FailoverNamespaceListerExpansion interface{}
+
 // GroupVolumeSnapshotListerExpansion allows custom methods to be added to
 // GroupVolumeSnapshotLister.
 type GroupVolumeSnapshotListerExpansion interface{}
@@ -106,6 +114,10 @@ type MigrationScheduleListerExpansion interface{}
 // MigrationScheduleNamespaceLister.
 type MigrationScheduleNamespaceListerExpansion interface{}
 
+// ResourceCollectorConfigListerExpansion allows custom methods to be added to
+// ResourceCollectorConfigLister.
+type ResourceCollectorConfigListerExpansion interface{}
+
 // RuleListerExpansion allows custom methods to be added to
 // RuleLister.
 type RuleListerExpansion interface{}
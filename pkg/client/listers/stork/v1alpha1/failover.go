@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// FailoverLister helps list Failovers.
+type FailoverLister interface {
+	// List lists all Failovers in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.Failover, err error)
+	// Failovers returns an object that can list and get Failovers.
+	Failovers(namespace string) FailoverNamespaceLister
+	FailoverListerExpansion
+}
+
+// failoverLister implements the FailoverLister interface.
+type failoverLister struct {
+	indexer cache.Indexer
+}
+
+// NewFailoverLister returns a new FailoverLister.
+func NewFailoverLister(indexer cache.Indexer) FailoverLister {
+	return &failoverLister{indexer: indexer}
+}
+
+// List lists all Failovers in the indexer.
+func (s *failoverLister) List(selector labels.Selector) (ret []*v1alpha1.Failover, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.Failover))
+	})
+	return ret, err
+}
+
+// Failovers returns an object that can list and get Failovers.
+func (s *failoverLister) Failovers(namespace string) FailoverNamespaceLister {
+	return failoverNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// FailoverNamespaceLister helps list and get Failovers.
+type FailoverNamespaceLister interface {
+	// List lists all Failovers in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.Failover, err error)
+	// Get retrieves the Failover from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.Failover, error)
+	FailoverNamespaceListerExpansion
+}
+
+// failoverNamespaceLister implements the FailoverNamespaceLister
+// interface.
+type failoverNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all Failovers in the indexer for a given namespace.
+func (s failoverNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.Failover, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.Failover))
+	})
+	return ret, err
+}
+
+// Get retrieves the Failover from the indexer for a given namespace and name.
+func (s failoverNamespaceLister) Get(name string) (*v1alpha1.Failover, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("failover"), name)
+	}
+	return obj.(*v1alpha1.Failover), nil
+}
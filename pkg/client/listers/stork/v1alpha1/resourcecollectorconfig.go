@@ -0,0 +1,65 @@
+/*
+Copyright 2018 Openstorage.org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ResourceCollectorConfigLister helps list ResourceCollectorConfigs.
+type ResourceCollectorConfigLister interface {
+	// List lists all ResourceCollectorConfigs in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.ResourceCollectorConfig, err error)
+	// Get retrieves the ResourceCollectorConfig from the index for a given name.
+	Get(name string) (*v1alpha1.ResourceCollectorConfig, error)
+	ResourceCollectorConfigListerExpansion
+}
+
+// resourceCollectorConfigLister implements the ResourceCollectorConfigLister interface.
+type resourceCollectorConfigLister struct {
+	indexer cache.Indexer
+}
+
+// NewResourceCollectorConfigLister returns a new ResourceCollectorConfigLister.
+func NewResourceCollectorConfigLister(indexer cache.Indexer) ResourceCollectorConfigLister {
+	return &resourceCollectorConfigLister{indexer: indexer}
+}
+
+// List lists all ResourceCollectorConfigs in the indexer.
+func (s *resourceCollectorConfigLister) List(selector labels.Selector) (ret []*v1alpha1.ResourceCollectorConfig, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ResourceCollectorConfig))
+	})
+	return ret, err
+}
+
+// Get retrieves the ResourceCollectorConfig from the index for a given name.
+func (s *resourceCollectorConfigLister) Get(name string) (*v1alpha1.ResourceCollectorConfig, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("resourcecollectorconfig"), name)
+	}
+	return obj.(*v1alpha1.ResourceCollectorConfig), nil
+}
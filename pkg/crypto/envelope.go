@@ -0,0 +1,191 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// EnvelopeVersion is the version of the envelope encryption format
+	EnvelopeVersion = 1
+
+	// AES-256 is used for both the data keys and key-encryption keys
+	keySize = 32
+)
+
+// envelopeMagic is the prefix for data encrypted with a data key. Data
+// without it was encrypted with Encrypt using the passphrase directly.
+var envelopeMagic = []byte("STORKENC")
+
+// KDFParams are the parameters used to derive a key-encryption key from a
+// passphrase
+type KDFParams struct {
+	Name       string `json:"name"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+}
+
+// WrappedKey is a data key encrypted with a key-encryption key, along with
+// everything except the key-encryption key itself that is needed to decrypt
+// it again
+type WrappedKey struct {
+	Version  int        `json:"version"`
+	Provider string     `json:"provider"`
+	KeyID    string     `json:"keyID"`
+	KDF      *KDFParams `json:"kdf,omitempty"`
+	Key      []byte     `json:"key"`
+}
+
+// AdditionalData returns everything in the wrapped key except the encrypted
+// key itself. It has to be authenticated when the key is encrypted so that
+// the provider, key ID and KDF parameters can't be modified.
+func (w *WrappedKey) AdditionalData() []byte {
+	header := struct {
+		Version  int        `json:"version"`
+		Provider string     `json:"provider"`
+		KeyID    string     `json:"keyID"`
+		KDF      *KDFParams `json:"kdf,omitempty"`
+	}{
+		Version:  w.Version,
+		Provider: w.Provider,
+		KeyID:    w.KeyID,
+		KDF:      w.KDF,
+	}
+	// Marshalling a struct can't fail
+	data, _ := json.Marshal(header)
+	return data
+}
+
+// DataKey is a random key used to encrypt data. It is stored wrapped by a
+// key-encryption key from a KeyProvider, so the key-encryption key can be
+// rotated without having to encrypt the data again.
+type DataKey struct {
+	key []byte
+}
+
+// NewDataKey generates a random data key
+func NewDataKey() (*DataKey, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("error generating data key: %v", err)
+	}
+	return &DataKey{key: key}, nil
+}
+
+// Encrypt the given data with the data key
+func (k *DataKey) Encrypt(data []byte) ([]byte, error) {
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return nil, err
+	}
+	header := envelopeHeader()
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce for encryption: %v", err)
+	}
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, data, header), nil
+}
+
+// Decrypt the given data with the data key
+func (k *DataKey) Decrypt(data []byte) ([]byte, error) {
+	if !IsEnvelopeEncrypted(data) {
+		return nil, fmt.Errorf("data wasn't encrypted with a data key")
+	}
+	header := envelopeHeader()
+	if data[len(envelopeMagic)] != EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported encryption version %v", data[len(envelopeMagic)])
+	}
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(header):]
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce, encryptedData := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, encryptedData, header)
+}
+
+// Wrap the data key using the provider
+func (k *DataKey) Wrap(provider KeyProvider) (*WrappedKey, error) {
+	wrapped := &WrappedKey{
+		Version:  EnvelopeVersion,
+		Provider: provider.Name(),
+	}
+	if err := provider.WrapKey(wrapped, k.key); err != nil {
+		return nil, fmt.Errorf("error wrapping data key: %v", err)
+	}
+	return wrapped, nil
+}
+
+// UnwrapDataKey decrypts the wrapped data key using the provider
+func UnwrapDataKey(wrapped *WrappedKey, provider KeyProvider) (*DataKey, error) {
+	if wrapped.Version > EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported data key version %v", wrapped.Version)
+	}
+	if wrapped.Provider != provider.Name() {
+		return nil, fmt.Errorf("data key was wrapped by key provider %v, configured provider is %v",
+			wrapped.Provider, provider.Name())
+	}
+	key, err := provider.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key with key %v: %v", wrapped.KeyID, err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid size %v for data key", len(key))
+	}
+	return &DataKey{key: key}, nil
+}
+
+// IsEnvelopeEncrypted returns true if the data was encrypted with a data key
+func IsEnvelopeEncrypted(data []byte) bool {
+	return len(data) > len(envelopeMagic) && bytes.HasPrefix(data, envelopeMagic)
+}
+
+func envelopeHeader() []byte {
+	header := make([]byte, 0, len(envelopeMagic)+1)
+	header = append(header, envelopeMagic...)
+	return append(header, EnvelopeVersion)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+// sealKey encrypts the data key with the key-encryption key, authenticating
+// the additional data along with it
+func sealKey(kek []byte, dataKey []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce for encryption: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, dataKey, additionalData), nil
+}
+
+// openKey decrypts a data key sealed with sealKey
+func openKey(kek []byte, sealed []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+	nonce, encryptedKey := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, encryptedKey, additionalData)
+}
@@ -0,0 +1,170 @@
+// +build unittest
+
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataKeyEncryptDecrypt(t *testing.T) {
+	originalData := make([]byte, 128)
+	_, err := io.ReadFull(rand.Reader, originalData)
+	require.NoError(t, err, "Error generating test data")
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err, "Error generating data key")
+
+	encryptedData, err := dataKey.Encrypt(originalData)
+	require.NoError(t, err, "Error encrypting data")
+	require.True(t, IsEnvelopeEncrypted(encryptedData), "Data should be envelope encrypted")
+
+	decryptedData, err := dataKey.Decrypt(encryptedData)
+	require.NoError(t, err, "Error decrypting data")
+	require.Equal(t, originalData, decryptedData, "Original and decrypted data mismatch")
+
+	otherKey, err := NewDataKey()
+	require.NoError(t, err, "Error generating data key")
+	_, err = otherKey.Decrypt(encryptedData)
+	require.Error(t, err, "Expected error decrypting with a different data key")
+
+	legacyData, err := Encrypt(originalData, "testkey")
+	require.NoError(t, err, "Error encrypting data")
+	require.False(t, IsEnvelopeEncrypted(legacyData), "Data encrypted with passphrase shouldn't be envelope encrypted")
+}
+
+func TestPassphraseKeyProvider(t *testing.T) {
+	dataKey, err := NewDataKey()
+	require.NoError(t, err, "Error generating data key")
+
+	provider, err := GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{Passphrase: "oldkey"})
+	require.NoError(t, err, "Error getting key provider")
+	wrapped, err := dataKey.Wrap(provider)
+	require.NoError(t, err, "Error wrapping data key")
+	require.Equal(t, PassphraseKeyProvider, wrapped.Provider)
+	require.Equal(t, provider.CurrentKeyID(), wrapped.KeyID)
+
+	// Data keys wrapped with the previous passphrase can still be unwrapped
+	provider, err = GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{
+		Passphrase:          "newkey",
+		PreviousPassphrases: []string{"oldkey"},
+	})
+	require.NoError(t, err, "Error getting key provider")
+	require.NotEqual(t, wrapped.KeyID, provider.CurrentKeyID(), "Key ID should change with the passphrase")
+	unwrapped, err := UnwrapDataKey(wrapped, provider)
+	require.NoError(t, err, "Error unwrapping data key with previous passphrase")
+	require.Equal(t, dataKey, unwrapped, "Unwrapped data key mismatch")
+
+	provider, err = GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{Passphrase: "newkey"})
+	require.NoError(t, err, "Error getting key provider")
+	_, err = UnwrapDataKey(wrapped, provider)
+	require.Error(t, err, "Expected error unwrapping without the previous passphrase")
+
+	_, err = GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{})
+	require.Error(t, err, "Expected error without passphrase")
+}
+
+func TestWrappedKeyTampering(t *testing.T) {
+	dataKey, err := NewDataKey()
+	require.NoError(t, err, "Error generating data key")
+	provider, err := GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{Passphrase: "testkey"})
+	require.NoError(t, err, "Error getting key provider")
+	wrapped, err := dataKey.Wrap(provider)
+	require.NoError(t, err, "Error wrapping data key")
+
+	tampered := *wrapped
+	tampered.Version = 0
+	_, err = UnwrapDataKey(&tampered, provider)
+	require.Error(t, err, "Expected error unwrapping with modified version")
+
+	kdf := *wrapped.KDF
+	kdf.Iterations = kdfIterations + 1
+	tampered = *wrapped
+	tampered.KDF = &kdf
+	_, err = UnwrapDataKey(&tampered, provider)
+	require.Error(t, err, "Expected error unwrapping with modified KDF parameters")
+
+	kdf.Iterations = maxKDFIterations + 1
+	_, err = UnwrapDataKey(&tampered, provider)
+	require.Error(t, err, "Expected error unwrapping with too many KDF iterations")
+	require.Contains(t, err.Error(), "key derivation iterations should be between")
+
+	unwrapped, err := UnwrapDataKey(wrapped, provider)
+	require.NoError(t, err, "Error unwrapping data key")
+	require.Equal(t, dataKey, unwrapped, "Unwrapped data key mismatch")
+}
+
+func writeTestKey(t *testing.T, dir string, keyID string) {
+	key := make([]byte, keySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	require.NoError(t, err, "Error generating key")
+	err = ioutil.WriteFile(filepath.Join(dir, keyID), []byte(base64.StdEncoding.EncodeToString(key)), 0600)
+	require.NoError(t, err, "Error writing key")
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	root, err := ioutil.TempDir("", "keys")
+	require.NoError(t, err, "Error creating key root")
+	defer os.RemoveAll(root) // nolint: errcheck
+	dir := filepath.Join(root, "location")
+	require.NoError(t, os.Mkdir(dir, 0700), "Error creating key dir")
+	writeTestKey(t, dir, "key1")
+	writeTestKey(t, dir, "key2")
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err, "Error generating data key")
+
+	options := map[string]string{FileKeyProviderDirOption: dir}
+	SetFileKeyProviderRoot("")
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{KeyID: "key1", Options: options})
+	require.Error(t, err, "Expected error without a root")
+
+	SetFileKeyProviderRoot(dir)
+	defer SetFileKeyProviderRoot("")
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{
+		KeyID:   "key1",
+		Options: map[string]string{FileKeyProviderDirOption: root},
+	})
+	require.Error(t, err, "Expected error for dir outside root")
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{
+		KeyID:   "key1",
+		Options: map[string]string{FileKeyProviderDirOption: filepath.Join(dir, "..")},
+	})
+	require.Error(t, err, "Expected error for dir outside root")
+	require.NoError(t, os.Symlink(root, filepath.Join(dir, "link")), "Error creating symlink")
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{
+		KeyID:   "key1",
+		Options: map[string]string{FileKeyProviderDirOption: filepath.Join(dir, "link")},
+	})
+	require.Error(t, err, "Expected error for symlink outside root")
+
+	SetFileKeyProviderRoot(root)
+	provider, err := GetKeyProvider(FileKeyProvider, &KeyProviderConfig{KeyID: "key1", Options: options})
+	require.NoError(t, err, "Error getting key provider")
+	wrapped, err := dataKey.Wrap(provider)
+	require.NoError(t, err, "Error wrapping data key")
+	require.Equal(t, "key1", wrapped.KeyID)
+
+	provider, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{KeyID: "key2", Options: options})
+	require.NoError(t, err, "Error getting key provider")
+	unwrapped, err := UnwrapDataKey(wrapped, provider)
+	require.NoError(t, err, "Error unwrapping data key with previous key")
+	require.Equal(t, dataKey, unwrapped, "Unwrapped data key mismatch")
+
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{KeyID: "key3", Options: options})
+	require.Error(t, err, "Expected error for missing key")
+	_, err = GetKeyProvider(FileKeyProvider, &KeyProviderConfig{KeyID: "../key1", Options: options})
+	require.Error(t, err, "Expected error for invalid key ID")
+
+	passphraseProvider, err := GetKeyProvider(PassphraseKeyProvider, &KeyProviderConfig{Passphrase: "testkey"})
+	require.NoError(t, err, "Error getting key provider")
+	_, err = UnwrapDataKey(wrapped, passphraseProvider)
+	require.Error(t, err, "Expected error unwrapping with a different provider")
+}
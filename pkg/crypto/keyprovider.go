@@ -0,0 +1,313 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// PassphraseKeyProvider derives the key-encryption key from a passphrase
+	PassphraseKeyProvider = "passphrase"
+	// FileKeyProvider reads the key-encryption keys from files in a
+	// directory, for example a mounted Secret. The name of each file is the
+	// key ID and it contains the base64 encoded 32 byte key.
+	FileKeyProvider = "file"
+	// FileKeyProviderDirOption is the option with the directory for the
+	// file key provider. It has to be under the root set with
+	// SetFileKeyProviderRoot.
+	FileKeyProviderDirOption = "dir"
+	// FileKeyProviderRootEnv is the environment variable with the default
+	// root directory for the file key provider
+	FileKeyProviderRootEnv = "STORK_FILE_KEY_PROVIDER_ROOT"
+
+	kdfPBKDF2SHA256 = "pbkdf2-sha256"
+	kdfIterations   = 100000
+	// Wrapped keys with more iterations are rejected so that a modified key
+	// can't make unwrapping take arbitrarily long
+	maxKDFIterations       = 10 * kdfIterations
+	kdfSaltSize            = 16
+	passphraseKeyIDContext = "stork-passphrase-key-id"
+)
+
+// KeyProvider wraps and unwraps data keys using key-encryption keys
+type KeyProvider interface {
+	// Name returns the name that the provider was registered with
+	Name() string
+	// WrapKey sets the KeyID and KDF in wrapped for the current
+	// key-encryption key, and then encrypts the data key with it into
+	// wrapped.Key. wrapped.AdditionalData() has to be authenticated along
+	// with the data key.
+	WrapKey(wrapped *WrappedKey, dataKey []byte) error
+	// UnwrapKey decrypts a data key that was wrapped by the provider with
+	// the key-encryption key in wrapped.KeyID, which might not be the current
+	// one
+	UnwrapKey(wrapped *WrappedKey) ([]byte, error)
+	// CurrentKeyID returns the ID of the key-encryption key that new data keys
+	// are wrapped with
+	CurrentKeyID() string
+}
+
+// KeyProviderConfig is the configuration used to initialize a KeyProvider
+type KeyProviderConfig struct {
+	// KeyID is the ID of the key-encryption key to wrap data keys with
+	KeyID string
+	// Passphrase is the current passphrase for the passphrase provider
+	Passphrase string
+	// PreviousPassphrases are used by the passphrase provider to unwrap data
+	// keys that haven't been rotated yet
+	PreviousPassphrases []string
+	// Options are provider specific options
+	Options map[string]string
+}
+
+// KeyProviderInitFunc initializes a KeyProvider with the config
+type KeyProviderInitFunc func(config *KeyProviderConfig) (KeyProvider, error)
+
+var (
+	keyProviders     = make(map[string]KeyProviderInitFunc)
+	keyProvidersLock sync.Mutex
+
+	fileKeyProviderRoot = os.Getenv(FileKeyProviderRootEnv)
+)
+
+// SetFileKeyProviderRoot sets the directory that the directories for the file
+// key provider need to be under. The file key provider can't be used if it
+// isn't set.
+func SetFileKeyProviderRoot(root string) {
+	keyProvidersLock.Lock()
+	defer keyProvidersLock.Unlock()
+	fileKeyProviderRoot = root
+}
+
+func getFileKeyProviderRoot() string {
+	keyProvidersLock.Lock()
+	defer keyProvidersLock.Unlock()
+	return fileKeyProviderRoot
+}
+
+func init() {
+	if err := RegisterKeyProvider(PassphraseKeyProvider, newPassphraseKeyProvider); err != nil {
+		panic(err)
+	}
+	if err := RegisterKeyProvider(FileKeyProvider, newFileKeyProvider); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterKeyProvider registers a KeyProvider so that it can be configured
+// for a BackupLocation
+func RegisterKeyProvider(name string, initFunc KeyProviderInitFunc) error {
+	keyProvidersLock.Lock()
+	defer keyProvidersLock.Unlock()
+	if _, ok := keyProviders[name]; ok {
+		return fmt.Errorf("key provider %v is already registered", name)
+	}
+	keyProviders[name] = initFunc
+	return nil
+}
+
+// GetKeyProvider returns the KeyProvider registered with the name
+// initialized with the config
+func GetKeyProvider(name string, config *KeyProviderConfig) (KeyProvider, error) {
+	keyProvidersLock.Lock()
+	initFunc, ok := keyProviders[name]
+	keyProvidersLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("key provider %v not found", name)
+	}
+	return initFunc(config)
+}
+
+type passphraseKeyProvider struct {
+	current     string
+	passphrases map[string]string
+}
+
+func newPassphraseKeyProvider(config *KeyProviderConfig) (KeyProvider, error) {
+	if config.Passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required for key provider %v", PassphraseKeyProvider)
+	}
+	p := &passphraseKeyProvider{
+		current:     passphraseKeyID(config.Passphrase),
+		passphrases: make(map[string]string),
+	}
+	for _, passphrase := range config.PreviousPassphrases {
+		if passphrase != "" {
+			p.passphrases[passphraseKeyID(passphrase)] = passphrase
+		}
+	}
+	p.passphrases[p.current] = config.Passphrase
+	return p, nil
+}
+
+// passphraseKeyID returns an ID for the passphrase so that the right one can
+// be found to unwrap a data key, without storing the passphrase itself
+func passphraseKeyID(passphrase string) string {
+	mac := hmac.New(sha256.New, []byte(passphrase))
+	_, _ = mac.Write([]byte(passphraseKeyIDContext))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (p *passphraseKeyProvider) Name() string {
+	return PassphraseKeyProvider
+}
+
+func (p *passphraseKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+func (p *passphraseKeyProvider) WrapKey(wrapped *WrappedKey, dataKey []byte) error {
+	kdf := &KDFParams{
+		Name:       kdfPBKDF2SHA256,
+		Salt:       make([]byte, kdfSaltSize),
+		Iterations: kdfIterations,
+	}
+	if _, err := io.ReadFull(rand.Reader, kdf.Salt); err != nil {
+		return fmt.Errorf("error generating salt: %v", err)
+	}
+	wrapped.KeyID = p.current
+	wrapped.KDF = kdf
+	kek := pbkdf2.Key([]byte(p.passphrases[p.current]), kdf.Salt, kdf.Iterations, keySize, sha256.New)
+	sealed, err := sealKey(kek, dataKey, wrapped.AdditionalData())
+	if err != nil {
+		return err
+	}
+	wrapped.Key = sealed
+	return nil
+}
+
+func (p *passphraseKeyProvider) UnwrapKey(wrapped *WrappedKey) ([]byte, error) {
+	passphrase, ok := p.passphrases[wrapped.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("passphrase not found")
+	}
+	if wrapped.KDF == nil || wrapped.KDF.Name != kdfPBKDF2SHA256 {
+		return nil, fmt.Errorf("unsupported key derivation function")
+	}
+	if wrapped.KDF.Iterations < kdfIterations || wrapped.KDF.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("key derivation iterations should be between %v and %v, found %v",
+			kdfIterations, maxKDFIterations, wrapped.KDF.Iterations)
+	}
+	if len(wrapped.KDF.Salt) != kdfSaltSize {
+		return nil, fmt.Errorf("invalid salt size %v for key derivation", len(wrapped.KDF.Salt))
+	}
+	kek := pbkdf2.Key([]byte(passphrase), wrapped.KDF.Salt, wrapped.KDF.Iterations, keySize, sha256.New)
+	return openKey(kek, wrapped.Key, wrapped.AdditionalData())
+}
+
+type fileKeyProvider struct {
+	dir   string
+	keyID string
+}
+
+func newFileKeyProvider(config *KeyProviderConfig) (KeyProvider, error) {
+	dir := config.Options[FileKeyProviderDirOption]
+	if dir == "" {
+		return nil, fmt.Errorf("option %v is required for key provider %v", FileKeyProviderDirOption, FileKeyProvider)
+	}
+	if config.KeyID == "" {
+		return nil, fmt.Errorf("keyID is required for key provider %v", FileKeyProvider)
+	}
+	dir, err := checkFileKeyProviderDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &fileKeyProvider{
+		dir:   dir,
+		keyID: config.KeyID,
+	}
+	// Make sure the current key is valid so that errors show up when it is
+	// configured and not when the first backup is taken
+	if _, err := p.getKey(p.keyID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// checkFileKeyProviderDir returns the directory with any symlinks resolved,
+// or an error if it isn't under the root for the file key provider
+func checkFileKeyProviderDir(dir string) (string, error) {
+	root := getFileKeyProviderRoot()
+	if root == "" {
+		return "", fmt.Errorf("key provider %v is disabled since no root directory is configured for it", FileKeyProvider)
+	}
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("error resolving root directory for key provider %v: %v", FileKeyProvider, err)
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("directory %v for key provider %v should be an absolute path", dir, FileKeyProvider)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving directory for key provider %v: %v", FileKeyProvider, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("directory %v for key provider %v should be under %v", dir, FileKeyProvider, root)
+	}
+	return resolved, nil
+}
+
+func (p *fileKeyProvider) getKey(keyID string) ([]byte, error) {
+	if keyID == "" || strings.ContainsAny(keyID, `/\`) || keyID == "." || keyID == ".." {
+		return nil, fmt.Errorf("invalid key ID %v", keyID)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(p.dir, keyID))
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %v: %v", keyID, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding key %v: %v", keyID, err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key %v should be %v bytes, found %v", keyID, keySize, len(key))
+	}
+	return key, nil
+}
+
+func (p *fileKeyProvider) Name() string {
+	return FileKeyProvider
+}
+
+func (p *fileKeyProvider) CurrentKeyID() string {
+	return p.keyID
+}
+
+func (p *fileKeyProvider) WrapKey(wrapped *WrappedKey, dataKey []byte) error {
+	kek, err := p.getKey(p.keyID)
+	if err != nil {
+		return err
+	}
+	wrapped.KeyID = p.keyID
+	sealed, err := sealKey(kek, dataKey, wrapped.AdditionalData())
+	if err != nil {
+		return err
+	}
+	wrapped.Key = sealed
+	return nil
+}
+
+func (p *fileKeyProvider) UnwrapKey(wrapped *WrappedKey) ([]byte, error) {
+	if wrapped.KDF != nil {
+		return nil, fmt.Errorf("unsupported key derivation function")
+	}
+	kek, err := p.getKey(wrapped.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return openKey(kek, wrapped.Key, wrapped.AdditionalData())
+}
@@ -0,0 +1,189 @@
+package extender
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// nodeCacheResyncPeriod is the resync period for the informer used to
+	// invalidate the cache when the status of a node changes
+	nodeCacheResyncPeriod = 5 * time.Minute
+)
+
+// nodeCache caches the nodes returned by the drivers for a short time, since
+// getting them from the driver for every request adds latency on large
+// clusters. The cache is invalidated when the status of a Kubernetes node
+// changes.
+type nodeCache struct {
+	sync.Mutex
+	entries     map[string]*nodeCacheEntry
+	stopChannel chan struct{}
+}
+
+type nodeCacheEntry struct {
+	nodes  []*volume.NodeInfo
+	expiry time.Time
+}
+
+func newNodeCache() *nodeCache {
+	return &nodeCache{
+		entries: make(map[string]*nodeCacheEntry),
+	}
+}
+
+// start watches for changes to the Kubernetes nodes to invalidate the cache
+func (c *nodeCache) start(client kubernetes.Interface) {
+	c.Lock()
+	defer c.Unlock()
+	if client == nil || c.stopChannel != nil {
+		return
+	}
+
+	c.stopChannel = make(chan struct{})
+	watchlist := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Nodes().List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Nodes().Watch(options)
+		},
+	}
+	_, controller := cache.NewInformer(watchlist, &v1.Node{}, nodeCacheResyncPeriod,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.invalidate()
+			},
+			UpdateFunc: c.handleNodeUpdate,
+			DeleteFunc: func(obj interface{}) {
+				c.invalidate()
+			},
+		},
+	)
+	go controller.Run(c.stopChannel)
+}
+
+// handleNodeUpdate invalidates the cache if the status of the node has
+// changed
+func (c *nodeCache) handleNodeUpdate(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*v1.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := newObj.(*v1.Node)
+	if !ok {
+		return
+	}
+	if isNodeStatusChanged(oldNode, newNode) {
+		log.Debugf("Status changed for node %v, invalidating node cache", newNode.Name)
+		c.invalidate()
+	}
+}
+
+func (c *nodeCache) stop() {
+	c.Lock()
+	defer c.Unlock()
+	if c.stopChannel != nil {
+		close(c.stopChannel)
+		c.stopChannel = nil
+	}
+	c.entries = make(map[string]*nodeCacheEntry)
+}
+
+// isNodeStatusChanged returns true if the schedulability, addresses or any of
+// the conditions of the node have changed. Heartbeat updates that don't
+// change the status of a condition are ignored.
+func isNodeStatusChanged(oldNode, newNode *v1.Node) bool {
+	if oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable {
+		return true
+	}
+	if !reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) {
+		return true
+	}
+	return !reflect.DeepEqual(getNodeConditionStatus(oldNode), getNodeConditionStatus(newNode))
+}
+
+func getNodeConditionStatus(node *v1.Node) map[v1.NodeConditionType]v1.ConditionStatus {
+	status := make(map[v1.NodeConditionType]v1.ConditionStatus)
+	for _, condition := range node.Status.Conditions {
+		status[condition.Type] = condition.Status
+	}
+	return status
+}
+
+// getNodes returns the nodes for the driver from the cache if they haven't
+// expired, otherwise they are fetched from the driver. Copies of the nodes are
+// returned since callers can update them.
+func (c *nodeCache) getNodes(driver volume.Driver, ttl time.Duration) ([]*volume.NodeInfo, error) {
+	if ttl > 0 {
+		c.Lock()
+		entry, ok := c.entries[driver.String()]
+		c.Unlock()
+		if ok && time.Now().Before(entry.expiry) {
+			nodeCacheRequests.WithLabelValues(cacheHit).Inc()
+			return copyNodes(entry.nodes), nil
+		}
+	}
+	nodeCacheRequests.WithLabelValues(cacheMiss).Inc()
+
+	nodes, err := driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		c.Lock()
+		c.entries[driver.String()] = &nodeCacheEntry{
+			nodes:  copyNodes(nodes),
+			expiry: time.Now().Add(ttl),
+		}
+		c.Unlock()
+	}
+	return copyNodes(nodes), nil
+}
+
+// updateNodes replaces the cached nodes for the driver if the status of any
+// of the nodes has changed
+func (c *nodeCache) updateNodes(driver volume.Driver, nodes []*volume.NodeInfo) {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[driver.String()]
+	if !ok {
+		return
+	}
+	status := make(map[string]volume.NodeStatus)
+	for _, node := range entry.nodes {
+		status[node.StorageID] = node.Status
+	}
+	for _, node := range nodes {
+		if cachedStatus, ok := status[node.StorageID]; !ok || cachedStatus != node.Status {
+			log.Debugf("Status changed for driver node %v, invalidating node cache", node.StorageID)
+			delete(c.entries, driver.String())
+			return
+		}
+	}
+}
+
+func (c *nodeCache) invalidate() {
+	c.Lock()
+	defer c.Unlock()
+	c.entries = make(map[string]*nodeCacheEntry)
+}
+
+func copyNodes(nodes []*volume.NodeInfo) []*volume.NodeInfo {
+	copies := make([]*volume.NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		nodeCopy := *node
+		copies = append(copies, &nodeCopy)
+	}
+	return copies
+}
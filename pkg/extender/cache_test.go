@@ -0,0 +1,118 @@
+// +build unittest
+
+package extender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCacheTestNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: name},
+			},
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+			},
+		},
+	}
+}
+
+func addCacheEntry(c *nodeCache) {
+	c.Lock()
+	defer c.Unlock()
+	c.entries["mock"] = &nodeCacheEntry{
+		expiry: time.Now().Add(time.Hour),
+	}
+}
+
+func isCacheEmpty(c *nodeCache) bool {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.entries) == 0
+}
+
+func waitForCacheInvalidated(t *testing.T, c *nodeCache, msg string) {
+	for i := 0; i < 50; i++ {
+		if isCacheEmpty(c) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for node cache to be invalidated: %v", msg)
+}
+
+func TestIsNodeStatusChanged(t *testing.T) {
+	oldNode := newCacheTestNode("node1")
+
+	newNode := oldNode.DeepCopy()
+	newNode.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+	require.False(t, isNodeStatusChanged(oldNode, newNode), "Heartbeat shouldn't change the status")
+
+	newNode = oldNode.DeepCopy()
+	newNode.Status.Conditions[0].Status = v1.ConditionFalse
+	require.True(t, isNodeStatusChanged(oldNode, newNode), "Ready condition change should change the status")
+
+	newNode = oldNode.DeepCopy()
+	newNode.Status.Conditions[1].Status = v1.ConditionTrue
+	require.True(t, isNodeStatusChanged(oldNode, newNode), "Other condition change should change the status")
+
+	newNode = oldNode.DeepCopy()
+	newNode.Status.Addresses[0].Address = "node1.example.com"
+	require.True(t, isNodeStatusChanged(oldNode, newNode), "Address change should change the status")
+
+	newNode = oldNode.DeepCopy()
+	newNode.Spec.Unschedulable = true
+	require.True(t, isNodeStatusChanged(oldNode, newNode), "Cordoning the node should change the status")
+}
+
+func TestNodeCacheInvalidation(t *testing.T) {
+	node := newCacheTestNode("node1")
+	client := fake.NewSimpleClientset(node)
+	c := newNodeCache()
+	// Adding the existing node when the informer starts invalidates the cache
+	addCacheEntry(c)
+	c.start(client)
+	defer c.stop()
+	waitForCacheInvalidated(t, c, "informer synced")
+
+	// Heartbeats shouldn't invalidate the cache
+	addCacheEntry(c)
+	node.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+	node, err := client.CoreV1().Nodes().UpdateStatus(node)
+	require.NoError(t, err, "Error updating node")
+	time.Sleep(500 * time.Millisecond)
+	require.False(t, isCacheEmpty(c), "Node cache shouldn't be invalidated for heartbeats")
+
+	node.Status.Conditions[0].Status = v1.ConditionFalse
+	node, err = client.CoreV1().Nodes().UpdateStatus(node)
+	require.NoError(t, err, "Error updating node")
+	waitForCacheInvalidated(t, c, "node not ready")
+
+	addCacheEntry(c)
+	node.Spec.Unschedulable = true
+	_, err = client.CoreV1().Nodes().Update(node)
+	require.NoError(t, err, "Error updating node")
+	waitForCacheInvalidated(t, c, "node cordoned")
+
+	addCacheEntry(c)
+	_, err = client.CoreV1().Nodes().Create(newCacheTestNode("node2"))
+	require.NoError(t, err, "Error creating node")
+	waitForCacheInvalidated(t, c, "node added")
+
+	addCacheEntry(c)
+	err = client.CoreV1().Nodes().Delete("node2", &metav1.DeleteOptions{})
+	require.NoError(t, err, "Error deleting node")
+	waitForCacheInvalidated(t, c, "node deleted")
+}
@@ -0,0 +1,167 @@
+package extender
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ScoringStrategy is the strategy used to combine the scores for all the
+// volumes used by a pod
+type ScoringStrategy string
+
+const (
+	// PackStrategy adds up the scores for all the volumes, packing pods onto
+	// the nodes that have data for the most volumes
+	PackStrategy ScoringStrategy = "pack"
+	// SpreadStrategy uses the best score across all the volumes, so that all
+	// nodes with data for any of the volumes are preferred equally. The
+	// score for each node is divided by the number of pods using the same
+	// volumes already running on it plus one, so that pods sharing volumes
+	// are spread across the replicas.
+	SpreadStrategy ScoringStrategy = "spread"
+	// SizeWeightedStrategy weights the score for each volume by its size, so
+	// that nodes with data for the largest volumes are preferred
+	SizeWeightedStrategy ScoringStrategy = "sizeWeighted"
+)
+
+const (
+	// defaultListenAddress is the address the extender listens on by default
+	defaultListenAddress = ":8099"
+	// defaultNodeCacheTTL is the default time for which the nodes returned by
+	// the drivers are cached
+	defaultNodeCacheTTL = 10 * time.Second
+
+	// Keys in the config map used to configure the extender
+	listenAddressKey       = "listenAddress"
+	strategyKey            = "strategy"
+	nodePriorityScoreKey   = "nodePriorityScore"
+	rackPriorityScoreKey   = "rackPriorityScore"
+	zonePriorityScoreKey   = "zonePriorityScore"
+	regionPriorityScoreKey = "regionPriorityScore"
+	defaultScoreKey        = "defaultScore"
+	nodeCacheTTLKey        = "nodeCacheTTL"
+)
+
+// Config is the configuration for the extender. Empty values and scores that
+// aren't set are replaced with the defaults, so a score can be explicitly set
+// to 0.
+type Config struct {
+	// ListenAddress is the address on which the extender listens for
+	// requests from the scheduler
+	ListenAddress string
+	// Strategy is the strategy used to score nodes
+	Strategy ScoringStrategy
+	// NodePriorityScore is the score for a node that has data for a volume
+	NodePriorityScore *int
+	// RackPriorityScore is the score for a node in the same rack as a node
+	// that has data for a volume
+	RackPriorityScore *int
+	// ZonePriorityScore is the score for a node in the same zone as a node
+	// that has data for a volume
+	ZonePriorityScore *int
+	// RegionPriorityScore is the score for a node in the same region as a
+	// node that has data for a volume
+	RegionPriorityScore *int
+	// DefaultScore is the score for a node that doesn't have data for any
+	// volume
+	DefaultScore *int
+	// NodeCacheTTL is the time for which the nodes returned by the drivers
+	// are cached. A negative value disables the cache.
+	NodeCacheTTL time.Duration
+}
+
+// Score returns a pointer to the score so that it can be set in the Config
+func Score(score int) *int {
+	return &score
+}
+
+// withDefaults returns a copy of the config with defaults set for any values
+// that haven't been configured
+func (c Config) withDefaults() Config {
+	if c.ListenAddress == "" {
+		c.ListenAddress = defaultListenAddress
+	}
+	if c.Strategy == "" {
+		c.Strategy = PackStrategy
+	}
+	if c.NodePriorityScore == nil {
+		c.NodePriorityScore = Score(nodePriorityScore)
+	}
+	if c.RackPriorityScore == nil {
+		c.RackPriorityScore = Score(rackPriorityScore)
+	}
+	if c.ZonePriorityScore == nil {
+		c.ZonePriorityScore = Score(zonePriorityScore)
+	}
+	if c.RegionPriorityScore == nil {
+		c.RegionPriorityScore = Score(regionPriorityScore)
+	}
+	if c.DefaultScore == nil {
+		c.DefaultScore = Score(defaultScore)
+	}
+	if c.NodeCacheTTL == 0 {
+		c.NodeCacheTTL = defaultNodeCacheTTL
+	}
+	return c
+}
+
+// validate checks that the strategy is supported and that the scores aren't
+// negative
+func (c Config) validate() error {
+	switch c.Strategy {
+	case PackStrategy, SpreadStrategy, SizeWeightedStrategy:
+	default:
+		return fmt.Errorf("invalid scoring strategy %v", c.Strategy)
+	}
+	for name, score := range map[string]*int{
+		nodePriorityScoreKey:   c.NodePriorityScore,
+		rackPriorityScoreKey:   c.RackPriorityScore,
+		zonePriorityScoreKey:   c.ZonePriorityScore,
+		regionPriorityScoreKey: c.RegionPriorityScore,
+		defaultScoreKey:        c.DefaultScore,
+	} {
+		if score != nil && *score < 0 {
+			return fmt.Errorf("invalid %v %v, should not be negative", name, *score)
+		}
+	}
+	return nil
+}
+
+// updateFromConfigMap overrides the config with the values set in the config
+// map
+func (c Config) updateFromConfigMap(cm *v1.ConfigMap) (Config, error) {
+	if value, ok := cm.Data[listenAddressKey]; ok && value != "" {
+		c.ListenAddress = value
+	}
+	if value, ok := cm.Data[strategyKey]; ok && value != "" {
+		c.Strategy = ScoringStrategy(value)
+	}
+	if value, ok := cm.Data[nodeCacheTTLKey]; ok && value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return c, fmt.Errorf("invalid value for %v in config map: %v", nodeCacheTTLKey, err)
+		}
+		c.NodeCacheTTL = ttl
+	}
+	for key, score := range map[string]**int{
+		nodePriorityScoreKey:   &c.NodePriorityScore,
+		rackPriorityScoreKey:   &c.RackPriorityScore,
+		zonePriorityScoreKey:   &c.ZonePriorityScore,
+		regionPriorityScoreKey: &c.RegionPriorityScore,
+		defaultScoreKey:        &c.DefaultScore,
+	} {
+		value, ok := cm.Data[key]
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return c, fmt.Errorf("invalid value for %v in config map: %v", key, err)
+		}
+		*score = &parsed
+	}
+	return c, c.validate()
+}
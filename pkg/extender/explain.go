@@ -0,0 +1,156 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ExplainPath is the path on the extender used to explain the scheduling
+	// decision for a pod
+	ExplainPath = "/" + explain
+	// ExplainNamespaceParam is the query parameter for the namespace of the
+	// pod to explain
+	ExplainNamespaceParam = "namespace"
+	// ExplainNameParam is the query parameter for the name of the pod to
+	// explain
+	ExplainNameParam = "name"
+)
+
+// SchedulingExplanation describes how the extender would filter and score
+// the nodes in the cluster for a pod
+type SchedulingExplanation struct {
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// Namespace is the namespace of the pod
+	Namespace string `json:"namespace"`
+	// Strategy is the strategy used to combine the scores for the volumes
+	Strategy ScoringStrategy `json:"strategy"`
+	// FilterError is the error that would be returned to the scheduler if
+	// the pod can't be placed on any of the nodes
+	FilterError string `json:"filterError,omitempty"`
+	// Nodes has the verdict for each node in the cluster
+	Nodes []*NodeExplanation `json:"nodes"`
+}
+
+// NodeExplanation describes the filter verdict and score for one node
+type NodeExplanation struct {
+	// Name is the name of the node
+	Name string `json:"name"`
+	// Filtered is set if the node would be filtered out
+	Filtered bool `json:"filtered"`
+	// FilterReason is the reason the node would be filtered out
+	FilterReason string `json:"filterReason,omitempty"`
+	// Score is the final score for the node
+	Score int `json:"score"`
+	// VolumeScores is the contribution of each volume to the score
+	VolumeScores []*VolumeScore `json:"volumeScores,omitempty"`
+}
+
+// VolumeScore is the score for a node for one volume
+type VolumeScore struct {
+	// Driver is the name of the driver that owns the volume
+	Driver string `json:"driver"`
+	// Volume is the name of the volume
+	Volume string `json:"volume"`
+	// Locality is the locality that matched for the node, one of node, rack,
+	// zone or region. Empty if the node doesn't have any locality to the
+	// volume.
+	Locality string `json:"locality,omitempty"`
+	// Score is the score for the node for the volume
+	Score int `json:"score"`
+}
+
+func (e *Extender) processExplainRequest(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := req.URL.Query().Get(ExplainNamespaceParam)
+	name := req.URL.Query().Get(ExplainNameParam)
+	if name == "" {
+		http.Error(w, "Pod name is required", http.StatusBadRequest)
+		return
+	}
+
+	pod, err := k8s.Instance().GetPodByName(name, namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	explanation := e.explainScheduling(pod, nodes.Items)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(explanation); err != nil {
+		log.Errorf("Error encoding explain response: %v", err)
+	}
+}
+
+// explainScheduling runs the filter and prioritize logic for the pod against
+// the nodes without recording any events or scheduling the pod
+func (e *Extender) explainScheduling(pod *v1.Pod, nodes []v1.Node) *SchedulingExplanation {
+	config := e.getConfig()
+	explanation := &SchedulingExplanation{
+		Pod:       pod.Name,
+		Namespace: pod.Namespace,
+		Strategy:  config.Strategy,
+	}
+
+	filteredNodes, filterReasons, err := e.filterNodes(pod, nodes, true)
+	if err != nil {
+		explanation.FilterError = err.Error()
+	}
+	candidates := make(map[string]bool)
+	for _, node := range filteredNodes {
+		candidates[node.Name] = true
+	}
+
+	var allScores []volumeScores
+	var priorityMap map[string]int
+	if len(filteredNodes) > 0 {
+		allScores, err = e.getVolumeScores(config, pod, filteredNodes, true)
+		if err != nil {
+			storklog.PodLog(pod).Warnf("Error getting scores for explain request: %v", err)
+		}
+		priorityMap = getNodeScores(config, filteredNodes, allScores, e.getVolumePodCounts(config, pod))
+	}
+
+	for _, node := range nodes {
+		nodeExplanation := &NodeExplanation{
+			Name: node.Name,
+		}
+		if !candidates[node.Name] {
+			nodeExplanation.Filtered = true
+			nodeExplanation.FilterReason = filterReasons[node.Name]
+			if nodeExplanation.FilterReason == "" {
+				nodeExplanation.FilterReason = explanation.FilterError
+			}
+		} else {
+			nodeExplanation.Score = priorityMap[node.Name]
+			for _, scores := range allScores {
+				nodeExplanation.VolumeScores = append(nodeExplanation.VolumeScores, &VolumeScore{
+					Driver:   scores.driver,
+					Volume:   scores.volume,
+					Locality: scores.localities[node.Name],
+					Score:    scores.scores[node.Name],
+				})
+			}
+		}
+		explanation.Nodes = append(explanation.Nodes, nodeExplanation)
+	}
+	sort.SliceStable(explanation.Nodes, func(i, j int) bool {
+		return explanation.Nodes[i].Name < explanation.Nodes[j].Name
+	})
+	return explanation
+}
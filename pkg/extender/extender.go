@@ -4,18 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	storklog "github.com/libopenstorage/stork/pkg/log"
 	restore "github.com/libopenstorage/stork/pkg/snapshot/controllers"
 	"github.com/portworx/sched-ops/k8s"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
 )
@@ -23,6 +31,9 @@ import (
 const (
 	filter     = "filter"
 	prioritize = "prioritize"
+	preempt    = "preempt"
+	bind       = "bind"
+	explain    = "explain"
 	// nodePriorityScore Score by which each node is bumped if it has data for a volume
 	nodePriorityScore = 100
 	// rackPriorityScore Score by which each node is bumped if it is in the same
@@ -41,13 +52,45 @@ const (
 	preferLocalNodeOnlyAnnotation = "stork.libopenstorage.org/preferLocalNodeOnly"
 )
 
+// Reasons returned for nodes that are filtered out
+const (
+	filterReasonDriverNotOnline   = "storage driver not online on node"
+	filterReasonNoLocalReplicas   = "node doesn't have replicas for all the volumes"
+	filterReasonNotInVolumeZones  = "node is not in the zones of the volumes"
+	filterReasonNotEnoughCapacity = "node doesn't have enough free capacity"
+)
+
+// Localities for which nodes are scored
+const (
+	localityNode   = "node"
+	localityRack   = "rack"
+	localityZone   = "zone"
+	localityRegion = "region"
+)
+
 // Extender Scheduler extender
 type Extender struct {
 	Recorder record.EventRecorder
-	Driver   volume.Driver
-	server   *http.Server
-	lock     sync.Mutex
-	started  bool
+	Drivers  []volume.Driver
+	// KubeClient is used to bind pods to nodes if the extender is configured
+	// to handle binding in the scheduler policy
+	KubeClient kubernetes.Interface
+	// Config is the initial config for the extender. Values that aren't set
+	// use the defaults.
+	Config Config
+	// ConfigMapName is the name of an optional config map that can be used
+	// to update the config at runtime
+	ConfigMapName string
+	// ConfigMapNamespace is the namespace of the config map
+	ConfigMapNamespace string
+
+	server       *http.Server
+	lock         sync.Mutex
+	started      bool
+	watchStarted bool
+	configLock   sync.RWMutex
+	config       Config
+	nodeCache    *nodeCache
 }
 
 // Start Starts the extender
@@ -59,19 +102,149 @@ func (e *Extender) Start() error {
 		return fmt.Errorf("Extender has already been started")
 	}
 
-	// TODO: Make the listen port configurable
-	e.server = &http.Server{Addr: ":8099"}
+	config := e.Config.withDefaults()
+	if err := config.validate(); err != nil {
+		return err
+	}
+	if e.ConfigMapName != "" {
+		cm, err := k8s.Instance().GetConfigMap(e.ConfigMapName, e.ConfigMapNamespace)
+		if err == nil {
+			if config, err = config.updateFromConfigMap(cm); err != nil {
+				return fmt.Errorf("error parsing extender config map %v/%v: %v",
+					e.ConfigMapNamespace, e.ConfigMapName, err)
+			}
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("error getting extender config map %v/%v: %v",
+				e.ConfigMapNamespace, e.ConfigMapName, err)
+		} else {
+			log.Infof("Extender config map %v/%v not found, using default config",
+				e.ConfigMapNamespace, e.ConfigMapName)
+		}
+	}
+	e.setConfig(config)
 
-	http.HandleFunc("/", e.serveHTTP)
-	go func() {
-		if err := e.server.ListenAndServe(); err != http.ErrServerClosed {
-			log.Panicf("Error starting extender server: %v", err)
+	if e.nodeCache == nil {
+		e.nodeCache = newNodeCache()
+	}
+	if err := e.startServer(config.ListenAddress); err != nil {
+		return err
+	}
+	e.nodeCache.start(e.KubeClient)
+
+	if e.ConfigMapName != "" && !e.watchStarted {
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      e.ConfigMapName,
+				Namespace: e.ConfigMapNamespace,
+			},
 		}
-	}()
+		if err := k8s.Instance().WatchConfigMap(cm, e.reloadConfig); err != nil {
+			log.Errorf("Failed to watch extender config map %v/%v: %v",
+				e.ConfigMapNamespace, e.ConfigMapName, err)
+		} else {
+			e.watchStarted = true
+		}
+	}
 	e.started = true
 	return nil
 }
 
+// startServer starts the http server on the given address. Should be called
+// with the lock held.
+func (e *Extender) startServer(address string) error {
+	e.server = &http.Server{
+		Addr:    address,
+		Handler: http.HandlerFunc(e.serveHTTP),
+	}
+	// Listen before returning so that requests sent right after Start don't
+	// race with the server coming up
+	listener, err := net.Listen("tcp", e.server.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting extender server: %v", err)
+	}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.Panicf("Error starting extender server: %v", err)
+		}
+	}(e.server)
+	log.Infof("Extender listening on %v", address)
+	return nil
+}
+
+// stopServer stops the http server. Should be called with the lock held.
+func (e *Extender) stopServer() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return e.server.Shutdown(ctx)
+}
+
+// getDriverNodes returns the nodes for the driver, using the cache if the
+// nodes were fetched recently
+func (e *Extender) getDriverNodes(driver volume.Driver) ([]*volume.NodeInfo, error) {
+	return e.nodeCache.getNodes(driver, e.getConfig().NodeCacheTTL)
+}
+
+func (e *Extender) getConfig() Config {
+	e.configLock.RLock()
+	defer e.configLock.RUnlock()
+	return e.config
+}
+
+func (e *Extender) setConfig(config Config) {
+	e.configLock.Lock()
+	defer e.configLock.Unlock()
+	e.config = config
+}
+
+// reloadConfig is called when the config map is updated. The config map is
+// fetched again so that the defaults are restored if it has been deleted. The
+// server is restarted if the listen address has changed.
+func (e *Extender) reloadConfig(object runtime.Object) error {
+	cm, ok := object.(*v1.ConfigMap)
+	if !ok || cm.Name != e.ConfigMapName {
+		return nil
+	}
+
+	config := e.Config.withDefaults()
+	cm, err := k8s.Instance().GetConfigMap(e.ConfigMapName, e.ConfigMapNamespace)
+	if err == nil {
+		if config, err = config.updateFromConfigMap(cm); err != nil {
+			log.Errorf("Error parsing extender config map %v/%v, keeping existing config: %v",
+				e.ConfigMapNamespace, e.ConfigMapName, err)
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		log.Errorf("Error getting extender config map %v/%v: %v",
+			e.ConfigMapNamespace, e.ConfigMapName, err)
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	oldConfig := e.getConfig()
+	if reflect.DeepEqual(oldConfig, config) {
+		return nil
+	}
+	log.Infof("Updating extender config to %+v", config)
+	if e.started && oldConfig.ListenAddress != config.ListenAddress {
+		if err := e.stopServer(); err != nil {
+			log.Errorf("Error stopping extender server to update listen address: %v", err)
+			return err
+		}
+		if err := e.startServer(config.ListenAddress); err != nil {
+			log.Errorf("Error updating extender listen address, reverting to %v: %v",
+				oldConfig.ListenAddress, err)
+			config.ListenAddress = oldConfig.ListenAddress
+			if err := e.startServer(oldConfig.ListenAddress); err != nil {
+				log.Panicf("Error restarting extender server: %v", err)
+			}
+		}
+	}
+	e.setConfig(config)
+	return nil
+}
+
 // Stop Stops the extender
 func (e *Extender) Stop() error {
 	e.lock.Lock()
@@ -81,21 +254,42 @@ func (e *Extender) Stop() error {
 		return fmt.Errorf("Extender has not been started")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := e.server.Shutdown(ctx); err != nil {
+	if err := e.stopServer(); err != nil {
 		return err
 	}
+	e.nodeCache.stop()
 	e.started = false
 	return nil
 }
 
 func (e *Extender) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == metricsPath {
+		promhttp.Handler().ServeHTTP(w, req)
+		return
+	}
+
+	verb := "unsupported"
+	start := time.Now()
+	defer func() {
+		requestCount.WithLabelValues(verb).Inc()
+		requestDuration.WithLabelValues(verb).Observe(time.Since(start).Seconds())
+	}()
+
 	if strings.Contains(req.URL.Path, filter) {
+		verb = filter
 		e.processFilterRequest(w, req)
 	} else if strings.Contains(req.URL.Path, prioritize) {
+		verb = prioritize
 		e.processPrioritizeRequest(w, req)
+	} else if strings.Contains(req.URL.Path, preempt) {
+		verb = preempt
+		e.processPreemptRequest(w, req)
+	} else if strings.Contains(req.URL.Path, bind) {
+		verb = bind
+		e.processBindRequest(w, req)
+	} else if strings.Contains(req.URL.Path, explain) {
+		verb = explain
+		e.processExplainRequest(w, req)
 	} else {
 		http.Error(w, "Unsupported request", http.StatusNotFound)
 	}
@@ -127,6 +321,37 @@ func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request
 	}
 
 	pod := args.Pod
+	filteredNodes, _, err := e.filterNodes(pod, args.Nodes.Items, false)
+	if err != nil {
+		filteredNodeCount.Add(float64(len(args.Nodes.Items)))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filteredNodeCount.Add(float64(len(args.Nodes.Items) - len(filteredNodes)))
+
+	storklog.PodLog(pod).Debugf("Nodes in filter response:")
+	for _, node := range filteredNodes {
+		log.Debugf("%v %+v", node.Name, node.Status.Addresses)
+	}
+	response := &schedulerapi.ExtenderFilterResult{
+		Nodes: &v1.NodeList{
+			Items: filteredNodes,
+		},
+	}
+	if err := encoder.Encode(response); err != nil {
+		storklog.PodLog(pod).Errorf("Error encoding filter response: %+v : %v", response, err)
+	}
+}
+
+// filterNodes returns the nodes on which the pod can be scheduled based on
+// the volumes used by it, along with the reason each of the other nodes was
+// filtered out. An error is returned if the pod can't be scheduled on any of
+// the nodes. No events are recorded for the pod if dryRun is set.
+func (e *Extender) filterNodes(
+	pod *v1.Pod,
+	nodes []v1.Node,
+	dryRun bool,
+) ([]v1.Node, map[string]string, error) {
 	for _, vol := range pod.Spec.Volumes {
 		// if any of pvc has restore annotation skip scheduling pod
 		if vol.PersistentVolumeClaim == nil {
@@ -135,138 +360,242 @@ func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request
 		pvc, err := k8s.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim.ClaimName, pod.Namespace)
 		if err != nil {
 			msg := fmt.Sprintf("Unable to find PVC %s, err: %v", vol.Name, err)
-			storklog.PodLog(pod).Warnf(msg)
-			e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-			http.Error(w, msg, http.StatusBadRequest)
-			return
+			storklog.PodLog(pod).Warn(msg)
+			e.recordEvent(pod, dryRun, msg)
+			return nil, nil, fmt.Errorf("%v", msg)
 		} else if pvc.Annotations != nil && pvc.Annotations[restore.RestoreAnnotation] == "true" {
 			msg := "Volume restore is in progress for pvc: " + pvc.Name
-			storklog.PodLog(pod).Warnf(msg)
-			e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-			http.Error(w, msg, http.StatusBadRequest)
-			return
+			storklog.PodLog(pod).Warn(msg)
+			e.recordEvent(pod, dryRun, msg)
+			return nil, nil, fmt.Errorf("%v", msg)
 		}
 	}
 
 	storklog.PodLog(pod).Debugf("Nodes in filter request:")
-	for _, node := range args.Nodes.Items {
+	for _, node := range nodes {
 		storklog.PodLog(pod).Debugf("%v %+v", node.Name, node.Status.Addresses)
 	}
 
+	preferLocalOnly := isPreferLocalOnly(pod)
+
+	// Each driver that has volumes for the pod narrows down the list of
+	// nodes, so that the pod is only placed on nodes where all the drivers
+	// used by it are available
 	filteredNodes := []v1.Node{}
-	driverVolumes, err := e.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
-	if err != nil {
-		msg := fmt.Sprintf("Error getting volumes for Pod for driver: %v", err)
-		storklog.PodLog(pod).Warnf(msg)
-		e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-		if _, ok := err.(*volume.ErrPVCPending); ok {
-			http.Error(w, "Waiting for PVC to be bound", http.StatusBadRequest)
-			return
-		}
-	} else if len(driverVolumes) > 0 {
-		driverNodes, err := e.Driver.GetNodes()
+	filterReasons := make(map[string]string)
+	candidateNodes := nodes
+	for _, driver := range e.Drivers {
+		driverVolumes, err := driver.GetPodVolumes(&pod.Spec, pod.Namespace)
 		if err != nil {
-			storklog.PodLog(pod).Errorf("Error getting list of driver nodes, returning all nodes")
-		} else {
-			for _, volumeInfo := range driverVolumes {
-				onlineNodeFound := false
-				for _, volumeNode := range volumeInfo.DataNodes {
-					for _, driverNode := range driverNodes {
-						if volumeNode == driverNode.StorageID && driverNode.Status == volume.NodeOnline {
-							onlineNodeFound = true
-						}
-					}
-				}
-				if !onlineNodeFound {
-					storklog.PodLog(pod).Errorf("No online storage nodes have replica for volume, returning error")
-					msg := "No online node found with volume replica"
-					e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-					http.Error(w, msg, http.StatusBadRequest)
-					return
-				}
-			}
-
-			preferLocalOnly := false
-			if pod.Annotations != nil {
-				if value, ok := pod.Annotations[preferLocalNodeOnlyAnnotation]; ok {
-					if preferLocalOnly, err = strconv.ParseBool(value); err != nil {
-						preferLocalOnly = false
-					}
-				}
+			e.reportPodVolumesError(pod, driver, dryRun, err)
+			if _, ok := err.(*volume.ErrPVCPending); ok {
+				return nil, nil, fmt.Errorf("Waiting for PVC to be bound")
 			}
+			continue
+		}
+		// PVCs that will be provisioned once the pod is scheduled need enough
+		// free capacity on the node
+		pendingPVCs, err := volume.GetWaitForFirstConsumerPVCs(driver, &pod.Spec, pod.Namespace)
+		if err != nil {
+			storklog.PodLog(pod).Warnf("Error getting pending PVCs for driver %v: %v", driver.String(), err)
+		}
+		if len(driverVolumes) == 0 && len(pendingPVCs) == 0 {
+			continue
+		}
+		requestedCapacity := volume.GetRequestedCapacity(pendingPVCs)
 
-			nodeVolumeCounts := make(map[string]int)
-			if preferLocalOnly {
-				// Get nodes that have replicas for all the volumes
-				for _, volumeInfo := range driverVolumes {
-					for _, volumeNode := range volumeInfo.DataNodes {
-						nodeVolumeCounts[volumeNode]++
-					}
-				}
+		driverNodes, err := e.getDriverNodes(driver)
+		if err != nil {
+			storklog.PodLog(pod).Errorf("Error getting list of nodes for driver %v, returning all nodes", driver.String())
+			continue
+		}
+		for _, volumeInfo := range driverVolumes {
+			// Volumes that are accessible from a zone don't have data on
+			// specific nodes
+			if isZonalVolume(volumeInfo) {
+				continue
 			}
-
-			for _, node := range args.Nodes.Items {
+			onlineNodeFound := false
+			for _, volumeNode := range volumeInfo.DataNodes {
 				for _, driverNode := range driverNodes {
-					storklog.PodLog(pod).Debugf("nodeInfo: %v", driverNode)
-					if driverNode.Status == volume.NodeOnline &&
-						volume.IsNodeMatch(&node, driverNode) {
-						// If only nodes with replicas are to be preferred,
-						// filter out all nodes that don't have a replica
-						// for all the volumes
-						if preferLocalOnly && nodeVolumeCounts[driverNode.StorageID] != len(driverVolumes) {
-							continue
-						}
-						filteredNodes = append(filteredNodes, node)
-						break
+					if volumeNode == driverNode.StorageID && driverNode.Status == volume.NodeOnline {
+						onlineNodeFound = true
 					}
 				}
 			}
+			if !onlineNodeFound {
+				storklog.PodLog(pod).Errorf("No online storage nodes have replica for volume, returning error")
+				msg := "No online node found with volume replica"
+				e.recordEvent(pod, dryRun, msg)
+				return nil, nil, fmt.Errorf("%v", msg)
+			}
+		}
 
-			// If we filtered out all the nodes, the driver isn't running on any
-			// of them, so return an error to avoid scheduling a pod on a
-			// non-driver node
-			if len(filteredNodes) == 0 {
-				var msg string
-				if preferLocalOnly {
-					msg = "No nodes with volume replica available"
-				} else {
-					msg = "No node found with storage driver"
-				}
-				storklog.PodLog(pod).Error(msg)
-				e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-				http.Error(w, msg, http.StatusBadRequest)
-				return
+		var reasons map[string]string
+		filteredNodes, reasons = e.filterNodesForDriver(pod, candidateNodes, driverNodes, driverVolumes, preferLocalOnly, requestedCapacity)
+		for name, reason := range reasons {
+			filterReasons[name] = reason
+		}
+
+		// If we filtered out all the nodes, the driver isn't running on any
+		// of them, so return an error to avoid scheduling a pod on a
+		// non-driver node
+		if len(filteredNodes) == 0 {
+			var msg string
+			if preferLocalOnly {
+				msg = "No nodes with volume replica available"
+			} else if hasZonalVolumes(driverVolumes) {
+				msg = "No nodes found in the zones of the volumes"
+			} else if requestedCapacity > 0 {
+				msg = "No nodes found with enough free capacity for the volumes"
+			} else {
+				msg = "No node found with storage driver"
 			}
+			storklog.PodLog(pod).Error(msg)
+			e.recordEvent(pod, dryRun, msg)
+			return nil, filterReasons, fmt.Errorf("%v", msg)
 		}
+		candidateNodes = filteredNodes
 	}
 
 	// If we didn't find a PVC that interested us, return all the nodes from the request
 	if len(filteredNodes) == 0 {
-		filteredNodes = args.Nodes.Items
+		filteredNodes = nodes
 	}
+	return filteredNodes, filterReasons, nil
+}
 
-	storklog.PodLog(pod).Debugf("Nodes in filter response:")
-	for _, node := range filteredNodes {
-		log.Debugf("%v %+v", node.Name, node.Status.Addresses)
+func isPreferLocalOnly(pod *v1.Pod) bool {
+	if pod.Annotations != nil {
+		if value, ok := pod.Annotations[preferLocalNodeOnlyAnnotation]; ok {
+			if preferLocalOnly, err := strconv.ParseBool(value); err == nil {
+				return preferLocalOnly
+			}
+		}
 	}
-	response := &schedulerapi.ExtenderFilterResult{
-		Nodes: &v1.NodeList{
-			Items: filteredNodes,
-		},
+	return false
+}
+
+// filterNodesForDriver returns the nodes on which the driver is online. If
+// preferLocalOnly is set, only nodes that have a replica for all the volumes
+// are returned. Nodes that are in a different zone or region than any of the
+// zonal volumes, or that don't have the requested free capacity, are
+// filtered out. The reason each node was filtered out is also returned.
+func (e *Extender) filterNodesForDriver(
+	pod *v1.Pod,
+	nodes []v1.Node,
+	driverNodes []*volume.NodeInfo,
+	driverVolumes []*volume.Info,
+	preferLocalOnly bool,
+	requestedCapacity uint64,
+) ([]v1.Node, map[string]string) {
+	nodeVolumeCounts := make(map[string]int)
+	localVolumes := 0
+	if preferLocalOnly {
+		// Get nodes that have replicas for all the volumes
+		for _, volumeInfo := range driverVolumes {
+			if isZonalVolume(volumeInfo) {
+				continue
+			}
+			localVolumes++
+			for _, volumeNode := range volumeInfo.DataNodes {
+				nodeVolumeCounts[volumeNode]++
+			}
+		}
 	}
-	if err := encoder.Encode(response); err != nil {
-		storklog.PodLog(pod).Errorf("Error encoding filter response: %+v : %v", response, err)
+
+	filteredNodes := []v1.Node{}
+	filterReasons := make(map[string]string)
+	for _, node := range nodes {
+		reason := filterReasonDriverNotOnline
+		for _, driverNode := range driverNodes {
+			storklog.PodLog(pod).Debugf("nodeInfo: %v", driverNode)
+			if driverNode.Status == volume.NodeOnline &&
+				volume.IsNodeMatch(&node, driverNode) {
+				// If only nodes with replicas are to be preferred,
+				// filter out all nodes that don't have a replica
+				// for all the volumes
+				if preferLocalOnly && nodeVolumeCounts[driverNode.StorageID] != localVolumes {
+					reason = filterReasonNoLocalReplicas
+					continue
+				}
+				if !isNodeInVolumeZones(driverNode, driverVolumes) {
+					storklog.PodLog(pod).Debugf("Filtering out node %v in zone %v region %v", node.Name, driverNode.Zone, driverNode.Region)
+					reason = filterReasonNotInVolumeZones
+					continue
+				}
+				if !driverNode.HasCapacity(requestedCapacity) {
+					storklog.PodLog(pod).Debugf("Filtering out node %v with free capacity %v, requested %v",
+						node.Name, driverNode.FreeCapacity, requestedCapacity)
+					reason = fmt.Sprintf("%v: free %v bytes, requested %v bytes",
+						filterReasonNotEnoughCapacity, driverNode.FreeCapacity, requestedCapacity)
+					continue
+				}
+				filteredNodes = append(filteredNodes, node)
+				reason = ""
+				break
+			}
+		}
+		if reason != "" {
+			filterReasons[node.Name] = reason
+		}
+	}
+	return filteredNodes, filterReasons
+}
+
+// isZonalVolume returns true if the volume can be accessed from any node in
+// its zones instead of having data on specific nodes
+func isZonalVolume(volumeInfo *volume.Info) bool {
+	return len(volumeInfo.DataNodes) == 0 &&
+		(len(volumeInfo.Zones) != 0 || volumeInfo.Region != "")
+}
+
+func hasZonalVolumes(volumeInfos []*volume.Info) bool {
+	for _, volumeInfo := range volumeInfos {
+		if isZonalVolume(volumeInfo) {
+			return true
+		}
 	}
+	return false
+}
+
+// isNodeInVolumeZones returns false if the node is known to be in a different
+// zone or region than any of the zonal volumes
+func isNodeInVolumeZones(driverNode *volume.NodeInfo, volumeInfos []*volume.Info) bool {
+	for _, volumeInfo := range volumeInfos {
+		if !isZonalVolume(volumeInfo) {
+			continue
+		}
+		if volumeInfo.Region != "" && driverNode.Region != "" &&
+			volumeInfo.Region != driverNode.Region {
+			return false
+		}
+		if len(volumeInfo.Zones) == 0 || driverNode.Zone == "" {
+			continue
+		}
+		found := false
+		for _, zone := range volumeInfo.Zones {
+			if zone == driverNode.Zone {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
 func (e *Extender) getNodeScore(
+	config Config,
 	node v1.Node,
 	volumeInfo *volume.Info,
 	rackInfo *localityInfo,
 	zoneInfo *localityInfo,
 	regionInfo *localityInfo,
 	idMap map[string]*volume.NodeInfo,
-) int {
+) (int, string) {
 	for _, address := range node.Status.Addresses {
 		if address.Type != v1.NodeHostName {
 			continue
@@ -283,26 +612,26 @@ func (e *Extender) getNodeScore(
 							if rack == nodeRack || nodeRack == "" {
 								for _, datanode := range volumeInfo.DataNodes {
 									if volume.IsNodeMatch(&node, idMap[datanode]) {
-										return nodePriorityScore
+										return *config.NodePriorityScore, localityNode
 									}
 								}
 								if nodeRack != "" {
-									return rackPriorityScore
+									return *config.RackPriorityScore, localityRack
 								}
 							}
 						}
 						if nodeZone != "" {
-							return zonePriorityScore
+							return *config.ZonePriorityScore, localityZone
 						}
 					}
 				}
 				if nodeRegion != "" {
-					return regionPriorityScore
+					return *config.RegionPriorityScore, localityRegion
 				}
 			}
 		}
 	}
-	return 0
+	return 0, ""
 }
 
 type localityInfo struct {
@@ -310,6 +639,177 @@ type localityInfo struct {
 	PreferredLocality []string
 }
 
+// volumeScores are the scores for the nodes for one volume
+type volumeScores struct {
+	driver string
+	volume string
+	// size of the volume in GB
+	size   uint64
+	scores map[string]int
+	// localities are the localities that were matched for each node
+	localities map[string]string
+}
+
+// scoreNodesForDriver returns the scores for the nodes for each of the driver
+// volumes used by the pod, based on the locality of the volumes
+func (e *Extender) scoreNodesForDriver(
+	config Config,
+	pod *v1.Pod,
+	nodes []v1.Node,
+	driverNodes []*volume.NodeInfo,
+	driver volume.Driver,
+	driverVolumes []*volume.Info,
+) []volumeScores {
+	// Create a map for ID->Node and Hostname->Rack/Zone/Region
+	idMap := make(map[string]*volume.NodeInfo)
+	var rackInfo, zoneInfo, regionInfo localityInfo
+	rackInfo.HostnameMap = make(map[string]string)
+	zoneInfo.HostnameMap = make(map[string]string)
+	regionInfo.HostnameMap = make(map[string]string)
+	for _, dnode := range driverNodes {
+		// Replace driver's hostname with the kubernetes hostname to make it
+		// easier to match nodes when calculating scores
+		for _, knode := range nodes {
+			if volume.IsNodeMatch(&knode, dnode) {
+				dnode.Hostname = e.getHostname(&knode)
+				break
+			}
+		}
+		idMap[dnode.StorageID] = dnode
+		storklog.PodLog(pod).Debugf("nodeInfo: %v", dnode)
+		// For any node that is offline remove the locality info so that we
+		// don't prioritize nodes close to it
+		if dnode.Status == volume.NodeOnline {
+			// Add region info into zone and zone info into rack so that we can
+			// differentiate same names in different localities
+			regionInfo.HostnameMap[dnode.Hostname] = dnode.Region
+			if regionInfo.HostnameMap[dnode.Hostname] != "" {
+				zoneInfo.HostnameMap[dnode.Hostname] = regionInfo.HostnameMap[dnode.Hostname] + "-" + dnode.Zone
+			} else {
+				zoneInfo.HostnameMap[dnode.Hostname] = dnode.Zone
+			}
+			if zoneInfo.HostnameMap[dnode.Hostname] != "" {
+				rackInfo.HostnameMap[dnode.Hostname] = zoneInfo.HostnameMap[dnode.Hostname] + "-" + dnode.Rack
+			} else {
+				rackInfo.HostnameMap[dnode.Hostname] = dnode.Rack
+			}
+		} else {
+			rackInfo.HostnameMap[dnode.Hostname] = ""
+			zoneInfo.HostnameMap[dnode.Hostname] = ""
+			regionInfo.HostnameMap[dnode.Hostname] = ""
+		}
+	}
+
+	storklog.PodLog(pod).Debugf("rackMap: %v", rackInfo.HostnameMap)
+	storklog.PodLog(pod).Debugf("zoneMap: %v", zoneInfo.HostnameMap)
+	storklog.PodLog(pod).Debugf("regionMap: %v", regionInfo.HostnameMap)
+
+	allScores := make([]volumeScores, 0, len(driverVolumes))
+	for _, volume := range driverVolumes {
+		storklog.PodLog(pod).Debugf("Volume %v allocated on nodes:", volume.VolumeName)
+		// Get the racks, zones and regions where the volume is located
+		rackInfo.PreferredLocality = rackInfo.PreferredLocality[:0]
+		zoneInfo.PreferredLocality = zoneInfo.PreferredLocality[:0]
+		regionInfo.PreferredLocality = regionInfo.PreferredLocality[:0]
+		if isZonalVolume(volume) {
+			e.addZonalVolumeLocality(volume, driverNodes, &zoneInfo, &regionInfo)
+		}
+		for _, node := range volume.DataNodes {
+			if _, ok := idMap[node]; ok {
+				log.Debugf("ID: %v Hostname: %v", node, idMap[node].Hostname)
+				regionInfo.PreferredLocality = append(regionInfo.PreferredLocality, regionInfo.HostnameMap[idMap[node].Hostname])
+				zoneInfo.PreferredLocality = append(zoneInfo.PreferredLocality, zoneInfo.HostnameMap[idMap[node].Hostname])
+				rackInfo.PreferredLocality = append(rackInfo.PreferredLocality, rackInfo.HostnameMap[idMap[node].Hostname])
+			} else {
+				log.Warnf("Node %v not found in list of nodes, skipping", node)
+			}
+		}
+		storklog.PodLog(pod).Debugf("Volume %v allocated on racks: %v", volume.VolumeName, rackInfo.PreferredLocality)
+		storklog.PodLog(pod).Debugf("Volume %v allocated in zones: %v", volume.VolumeName, zoneInfo.PreferredLocality)
+		storklog.PodLog(pod).Debugf("Volume %v allocated in regions: %v", volume.VolumeName, regionInfo.PreferredLocality)
+
+		scores := volumeScores{
+			driver:     driver.String(),
+			volume:     volume.VolumeName,
+			size:       volume.Size,
+			scores:     make(map[string]int),
+			localities: make(map[string]string),
+		}
+		for _, node := range nodes {
+			scores.scores[node.Name], scores.localities[node.Name] =
+				e.getNodeScore(config, node, volume, &rackInfo, &zoneInfo, &regionInfo, idMap)
+		}
+		allScores = append(allScores, scores)
+	}
+	return allScores
+}
+
+// combineScores combines the scores for all the volumes used by a pod using
+// the configured strategy
+func combineScores(strategy ScoringStrategy, allScores []volumeScores, priorityMap map[string]int) {
+	var totalSize uint64
+	for _, volumeScores := range allScores {
+		totalSize += volumeScores.size
+	}
+	// Fall back to adding up the scores if the volume sizes aren't known
+	if strategy == SizeWeightedStrategy && totalSize == 0 {
+		strategy = PackStrategy
+	}
+
+	weightedScores := make(map[string]uint64)
+	for _, volumeScores := range allScores {
+		for node, score := range volumeScores.scores {
+			switch strategy {
+			case SpreadStrategy:
+				if score > priorityMap[node] {
+					priorityMap[node] = score
+				}
+			case SizeWeightedStrategy:
+				weightedScores[node] += uint64(score) * volumeScores.size
+			default:
+				priorityMap[node] += score
+			}
+		}
+	}
+	for node, weightedScore := range weightedScores {
+		priorityMap[node] = int(weightedScore / totalSize)
+	}
+}
+
+// addZonalVolumeLocality adds the zones and region of a zonal volume to the
+// preferred localities. The region for a zone is looked up from the nodes if
+// the volume doesn't have one.
+func (e *Extender) addZonalVolumeLocality(
+	volumeInfo *volume.Info,
+	driverNodes []*volume.NodeInfo,
+	zoneInfo *localityInfo,
+	regionInfo *localityInfo,
+) {
+	zoneRegions := make(map[string]string)
+	for _, dnode := range driverNodes {
+		if dnode.Zone != "" && dnode.Region != "" {
+			zoneRegions[dnode.Zone] = dnode.Region
+		}
+	}
+
+	if len(volumeInfo.Zones) == 0 {
+		regionInfo.PreferredLocality = append(regionInfo.PreferredLocality, volumeInfo.Region)
+		return
+	}
+	for _, zone := range volumeInfo.Zones {
+		region := volumeInfo.Region
+		if region == "" {
+			region = zoneRegions[zone]
+		}
+		regionInfo.PreferredLocality = append(regionInfo.PreferredLocality, region)
+		if region != "" {
+			zoneInfo.PreferredLocality = append(zoneInfo.PreferredLocality, region+"-"+zone)
+		} else {
+			zoneInfo.PreferredLocality = append(zoneInfo.PreferredLocality, zone)
+		}
+	}
+}
+
 func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Request) {
 	decoder := json.NewDecoder(req.Body)
 	defer func() {
@@ -331,124 +831,469 @@ func (e *Extender) processPrioritizeRequest(w http.ResponseWriter, req *http.Req
 	for _, node := range args.Nodes.Items {
 		storklog.PodLog(pod).Debugf("%+v", node.Status.Addresses)
 	}
-	respList := schedulerapi.HostPriorityList{}
 
-	// Intialize scores to 0
-	priorityMap := make(map[string]int)
+	config := e.getConfig()
+	allScores, err := e.getVolumeScores(config, pod, args.Nodes.Items, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priorityMap := getNodeScores(config, args.Nodes.Items, allScores, e.getVolumePodCounts(config, pod))
+
+	respList := schedulerapi.HostPriorityList{}
 	for _, node := range args.Nodes.Items {
-		for _, address := range node.Status.Addresses {
-			if address.Type == v1.NodeHostName {
-				priorityMap[address.Address] = 0
+		hostPriority := schedulerapi.HostPriority{Host: node.Name, Score: priorityMap[node.Name]}
+		respList = append(respList, hostPriority)
+	}
+
+	storklog.PodLog(pod).Debugf("Nodes in response:")
+	for _, node := range respList {
+		storklog.PodLog(pod).Debugf("%+v", node)
+	}
+
+	if err := encoder.Encode(respList); err != nil {
+		storklog.PodLog(pod).Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// getVolumeScores returns the scores for the nodes for all the volumes used by
+// the pod. An error is returned if the pod is waiting for a PVC to be bound.
+func (e *Extender) getVolumeScores(
+	config Config,
+	pod *v1.Pod,
+	nodes []v1.Node,
+	dryRun bool,
+) ([]volumeScores, error) {
+	var allScores []volumeScores
+	for _, driver := range e.Drivers {
+		driverVolumes, err := driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+		if err != nil {
+			e.reportPodVolumesError(pod, driver, dryRun, err)
+			if _, ok := err.(*volume.ErrPVCPending); ok {
+				return nil, fmt.Errorf("Waiting for PVC to be bound")
 			}
+			continue
+		} else if len(driverVolumes) == 0 {
+			continue
+		}
+
+		driverNodes, err := e.getDriverNodes(driver)
+		if err != nil {
+			storklog.PodLog(pod).Errorf("Error getting nodes for driver %v: %v", driver.String(), err)
+			continue
+		}
+		allScores = append(allScores, e.scoreNodesForDriver(config, pod, nodes, driverNodes, driver, driverVolumes)...)
+	}
+	return allScores, nil
+}
+
+// getNodeScores combines the scores for the volumes from all the drivers based
+// on the configured strategy. Nodes that don't have data for any volumes are
+// assigned the default score so that they don't get completely ignored by the
+// scheduler. With the spread strategy the score for each node is divided by
+// the number of pods using the same volumes already running on it, given in
+// volumePodCounts, plus one.
+func getNodeScores(
+	config Config,
+	nodes []v1.Node,
+	allScores []volumeScores,
+	volumePodCounts map[string]int,
+) map[string]int {
+	priorityMap := make(map[string]int)
+	combineScores(config.Strategy, allScores, priorityMap)
+	for _, node := range nodes {
+		if priorityMap[node.Name] == 0 {
+			priorityMap[node.Name] = *config.DefaultScore
+		}
+		if config.Strategy == SpreadStrategy {
+			priorityMap[node.Name] /= volumePodCounts[node.Name] + 1
 		}
 	}
+	return priorityMap
+}
 
-	driverVolumes, err := e.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+// getVolumePodCounts returns the number of other pods using any of the PVCs
+// used by the pod that are running on each node, indexed by the node name.
+// The pods are only looked up for the spread strategy.
+func (e *Extender) getVolumePodCounts(config Config, pod *v1.Pod) map[string]int {
+	counts := make(map[string]int)
+	if config.Strategy != SpreadStrategy {
+		return counts
+	}
+	claims := make(map[string]bool)
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			claims[vol.PersistentVolumeClaim.ClaimName] = true
+		}
+	}
+	if len(claims) == 0 {
+		return counts
+	}
+	pods, err := k8s.Instance().GetPods(pod.Namespace, nil)
 	if err != nil {
-		msg := fmt.Sprintf("Error getting volumes for Pod for driver: %v", err)
-		storklog.PodLog(pod).Warnf(msg)
+		storklog.PodLog(pod).Warnf("Error getting pods to spread across nodes: %v", err)
+		return counts
+	}
+	for _, p := range pods.Items {
+		if p.Name == pod.Name || p.Spec.NodeName == "" || p.DeletionTimestamp != nil ||
+			p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+			continue
+		}
+		for _, vol := range p.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && claims[vol.PersistentVolumeClaim.ClaimName] {
+				counts[p.Spec.NodeName]++
+				break
+			}
+		}
+	}
+	return counts
+}
+
+// recordEvent records a scheduling failure event for the pod, unless the
+// request is a dry run
+func (e *Extender) recordEvent(pod *v1.Pod, dryRun bool, msg string) {
+	if !dryRun {
 		e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
-		if _, ok := err.(*volume.ErrPVCPending); ok {
-			http.Error(w, "Waiting for PVC to be bound", http.StatusBadRequest)
-			return
+	}
+}
+
+// reportPodVolumesError logs the error from getting the volumes for the pod
+// from the driver. An event is only recorded if the driver owns any of the
+// PVCs used by the pod, since errors from the other drivers don't affect
+// where the pod can be scheduled.
+func (e *Extender) reportPodVolumesError(pod *v1.Pod, driver volume.Driver, dryRun bool, err error) {
+	if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+		return
+	}
+	storklog.PodLog(pod).Warnf("Error getting volumes for Pod for driver %v: %v", driver.String(), err)
+	if ownsPodVolumes(driver, pod) {
+		e.recordEvent(pod, dryRun, fmt.Sprintf("Error getting volumes for Pod for driver: %v", err))
+	}
+}
+
+// ownsPodVolumes returns true if the driver owns any of the PVCs used by the
+// pod
+func ownsPodVolumes(driver volume.Driver, pod *v1.Pod) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
 		}
-		goto sendResponse
-	} else if len(driverVolumes) > 0 {
-		driverNodes, err := e.Driver.GetNodes()
+		pvc, err := k8s.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim.ClaimName, pod.Namespace)
 		if err != nil {
-			storklog.PodLog(pod).Errorf("Error getting nodes for driver: %v", err)
-			goto sendResponse
-		}
-
-		// Create a map for ID->Node and Hostname->Rack/Zone/Region
-		idMap := make(map[string]*volume.NodeInfo)
-		var rackInfo, zoneInfo, regionInfo localityInfo
-		rackInfo.HostnameMap = make(map[string]string)
-		zoneInfo.HostnameMap = make(map[string]string)
-		regionInfo.HostnameMap = make(map[string]string)
-		for _, dnode := range driverNodes {
-			// Replace driver's hostname with the kubernetes hostname to make it
-			// easier to match nodes when calculating scores
-			for _, knode := range args.Nodes.Items {
-				if volume.IsNodeMatch(&knode, dnode) {
-					dnode.Hostname = e.getHostname(&knode)
-					break
-				}
-			}
-			idMap[dnode.StorageID] = dnode
-			storklog.PodLog(pod).Debugf("nodeInfo: %v", dnode)
-			// For any node that is offline remove the locality info so that we
-			// don't prioritize nodes close to it
-			if dnode.Status == volume.NodeOnline {
-				// Add region info into zone and zone info into rack so that we can
-				// differentiate same names in different localities
-				regionInfo.HostnameMap[dnode.Hostname] = dnode.Region
-				if regionInfo.HostnameMap[dnode.Hostname] != "" {
-					zoneInfo.HostnameMap[dnode.Hostname] = regionInfo.HostnameMap[dnode.Hostname] + "-" + dnode.Zone
-				} else {
-					zoneInfo.HostnameMap[dnode.Hostname] = dnode.Zone
+			continue
+		}
+		if driver.OwnsPVC(pvc) {
+			return true
+		}
+	}
+	return false
+}
+
+// getLocalVolumeCounts returns the number of volumes used by the pod that have
+// a replica on each of the nodes, indexed by the node name
+func (e *Extender) getLocalVolumeCounts(pod *v1.Pod, nodes []v1.Node) map[string]int {
+	counts := make(map[string]int)
+	for _, driver := range e.Drivers {
+		driverVolumes, err := driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+		if err != nil || len(driverVolumes) == 0 {
+			continue
+		}
+		driverNodes, err := e.getDriverNodes(driver)
+		if err != nil {
+			storklog.PodLog(pod).Errorf("Error getting nodes for driver %v: %v", driver.String(), err)
+			continue
+		}
+		for _, node := range nodes {
+			for _, driverNode := range driverNodes {
+				if driverNode.Status != volume.NodeOnline || !volume.IsNodeMatch(&node, driverNode) {
+					continue
 				}
-				if zoneInfo.HostnameMap[dnode.Hostname] != "" {
-					rackInfo.HostnameMap[dnode.Hostname] = zoneInfo.HostnameMap[dnode.Hostname] + "-" + dnode.Rack
-				} else {
-					rackInfo.HostnameMap[dnode.Hostname] = dnode.Rack
+				for _, volumeInfo := range driverVolumes {
+					for _, dataNode := range volumeInfo.DataNodes {
+						if dataNode == driverNode.StorageID {
+							counts[node.Name]++
+							break
+						}
+					}
 				}
-			} else {
-				rackInfo.HostnameMap[dnode.Hostname] = ""
-				zoneInfo.HostnameMap[dnode.Hostname] = ""
-				regionInfo.HostnameMap[dnode.Hostname] = ""
+				break
 			}
 		}
+	}
+	return counts
+}
 
-		storklog.PodLog(pod).Debugf("rackMap: %v", rackInfo.HostnameMap)
-		storklog.PodLog(pod).Debugf("zoneMap: %v", zoneInfo.HostnameMap)
-		storklog.PodLog(pod).Debugf("regionMap: %v", regionInfo.HostnameMap)
-
-		for _, volume := range driverVolumes {
-			storklog.PodLog(pod).Debugf("Volume %v allocated on nodes:", volume.VolumeName)
-			// Get the racks, zones and regions where the volume is located
-			rackInfo.PreferredLocality = rackInfo.PreferredLocality[:0]
-			zoneInfo.PreferredLocality = zoneInfo.PreferredLocality[:0]
-			regionInfo.PreferredLocality = regionInfo.PreferredLocality[:0]
-			for _, node := range volume.DataNodes {
-				if _, ok := idMap[node]; ok {
-					log.Debugf("ID: %v Hostname: %v", node, idMap[node].Hostname)
-					regionInfo.PreferredLocality = append(regionInfo.PreferredLocality, regionInfo.HostnameMap[idMap[node].Hostname])
-					zoneInfo.PreferredLocality = append(zoneInfo.PreferredLocality, zoneInfo.HostnameMap[idMap[node].Hostname])
-					rackInfo.PreferredLocality = append(rackInfo.PreferredLocality, rackInfo.HostnameMap[idMap[node].Hostname])
-				} else {
-					log.Warnf("Node %v not found in list of nodes, skipping", node)
-				}
+// getVictimPods returns the pods for the victims on a node when the scheduler
+// only sends the UIDs of the pods
+func (e *Extender) getVictimPods(nodeName string, victims *schedulerapi.MetaVictims) ([]*v1.Pod, error) {
+	pods, err := k8s.Instance().GetPodsByNode(nodeName, "")
+	if err != nil {
+		return nil, err
+	}
+	podMap := make(map[string]*v1.Pod)
+	for i := range pods.Items {
+		podMap[string(pods.Items[i].UID)] = &pods.Items[i]
+	}
+	victimPods := make([]*v1.Pod, 0)
+	for _, victim := range victims.Pods {
+		if pod, ok := podMap[victim.UID]; ok {
+			victimPods = append(victimPods, pod)
+		}
+	}
+	return victimPods, nil
+}
+
+// processPreemptRequest narrows down the nodes on which the scheduler can
+// preempt pods to make room for a pod. Nodes on which the pod can't use its
+// volumes are removed. If some of the nodes have replicas for the volumes
+// used by the pod, only those nodes are returned so that preemption doesn't
+// move the pod away from its data. If possible, nodes where the victims have
+// replicas for their own volumes are also avoided so that the victims aren't
+// moved away from their data.
+func (e *Extender) processPreemptRequest(w http.ResponseWriter, req *http.Request) {
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	encoder := json.NewEncoder(w)
+
+	var args schedulerapi.ExtenderPreemptionArgs
+	if err := decoder.Decode(&args); err != nil || args.Pod == nil {
+		log.Errorf("Error decoding preempt request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	pod := args.Pod
+	// The response always contains the UIDs of the victims. The pods are
+	// looked up if the scheduler only sent the UIDs.
+	metaVictims := make(map[string]*schedulerapi.MetaVictims)
+	nodeVictims := make(map[string][]*v1.Pod)
+	if args.NodeNameToVictims != nil {
+		for nodeName, victims := range args.NodeNameToVictims {
+			meta := &schedulerapi.MetaVictims{
+				NumPDBViolations: victims.NumPDBViolations,
+			}
+			for _, victim := range victims.Pods {
+				meta.Pods = append(meta.Pods, &schedulerapi.MetaPod{UID: string(victim.UID)})
 			}
-			storklog.PodLog(pod).Debugf("Volume %v allocated on racks: %v", volume.VolumeName, rackInfo.PreferredLocality)
-			storklog.PodLog(pod).Debugf("Volume %v allocated in zones: %v", volume.VolumeName, zoneInfo.PreferredLocality)
-			storklog.PodLog(pod).Debugf("Volume %v allocated in regions: %v", volume.VolumeName, regionInfo.PreferredLocality)
+			metaVictims[nodeName] = meta
+			nodeVictims[nodeName] = victims.Pods
+		}
+	} else {
+		for nodeName, victims := range args.NodeNameToMetaVictims {
+			metaVictims[nodeName] = victims
+			victimPods, err := e.getVictimPods(nodeName, victims)
+			if err != nil {
+				storklog.PodLog(pod).Warnf("Error getting victims on node %v: %v", nodeName, err)
+				continue
+			}
+			nodeVictims[nodeName] = victimPods
+		}
+	}
+
+	nodes := make([]v1.Node, 0)
+	for nodeName := range metaVictims {
+		node, err := k8s.Instance().GetNodeByName(nodeName)
+		if err != nil {
+			storklog.PodLog(pod).Warnf("Error getting node %v for preemption: %v", nodeName, err)
+			continue
+		}
+		nodes = append(nodes, *node)
+	}
 
-			for _, node := range args.Nodes.Items {
-				priorityMap[node.Name] += e.getNodeScore(node, volume, &rackInfo, &zoneInfo, &regionInfo, idMap)
+	response := &schedulerapi.ExtenderPreemptionResult{
+		NodeNameToMetaVictims: make(map[string]*schedulerapi.MetaVictims),
+	}
+	// Events aren't recorded since the pod has already failed scheduling and
+	// the nodes are only being checked for preemption
+	candidateNodes, _, err := e.filterNodes(pod, nodes, true)
+	if err != nil {
+		storklog.PodLog(pod).Warnf("No nodes available for preemption: %v", err)
+		candidateNodes = nil
+	}
+	candidateNodes = e.getLocalNodes(pod, candidateNodes)
+	candidateNodes = e.getNodesWithoutLocalVictims(pod, candidateNodes, nodeVictims)
+
+	storklog.PodLog(pod).Debugf("Nodes in preempt response:")
+	for _, node := range candidateNodes {
+		storklog.PodLog(pod).Debugf("%v", node.Name)
+		response.NodeNameToMetaVictims[node.Name] = metaVictims[node.Name]
+	}
+	if err := encoder.Encode(response); err != nil {
+		storklog.PodLog(pod).Errorf("Error encoding preempt response: %+v : %v", response, err)
+	}
+}
+
+// getLocalNodes returns the nodes that have replicas for the most volumes used
+// by the pod. All the nodes are returned if none of them have a replica.
+func (e *Extender) getLocalNodes(pod *v1.Pod, nodes []v1.Node) []v1.Node {
+	counts := e.getLocalVolumeCounts(pod, nodes)
+	maxCount := 0
+	for _, node := range nodes {
+		if counts[node.Name] > maxCount {
+			maxCount = counts[node.Name]
+		}
+	}
+	if maxCount == 0 {
+		return nodes
+	}
+
+	localNodes := make([]v1.Node, 0)
+	for _, node := range nodes {
+		if counts[node.Name] == maxCount {
+			localNodes = append(localNodes, node)
+		}
+	}
+	return localNodes
+}
+
+// getNodesWithoutLocalVictims returns the nodes where none of the victims have
+// replicas for their volumes on the same node. All the nodes are returned if
+// there are victims with local replicas on all of them.
+func (e *Extender) getNodesWithoutLocalVictims(
+	pod *v1.Pod,
+	nodes []v1.Node,
+	nodeVictims map[string][]*v1.Pod,
+) []v1.Node {
+	filteredNodes := make([]v1.Node, 0)
+	for _, node := range nodes {
+		hasLocalVictim := false
+		for _, victim := range nodeVictims[node.Name] {
+			if e.getLocalVolumeCounts(victim, []v1.Node{node})[node.Name] > 0 {
+				storklog.PodLog(pod).Debugf("Victim %v/%v has a replica on node %v", victim.Namespace, victim.Name, node.Name)
+				hasLocalVictim = true
+				break
 			}
 		}
+		if !hasLocalVictim {
+			filteredNodes = append(filteredNodes, node)
+		}
+	}
+	if len(filteredNodes) == 0 {
+		return nodes
 	}
+	return filteredNodes
+}
 
-sendResponse:
-	// For any nodes that didn't have any volumes, assign it a
-	// default score so that it doesn't get completely ignored
-	// by the scheduler
-	for _, node := range args.Nodes.Items {
-		score, ok := priorityMap[node.Name]
-		if !ok || score == 0 {
-			score = defaultScore
+func (e *Extender) processBindRequest(w http.ResponseWriter, req *http.Request) {
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
 		}
-		hostPriority := schedulerapi.HostPriority{Host: node.Name, Score: score}
-		respList = append(respList, hostPriority)
+	}()
+	encoder := json.NewEncoder(w)
+
+	var args schedulerapi.ExtenderBindingArgs
+	if err := decoder.Decode(&args); err != nil {
+		log.Errorf("Error decoding bind request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
 	}
 
-	storklog.PodLog(pod).Debugf("Nodes in response:")
-	for _, node := range respList {
-		storklog.PodLog(pod).Debugf("%+v", node)
+	response := &schedulerapi.ExtenderBindingResult{}
+	if err := e.bindPod(&args); err != nil {
+		log.Errorf("Error binding pod %v/%v to node %v: %v", args.PodNamespace, args.PodName, args.Node, err)
+		response.Error = err.Error()
 	}
+	if err := encoder.Encode(response); err != nil {
+		log.Errorf("Error encoding bind response: %+v : %v", response, err)
+	}
+}
 
-	if err := encoder.Encode(respList); err != nil {
-		storklog.PodLog(pod).Errorf("Failed to encode response: %v", err)
+// bindPod binds the pod to the node after checking that the volumes used by
+// the pod are still healthy, since a replica could have gone offline after
+// the pod was filtered
+func (e *Extender) bindPod(args *schedulerapi.ExtenderBindingArgs) error {
+	if e.KubeClient == nil {
+		return fmt.Errorf("binding is not supported by the extender")
+	}
+	pod, err := k8s.Instance().GetPodByName(args.PodName, args.PodNamespace)
+	if err != nil {
+		return fmt.Errorf("error getting pod: %v", err)
+	}
+	node, err := k8s.Instance().GetNodeByName(args.Node)
+	if err != nil {
+		return fmt.Errorf("error getting node: %v", err)
 	}
+
+	if err := e.checkVolumeHealth(pod, node); err != nil {
+		msg := fmt.Sprintf("Not binding pod to node %v: %v", node.Name, err)
+		storklog.PodLog(pod).Warn(msg)
+		e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
+		return fmt.Errorf("%v", msg)
+	}
+
+	binding := &v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      args.PodName,
+			Namespace: args.PodNamespace,
+			UID:       args.PodUID,
+		},
+		Target: v1.ObjectReference{
+			Kind: "Node",
+			Name: args.Node,
+		},
+	}
+	return e.KubeClient.CoreV1().Pods(args.PodNamespace).Bind(binding)
+}
+
+// checkVolumeHealth inspects the volumes used by the pod and returns an error
+// if any of them don't have an online replica. If the pod should only be
+// placed on nodes with replicas, the node is also checked to still have an
+// online replica for all the volumes.
+func (e *Extender) checkVolumeHealth(pod *v1.Pod, node *v1.Node) error {
+	preferLocalOnly := isPreferLocalOnly(pod)
+	for _, driver := range e.Drivers {
+		driverVolumes, err := driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+		if err != nil {
+			if _, ok := err.(*volume.ErrPVCPending); ok {
+				return err
+			}
+			storklog.PodLog(pod).Warnf("Error getting volumes for Pod for driver %v: %v", driver.String(), err)
+			continue
+		} else if len(driverVolumes) == 0 {
+			continue
+		}
+
+		// Get the nodes from the driver instead of the cache since the
+		// status could have changed after the pod was filtered
+		driverNodes, err := driver.GetNodes()
+		if err != nil {
+			storklog.PodLog(pod).Errorf("Error getting nodes for driver %v: %v", driver.String(), err)
+			continue
+		}
+		e.nodeCache.updateNodes(driver, driverNodes)
+		for _, driverVolume := range driverVolumes {
+			if isZonalVolume(driverVolume) {
+				continue
+			}
+			volumeInfo, err := driver.InspectVolume(driverVolume.VolumeID)
+			if err != nil {
+				return fmt.Errorf("error inspecting volume %v: %v", driverVolume.VolumeName, err)
+			}
+			onlineNodeFound := false
+			localNodeFound := false
+			for _, dataNode := range volumeInfo.DataNodes {
+				for _, driverNode := range driverNodes {
+					if dataNode == driverNode.StorageID && driverNode.Status == volume.NodeOnline {
+						onlineNodeFound = true
+						if volume.IsNodeMatch(node, driverNode) {
+							localNodeFound = true
+						}
+					}
+				}
+			}
+			if !onlineNodeFound {
+				return fmt.Errorf("no online node found with replica for volume %v", driverVolume.VolumeName)
+			}
+			if preferLocalOnly && !localNodeFound {
+				return fmt.Errorf("node doesn't have an online replica for volume %v", driverVolume.VolumeName)
+			}
+		}
+	}
+	return nil
 }
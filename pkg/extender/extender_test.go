@@ -3,35 +3,49 @@
 package extender
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/drivers/volume/mock"
 	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	restore "github.com/libopenstorage/stork/pkg/snapshot/controllers"
 	fakeocpclient "github.com/openshift/client-go/apps/clientset/versioned/fake"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/api/legacyscheme"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
 )
 
 const (
-	mockDriverName   = "MockDriver"
-	defaultNamespace = "testNamespace"
+	mockDriverName     = "MockDriver"
+	defaultNamespace   = "testNamespace"
+	configMapName      = "stork-extender-config"
+	configMapNamespace = "kube-system"
 )
 
 var driver *mock.Driver
@@ -39,6 +53,7 @@ var extender *Extender
 var fakeStorkClient *fakeclient.Clientset
 var fakeOCPClient *fakeocpclient.Clientset
 var fakeRestClient *fake.RESTClient
+var fakeKubeClient *kubernetes.Clientset
 
 func setup(t *testing.T) {
 	logrus.SetLevel(logrus.DebugLevel)
@@ -56,7 +71,7 @@ func setup(t *testing.T) {
 		t.Fatalf("Error initializing mock volume driver: %v", err)
 	}
 
-	fakeKubeClient := kubernetes.NewSimpleClientset()
+	fakeKubeClient = kubernetes.NewSimpleClientset()
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: corev1.New(fakeKubeClient.Core().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(legacyscheme.Scheme, v1.EventSource{Component: "storktest"})
@@ -67,9 +82,15 @@ func setup(t *testing.T) {
 	fakeRestClient = &fake.RESTClient{}
 	k8s.Instance().SetClient(fakeKubeClient, fakeRestClient, fakeStorkClient, nil, nil, fakeOCPClient, nil, nil)
 
+	// Disable the node cache so that changes in the mock driver are seen
+	// immediately
 	extender = &Extender{
-		Driver:   storkdriver,
-		Recorder: recorder,
+		Drivers:            []volume.Driver{storkdriver},
+		Recorder:           recorder,
+		KubeClient:         fakeKubeClient,
+		Config:             Config{NodeCacheTTL: -1},
+		ConfigMapName:      configMapName,
+		ConfigMapNamespace: configMapNamespace,
 	}
 
 	if err = extender.Start(); err != nil {
@@ -134,7 +155,13 @@ func sendRequest(
 		Pod:   pod,
 		Nodes: nodeList,
 	}
+	return sendArgs(path, args)
+}
 
+func sendArgs(
+	path string,
+	args interface{},
+) (*http.Response, error) {
 	b, err := json.Marshal(args)
 	if err != nil {
 		return nil, err
@@ -213,6 +240,72 @@ func sendPrioritizeRequest(
 	return &priorityList, nil
 }
 
+func sendPreemptRequest(
+	args *schedulerapi.ExtenderPreemptionArgs,
+) (*schedulerapi.ExtenderPreemptionResult, error) {
+	resp, err := sendArgs("preempt", args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing decoder: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(strings.TrimSpace(string(contents)))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	var preemptResult schedulerapi.ExtenderPreemptionResult
+	if err := decoder.Decode(&preemptResult); err != nil {
+		logrus.Errorf("Error decoding preempt response: %v", err)
+		return nil, err
+	}
+	return &preemptResult, nil
+}
+
+func sendBindRequest(
+	args *schedulerapi.ExtenderBindingArgs,
+) (*schedulerapi.ExtenderBindingResult, error) {
+	resp, err := sendArgs("bind", args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing decoder: %v", err)
+		}
+	}()
+
+	decoder := json.NewDecoder(resp.Body)
+	var bindResult schedulerapi.ExtenderBindingResult
+	if err := decoder.Decode(&bindResult); err != nil {
+		logrus.Errorf("Error decoding bind response: %v", err)
+		return nil, err
+	}
+	return &bindResult, nil
+}
+
+func verifyPreemptResponse(
+	t *testing.T,
+	expectedNodes []string,
+	response *schedulerapi.ExtenderPreemptionResult,
+) {
+	responseNodes := make([]string, 0)
+	for node := range response.NodeNameToMetaVictims {
+		responseNodes = append(responseNodes, node)
+	}
+	require.ElementsMatch(t, expectedNodes, responseNodes, "Unexpected nodes in preempt response")
+}
+
 func verifyFilterResponse(
 	t *testing.T,
 	requestNodes *v1.NodeList,
@@ -298,6 +391,23 @@ func TestExtender(t *testing.T) {
 	t.Run("noReplicasTest", noReplicasTest)
 	t.Run("restorePVCTest", restorePVCTest)
 	t.Run("preferLocalNodeTest", preferLocalNodeTest)
+	t.Run("multipleDriverTest", multipleDriverTest)
+	t.Run("podVolumesErrorTest", podVolumesErrorTest)
+	t.Run("zonalVolumeTest", zonalVolumeTest)
+	t.Run("regionalVolumeTest", regionalVolumeTest)
+	t.Run("capacityTest", capacityTest)
+	t.Run("preemptTest", preemptTest)
+	t.Run("preemptMetaVictimsTest", preemptMetaVictimsTest)
+	t.Run("bindTest", bindTest)
+	t.Run("explainTest", explainTest)
+	t.Run("nodeCacheTest", nodeCacheTest)
+	t.Run("metricsTest", metricsTest)
+	t.Run("spreadStrategyTest", spreadStrategyTest)
+	t.Run("sizeWeightedStrategyTest", sizeWeightedStrategyTest)
+	t.Run("configMapListenAddressTest", configMapListenAddressTest)
+	t.Run("configMapDeleteTest", configMapDeleteTest)
+	t.Run("invalidConfigTest", invalidConfigTest)
+	t.Run("zeroScoreConfigTest", zeroScoreConfigTest)
 	t.Run("teardown", teardown)
 }
 
@@ -947,3 +1057,1017 @@ func preferLocalNodeTest(t *testing.T) {
 	_, err = sendFilterRequest(pod, requestNodes)
 	require.Error(t, err, "Expected error since local node was not sent in filter request")
 }
+
+// Create a pod with a PVC for the mock driver and a PVC for a second mock
+// driver. Place the data for the first volume on nodes n1, n2 and the data for
+// the second volume on n2, n3. The second driver only runs on n1, n2, n3.
+// Send requests with node n1, n2, n3, n4, n5
+// The filter response should return only the nodes where both drivers are
+// running
+// The prioritize response should add up the scores from both drivers
+func multipleDriverTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "rack2", "", ""))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	secondDriver := mock.NewDriver("MockDriver2", "mockDriver2StorageClass")
+	if err := secondDriver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster for second driver: %v", err)
+	}
+	extender.Drivers = append(extender.Drivers, secondDriver)
+	defer func() {
+		extender.Drivers = extender.Drivers[:1]
+	}()
+
+	pod := newPod("multipleDriverPod", []string{"firstDriverVolume"})
+	pvc := secondDriver.NewPVC("secondDriverVolume")
+	_, err := k8s.Instance().CreatePersistentVolumeClaim(pvc)
+	require.NoError(t, err)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: pvc.Name,
+			},
+		},
+	})
+
+	provNodes := []int{0, 1}
+	if err := driver.ProvisionVolume("firstDriverVolume", provNodes, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	provNodes = []int{1, 2}
+	if err := secondDriver.ProvisionVolume("secondDriverVolume", provNodes, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore,
+			2 * nodePriorityScore,
+			nodePriorityScore,
+			rackPriorityScore,
+			rackPriorityScore},
+		prioritizeResponse)
+}
+
+// notOwnedDriver doesn't own any PVCs
+type notOwnedDriver struct {
+	*mock.Driver
+}
+
+func (d *notOwnedDriver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	return false
+}
+
+// Errors from drivers that don't support getting the pod volumes or don't
+// own any of the volumes used by the pod shouldn't be recorded as events for
+// the pod. Errors from the driver owning the volumes should be recorded.
+func podVolumesErrorTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	pod := newPod("podVolumesErrorPod", []string{"podVolumesErrorVolume"})
+	if err := driver.ProvisionVolume("podVolumesErrorVolume", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	notSupportedDriver := mock.NewDriver("NotSupportedDriver", "notSupportedStorageClass")
+	notSupportedDriver.SetInterfaceError(&storkerrors.ErrNotSupported{})
+	otherDriver := &notOwnedDriver{mock.NewDriver("NotOwnedDriver", "notOwnedStorageClass")}
+	otherDriver.SetInterfaceError(fmt.Errorf("PVC not owned by driver"))
+
+	recorder := record.NewFakeRecorder(10)
+	errorExtender := &Extender{
+		Drivers:   []volume.Driver{driver, notSupportedDriver, otherDriver},
+		Recorder:  recorder,
+		nodeCache: newNodeCache(),
+	}
+	errorExtender.setConfig(extender.getConfig())
+	filteredNodes, _, err := errorExtender.filterNodes(pod, nodes.Items, false)
+	require.NoError(t, err, "Error filtering nodes")
+	require.Len(t, filteredNodes, 3, "All nodes should be returned")
+	_, err = errorExtender.getVolumeScores(errorExtender.getConfig(), pod, nodes.Items, false)
+	require.NoError(t, err, "Error getting volume scores")
+	require.Len(t, recorder.Events, 0, "No events should be recorded for drivers not owning the volumes")
+
+	ownerDriver := mock.NewDriver("OwnerDriver", "ownerStorageClass")
+	ownerDriver.SetInterfaceError(fmt.Errorf("Driver error"))
+	errorExtender.Drivers = []volume.Driver{ownerDriver}
+	_, _, err = errorExtender.filterNodes(pod, nodes.Items, false)
+	require.NoError(t, err, "Error filtering nodes")
+	require.Len(t, recorder.Events, 1, "Event should be recorded for the driver owning the volumes")
+	event := <-recorder.Events
+	require.Contains(t, event, "Driver error")
+}
+
+// Create a pod with a PVC for a volume that is accessible from zone a.
+// Nodes n1 and n2 are in zone a, n3 is in zone b in the same region and n4,
+// n5 are in another region.
+// The filter response should only return n1 and n2.
+// The prioritize response should prefer n1, n2 (same zone) >> n3 (same region)
+// >> n4, n5
+func zonalVolumeTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "", "a", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "", "a", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "", "b", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "", "c", "region2"))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "", "c", "region2"))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("zonalVolumePod", []string{"zonalVolume"})
+	if err := driver.ProvisionZonalVolume("zonalVolume", []string{"a"}, "", 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1}, filterResponse)
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{zonePriorityScore,
+			zonePriorityScore,
+			regionPriorityScore,
+			defaultScore,
+			defaultScore},
+		prioritizeResponse)
+
+	// None of the nodes are in the zone of the volume
+	filterNodes := &v1.NodeList{Items: nodes.Items[2:]}
+	_, err = sendFilterRequest(pod, filterNodes)
+	if err == nil {
+		t.Fatalf("Filter request should have failed for nodes in other zones")
+	}
+}
+
+// Create a pod with a PVC for a regional volume in zones a and b in region1.
+// The filter response should only return the nodes in zones a and b.
+// The prioritize response should prefer the nodes in zones a and b equally.
+func regionalVolumeTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "", "a", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "", "b", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "", "c", "region1"))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "", "a", "region2"))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "", "", ""))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("regionalVolumePod", []string{"regionalVolume"})
+	if err := driver.ProvisionZonalVolume("regionalVolume", []string{"a", "b"}, "region1", 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	// node4 is in a zone with the same name in a different region. node5
+	// doesn't have any topology information so it isn't filtered out.
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 4}, filterResponse)
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{zonePriorityScore,
+			zonePriorityScore,
+			regionPriorityScore,
+			defaultScore,
+			defaultScore},
+		prioritizeResponse)
+}
+
+func addWaitForFirstConsumerPVC(t *testing.T, pod *v1.Pod, name string, size string) {
+	storageClassName := "waitForFirstConsumerStorageClass"
+	bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+	_, err := k8s.Instance().CreateStorageClass(&storagev1.StorageClass{
+		ObjectMeta:        metav1.ObjectMeta{Name: storageClassName},
+		Provisioner:       "kubernetes.io/mock-volume",
+		VolumeBindingMode: &bindingMode,
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		t.Fatalf("Error creating storage class: %v", err)
+	}
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pod.Namespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse(size),
+				},
+			},
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Phase: v1.ClaimPending,
+		},
+	}
+	_, err = k8s.Instance().CreatePersistentVolumeClaim(pvc)
+	require.NoError(t, err, "Error creating PVC")
+
+	podVolume := v1.Volume{}
+	podVolume.PersistentVolumeClaim = &v1.PersistentVolumeClaimVolumeSource{
+		ClaimName: pvc.Name,
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, podVolume)
+}
+
+// Create a pod with a 5Gi PVC using a WaitForFirstConsumer storage class.
+// n1 has 10Gi free, n2 has 1Gi free and the capacity isn't known for n3.
+// The filter response should return n1 and n3.
+// With a bound volume with replicas on n1 and n2 the response shouldn't
+// change. With another 6Gi PVC only n3 should be returned, and once n3 reports
+// 1Gi free the filter request should fail.
+func capacityTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	gib := uint64(1024 * 1024 * 1024)
+	if err := driver.UpdateNodeCapacity(0, 10*gib, 100*gib); err != nil {
+		t.Fatalf("Error updating node capacity: %v", err)
+	}
+	if err := driver.UpdateNodeCapacity(1, 1*gib, 100*gib); err != nil {
+		t.Fatalf("Error updating node capacity: %v", err)
+	}
+
+	pod := newPod("capacityPod", nil)
+	addWaitForFirstConsumerPVC(t, pod, "capacityPVC1", "5Gi")
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 2}, filterResponse)
+
+	pod = newPod("capacityBoundPod", []string{"capacityVolume"})
+	if err := driver.ProvisionVolume("capacityVolume", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: "capacityPVC1",
+			},
+		},
+	})
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 2}, filterResponse)
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore, nodePriorityScore, defaultScore},
+		prioritizeResponse)
+
+	addWaitForFirstConsumerPVC(t, pod, "capacityPVC2", "6Gi")
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{2}, filterResponse)
+
+	if err := driver.UpdateNodeCapacity(2, 1*gib, 100*gib); err != nil {
+		t.Fatalf("Error updating node capacity: %v", err)
+	}
+	_, err = sendFilterRequest(pod, nodes)
+	require.Error(t, err, "Expected error for filter request")
+	require.Contains(t, err.Error(), "enough free capacity", "Unexpected error for filter request")
+}
+
+func createNodes(t *testing.T, nodes *v1.NodeList) {
+	for _, node := range nodes.Items {
+		_, err := k8s.Instance().CreateNode(node.DeepCopy())
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			t.Fatalf("Error creating node: %v", err)
+		}
+	}
+}
+
+func newVictimPod(t *testing.T, podName string, nodeName string, volumes []string) *v1.Pod {
+	pod := newPod(podName, volumes)
+	require.NotNil(t, pod, "Error creating victim pod")
+	pod.UID = types.UID(podName + "-uid")
+	pod.Spec.NodeName = nodeName
+	_, err := k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "Error creating victim pod")
+	return pod
+}
+
+// Create a pod with a volume with replicas on n1 and n2, and victims on n1,
+// n2 and n3. The victim on n1 has a volume with a replica on n1.
+// Only n2 should be returned since n3 doesn't have a replica for the pod and
+// preempting on n1 would move the victim away from its data.
+// If n2 is offline, n1 should be returned since preempting there is the only
+// way to keep the pod on its data.
+func preemptTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack1", "", ""))
+	createNodes(t, nodes)
+
+	if err := driver.CreateCluster(4, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("preemptPod", []string{"preemptVolume"})
+	if err := driver.ProvisionVolume("preemptVolume", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	victim1 := newPod("preemptVictim1", []string{"preemptVictimVolume"})
+	if err := driver.ProvisionVolume("preemptVictimVolume", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	victim2 := newPod("preemptVictim2", nil)
+	victim3 := newPod("preemptVictim3", nil)
+
+	args := &schedulerapi.ExtenderPreemptionArgs{
+		Pod: pod,
+		NodeNameToVictims: map[string]*schedulerapi.Victims{
+			"node1": {Pods: []*v1.Pod{victim1}},
+			"node2": {Pods: []*v1.Pod{victim2}},
+			"node3": {Pods: []*v1.Pod{victim3}},
+		},
+	}
+	preemptResponse, err := sendPreemptRequest(args)
+	require.NoError(t, err, "Error sending preempt request")
+	verifyPreemptResponse(t, []string{"node2"}, preemptResponse)
+
+	// Without a replica on any of the nodes, all of them can be used
+	args.NodeNameToVictims = map[string]*schedulerapi.Victims{
+		"node3": {Pods: []*v1.Pod{victim3}},
+		"node4": {Pods: []*v1.Pod{victim2}},
+	}
+	preemptResponse, err = sendPreemptRequest(args)
+	require.NoError(t, err, "Error sending preempt request")
+	verifyPreemptResponse(t, []string{"node3", "node4"}, preemptResponse)
+
+	if err := driver.UpdateNodeStatus(1, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	args.NodeNameToVictims = map[string]*schedulerapi.Victims{
+		"node1": {Pods: []*v1.Pod{victim1}},
+		"node2": {Pods: []*v1.Pod{victim2}},
+		"node3": {Pods: []*v1.Pod{victim3}},
+	}
+	preemptResponse, err = sendPreemptRequest(args)
+	require.NoError(t, err, "Error sending preempt request")
+	verifyPreemptResponse(t, []string{"node1"}, preemptResponse)
+
+	// No nodes should be returned if the pod can't be scheduled
+	if err := driver.UpdateNodeStatus(0, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	preemptResponse, err = sendPreemptRequest(args)
+	require.NoError(t, err, "Error sending preempt request")
+	verifyPreemptResponse(t, []string{}, preemptResponse)
+
+	// Scheduling failures shouldn't be recorded as events for the pod when
+	// checking nodes for preemption
+	recorder := record.NewFakeRecorder(10)
+	preemptExtender := &Extender{
+		Drivers:   extender.Drivers,
+		Recorder:  recorder,
+		nodeCache: newNodeCache(),
+	}
+	preemptExtender.setConfig(extender.getConfig())
+	body, err := json.Marshal(args)
+	require.NoError(t, err, "Error encoding preempt request")
+	resp := httptest.NewRecorder()
+	preemptExtender.processPreemptRequest(resp, httptest.NewRequest(http.MethodPost, "/preempt", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, resp.Code, "Unexpected response status")
+	require.Len(t, recorder.Events, 0, "No events should be recorded when preempting")
+}
+
+// Same as preemptTest but the scheduler only sends the UIDs of the victims
+func preemptMetaVictimsTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	createNodes(t, nodes)
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("metaPreemptPod", []string{"metaPreemptVolume"})
+	if err := driver.ProvisionVolume("metaPreemptVolume", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	victim1 := newVictimPod(t, "metaPreemptVictim1", "node1", []string{"metaPreemptVictimVolume"})
+	if err := driver.ProvisionVolume("metaPreemptVictimVolume", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	victim2 := newVictimPod(t, "metaPreemptVictim2", "node2", nil)
+	victim3 := newVictimPod(t, "metaPreemptVictim3", "node3", nil)
+
+	args := &schedulerapi.ExtenderPreemptionArgs{
+		Pod: pod,
+		NodeNameToMetaVictims: map[string]*schedulerapi.MetaVictims{
+			"node1": {Pods: []*schedulerapi.MetaPod{{UID: string(victim1.UID)}}},
+			"node2": {Pods: []*schedulerapi.MetaPod{{UID: string(victim2.UID)}}},
+			"node3": {Pods: []*schedulerapi.MetaPod{{UID: string(victim3.UID)}}},
+		},
+	}
+	preemptResponse, err := sendPreemptRequest(args)
+	require.NoError(t, err, "Error sending preempt request")
+	verifyPreemptResponse(t, []string{"node2"}, preemptResponse)
+	require.Len(t, preemptResponse.NodeNameToMetaVictims["node2"].Pods, 1, "Unexpected victims in preempt response")
+	require.Equal(t, string(victim2.UID), preemptResponse.NodeNameToMetaVictims["node2"].Pods[0].UID, "Unexpected victim in preempt response")
+}
+
+// Create a pod with a volume with replicas on n1 and n2 and bind it to n3.
+// The pod should be bound while a replica is online, and binding should fail
+// once both the replicas go offline.
+// With preferLocalNodeOnly, binding to n3 should always fail.
+func bindTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	createNodes(t, nodes)
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	var bindings []*v1.Binding
+	fakeKubeClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "bindings" {
+			return false, nil, nil
+		}
+		binding := action.(k8stesting.CreateAction).GetObject().(*v1.Binding)
+		bindings = append(bindings, binding)
+		return true, binding, nil
+	})
+
+	pod := newVictimPod(t, "bindPod", "", []string{"bindVolume"})
+	if err := driver.ProvisionVolume("bindVolume", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	args := &schedulerapi.ExtenderBindingArgs{
+		PodName:      pod.Name,
+		PodNamespace: pod.Namespace,
+		PodUID:       pod.UID,
+		Node:         "node3",
+	}
+	bindResponse, err := sendBindRequest(args)
+	require.NoError(t, err, "Error sending bind request")
+	require.Empty(t, bindResponse.Error, "Unexpected error in bind response")
+	require.Len(t, bindings, 1, "Pod should have been bound")
+	require.Equal(t, "node3", bindings[0].Target.Name, "Pod bound to wrong node")
+	require.Equal(t, pod.UID, bindings[0].UID, "Wrong pod bound")
+
+	if err := driver.UpdateNodeStatus(0, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	if err := driver.UpdateNodeStatus(1, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	bindResponse, err = sendBindRequest(args)
+	require.NoError(t, err, "Error sending bind request")
+	require.Contains(t, bindResponse.Error, "no online node found with replica", "Expected error in bind response")
+	require.Len(t, bindings, 1, "Pod should not have been bound")
+
+	if err := driver.UpdateNodeStatus(0, volume.NodeOnline); err != nil {
+		t.Fatalf("Error setting node status to Online: %v", err)
+	}
+	pod.Annotations[preferLocalNodeOnlyAnnotation] = "true"
+	_, err = k8s.Instance().UpdatePod(pod)
+	require.NoError(t, err, "Error updating pod")
+	bindResponse, err = sendBindRequest(args)
+	require.NoError(t, err, "Error sending bind request")
+	require.Contains(t, bindResponse.Error, "node doesn't have an online replica", "Expected error in bind response")
+
+	args.Node = "node1"
+	bindResponse, err = sendBindRequest(args)
+	require.NoError(t, err, "Error sending bind request")
+	require.Empty(t, bindResponse.Error, "Unexpected error in bind response")
+	require.Len(t, bindings, 2, "Pod should have been bound")
+	require.Equal(t, "node1", bindings[1].Target.Name, "Pod bound to wrong node")
+}
+
+func sendExplainRequest(namespace, name string) (*SchedulingExplanation, error) {
+	params := url.Values{}
+	params.Set(ExplainNamespaceParam, namespace)
+	params.Set(ExplainNameParam, name)
+	resp, err := http.Get("http://localhost:8099" + ExplainPath + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing decoder: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(strings.TrimSpace(string(contents)))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	var explanation SchedulingExplanation
+	if err := decoder.Decode(&explanation); err != nil {
+		logrus.Errorf("Error decoding explain response: %v", err)
+		return nil, err
+	}
+	return &explanation, nil
+}
+
+// Explain the scheduling for a pod with a volume with a replica on n1. n2 is in
+// the same rack as n1 and n4 isn't a storage node.
+// n1 should get the node score, n2 the rack score, n3 the default score and n4
+// should be filtered out.
+// Once n1 goes offline all the nodes should be filtered out and no events
+// should be recorded for the pod, unlike when filtering for scheduling.
+func explainTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack4", "", ""))
+	createNodes(t, nodes)
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newVictimPod(t, "explainPod", "", []string{"explainVolume"})
+	if err := driver.ProvisionVolume("explainVolume", []int{0}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	_, err := sendExplainRequest(pod.Namespace, "")
+	require.Error(t, err, "Expected error for explain request without pod name")
+	_, err = sendExplainRequest(pod.Namespace, "missingPod")
+	require.Error(t, err, "Expected error for explain request for missing pod")
+
+	explanation, err := sendExplainRequest(pod.Namespace, pod.Name)
+	require.NoError(t, err, "Error sending explain request")
+	require.Equal(t, pod.Name, explanation.Pod, "Unexpected pod in explanation")
+	require.Equal(t, PackStrategy, explanation.Strategy, "Unexpected strategy in explanation")
+	require.Empty(t, explanation.FilterError, "Unexpected filter error in explanation")
+	require.Len(t, explanation.Nodes, 4, "Unexpected number of nodes in explanation")
+
+	expectedScores := []int{nodePriorityScore, rackPriorityScore, defaultScore}
+	expectedLocalities := []string{localityNode, localityRack, ""}
+	for i, nodeExplanation := range explanation.Nodes[:3] {
+		require.Equal(t, nodes.Items[i].Name, nodeExplanation.Name, "Unexpected node in explanation")
+		require.False(t, nodeExplanation.Filtered, "Node shouldn't have been filtered out")
+		require.Equal(t, expectedScores[i], nodeExplanation.Score, "Unexpected score for node %v", nodeExplanation.Name)
+		require.Len(t, nodeExplanation.VolumeScores, 1, "Unexpected volume scores for node %v", nodeExplanation.Name)
+		require.Equal(t, "explainVolume", nodeExplanation.VolumeScores[0].Volume, "Unexpected volume")
+		require.Equal(t, mockDriverName, nodeExplanation.VolumeScores[0].Driver, "Unexpected driver")
+		require.Equal(t, expectedLocalities[i], nodeExplanation.VolumeScores[0].Locality,
+			"Unexpected locality for node %v", nodeExplanation.Name)
+	}
+	require.Equal(t, "node4", explanation.Nodes[3].Name, "Unexpected node in explanation")
+	require.True(t, explanation.Nodes[3].Filtered, "Node should have been filtered out")
+	require.Equal(t, filterReasonDriverNotOnline, explanation.Nodes[3].FilterReason, "Unexpected filter reason")
+
+	if err := driver.UpdateNodeStatus(0, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	explanation, err = sendExplainRequest(pod.Namespace, pod.Name)
+	require.NoError(t, err, "Error sending explain request")
+	require.Equal(t, "No online node found with volume replica", explanation.FilterError,
+		"Unexpected filter error in explanation")
+	for _, nodeExplanation := range explanation.Nodes {
+		require.True(t, nodeExplanation.Filtered, "Node %v should have been filtered out", nodeExplanation.Name)
+		require.Equal(t, explanation.FilterError, nodeExplanation.FilterReason, "Unexpected filter reason")
+	}
+
+	// Events can't be recorded for the test pods through the API, so use a
+	// fake recorder to check that they are only recorded when scheduling
+	recorder := record.NewFakeRecorder(10)
+	dryRunExtender := &Extender{
+		Drivers:   extender.Drivers,
+		Recorder:  recorder,
+		nodeCache: newNodeCache(),
+	}
+	dryRunExtender.setConfig(extender.getConfig())
+	explanation = dryRunExtender.explainScheduling(pod, nodes.Items)
+	require.NotEmpty(t, explanation.FilterError, "Expected filter error in explanation")
+	require.Len(t, recorder.Events, 0, "No events should be recorded when explaining")
+	_, _, err = dryRunExtender.filterNodes(pod, nodes.Items, false)
+	require.Error(t, err, "Expected error when filtering nodes")
+	require.Len(t, recorder.Events, 1, "Event should be recorded when filtering nodes")
+}
+
+// Enable the node cache and send filter requests for a pod with a volume with
+// replicas on n1 and n2.
+// Once n3 goes offline in the driver the cached nodes should still be used
+// until the status of the node changes in Kubernetes.
+// A bind request should update the cache when the status of a driver node
+// changes.
+func nodeCacheTest(t *testing.T) {
+	updateConfigMap(t, map[string]string{
+		nodeCacheTTLKey: "1h",
+	})
+	waitForConfig(t, func(config Config) bool {
+		return config.NodeCacheTTL == time.Hour
+	})
+
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	createNodes(t, nodes)
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	extender.nodeCache.invalidate()
+
+	pod := newVictimPod(t, "nodeCachePod", "", []string{"nodeCacheVolume"})
+	if err := driver.ProvisionVolume("nodeCacheVolume", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	filterResponse, err := sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+
+	if err := driver.UpdateNodeStatus(2, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1, 2}, filterResponse)
+
+	node, err := k8s.Instance().GetNodeByName("node3")
+	require.NoError(t, err, "Error getting node")
+	node.Status.Conditions = []v1.NodeCondition{{
+		Type:   v1.NodeReady,
+		Status: v1.ConditionFalse,
+	}}
+	_, err = k8s.Instance().UpdateNode(node)
+	require.NoError(t, err, "Error updating node")
+	for i := 0; i < 50; i++ {
+		filterResponse, err = sendFilterRequest(pod, nodes)
+		if err != nil {
+			t.Fatalf("Error sending filter request: %v", err)
+		}
+		if len(filterResponse.Nodes.Items) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	verifyFilterResponse(t, nodes, []int{0, 1}, filterResponse)
+
+	// The bind request gets the nodes from the driver and updates the cache
+	if err := driver.UpdateNodeStatus(1, volume.NodeOffline); err != nil {
+		t.Fatalf("Error setting node status to Offline: %v", err)
+	}
+	bindResponse, err := sendBindRequest(&schedulerapi.ExtenderBindingArgs{
+		PodName:      pod.Name,
+		PodNamespace: pod.Namespace,
+		PodUID:       pod.UID,
+		Node:         "node1",
+	})
+	require.NoError(t, err, "Error sending bind request")
+	require.Empty(t, bindResponse.Error, "Unexpected error in bind response")
+	filterResponse, err = sendFilterRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending filter request: %v", err)
+	}
+	verifyFilterResponse(t, nodes, []int{0}, filterResponse)
+
+	updateConfigMap(t, map[string]string{})
+	waitForConfig(t, func(config Config) bool {
+		return config.NodeCacheTTL < 0
+	})
+}
+
+// Check that the metrics for the requests sent by the previous tests are
+// exported
+func metricsTest(t *testing.T) {
+	resp, err := http.Get("http://localhost:8099/metrics")
+	require.NoError(t, err, "Error getting metrics")
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Unexpected response status")
+	contents, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err, "Error reading metrics")
+
+	metrics := string(contents)
+	require.Contains(t, metrics, `stork_extender_requests_total{verb="filter"}`)
+	require.Contains(t, metrics, `stork_extender_requests_total{verb="prioritize"}`)
+	require.Contains(t, metrics, `stork_extender_requests_total{verb="preempt"}`)
+	require.Contains(t, metrics, `stork_extender_requests_total{verb="bind"}`)
+	require.Contains(t, metrics, `stork_extender_request_duration_seconds_bucket{verb="filter"`)
+	require.Contains(t, metrics, `stork_extender_filtered_nodes_total`)
+	require.Contains(t, metrics, `stork_extender_node_cache_requests_total{result="hit"}`)
+	require.Contains(t, metrics, `stork_extender_node_cache_requests_total{result="miss"}`)
+}
+
+func updateConfigMap(t *testing.T, data map[string]string) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: configMapNamespace,
+		},
+		Data: data,
+	}
+	_, err := k8s.Instance().GetConfigMap(configMapName, configMapNamespace)
+	if err == nil {
+		_, err = k8s.Instance().UpdateConfigMap(cm)
+	} else {
+		_, err = k8s.Instance().CreateConfigMap(cm)
+	}
+	require.NoError(t, err, "Error updating config map")
+}
+
+func waitForConfig(t *testing.T, check func(Config) bool) {
+	for i := 0; i < 50; i++ {
+		if check(extender.getConfig()) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for config to be updated, current config: %+v", extender.getConfig())
+}
+
+// Create a pod with 2 volumes with replicas on n1, n2 and n2, n3 and use the
+// spread strategy with custom scores from the config map.
+// All the nodes with replicas should get the same score instead of n2 getting
+// a higher score.
+// Then run pods using the volumes on n1 and n2. The scores for those nodes
+// should be divided by the number of pods plus one, so n3 is preferred.
+func spreadStrategyTest(t *testing.T) {
+	updateConfigMap(t, map[string]string{
+		strategyKey:          string(SpreadStrategy),
+		nodePriorityScoreKey: "80",
+		rackPriorityScoreKey: "40",
+	})
+	waitForConfig(t, func(config Config) bool {
+		return config.Strategy == SpreadStrategy && *config.NodePriorityScore == 80
+	})
+
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "rack2", "", ""))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("spreadPod", []string{"spreadVolume1", "spreadVolume2"})
+	if err := driver.ProvisionVolume("spreadVolume1", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	if err := driver.ProvisionVolume("spreadVolume2", []int{1, 2}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{80, 80, 80, 40, 40},
+		prioritizeResponse)
+
+	runningPods := []*v1.Pod{
+		newSpreadPod("spreadRunningPod1", "node1", "spreadVolume1", v1.PodRunning),
+		newSpreadPod("spreadRunningPod2", "node2", "spreadVolume1", v1.PodRunning),
+		newSpreadPod("spreadRunningPod3", "node2", "spreadVolume2", v1.PodRunning),
+		// Completed pods and pods for other volumes shouldn't be counted
+		newSpreadPod("spreadCompletedPod", "node3", "spreadVolume2", v1.PodSucceeded),
+		newSpreadPod("spreadOtherPod", "node3", "otherVolume", v1.PodRunning),
+	}
+	for _, runningPod := range runningPods {
+		_, err := k8s.Instance().CreatePod(runningPod)
+		require.NoError(t, err, "Error creating pod")
+	}
+	defer func() {
+		for _, runningPod := range runningPods {
+			require.NoError(t, k8s.Instance().DeletePods([]v1.Pod{*runningPod}, true), "Error deleting pod")
+		}
+	}()
+
+	prioritizeResponse, err = sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{40, 26, 80, 40, 40},
+		prioritizeResponse)
+}
+
+func newSpreadPod(name string, nodeName string, claimName string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Volumes: []v1.Volume{{
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: claimName,
+					},
+				},
+			}},
+		},
+		Status: v1.PodStatus{Phase: phase},
+	}
+}
+
+// Create a pod with 2 volumes, with a 1GB volume on n1, n2 and a 3GB volume on
+// n2, n3 and use the sizeWeighted strategy.
+// The scores for each node should be weighted by the size of the volumes, so
+// n3 should get a higher score than n1
+func sizeWeightedStrategyTest(t *testing.T) {
+	updateConfigMap(t, map[string]string{
+		strategyKey: string(SizeWeightedStrategy),
+	})
+	waitForConfig(t, func(config Config) bool {
+		return config.Strategy == SizeWeightedStrategy && *config.NodePriorityScore == nodePriorityScore
+	})
+
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack3", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node5", "node5", "192.168.0.5", "rack2", "", ""))
+
+	if err := driver.CreateCluster(5, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+
+	pod := newPod("sizeWeightedPod", []string{"sizeWeightedVolume1", "sizeWeightedVolume2"})
+	if err := driver.ProvisionVolume("sizeWeightedVolume1", []int{0, 1}, 1); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+	if err := driver.ProvisionVolume("sizeWeightedVolume2", []int{1, 2}, 3); err != nil {
+		t.Fatalf("Error provisioning volume: %v", err)
+	}
+
+	prioritizeResponse, err := sendPrioritizeRequest(pod, nodes)
+	if err != nil {
+		t.Fatalf("Error sending prioritize request: %v", err)
+	}
+	verifyPrioritizeResponse(
+		t,
+		nodes,
+		[]int{nodePriorityScore / 4,
+			nodePriorityScore,
+			3 * nodePriorityScore / 4,
+			rackPriorityScore / 4,
+			rackPriorityScore},
+		prioritizeResponse)
+}
+
+// Update the listen address in the config map and check that the extender
+// starts serving requests on the new address
+func configMapListenAddressTest(t *testing.T) {
+	updateConfigMap(t, map[string]string{
+		listenAddressKey: ":8098",
+	})
+	waitForConfig(t, func(config Config) bool {
+		return config.ListenAddress == ":8098"
+	})
+
+	resp, err := http.Get("http://localhost:8098/unsupported")
+	require.NoError(t, err, "Error sending request to new listen address")
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "Unexpected response status")
+
+	_, err = http.Get("http://localhost:8099/unsupported")
+	require.Error(t, err, "Expected error sending request to old listen address")
+}
+
+// Delete the config map and check that the default config is restored
+func configMapDeleteTest(t *testing.T) {
+	err := k8s.Instance().DeleteConfigMap(configMapName, configMapNamespace)
+	require.NoError(t, err, "Error deleting config map")
+	waitForConfig(t, func(config Config) bool {
+		return reflect.DeepEqual(config, extender.Config.withDefaults())
+	})
+
+	resp, err := http.Get("http://localhost:8099/unsupported")
+	require.NoError(t, err, "Error sending request to default listen address")
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "Unexpected response status")
+
+	// Reloading without any changes shouldn't replace the config
+	oldConfig := extender.getConfig()
+	err = extender.reloadConfig(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName}})
+	require.NoError(t, err, "Error reloading config")
+	require.True(t, oldConfig.NodePriorityScore == extender.getConfig().NodePriorityScore,
+		"Config shouldn't be updated if it hasn't changed")
+}
+
+func invalidConfigTest(t *testing.T) {
+	config := Config{}.withDefaults()
+	_, err := config.updateFromConfigMap(&v1.ConfigMap{
+		Data: map[string]string{strategyKey: "invalid"},
+	})
+	require.Error(t, err, "Expected error for invalid strategy")
+
+	_, err = config.updateFromConfigMap(&v1.ConfigMap{
+		Data: map[string]string{nodePriorityScoreKey: "abc"},
+	})
+	require.Error(t, err, "Expected error for invalid score")
+
+	_, err = config.updateFromConfigMap(&v1.ConfigMap{
+		Data: map[string]string{rackPriorityScoreKey: "-1"},
+	})
+	require.Error(t, err, "Expected error for negative score")
+
+	err = (&Extender{Config: Config{Strategy: "invalid"}}).Start()
+	require.Error(t, err, "Expected error starting extender with invalid strategy")
+}
+
+// Scores explicitly set to 0 shouldn't be replaced with the defaults
+func zeroScoreConfigTest(t *testing.T) {
+	config := Config{DefaultScore: Score(0)}.withDefaults()
+	require.Equal(t, 0, *config.DefaultScore, "Default score set to 0 should be kept")
+	require.Equal(t, nodePriorityScore, *config.NodePriorityScore, "Unset score should be defaulted")
+
+	config, err := config.updateFromConfigMap(&v1.ConfigMap{
+		Data: map[string]string{rackPriorityScoreKey: "0"},
+	})
+	require.NoError(t, err, "Error updating config with zero score")
+	config = config.withDefaults()
+	require.Equal(t, 0, *config.RackPriorityScore, "Rack score set to 0 should be kept")
+	require.Equal(t, 0, *config.DefaultScore, "Default score set to 0 should be kept")
+
+	nodes := []v1.Node{*newNode("node1", "node1", "192.168.0.1", "rack1", "", "")}
+	scores := getNodeScores(config, nodes, nil, nil)
+	require.Equal(t, 0, scores["node1"], "Node without data should get the zero default score")
+}
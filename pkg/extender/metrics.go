@@ -0,0 +1,57 @@
+package extender
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsPath = "/metrics"
+	cacheHit    = "hit"
+	cacheMiss   = "miss"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "stork",
+			Subsystem: "extender",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to the scheduler extender",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"verb"},
+	)
+	requestCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "stork",
+			Subsystem: "extender",
+			Name:      "requests_total",
+			Help:      "Number of requests to the scheduler extender",
+		},
+		[]string{"verb"},
+	)
+	filteredNodeCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "stork",
+			Subsystem: "extender",
+			Name:      "filtered_nodes_total",
+			Help:      "Number of nodes filtered out by the scheduler extender",
+		},
+	)
+	nodeCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "stork",
+			Subsystem: "extender",
+			Name:      "node_cache_requests_total",
+			Help:      "Number of lookups in the driver node cache, by result (hit or miss)",
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(requestCount)
+	prometheus.MustRegister(filteredNodeCount)
+	prometheus.MustRegister(nodeCacheRequests)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/rule"
@@ -53,7 +54,7 @@ var snapDeleteBackoff = wait.Backoff{
 
 // GroupSnapshotController groupSnapshotcontroller
 type GroupSnapshotController struct {
-	Driver              volume.Driver
+	Drivers             []volume.Driver
 	Recorder            record.EventRecorder
 	bgChannelsForRules  map[string]chan bool
 	minResourceVersions map[string]string
@@ -310,12 +311,17 @@ func (m *GroupSnapshotController) handleSnap(groupSnap *stork_api.GroupVolumeSna
 		response *volume.GroupSnapshotCreateResponse
 	)
 
+	driver, err := m.getDriver(groupSnap)
+	if err != nil {
+		return !updateCRD, err
+	}
+
 	if len(groupSnap.Status.VolumeSnapshots) > 0 {
 		log.GroupSnapshotLog(groupSnap).Infof("Group snapshot already active. Checking status")
-		response, err = m.Driver.GetGroupSnapshotStatus(groupSnap)
+		response, err = driver.GetGroupSnapshotStatus(groupSnap)
 	} else {
 		log.GroupSnapshotLog(groupSnap).Infof("Creating new group snapshot")
-		response, err = m.Driver.CreateGroupSnapshot(groupSnap)
+		response, err = driver.CreateGroupSnapshot(groupSnap)
 	}
 
 	if err != nil {
@@ -359,7 +365,7 @@ func (m *GroupSnapshotController) handleSnap(groupSnap *stork_api.GroupVolumeSna
 	} else if areAllSnapshotsDone(response.Snapshots) {
 		log.GroupSnapshotLog(groupSnap).Infof("All snapshots in group are done")
 		// Create volumesnapshot and volumesnapshotdata objects in API
-		response.Snapshots, err = m.createSnapAndDataObjects(groupSnap, response.Snapshots)
+		response.Snapshots, err = m.createSnapAndDataObjects(driver, groupSnap, response.Snapshots)
 		if err != nil {
 			return !updateCRD, err
 		}
@@ -420,6 +426,7 @@ func (m *GroupSnapshotController) replaceSnapshot(
 }
 
 func (m *GroupSnapshotController) createSnapAndDataObjects(
+	driver volume.Driver,
 	groupSnap *stork_api.GroupVolumeSnapshot, snapshots []*stork_api.VolumeSnapshotStatus) (
 	[]*stork_api.VolumeSnapshotStatus, error) {
 	updatedStatues := make([]*stork_api.VolumeSnapshotStatus, 0)
@@ -443,7 +450,7 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 	}
 
 	for _, snapshot := range snapshots {
-		parentPVCOrVolID, err := m.getPVCNameFromVolumeID(snapshot.ParentVolumeID)
+		parentPVCOrVolID, err := m.getPVCNameFromVolumeID(driver, snapshot.ParentVolumeID)
 		if err != nil {
 			return nil, err
 		}
@@ -585,8 +592,8 @@ func revertSnapObjs(snapObjs []*crdv1.VolumeSnapshot) {
 }
 
 // this is best effort as can be vol ID if PVC is deleted
-func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string, error) {
-	volInfo, err := m.Driver.InspectVolume(volID)
+func (m *GroupSnapshotController) getPVCNameFromVolumeID(driver volume.Driver, volID string) (string, error) {
+	volInfo, err := driver.InspectVolume(volID)
 	if err != nil {
 		logrus.Warnf("Volume: %s not found due to: %v", volID, err)
 		return volID, nil
@@ -693,13 +700,44 @@ func (m *GroupSnapshotController) handleDelete(groupSnap *stork_api.GroupVolumeS
 	// no need to track minResourceVersion for this group snap any longer
 	delete(m.minResourceVersions, string(groupSnap.UID))
 
-	if err := m.Driver.DeleteGroupSnapshot(groupSnap); err != nil {
-		return err
+	// The PVCs might have been deleted already, so let every driver that
+	// supports group snapshots cleanup the snapshots it owns
+	for _, driver := range m.Drivers {
+		if err := driver.DeleteGroupSnapshot(groupSnap); err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				continue
+			}
+			return err
+		}
 	}
 
 	return nil
 }
 
+// getDriver returns the driver that owns the PVCs selected by the group
+// snapshot. All PVCs in a group snapshot need to be owned by the same driver.
+func (m *GroupSnapshotController) getDriver(groupSnap *stork_api.GroupVolumeSnapshot) (volume.Driver, error) {
+	pvcs, err := k8sutils.GetPVCsForGroupSnapshot(groupSnap.Namespace, groupSnap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupDriver volume.Driver
+	for i := range pvcs {
+		driver, err := volume.GetPVCDriverFromList(m.Drivers, &pvcs[i])
+		if err != nil {
+			return nil, err
+		}
+		if groupDriver == nil {
+			groupDriver = driver
+		} else if groupDriver.String() != driver.String() {
+			return nil, fmt.Errorf("PVCs for group snapshot are owned by different drivers: %v and %v",
+				groupDriver.String(), driver.String())
+		}
+	}
+	return groupDriver, nil
+}
+
 // isAnySnapshotFailed checks if any of the given snapshots is in error state and returns
 // task IDs of failed snapshots
 func isAnySnapshotFailed(snapshots []*stork_api.VolumeSnapshotStatus) (bool, []string) {
@@ -14,7 +14,7 @@ import (
 
 // GroupSnapshot instance
 type GroupSnapshot struct {
-	Driver                  volume.Driver
+	Drivers                 []volume.Driver
 	Recorder                record.EventRecorder
 	groupSnapshotController *controllers.GroupSnapshotController
 }
@@ -22,7 +22,7 @@ type GroupSnapshot struct {
 // Init init
 func (m *GroupSnapshot) Init() error {
 	m.groupSnapshotController = &controllers.GroupSnapshotController{
-		Driver:   m.Driver,
+		Drivers:  m.Drivers,
 		Recorder: m.Recorder,
 	}
 
@@ -3,7 +3,6 @@ package initializer
 import (
 	"encoding/json"
 
-	"github.com/libopenstorage/stork/drivers/volume"
 	storklog "github.com/libopenstorage/stork/pkg/log"
 	appv1 "k8s.io/api/apps/v1"
 	appv1beta1 "k8s.io/api/apps/v1beta1"
@@ -39,15 +38,11 @@ func (i *Initializer) initializeDeploymentV1(deployment *appv1.Deployment, clien
 	// Only check to update scheduler name if it is set to the default
 	if deployment.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumes, err := i.Driver.GetPodVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
-		if err != nil {
-			if _, ok := err.(*volume.ErrPVCPending); ok {
-				updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
-			} else {
-				storklog.DeploymentV1Log(deployment).Errorf("error getting volumes for pod: %v", err)
-			}
-		} else if len(driverVolumes) != 0 {
+		usesDriverVolumes, err := i.podUsesDriverVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
+		if usesDriverVolumes {
 			updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
+		} else if err != nil {
+			storklog.DeploymentV1Log(deployment).Errorf("error getting volumes for pod: %v", err)
 		}
 	}
 
@@ -94,15 +89,11 @@ func (i *Initializer) initializeDeploymentV1Beta1(deployment *appv1beta1.Deploym
 	// Only check to update scheduler name if it is set to the default
 	if deployment.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumes, err := i.Driver.GetPodVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
-		if err != nil {
-			if _, ok := err.(*volume.ErrPVCPending); ok {
-				updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
-			} else {
-				storklog.DeploymentV1Beta1Log(deployment).Errorf("error getting volumes for pod: %v", err)
-			}
-		} else if len(driverVolumes) != 0 {
+		usesDriverVolumes, err := i.podUsesDriverVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
+		if usesDriverVolumes {
 			updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
+		} else if err != nil {
+			storklog.DeploymentV1Beta1Log(deployment).Errorf("error getting volumes for pod: %v", err)
 		}
 	}
 
@@ -149,15 +140,11 @@ func (i *Initializer) initializeDeploymentV1Beta2(deployment *appv1beta2.Deploym
 	// Only check to update scheduler name if it is set to the default
 	if deployment.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumes, err := i.Driver.GetPodVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
-		if err != nil {
-			if _, ok := err.(*volume.ErrPVCPending); ok {
-				updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
-			} else {
-				storklog.DeploymentV1Beta2Log(deployment).Errorf("error getting volumes for pod: %v", err)
-			}
-		} else if len(driverVolumes) != 0 {
+		usesDriverVolumes, err := i.podUsesDriverVolumes(&deployment.Spec.Template.Spec, deployment.Namespace)
+		if usesDriverVolumes {
 			updatedDeployment.Spec.Template.Spec.SchedulerName = storkSchedulerName
+		} else if err != nil {
+			storklog.DeploymentV1Beta2Log(deployment).Errorf("error getting volumes for pod: %v", err)
 		}
 	}
 
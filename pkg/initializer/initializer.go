@@ -28,12 +28,50 @@ const (
 
 // Initializer Kubernetes object initializer
 type Initializer struct {
-	Driver      volume.Driver
+	Drivers     []volume.Driver
 	lock        sync.Mutex
 	started     bool
 	stopChannel chan struct{}
 }
 
+// podUsesDriverVolumes returns true if any of the drivers owns volumes used by
+// the pod spec. Pending PVCs are treated as driver volumes since they could
+// get bound by one of the drivers.
+func (i *Initializer) podUsesDriverVolumes(podSpec *v1.PodSpec, namespace string) (bool, error) {
+	var lastErr error
+	for _, driver := range i.Drivers {
+		driverVolumes, err := driver.GetPodVolumes(podSpec, namespace)
+		if err != nil {
+			if _, ok := err.(*volume.ErrPVCPending); ok {
+				return true, nil
+			}
+			lastErr = err
+			continue
+		}
+		if len(driverVolumes) != 0 {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
+// hasDriverVolumeTemplates returns true if any of the drivers owns one of the
+// volume claim templates
+func (i *Initializer) hasDriverVolumeTemplates(templates []v1.PersistentVolumeClaim) (bool, error) {
+	var lastErr error
+	for _, driver := range i.Drivers {
+		driverVolumeTemplates, err := driver.GetVolumeClaimTemplates(templates)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(driverVolumeTemplates) > 0 {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
 // Start Starts the Initializer
 func (i *Initializer) Start() error {
 	i.lock.Lock()
@@ -38,10 +38,11 @@ func (i *Initializer) initializeStatefulSetV1(ss *appv1.StatefulSet, clientset *
 	// Only check to update scheduler name if it is set to the default
 	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
+		hasDriverVolumeTemplates, err := i.hasDriverVolumeTemplates(ss.Spec.VolumeClaimTemplates)
 		if err != nil {
 			storklog.StatefulSetV1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
+		}
+		if hasDriverVolumeTemplates {
 			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
 		}
 	}
@@ -89,10 +90,11 @@ func (i *Initializer) initializeStatefulSetV1Beta1(ss *appv1beta1.StatefulSet, c
 	// Only check to update scheduler name if it is set to the default
 	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
+		hasDriverVolumeTemplates, err := i.hasDriverVolumeTemplates(ss.Spec.VolumeClaimTemplates)
 		if err != nil {
 			storklog.StatefulSetV1Beta1Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
+		}
+		if hasDriverVolumeTemplates {
 			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
 		}
 	}
@@ -140,10 +142,11 @@ func (i *Initializer) initializeStatefulSetV1Beta2(ss *appv1beta2.StatefulSet, c
 	// Only check to update scheduler name if it is set to the default
 	if ss.Spec.Template.Spec.SchedulerName == defaultSchedulerName {
 		// Remove the initializer even if we get errors in this step
-		driverVolumeTemplates, err := i.Driver.GetVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates)
+		hasDriverVolumeTemplates, err := i.hasDriverVolumeTemplates(ss.Spec.VolumeClaimTemplates)
 		if err != nil {
 			storklog.StatefulSetV1Beta2Log(ss).Infof("Error getting volume templates for statefulset: %v", err)
-		} else if len(driverVolumeTemplates) > 0 {
+		}
+		if hasDriverVolumeTemplates {
 			updatedStatefulSet.Spec.Template.Spec.SchedulerName = storkSchedulerName
 		}
 	}
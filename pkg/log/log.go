@@ -169,6 +169,30 @@ func MigrationScheduleLog(migrationSchedule *storkv1.MigrationSchedule) *logrus.
 	return logrus.WithFields(logrus.Fields{})
 }
 
+// FailoverLog formats a log message with failover information
+func FailoverLog(failover *storkv1.Failover) *logrus.Entry {
+	if failover != nil {
+		return logrus.WithFields(logrus.Fields{
+			"FailoverName": failover.Name,
+			"Namespace":    failover.Namespace,
+		})
+	}
+
+	return logrus.WithFields(logrus.Fields{})
+}
+
+// ClusterPairLog formats a log message with clusterpair information
+func ClusterPairLog(clusterPair *storkv1.ClusterPair) *logrus.Entry {
+	if clusterPair != nil {
+		return logrus.WithFields(logrus.Fields{
+			"ClusterPairName": clusterPair.Name,
+			"Namespace":       clusterPair.Namespace,
+		})
+	}
+
+	return logrus.WithFields(logrus.Fields{})
+}
+
 // GroupSnapshotLog formats a log message with groupsnapshot information
 func GroupSnapshotLog(groupsnapshot *storkv1.GroupVolumeSnapshot) *logrus.Entry {
 	if groupsnapshot != nil {
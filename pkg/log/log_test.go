@@ -22,6 +22,8 @@ func TestLog(t *testing.T) {
 	t.Run("snapshotScheduleLogTest", snapshotScheduleLogTest)
 	t.Run("migrationLogTest", migrationLogTest)
 	t.Run("migrationScheduleLogTest", migrationScheduleLogTest)
+	t.Run("failoverLogTest", failoverLogTest)
+	t.Run("clusterPairLogTest", clusterPairLogTest)
 	t.Run("ruleLogTest", ruleLogTest)
 	t.Run("pvcLogTest", pvcLogTest)
 	t.Run("clusterDomainUpdateLogTest", clusterDomainUpdateLogTest)
@@ -149,6 +151,30 @@ func migrationScheduleLogTest(t *testing.T) {
 	MigrationScheduleLog(nil).Infof("migrationschedule nil log")
 }
 
+func failoverLogTest(t *testing.T) {
+	metadata := metav1.ObjectMeta{
+		Name:      "testfailover",
+		Namespace: "testnamespace",
+	}
+	failover := &storkv1.Failover{
+		ObjectMeta: metadata,
+	}
+	FailoverLog(failover).Infof("failover log")
+	FailoverLog(nil).Infof("failover nil log")
+}
+
+func clusterPairLogTest(t *testing.T) {
+	metadata := metav1.ObjectMeta{
+		Name:      "testclusterpair",
+		Namespace: "testnamespace",
+	}
+	clusterPair := &storkv1.ClusterPair{
+		ObjectMeta: metadata,
+	}
+	ClusterPairLog(clusterPair).Infof("clusterpair log")
+	ClusterPairLog(nil).Infof("clusterpair nil log")
+}
+
 func ruleLogTest(t *testing.T) {
 	metadata := metav1.ObjectMeta{
 		Name:      "testrule",
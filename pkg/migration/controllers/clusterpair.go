@@ -2,34 +2,49 @@ package controllers
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/portworx/sched-ops/k8s"
 	v1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/tools/record"
 )
 
 const (
 	validateCRDInterval time.Duration = 5 * time.Second
 	validateCRDTimeout  time.Duration = 1 * time.Minute
+	// clusterPairValidateInterval is how often the connectivity to the remote
+	// cluster is validated once the pair is ready
+	clusterPairValidateInterval time.Duration = 5 * time.Minute
+	// credentialsExpiryWarning is how long before the credentials for the
+	// remote cluster expire that warnings are raised
+	credentialsExpiryWarning time.Duration = 7 * 24 * time.Hour
 )
 
 // ClusterPairController controller to watch over ClusterPair
 type ClusterPairController struct {
-	Driver   volume.Driver
+	Drivers  []volume.Driver
 	Recorder record.EventRecorder
 }
 
@@ -59,7 +74,7 @@ func (c *ClusterPairController) Handle(ctx context.Context, event sdk.Event) err
 		clusterPair := o
 		if event.Deleted {
 			if clusterPair.Status.RemoteStorageID != "" {
-				return c.Driver.DeletePair(clusterPair)
+				return c.deletePair(clusterPair)
 			}
 			return nil
 		}
@@ -76,7 +91,7 @@ func (c *ClusterPairController) Handle(ctx context.Context, event sdk.Event) err
 			}
 		} else {
 			if clusterPair.Status.StorageStatus != stork_api.ClusterPairStatusReady {
-				remoteID, err := c.Driver.CreatePair(clusterPair)
+				remoteID, err := c.createPair(clusterPair)
 				if err != nil {
 					clusterPair.Status.StorageStatus = stork_api.ClusterPairStatusError
 					c.Recorder.Event(clusterPair,
@@ -97,33 +112,102 @@ func (c *ClusterPairController) Handle(ctx context.Context, event sdk.Event) err
 				}
 			}
 		}
-		if clusterPair.Status.SchedulerStatus != stork_api.ClusterPairStatusReady {
-			remoteConfig, err := getClusterPairSchedulerConfig(clusterPair.Name, clusterPair.Namespace)
+		// Validate the connectivity periodically once the pair is ready so
+		// that expired or rotated credentials are detected
+		if clusterPair.Status.SchedulerStatus != stork_api.ClusterPairStatusReady ||
+			time.Since(clusterPair.Status.LastValidated.Time) >= clusterPairValidateInterval {
+			c.validateScheduler(clusterPair)
+			err := sdk.Update(clusterPair)
 			if err != nil {
 				return err
 			}
+		}
+	}
+	return nil
+}
 
-			client, err := kubernetes.NewForConfig(remoteConfig)
-			if err != nil {
-				return err
-			}
-			if _, err = client.ServerVersion(); err != nil {
-				clusterPair.Status.SchedulerStatus = stork_api.ClusterPairStatusError
-				c.Recorder.Event(clusterPair,
-					v1.EventTypeWarning,
-					string(clusterPair.Status.SchedulerStatus),
-					err.Error())
-			} else {
-				clusterPair.Status.SchedulerStatus = stork_api.ClusterPairStatusReady
-				c.Recorder.Event(clusterPair,
-					v1.EventTypeNormal,
-					string(clusterPair.Status.SchedulerStatus),
-					"Scheduler successfully paired")
-			}
-			err = sdk.Update(clusterPair)
-			if err != nil {
-				return err
+// validateScheduler checks the connectivity to the remote cluster and the
+// expiry of the credentials, and updates the scheduler status. Events are only
+// raised when the status changes, or when the credentials are about to expire.
+func (c *ClusterPairController) validateScheduler(clusterPair *stork_api.ClusterPair) {
+	previousStatus := clusterPair.Status.SchedulerStatus
+	clusterPair.Status.LastValidated = metav1.Now()
+	clusterPair.Status.CredentialsExpiry = nil
+
+	status, reason := stork_api.ClusterPairStatusReady, "Scheduler successfully paired"
+	rawConfig, err := getClusterPairRawConfig(clusterPair)
+	if err != nil {
+		status, reason = stork_api.ClusterPairStatusError, err.Error()
+	} else {
+		expiry, err := getCredentialsExpiry(rawConfig)
+		if err != nil {
+			log.ClusterPairLog(clusterPair).Warnf("Error checking expiry of credentials: %v", err)
+		} else if expiry != nil {
+			clusterPair.Status.CredentialsExpiry = &metav1.Time{Time: *expiry}
+		}
+
+		if expiry != nil && time.Now().After(*expiry) {
+			status, reason = stork_api.ClusterPairStatusError,
+				fmt.Sprintf("Credentials for the remote cluster expired at %v", expiry)
+		} else if err := validateRemoteCluster(rawConfig); err != nil {
+			status, reason = stork_api.ClusterPairStatusError, err.Error()
+		} else if expiry != nil && time.Until(*expiry) < credentialsExpiryWarning {
+			c.Recorder.Event(clusterPair,
+				v1.EventTypeWarning,
+				"CredentialsExpiring",
+				fmt.Sprintf("Credentials for the remote cluster expire at %v", expiry))
+		}
+	}
+
+	clusterPair.Status.SchedulerStatus = status
+	clusterPair.Status.SchedulerReason = reason
+	if status == previousStatus {
+		return
+	}
+	eventType := v1.EventTypeNormal
+	if status == stork_api.ClusterPairStatusError {
+		eventType = v1.EventTypeWarning
+	}
+	c.Recorder.Event(clusterPair, eventType, string(status), reason)
+}
+
+func validateRemoteCluster(rawConfig *clientcmdapi.Config) error {
+	remoteConfig, err := getRestConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+	_, err = client.ServerVersion()
+	return err
+}
+
+// createPair pairs the storage using the first driver that supports it
+func (c *ClusterPairController) createPair(clusterPair *stork_api.ClusterPair) (string, error) {
+	err := fmt.Errorf("no driver supports cluster pairing")
+	for _, driver := range c.Drivers {
+		var remoteID string
+		remoteID, err = driver.CreatePair(clusterPair)
+		if err == nil {
+			return remoteID, nil
+		}
+		if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+			return "", err
+		}
+	}
+	return "", err
+}
+
+// deletePair deletes the storage pairing for all the drivers that support it
+func (c *ClusterPairController) deletePair(clusterPair *stork_api.ClusterPair) error {
+	for _, driver := range c.Drivers {
+		if err := driver.DeletePair(clusterPair); err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				continue
 			}
+			return err
 		}
 	}
 	return nil
@@ -134,14 +218,92 @@ func getClusterPairSchedulerConfig(clusterPairName string, namespace string) (*r
 	if err != nil {
 		return nil, fmt.Errorf("error getting clusterpair (%v/%v): %v", namespace, clusterPairName, err)
 	}
+	rawConfig, err := getClusterPairRawConfig(clusterPair)
+	if err != nil {
+		return nil, err
+	}
+	return getRestConfig(rawConfig)
+}
+
+// getClusterPairRawConfig returns the kubeconfig for the remote cluster, from
+// the config secret if one is set
+func getClusterPairRawConfig(clusterPair *stork_api.ClusterPair) (*clientcmdapi.Config, error) {
+	if clusterPair.Spec.ConfigSecret == "" {
+		return &clusterPair.Spec.Config, nil
+	}
+	secret, err := k8s.Instance().GetSecret(clusterPair.Spec.ConfigSecret, clusterPair.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting config secret %v for clusterpair (%v/%v): %v",
+			clusterPair.Spec.ConfigSecret, clusterPair.Namespace, clusterPair.Name, err)
+	}
+	data, ok := secret.Data[stork_api.ClusterPairConfigSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("config secret %v for clusterpair (%v/%v) doesn't have key %v",
+			clusterPair.Spec.ConfigSecret, clusterPair.Namespace, clusterPair.Name, stork_api.ClusterPairConfigSecretKey)
+	}
+	rawConfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config secret %v for clusterpair (%v/%v): %v",
+			clusterPair.Spec.ConfigSecret, clusterPair.Namespace, clusterPair.Name, err)
+	}
+	return rawConfig, nil
+}
+
+func getRestConfig(rawConfig *clientcmdapi.Config) (*restclient.Config, error) {
 	remoteClientConfig := clientcmd.NewNonInteractiveClientConfig(
-		clusterPair.Spec.Config,
-		clusterPair.Spec.Config.CurrentContext,
+		*rawConfig,
+		rawConfig.CurrentContext,
 		&clientcmd.ConfigOverrides{},
 		clientcmd.NewDefaultClientConfigLoadingRules())
 	return remoteClientConfig.ClientConfig()
 }
 
+// getCredentialsExpiry returns the earliest expiry of the client certificate
+// and token for the current context. Returns nil if the credentials don't
+// expire. Tokens are only checked if they are JWTs with an expiry claim.
+func getCredentialsExpiry(rawConfig *clientcmdapi.Config) (*time.Time, error) {
+	currentContext, ok := rawConfig.Contexts[rawConfig.CurrentContext]
+	if !ok {
+		return nil, nil
+	}
+	authInfo, ok := rawConfig.AuthInfos[currentContext.AuthInfo]
+	if !ok {
+		return nil, nil
+	}
+
+	var expiry *time.Time
+	if len(authInfo.ClientCertificateData) > 0 {
+		block, _ := pem.Decode(authInfo.ClientCertificateData)
+		if block == nil {
+			return nil, fmt.Errorf("invalid client certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing client certificate: %v", err)
+		}
+		expiry = &cert.NotAfter
+	}
+	if parts := strings.Split(authInfo.Token, "."); len(parts) == 3 {
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("error decoding token: %v", err)
+		}
+		claims := struct {
+			Expiry int64 `json:"exp"`
+		}{}
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return nil, fmt.Errorf("error parsing token: %v", err)
+		}
+		if claims.Expiry != 0 {
+			tokenExpiry := time.Unix(claims.Expiry, 0)
+			if expiry == nil || tokenExpiry.Before(*expiry) {
+				expiry = &tokenExpiry
+			}
+		}
+	}
+	return expiry, nil
+}
+
 func getClusterPairStorageStatus(clusterPairName string, namespace string) (stork_api.ClusterPairStatusType, error) {
 	clusterPair, err := k8s.Instance().GetClusterPair(clusterPairName, namespace)
 	if err != nil {
@@ -0,0 +1,551 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controller"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+// FailoverController reconciles Failover objects
+type FailoverController struct {
+	Drivers                []volume.Driver
+	Recorder               record.EventRecorder
+	failoverAdminNamespace string
+	// updateFailover persists the failover, sdk.Update is used if it isn't
+	// set
+	updateFailover func(*stork_api.Failover) error
+}
+
+// Init Initialize the failover controller
+func (f *FailoverController) Init(failoverAdminNamespace string) error {
+	f.failoverAdminNamespace = failoverAdminNamespace
+	err := f.createCRD()
+	if err != nil {
+		return err
+	}
+	return controller.Register(
+		&schema.GroupVersionKind{
+			Group:   stork.GroupName,
+			Version: stork_api.SchemeGroupVersion.Version,
+			Kind:    reflect.TypeOf(stork_api.Failover{}).Name(),
+		},
+		"",
+		resyncPeriod,
+		f)
+}
+
+// Handle updates for Failover objects
+func (f *FailoverController) Handle(ctx context.Context, event sdk.Event) error {
+	switch o := event.Object.(type) {
+	case *stork_api.Failover:
+		failover := o
+		if event.Deleted {
+			return nil
+		}
+		return f.handle(failover)
+	}
+	return nil
+}
+
+func (f *FailoverController) handle(failover *stork_api.Failover) error {
+	switch failover.Status.Status {
+	case stork_api.FailoverStatusInitial:
+		err := f.initFailover(failover)
+		// The spec for a failback is only filled in during init, so check
+		// the namespaces after that
+		if err == nil {
+			err = f.checkFailoverAllowed(failover)
+		}
+		if err != nil {
+			log.FailoverLog(failover).Errorf("Error initializing failover: %v", err)
+			f.failFailover(failover, err.Error())
+		}
+		return f.update(failover)
+	case stork_api.FailoverStatusInProgress:
+		if err := f.checkFailoverAllowed(failover); err != nil {
+			f.failFailover(failover, err.Error())
+			return f.update(failover)
+		}
+		return f.runNextStep(failover)
+	case stork_api.FailoverStatusFailed, stork_api.FailoverStatusSuccessful:
+		return nil
+	default:
+		log.FailoverLog(failover).Errorf("Invalid status for failover: %v", failover.Status.Status)
+	}
+	return nil
+}
+
+func (f *FailoverController) update(failover *stork_api.Failover) error {
+	if f.updateFailover != nil {
+		return f.updateFailover(failover)
+	}
+	return sdk.Update(failover)
+}
+
+// checkFailoverAllowed restricts failovers to only the namespace that the
+// object belongs to, except for the namespace designated by the admin, since
+// the apps are scaled down using stork's permissions. Cluster domains affect
+// the whole cluster, so they can only be updated from the admin namespace.
+func (f *FailoverController) checkFailoverAllowed(failover *stork_api.Failover) error {
+	if failover.Namespace == f.failoverAdminNamespace {
+		return nil
+	}
+	if failover.Spec.ClusterDomain != "" {
+		return fmt.Errorf("Spec.ClusterDomain can only be set for failovers in the admin namespace")
+	}
+	for _, ns := range failover.Spec.Namespaces {
+		if ns != failover.Namespace {
+			return fmt.Errorf("Spec.Namespaces and Spec.NamespaceMapping should only contain the current namespace")
+		}
+	}
+	for _, ns := range failover.Spec.NamespaceMapping {
+		if ns != failover.Namespace {
+			return fmt.Errorf("Spec.Namespaces and Spec.NamespaceMapping should only contain the current namespace")
+		}
+	}
+	return nil
+}
+
+// initFailover validates the failover and records the steps that need to be
+// performed in the status. For a failback the spec is filled in from the
+// failover being reversed, and the steps of that failover that were
+// successful are reversed in the opposite order.
+func (f *FailoverController) initFailover(failover *stork_api.Failover) error {
+	var steps []stork_api.FailoverStepType
+	if failover.Spec.Failback != "" {
+		original := &stork_api.Failover{
+			TypeMeta: meta.TypeMeta{
+				Kind:       reflect.TypeOf(stork_api.Failover{}).Name(),
+				APIVersion: stork_api.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: meta.ObjectMeta{
+				Name:      failover.Spec.Failback,
+				Namespace: failover.Namespace,
+			},
+		}
+		if err := sdk.Get(original); err != nil {
+			return fmt.Errorf("error getting failover %v to fail back: %v", failover.Spec.Failback, err)
+		}
+		if original.Spec.Failback != "" {
+			return fmt.Errorf("failover %v is a failback and can't be reversed", original.Name)
+		}
+		if original.Status.Status != stork_api.FailoverStatusSuccessful &&
+			original.Status.Status != stork_api.FailoverStatusFailed {
+			return fmt.Errorf("failover %v hasn't completed", original.Name)
+		}
+		failover.Spec.ClusterPair = original.Spec.ClusterPair
+		failover.Spec.Namespaces = original.Spec.Namespaces
+		failover.Spec.NamespaceMapping = original.Spec.NamespaceMapping
+		failover.Spec.IncludeVolumes = original.Spec.IncludeVolumes
+		failover.Spec.ClusterDomain = original.Spec.ClusterDomain
+		steps = getFailbackSteps(original)
+	} else {
+		if failover.Spec.ClusterPair == "" {
+			return fmt.Errorf("clusterPair needs to be provided for failover")
+		}
+		if len(failover.Spec.Namespaces) == 0 {
+			return fmt.Errorf("need to provide at least one namespace to fail over")
+		}
+		steps = getFailoverSteps(failover)
+	}
+
+	failover.Status.Steps = make([]*stork_api.FailoverStepInfo, 0, len(steps))
+	for _, step := range steps {
+		failover.Status.Steps = append(failover.Status.Steps, &stork_api.FailoverStepInfo{
+			Step:   step,
+			Status: stork_api.FailoverStatusInitial,
+		})
+	}
+	failover.Status.Status = stork_api.FailoverStatusInProgress
+	return nil
+}
+
+func getFailoverSteps(failover *stork_api.Failover) []stork_api.FailoverStepType {
+	steps := []stork_api.FailoverStepType{
+		stork_api.FailoverStepScaleDownSource,
+		stork_api.FailoverStepMigrate,
+	}
+	if failover.Spec.ClusterDomain != "" {
+		steps = append(steps, stork_api.FailoverStepDeactivateClusterDomain)
+	}
+	return append(steps, stork_api.FailoverStepActivateDestination)
+}
+
+// getFailbackSteps returns the steps that reverse the successful steps of a
+// failover. The migrated resources are left deactivated on the destination.
+func getFailbackSteps(original *stork_api.Failover) []stork_api.FailoverStepType {
+	steps := make([]stork_api.FailoverStepType, 0)
+	for i := len(original.Status.Steps) - 1; i >= 0; i-- {
+		step := original.Status.Steps[i]
+		if step.Status != stork_api.FailoverStatusSuccessful {
+			continue
+		}
+		switch step.Step {
+		case stork_api.FailoverStepActivateDestination:
+			steps = append(steps, stork_api.FailoverStepDeactivateDestination)
+		case stork_api.FailoverStepDeactivateClusterDomain:
+			steps = append(steps, stork_api.FailoverStepActivateClusterDomain)
+		case stork_api.FailoverStepScaleDownSource:
+			steps = append(steps, stork_api.FailoverStepScaleUpSource)
+		}
+	}
+	return steps
+}
+
+// runNextStep runs the first step that hasn't completed. Steps that need to
+// wait, like the final migration, are checked again on the next resync.
+func (f *FailoverController) runNextStep(failover *stork_api.Failover) error {
+	var step *stork_api.FailoverStepInfo
+	for _, s := range failover.Status.Steps {
+		if s.Status != stork_api.FailoverStatusSuccessful {
+			step = s
+			break
+		}
+	}
+	if step == nil {
+		failover.Status.Status = stork_api.FailoverStatusSuccessful
+		failover.Status.Reason = ""
+		f.Recorder.Event(failover,
+			v1.EventTypeNormal,
+			string(stork_api.FailoverStatusSuccessful),
+			"Failover completed successfully")
+		return f.update(failover)
+	}
+
+	done, err := f.runStep(failover, step.Step)
+	if err != nil {
+		msg := fmt.Sprintf("Error running step %v: %v", step.Step, err)
+		log.FailoverLog(failover).Error(msg)
+		step.Status = stork_api.FailoverStatusFailed
+		step.Reason = err.Error()
+		step.Timestamp = meta.Now()
+		f.failFailover(failover, msg)
+		return f.update(failover)
+	}
+	if !done {
+		if step.Status == stork_api.FailoverStatusInProgress {
+			return nil
+		}
+		step.Status = stork_api.FailoverStatusInProgress
+		step.Timestamp = meta.Now()
+		return f.update(failover)
+	}
+
+	log.FailoverLog(failover).Infof("Completed step %v", step.Step)
+	step.Status = stork_api.FailoverStatusSuccessful
+	step.Reason = ""
+	step.Timestamp = meta.Now()
+	f.Recorder.Event(failover,
+		v1.EventTypeNormal,
+		string(step.Step),
+		fmt.Sprintf("Completed step %v", step.Step))
+	if err := f.update(failover); err != nil {
+		return err
+	}
+	// Move on to the next step without waiting for a resync
+	return f.runNextStep(failover)
+}
+
+// runStep performs one step of the failover. Returns false if the step
+// hasn't completed yet.
+func (f *FailoverController) runStep(
+	failover *stork_api.Failover,
+	step stork_api.FailoverStepType,
+) (bool, error) {
+	switch step {
+	case stork_api.FailoverStepScaleDownSource:
+		return true, deactivateApps(k8s.Instance(), failover.Spec.Namespaces)
+	case stork_api.FailoverStepMigrate:
+		return f.runMigration(failover)
+	case stork_api.FailoverStepDeactivateClusterDomain:
+		return true, f.updateClusterDomain(failover, false)
+	case stork_api.FailoverStepActivateDestination:
+		ops, err := getFailoverDestOps(failover)
+		if err != nil {
+			return false, err
+		}
+		return true, activateApps(ops, getFailoverDestNamespaces(failover), false)
+	case stork_api.FailoverStepDeactivateDestination:
+		ops, err := getFailoverDestOps(failover)
+		if err != nil {
+			return false, err
+		}
+		return true, deactivateApps(ops, getFailoverDestNamespaces(failover))
+	case stork_api.FailoverStepActivateClusterDomain:
+		return true, f.updateClusterDomain(failover, true)
+	case stork_api.FailoverStepScaleUpSource:
+		return true, activateApps(k8s.Instance(), failover.Spec.Namespaces, true)
+	default:
+		return false, fmt.Errorf("invalid step %v", step)
+	}
+}
+
+func (f *FailoverController) failFailover(failover *stork_api.Failover, reason string) {
+	failover.Status.Status = stork_api.FailoverStatusFailed
+	failover.Status.Reason = reason
+	f.Recorder.Event(failover,
+		v1.EventTypeWarning,
+		string(stork_api.FailoverStatusFailed),
+		reason)
+}
+
+// runMigration starts the final migration of the apps, which are already
+// scaled down on the source, and waits for it to complete
+func (f *FailoverController) runMigration(failover *stork_api.Failover) (bool, error) {
+	if failover.Status.MigrationName == "" {
+		includeResources := true
+		startApplications := false
+		migration := &stork_api.Migration{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      failover.Name + "-migration",
+				Namespace: failover.Namespace,
+				OwnerReferences: []meta.OwnerReference{
+					{
+						Name:       failover.Name,
+						UID:        failover.UID,
+						Kind:       failover.GetObjectKind().GroupVersionKind().Kind,
+						APIVersion: failover.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+					},
+				},
+			},
+			Spec: stork_api.MigrationSpec{
+				ClusterPair:       failover.Spec.ClusterPair,
+				Namespaces:        failover.Spec.Namespaces,
+				NamespaceMapping:  failover.Spec.NamespaceMapping,
+				IncludeResources:  &includeResources,
+				IncludeVolumes:    failover.Spec.IncludeVolumes,
+				StartApplications: &startApplications,
+			},
+		}
+		log.FailoverLog(failover).Infof("Starting migration %v", migration.Name)
+		if _, err := k8s.Instance().CreateMigration(migration); err != nil && !errors.IsAlreadyExists(err) {
+			return false, err
+		}
+		failover.Status.MigrationName = migration.Name
+		return false, nil
+	}
+
+	migration, err := k8s.Instance().GetMigration(failover.Status.MigrationName, failover.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if migration.Status.Stage != stork_api.MigrationStageFinal {
+		return false, nil
+	}
+	if migration.Status.Status != stork_api.MigrationStatusSuccessful {
+		return false, fmt.Errorf("migration %v completed with status %v", migration.Name, migration.Status.Status)
+	}
+	return true, nil
+}
+
+// getClusterDomainDriver returns the driver that manages the cluster domain.
+// Drivers that don't support cluster domains are skipped.
+func (f *FailoverController) getClusterDomainDriver(clusterDomain string) (volume.Driver, error) {
+	for _, driver := range f.Drivers {
+		clusterDomains, err := driver.GetClusterDomains()
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				continue
+			}
+			return nil, fmt.Errorf("error getting cluster domains from driver %v: %v", driver.String(), err)
+		}
+		for _, info := range clusterDomains.ClusterDomainInfos {
+			if info.Name == clusterDomain {
+				return driver, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cluster domain %v isn't managed by any of the drivers", clusterDomain)
+}
+
+// updateClusterDomain activates or deactivates the cluster domain of the
+// source cluster using the driver that manages it
+func (f *FailoverController) updateClusterDomain(failover *stork_api.Failover, active bool) error {
+	driver, err := f.getClusterDomainDriver(failover.Spec.ClusterDomain)
+	if err != nil {
+		return err
+	}
+	clusterDomainUpdate := &stork_api.ClusterDomainUpdate{
+		ObjectMeta: meta.ObjectMeta{
+			Name: failover.Name,
+		},
+		Spec: stork_api.ClusterDomainUpdateSpec{
+			ClusterDomain: failover.Spec.ClusterDomain,
+			Active:        active,
+		},
+	}
+	if active {
+		return driver.ActivateClusterDomain(clusterDomainUpdate)
+	}
+	return driver.DeactivateClusterDomain(clusterDomainUpdate)
+}
+
+func getFailoverDestOps(failover *stork_api.Failover) (k8s.Ops, error) {
+	remoteConfig, err := getClusterPairSchedulerConfig(failover.Spec.ClusterPair, failover.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return k8s.NewInstanceFromRestConfig(remoteConfig)
+}
+
+func getFailoverDestNamespaces(failover *stork_api.Failover) []string {
+	namespaces := make([]string, 0, len(failover.Spec.Namespaces))
+	for _, ns := range failover.Spec.Namespaces {
+		if destNamespace, ok := failover.Spec.NamespaceMapping[ns]; ok {
+			ns = destNamespace
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// deactivateApps scales down the deployments and statefulsets in the
+// namespaces, storing their replicas in the migration replicas annotation.
+// Apps that are already scaled down are skipped so that the stored replicas
+// aren't overwritten if a step is retried.
+func deactivateApps(ops k8s.Ops, namespaces []string) error {
+	for _, ns := range namespaces {
+		deployments, err := ops.ListDeployments(ns, meta.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, deployment := range deployments.Items {
+			replicas := int32(1)
+			if deployment.Spec.Replicas != nil {
+				replicas = *deployment.Spec.Replicas
+			}
+			if replicas == 0 {
+				continue
+			}
+			deployment.Annotations = setReplicasAnnotation(deployment.Annotations, replicas)
+			deployment.Spec.Replicas = new(int32)
+			if _, err := ops.UpdateDeployment(&deployment); err != nil {
+				return fmt.Errorf("error scaling down deployment %v/%v: %v", ns, deployment.Name, err)
+			}
+		}
+
+		statefulSets, err := ops.ListStatefulSets(ns)
+		if err != nil {
+			return err
+		}
+		for _, statefulSet := range statefulSets.Items {
+			replicas := int32(1)
+			if statefulSet.Spec.Replicas != nil {
+				replicas = *statefulSet.Spec.Replicas
+			}
+			if replicas == 0 {
+				continue
+			}
+			statefulSet.Annotations = setReplicasAnnotation(statefulSet.Annotations, replicas)
+			statefulSet.Spec.Replicas = new(int32)
+			if _, err := ops.UpdateStatefulSet(&statefulSet); err != nil {
+				return fmt.Errorf("error scaling down statefulset %v/%v: %v", ns, statefulSet.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// activateApps scales up the deployments and statefulsets in the namespaces
+// to the replicas stored in the migration replicas annotation. The annotation
+// is removed if requested.
+func activateApps(ops k8s.Ops, namespaces []string, removeAnnotation bool) error {
+	for _, ns := range namespaces {
+		deployments, err := ops.ListDeployments(ns, meta.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, deployment := range deployments.Items {
+			replicas, ok, err := getReplicasAnnotation(deployment.Annotations)
+			if err != nil {
+				return fmt.Errorf("error parsing replicas for deployment %v/%v: %v", ns, deployment.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			if removeAnnotation {
+				delete(deployment.Annotations, StorkMigrationReplicasAnnotation)
+			}
+			deployment.Spec.Replicas = &replicas
+			if _, err := ops.UpdateDeployment(&deployment); err != nil {
+				return fmt.Errorf("error scaling up deployment %v/%v: %v", ns, deployment.Name, err)
+			}
+		}
+
+		statefulSets, err := ops.ListStatefulSets(ns)
+		if err != nil {
+			return err
+		}
+		for _, statefulSet := range statefulSets.Items {
+			replicas, ok, err := getReplicasAnnotation(statefulSet.Annotations)
+			if err != nil {
+				return fmt.Errorf("error parsing replicas for statefulset %v/%v: %v", ns, statefulSet.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			if removeAnnotation {
+				delete(statefulSet.Annotations, StorkMigrationReplicasAnnotation)
+			}
+			statefulSet.Spec.Replicas = &replicas
+			if _, err := ops.UpdateStatefulSet(&statefulSet); err != nil {
+				return fmt.Errorf("error scaling up statefulset %v/%v: %v", ns, statefulSet.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func setReplicasAnnotation(annotations map[string]string, replicas int32) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[StorkMigrationReplicasAnnotation] = strconv.FormatInt(int64(replicas), 10)
+	return annotations
+}
+
+func getReplicasAnnotation(annotations map[string]string) (int32, bool, error) {
+	value, ok := annotations[StorkMigrationReplicasAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, false, err
+	}
+	return int32(replicas), true, nil
+}
+
+func (f *FailoverController) createCRD() error {
+	resource := k8s.CustomResource{
+		Name:       stork_api.FailoverResourceName,
+		Plural:     stork_api.FailoverResourcePlural,
+		Group:      stork.GroupName,
+		Version:    stork_api.SchemeGroupVersion.Version,
+		Scope:      apiextensionsv1beta1.NamespaceScoped,
+		Kind:       reflect.TypeOf(stork_api.Failover{}).Name(),
+		ShortNames: []string{stork_api.FailoverShortName},
+	}
+	err := k8s.Instance().CreateCRD(resource)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return k8s.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
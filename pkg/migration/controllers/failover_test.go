@@ -0,0 +1,326 @@
+// +build unittest
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	apps_api "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+const testAdminNamespace = "kube-system"
+
+func newTestFailoverController(t *testing.T, deployments ...*apps_api.Deployment) *FailoverController {
+	fakeKubeClient := kubernetes.NewSimpleClientset()
+	for _, deployment := range deployments {
+		_, err := fakeKubeClient.AppsV1().Deployments(deployment.Namespace).Create(deployment)
+		require.NoError(t, err, "Error creating deployment")
+	}
+	k8s.Instance().SetClient(fakeKubeClient, nil, fakeclient.NewSimpleClientset(), nil, nil, nil, nil, nil)
+	return &FailoverController{
+		Recorder:               record.NewFakeRecorder(100),
+		failoverAdminNamespace: testAdminNamespace,
+		updateFailover: func(*stork_api.Failover) error {
+			return nil
+		},
+	}
+}
+
+func newTestDeployment(namespace string, replicas int32) *apps_api.Deployment {
+	return &apps_api.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "app",
+			Namespace: namespace,
+		},
+		Spec: apps_api.DeploymentSpec{
+			Replicas: &replicas,
+		},
+	}
+}
+
+func newTestFailover(namespace string, namespaces ...string) *stork_api.Failover {
+	return &stork_api.Failover{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "failover",
+			Namespace: namespace,
+		},
+		Spec: stork_api.FailoverSpec{
+			ClusterPair: "clusterpair",
+			Namespaces:  namespaces,
+		},
+	}
+}
+
+func getTestDeploymentReplicas(t *testing.T, namespace string) int32 {
+	deployment, err := k8s.Instance().GetDeployment("app", namespace)
+	require.NoError(t, err, "Error getting deployment")
+	return *deployment.Spec.Replicas
+}
+
+func TestFailoverNamespaceNotAllowed(t *testing.T) {
+	f := newTestFailoverController(t, newTestDeployment("other", 3))
+
+	failover := newTestFailover("app", "other")
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status,
+		"Failover of other namespaces should fail")
+	require.Contains(t, failover.Status.Reason, "should only contain the current namespace")
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, int32(3), getTestDeploymentReplicas(t, "other"), "Apps in other namespaces shouldn't be scaled down")
+
+	failover = newTestFailover("app", "app")
+	failover.Spec.NamespaceMapping = map[string]string{"app": "other"}
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status,
+		"Failover mapped to other namespaces should fail")
+
+	// A failover that was already started is checked again before running
+	// any step
+	failover = newTestFailover("app", "other")
+	failover.Status.Status = stork_api.FailoverStatusInProgress
+	failover.Status.Steps = []*stork_api.FailoverStepInfo{{Step: stork_api.FailoverStepScaleDownSource}}
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status)
+	require.Equal(t, int32(3), getTestDeploymentReplicas(t, "other"), "Apps in other namespaces shouldn't be scaled down")
+
+	failover = newTestFailover(testAdminNamespace, "other")
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusInProgress, failover.Status.Status,
+		"Failover in admin namespace should be allowed for other namespaces")
+}
+
+func TestFailoverClusterDomainNotAllowed(t *testing.T) {
+	f := newTestFailoverController(t, newTestDeployment("app", 3))
+
+	failover := newTestFailover("app", "app")
+	failover.Spec.ClusterDomain = "dc1"
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status,
+		"Failover updating cluster domain outside the admin namespace should fail")
+	require.Contains(t, failover.Status.Reason, "Spec.ClusterDomain")
+	require.Equal(t, int32(3), getTestDeploymentReplicas(t, "app"), "Apps shouldn't be scaled down")
+
+	failover = newTestFailover(testAdminNamespace, "app")
+	failover.Spec.ClusterDomain = "dc1"
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusInProgress, failover.Status.Status,
+		"Failover in the admin namespace should be allowed to update the cluster domain")
+}
+
+// testClusterDomainDriver manages a fixed list of cluster domains
+type testClusterDomainDriver struct {
+	*mock.Driver
+	clusterDomains []string
+	deactivated    []string
+}
+
+func (d *testClusterDomainDriver) GetClusterDomains() (*stork_api.ClusterDomains, error) {
+	clusterDomains := &stork_api.ClusterDomains{}
+	for _, name := range d.clusterDomains {
+		clusterDomains.ClusterDomainInfos = append(clusterDomains.ClusterDomainInfos,
+			stork_api.ClusterDomainInfo{Name: name})
+	}
+	return clusterDomains, nil
+}
+
+func (d *testClusterDomainDriver) DeactivateClusterDomain(update *stork_api.ClusterDomainUpdate) error {
+	d.deactivated = append(d.deactivated, update.Spec.ClusterDomain)
+	return nil
+}
+
+func TestUpdateClusterDomain(t *testing.T) {
+	driver := &testClusterDomainDriver{
+		Driver:         mock.NewDriver("domains", "domains"),
+		clusterDomains: []string{"dc1", "dc2"},
+	}
+	f := newTestFailoverController(t)
+	// The first driver doesn't support cluster domains
+	f.Drivers = []volume.Driver{mock.NewDriver("primary", "primary"), driver}
+
+	failover := newTestFailover(testAdminNamespace, "app")
+	failover.Spec.ClusterDomain = "dc2"
+	require.NoError(t, f.updateClusterDomain(failover, false), "Error deactivating cluster domain")
+	require.Equal(t, []string{"dc2"}, driver.deactivated, "Cluster domain should be deactivated by the driver managing it")
+
+	failover.Spec.ClusterDomain = "dc3"
+	require.Error(t, f.updateClusterDomain(failover, false), "Cluster domain not managed by any driver should fail")
+}
+
+func TestFailoverSteps(t *testing.T) {
+	f := newTestFailoverController(t, newTestDeployment("app", 3))
+
+	failover := newTestFailover("app", "app")
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusInProgress, failover.Status.Status)
+	steps := make([]stork_api.FailoverStepType, 0)
+	for _, step := range failover.Status.Steps {
+		steps = append(steps, step.Step)
+		require.Equal(t, stork_api.FailoverStatusInitial, step.Status)
+	}
+	require.Equal(t, []stork_api.FailoverStepType{
+		stork_api.FailoverStepScaleDownSource,
+		stork_api.FailoverStepMigrate,
+		stork_api.FailoverStepActivateDestination,
+	}, steps)
+
+	// Scales down the source and starts the migration
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusSuccessful, failover.Status.Steps[0].Status)
+	require.Equal(t, stork_api.FailoverStatusInProgress, failover.Status.Steps[1].Status)
+	require.Equal(t, int32(0), getTestDeploymentReplicas(t, "app"), "Source should be scaled down")
+	deployment, err := k8s.Instance().GetDeployment("app", "app")
+	require.NoError(t, err, "Error getting deployment")
+	require.Equal(t, "3", deployment.Annotations[StorkMigrationReplicasAnnotation])
+	require.Equal(t, "failover-migration", failover.Status.MigrationName)
+	migration, err := k8s.Instance().GetMigration(failover.Status.MigrationName, "app")
+	require.NoError(t, err, "Error getting migration")
+	require.False(t, *migration.Spec.StartApplications, "Apps shouldn't be started by the migration")
+
+	// Waits for the migration to complete
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusInProgress, failover.Status.Steps[1].Status)
+
+	// Fails activating the destination without a cluster pair
+	migration.Status.Stage = stork_api.MigrationStageFinal
+	migration.Status.Status = stork_api.MigrationStatusSuccessful
+	_, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusSuccessful, failover.Status.Steps[1].Status)
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Steps[2].Status)
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status)
+
+	// Steps aren't run again once the failover has failed
+	require.NoError(t, f.handle(failover))
+	require.Equal(t, stork_api.FailoverStatusFailed, failover.Status.Status)
+}
+
+func TestGetFailbackSteps(t *testing.T) {
+	original := newTestFailover("app", "app")
+	original.Status.Steps = []*stork_api.FailoverStepInfo{
+		{Step: stork_api.FailoverStepScaleDownSource, Status: stork_api.FailoverStatusSuccessful},
+		{Step: stork_api.FailoverStepMigrate, Status: stork_api.FailoverStatusSuccessful},
+		{Step: stork_api.FailoverStepDeactivateClusterDomain, Status: stork_api.FailoverStatusSuccessful},
+		{Step: stork_api.FailoverStepActivateDestination, Status: stork_api.FailoverStatusFailed},
+	}
+	require.Equal(t, []stork_api.FailoverStepType{
+		stork_api.FailoverStepActivateClusterDomain,
+		stork_api.FailoverStepScaleUpSource,
+	}, getFailbackSteps(original), "Only successful steps should be reversed in the opposite order")
+}
+
+// newFakeFailoverAPIServer returns a server that serves discovery for the
+// Failover resource and records the failovers that are updated
+func newFakeFailoverAPIServer(t *testing.T, updated *[]*stork_api.Failover, lock *sync.Mutex) *httptest.Server {
+	groupVersion := stork_api.SchemeGroupVersion.String()
+	failoverPath := fmt.Sprintf("/apis/%v/namespaces/app/failovers/failover", groupVersion)
+	write := func(w http.ResponseWriter, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(obj))
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api":
+			write(w, &meta.APIVersions{Versions: []string{"v1"}})
+		case r.URL.Path == "/api/v1":
+			write(w, &meta.APIResourceList{GroupVersion: "v1"})
+		case r.URL.Path == "/apis":
+			version := meta.GroupVersionForDiscovery{
+				GroupVersion: groupVersion,
+				Version:      stork_api.SchemeGroupVersion.Version,
+			}
+			write(w, &meta.APIGroupList{Groups: []meta.APIGroup{{
+				Name:             stork_api.SchemeGroupVersion.Group,
+				Versions:         []meta.GroupVersionForDiscovery{version},
+				PreferredVersion: version,
+			}}})
+		case r.URL.Path == "/apis/"+groupVersion:
+			write(w, &meta.APIResourceList{
+				GroupVersion: groupVersion,
+				APIResources: []meta.APIResource{{
+					Name:       stork_api.FailoverResourcePlural,
+					Namespaced: true,
+					Kind:       "Failover",
+					Verbs:      meta.Verbs{"get", "list", "update"},
+				}},
+			})
+		case r.URL.Path == failoverPath && r.Method == http.MethodPut:
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			failover := &stork_api.Failover{}
+			require.NoError(t, json.Unmarshal(body, failover))
+			lock.Lock()
+			*updated = append(*updated, failover)
+			lock.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, err = w.Write(body)
+			require.NoError(t, err)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// The controller created by stork doesn't set updateFailover, so check that
+// failovers are persisted through the API server in that case
+func TestFailoverUpdateWithoutInjection(t *testing.T) {
+	var updated []*stork_api.Failover
+	var lock sync.Mutex
+	server := newFakeFailoverAPIServer(t, &updated, &lock)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "failover")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	err = ioutil.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %v
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, server.URL)), 0600)
+	require.NoError(t, err)
+	require.NoError(t, os.Setenv("KUBERNETES_CONFIG", kubeconfig))
+	defer func() {
+		require.NoError(t, os.Unsetenv("KUBERNETES_CONFIG"))
+	}()
+
+	f := newTestFailoverController(t, newTestDeployment("other", 3))
+	f.updateFailover = nil
+	failover := newTestFailover("app", "other")
+	failover.TypeMeta = meta.TypeMeta{
+		Kind:       "Failover",
+		APIVersion: stork_api.SchemeGroupVersion.String(),
+	}
+	require.NoError(t, f.handle(failover), "Error handling failover")
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, updated, 1, "Failover should be updated through the API server")
+	require.Equal(t, stork_api.FailoverStatusFailed, updated[0].Status.Status, "Unexpected failover status")
+}
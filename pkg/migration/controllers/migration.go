@@ -13,6 +13,7 @@ import (
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controller"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/registry/core/service/portallocator"
 )
@@ -52,10 +54,14 @@ const (
 
 // MigrationController reconciles migration objects
 type MigrationController struct {
-	Driver                  volume.Driver
-	Recorder                record.EventRecorder
-	ResourceCollector       resourcecollector.ResourceCollector
+	Drivers           []volume.Driver
+	Recorder          record.EventRecorder
+	ResourceCollector resourcecollector.ResourceCollector
+	// MaxConcurrentMigrations is the maximum number of migrations that can
+	// run at the same time. There is no limit if it is 0.
+	MaxConcurrentMigrations int
 	migrationAdminNamespace string
+	queue                   migrationQueue
 }
 
 // Init Initialize the migration controller
@@ -66,6 +72,7 @@ func (m *MigrationController) Init(migrationAdminNamespace string) error {
 	}
 
 	m.migrationAdminNamespace = migrationAdminNamespace
+	m.queue.maxConcurrent = m.MaxConcurrentMigrations
 	if err := m.performRuleRecovery(); err != nil {
 		logrus.Errorf("Failed to perform recovery for migration rules: %v", err)
 		return err
@@ -128,9 +135,72 @@ func setDefaults(migration *stork_api.Migration) *stork_api.Migration {
 		defaultBool := false
 		migration.Spec.PurgeDeletedResources = &defaultBool
 	}
+	if migration.Spec.Verify == nil {
+		defaultBool := false
+		migration.Spec.Verify = &defaultBool
+	}
+	if migration.Spec.VerifyApplications == nil {
+		defaultBool := false
+		migration.Spec.VerifyApplications = &defaultBool
+	}
+	// Migrate to the same namespace on the destination if there is no
+	// mapping for a namespace
+	if migration.Spec.NamespaceMapping == nil {
+		migration.Spec.NamespaceMapping = make(map[string]string)
+	}
+	for _, ns := range migration.Spec.Namespaces {
+		if _, ok := migration.Spec.NamespaceMapping[ns]; !ok {
+			migration.Spec.NamespaceMapping[ns] = ns
+		}
+	}
 	return migration
 }
 
+// validateSpec checks that the namespace mappings are only for namespaces
+// being migrated and that the transforms are valid
+func validateSpec(migration *stork_api.Migration) error {
+	destNamespaces := make(map[string]string)
+	for sourceNamespace, destNamespace := range migration.Spec.NamespaceMapping {
+		found := false
+		for _, ns := range migration.Spec.Namespaces {
+			if ns == sourceNamespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("namespace mapping provided for namespace %v which isn't being migrated", sourceNamespace)
+		}
+		if destNamespace == "" {
+			return fmt.Errorf("destination namespace for %v can't be empty", sourceNamespace)
+		}
+		if ns, ok := destNamespaces[destNamespace]; ok {
+			return fmt.Errorf("namespaces %v and %v can't both be mapped to %v", ns, sourceNamespace, destNamespace)
+		}
+		destNamespaces[destNamespace] = sourceNamespace
+	}
+	if migration.Spec.MaxConcurrentVolumes < 0 {
+		return fmt.Errorf("maxConcurrentVolumes can't be negative")
+	}
+	if migration.Spec.MaxBandwidthMBps < 0 {
+		return fmt.Errorf("maxBandwidthMBps can't be negative")
+	}
+	return resourcecollector.ValidateTransforms(migration.Spec.Transforms)
+}
+
+// getDestNamespaces returns the namespaces on the destination cluster that the
+// namespaces are being migrated to
+func getDestNamespaces(migration *stork_api.Migration) []string {
+	namespaces := make([]string, 0, len(migration.Spec.Namespaces))
+	for _, ns := range migration.Spec.Namespaces {
+		if destNamespace, ok := migration.Spec.NamespaceMapping[ns]; ok {
+			ns = destNamespace
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
 // Handle updates for Migration objects
 func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error {
 	switch o := event.Object.(type) {
@@ -138,13 +208,13 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 		migration := o
 		if event.Deleted {
 			if migration.Status.Stage != stork_api.MigrationStageFinal {
-				return m.Driver.CancelMigration(migration)
+				return m.cancelMigration(migration)
 			}
 			return nil
 		}
 		migration = setDefaults(migration)
 
-		if migration.Spec.ClusterPair == "" {
+		if len(getClusterPairs(migration)) == 0 {
 			err := fmt.Errorf("clusterPair to migrate to cannot be empty")
 			log.MigrationLog(migration).Errorf(err.Error())
 			m.Recorder.Event(migration,
@@ -158,7 +228,7 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 		// Restrict migration to only the namespace that the object belongs
 		// except for the namespace designated by the admin
 		if !m.namespaceMigrationAllowed(migration) {
-			err := fmt.Errorf("Spec.Namespaces should only contain the current namespace")
+			err := fmt.Errorf("Spec.Namespaces and Spec.NamespaceMapping should only contain the current namespace")
 			log.MigrationLog(migration).Errorf(err.Error())
 			m.Recorder.Event(migration,
 				v1.EventTypeWarning,
@@ -168,15 +238,7 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 		}
 
 		var terminationChannels []chan bool
-		var err error
-		var clusterDomains *stork_api.ClusterDomains
-		for i := 0; i < domainsMaxRetries; i++ {
-			clusterDomains, err = m.Driver.GetClusterDomains()
-			if err == nil {
-				break
-			}
-			time.Sleep(domainsRetryInterval)
-		}
+		clusterDomains, err := getClusterDomains(m.Drivers)
 		// Fail the migration if the current domain is inactive
 		// Ignore errors
 		if err == nil {
@@ -199,6 +261,18 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 
 		switch migration.Status.Stage {
 		case stork_api.MigrationStageInitial:
+			if err := validateSpec(migration); err != nil {
+				migration.Status.Status = stork_api.MigrationStatusFailed
+				migration.Status.Stage = stork_api.MigrationStageFinal
+				migration.Status.FinishTimestamp = metav1.Now()
+				err = fmt.Errorf("invalid migration spec: %v", err)
+				log.MigrationLog(migration).Errorf(err.Error())
+				m.Recorder.Event(migration,
+					v1.EventTypeWarning,
+					string(stork_api.MigrationStatusFailed),
+					err.Error())
+				return sdk.Update(migration)
+			}
 			// Make sure the namespaces exist
 			for _, ns := range migration.Spec.Namespaces {
 				_, err := k8s.Instance().GetNamespace(ns)
@@ -244,6 +318,24 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 					return nil
 				}
 			}
+			// Wait for other migrations to complete if the maximum number of
+			// migrations are already running
+			admitted, err := m.admitMigration(migration)
+			if err != nil {
+				log.MigrationLog(migration).Errorf("Error checking if migration can be started: %v", err)
+				return nil
+			}
+			if !admitted {
+				if migration.Status.Status == stork_api.MigrationStatusPending {
+					return nil
+				}
+				migration.Status.Status = stork_api.MigrationStatusPending
+				m.Recorder.Event(migration,
+					v1.EventTypeNormal,
+					"Queued",
+					"Waiting for other migrations to complete")
+				return sdk.Update(migration)
+			}
 			fallthrough
 		case stork_api.MigrationStagePreExecRule:
 			terminationChannels, err = m.runPreExecRule(migration)
@@ -294,6 +386,17 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 					message)
 				return nil
 			}
+		case stork_api.MigrationStageVerify:
+			err := m.verifyMigration(migration)
+			if err != nil {
+				message := fmt.Sprintf("Error verifying migration: %v", err)
+				log.MigrationLog(migration).Errorf("%s", message)
+				m.Recorder.Event(migration,
+					v1.EventTypeWarning,
+					string(stork_api.MigrationStatusFailed),
+					message)
+				return nil
+			}
 		case stork_api.MigrationStageFinal:
 			return nil
 		default:
@@ -303,24 +406,46 @@ func (m *MigrationController) Handle(ctx context.Context, event sdk.Event) error
 	return nil
 }
 
-func (m *MigrationController) purgeMigratedResources(migration *stork_api.Migration) error {
+// purgeMigratedResources deletes resources from the destination that have been
+// deleted on the source. If there is state from a previous migration only the
+// resources that were migrated previously are checked, otherwise the resources
+// on the source and destination are listed and compared.
+func (m *MigrationController) purgeMigratedResources(
+	migration *stork_api.Migration,
+	state *migrationState,
+	srcObjects []runtime.Unstructured,
+) error {
 	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
 	if err != nil {
 		return err
 	}
 
+	if state != nil && state.existing {
+		log.MigrationLog(migration).Infof("Purging resources deleted since the last migration ...")
+		toBeDeleted, err := state.getRemovedResources(srcObjects)
+		if err != nil {
+			return err
+		}
+		return m.deleteMigratedResources(migration, remoteConfig, toBeDeleted)
+	}
+
 	log.MigrationLog(migration).Infof("Purging old unused resources ...")
 	// use seperate resource collector for collecting resources
 	// from destination cluster
 	rc := resourcecollector.ResourceCollector{
-		Driver: m.Driver,
+		Drivers: m.Drivers,
 	}
 	err = rc.Init(remoteConfig)
 	if err != nil {
 		log.MigrationLog(migration).Errorf("Error initializing resource collector: %v", err)
 		return err
 	}
-	destObjects, err := rc.GetResources(migration.Spec.Namespaces, migration.Spec.Selectors, false)
+	destObjects, err := rc.GetResources(
+		getDestNamespaces(migration),
+		migration.Spec.Selectors,
+		migration.Spec.IncludeResourceTypes,
+		migration.Spec.ExcludeResourceTypes,
+		false)
 	if err != nil {
 		m.Recorder.Event(migration,
 			v1.EventTypeWarning,
@@ -329,20 +454,23 @@ func (m *MigrationController) purgeMigratedResources(migration *stork_api.Migrat
 		log.MigrationLog(migration).Errorf("Error getting resources: %v", err)
 		return err
 	}
-	srcObjects, err := m.ResourceCollector.GetResources(migration.Spec.Namespaces, migration.Spec.Selectors, false)
-	if err != nil {
-		m.Recorder.Event(migration,
-			v1.EventTypeWarning,
-			string(stork_api.MigrationStatusFailed),
-			fmt.Sprintf("Error getting resources from source: %v", err))
-		log.MigrationLog(migration).Errorf("Error getting resources: %v", err)
-		return err
-	}
 	obj, err := objectToCollect(destObjects)
 	if err != nil {
 		return err
 	}
+	// The source objects have already been prepared for the destination, so
+	// their namespaces have already been mapped
 	toBeDeleted := objectTobeDeleted(srcObjects, obj)
+	return m.deleteMigratedResources(migration, remoteConfig, toBeDeleted)
+}
+
+// deleteMigratedResources deletes the resources from the destination and
+// adds them to the status as purged
+func (m *MigrationController) deleteMigratedResources(
+	migration *stork_api.Migration,
+	remoteConfig *restclient.Config,
+	toBeDeleted []runtime.Unstructured,
+) error {
 	dynamicInterface, err := dynamic.NewForConfig(remoteConfig)
 	if err != nil {
 		return err
@@ -440,6 +568,11 @@ func (m *MigrationController) namespaceMigrationAllowed(migration *stork_api.Mig
 				return false
 			}
 		}
+		for _, ns := range migration.Spec.NamespaceMapping {
+			if ns != migration.Namespace {
+				return false
+			}
+		}
 	}
 	return true
 }
@@ -454,34 +587,45 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 	migration.Status.Stage = stork_api.MigrationStageVolumes
 	// Trigger the migration if we don't have any status
 	if migration.Status.Volumes == nil {
-		// Make sure storage is ready in the cluster pair
-		storageStatus, err := getClusterPairStorageStatus(
-			migration.Spec.ClusterPair,
-			migration.Namespace)
-		if err != nil || storageStatus != stork_api.ClusterPairStatusReady {
-			// If there was a preExecRule configured, reset the stage so that it
-			// gets retriggered in the next cycle
-			if migration.Spec.PreExecRule != "" {
-				migration.Status.Stage = stork_api.MigrationStageInitial
-				err := sdk.Update(migration)
-				if err != nil {
-					return err
+		// Make sure storage is ready in all the cluster pairs
+		for _, clusterPair := range getClusterPairs(migration) {
+			storageStatus, err := getClusterPairStorageStatus(
+				clusterPair,
+				migration.Namespace)
+			if err != nil || storageStatus != stork_api.ClusterPairStatusReady {
+				// If there was a preExecRule configured, reset the stage so that it
+				// gets retriggered in the next cycle
+				if migration.Spec.PreExecRule != "" {
+					migration.Status.Stage = stork_api.MigrationStageInitial
+					err := sdk.Update(migration)
+					if err != nil {
+						return err
+					}
 				}
+				return fmt.Errorf("cluster pair %v storage status is not ready. Status: %v Err: %v",
+					clusterPair, storageStatus, err)
 			}
-			return fmt.Errorf("cluster pair storage status is not ready. Status: %v Err: %v",
-				storageStatus, err)
 		}
 
-		volumeInfos, err := m.Driver.StartMigration(migration)
-		if err != nil {
-			return err
-		}
-		if volumeInfos == nil {
-			volumeInfos = make([]*stork_api.MigrationVolumeInfo, 0)
+		volumeInfos := make([]*stork_api.MigrationVolumeInfo, 0)
+		for _, clusterPair := range getClusterPairs(migration) {
+			getDestinationInfo(migration, clusterPair)
+			destVolumeInfos, warnings, err := m.startVolumeMigrations(getDestinationMigration(migration, clusterPair))
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				addMigrationWarning(migration, warning)
+			}
+			for _, vInfo := range destVolumeInfos {
+				vInfo.ClusterPair = clusterPair
+			}
+			volumeInfos = append(volumeInfos, destVolumeInfos...)
 		}
 		migration.Status.Volumes = volumeInfos
+		m.startPendingVolumes(migration)
 		migration.Status.Status = stork_api.MigrationStatusInProgress
-		err = sdk.Update(migration)
+		err := sdk.Update(migration)
 		if err != nil {
 			return err
 		}
@@ -504,7 +648,7 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 					message)
 
 				// Cancel the migration and mark it as failed if the postExecRule failed
-				err = m.Driver.CancelMigration(migration)
+				err = m.cancelMigration(migration)
 				if err != nil {
 					log.MigrationLog(migration).Errorf("Error cancelling migration: %v", err)
 				}
@@ -523,17 +667,48 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 	inProgress := false
 	// Skip checking status if no volumes are being migrated
 	if len(migration.Status.Volumes) != 0 {
-		// Now check the status
-		volumeInfos, err := m.Driver.GetMigrationStatus(migration)
-		if err != nil {
-			return err
-		}
-		if volumeInfos == nil {
-			volumeInfos = make([]*stork_api.MigrationVolumeInfo, 0)
+		volumeInfos := make([]*stork_api.MigrationVolumeInfo, 0)
+		for _, clusterPair := range getClusterPairs(migration) {
+			// Now check the status for each of the drivers. The drivers only
+			// track the volumes that have been started.
+			dest := getDestinationMigration(migration, clusterPair)
+			destVolumeInfos := make([]*stork_api.MigrationVolumeInfo, 0)
+			started := getStartedVolumesMigration(dest)
+			drivers, err := m.getDriversForMigration(started)
+			if err != nil {
+				return fmt.Errorf("error getting drivers for migration to cluster pair %v: %v", clusterPair, err)
+			}
+			for _, driver := range drivers {
+				status, err := driver.GetMigrationStatus(started)
+				if err != nil {
+					return fmt.Errorf("error getting migration status for driver %v to cluster pair %v: %v",
+						driver.String(), clusterPair, err)
+				}
+				destVolumeInfos = append(destVolumeInfos, status...)
+			}
+			// Fail the volumes whose driver isn't configured anymore since their
+			// status can't be tracked
+			for _, vInfo := range dest.Status.Volumes {
+				if _, err := volume.GetDriverFromList(m.Drivers, vInfo.DriverName); err != nil {
+					vInfo.Status = stork_api.MigrationStatusFailed
+					vInfo.Reason = fmt.Sprintf("Driver %v for volume not configured", vInfo.DriverName)
+					destVolumeInfos = append(destVolumeInfos, vInfo)
+				} else if vInfo.Status == stork_api.MigrationStatusPending {
+					destVolumeInfos = append(destVolumeInfos, vInfo)
+				}
+			}
+			for _, vInfo := range destVolumeInfos {
+				vInfo.ClusterPair = clusterPair
+			}
+			volumeInfos = append(volumeInfos, destVolumeInfos...)
 		}
 		migration.Status.Volumes = volumeInfos
+		setMigrationProgress(migration)
+		// Start more volumes if others have completed, unless one of them
+		// has failed for the destination since it will be failed
+		m.startPendingVolumes(migration)
 		// Store the new status
-		err = sdk.Update(migration)
+		err := sdk.Update(migration)
 		if err != nil {
 			return err
 		}
@@ -544,14 +719,18 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 			if vInfo.Status == stork_api.MigrationStatusInProgress {
 				log.MigrationLog(migration).Infof("Volume migration still in progress: %v", vInfo.Volume)
 				inProgress = true
+			} else if vInfo.Status == stork_api.MigrationStatusPending {
+				inProgress = true
 			} else if vInfo.Status == stork_api.MigrationStatusFailed {
+				message := fmt.Sprintf("Error migrating volume %v: %v", vInfo.Volume, vInfo.Reason)
+				if len(getClusterPairs(migration)) > 1 {
+					message = fmt.Sprintf("Error migrating volume %v to cluster pair %v: %v", vInfo.Volume, vInfo.ClusterPair, vInfo.Reason)
+				}
 				m.Recorder.Event(migration,
 					v1.EventTypeWarning,
 					string(vInfo.Status),
-					fmt.Sprintf("Error migrating volume %v: %v", vInfo.Volume, vInfo.Reason))
-				migration.Status.Stage = stork_api.MigrationStageFinal
-				migration.Status.FinishTimestamp = metav1.Now()
-				migration.Status.Status = stork_api.MigrationStatusFailed
+					message)
+				failDestination(migration, vInfo.ClusterPair, message)
 			} else if vInfo.Status == stork_api.MigrationStatusSuccessful {
 				m.Recorder.Event(migration,
 					v1.EventTypeNormal,
@@ -566,30 +745,284 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 		return nil
 	}
 
-	// If the migration hasn't failed move on to the next stage.
-	if migration.Status.Status != stork_api.MigrationStatusFailed {
-		if *migration.Spec.IncludeResources {
-			migration.Status.Stage = stork_api.MigrationStageApplications
-			migration.Status.Status = stork_api.MigrationStatusInProgress
-			// Update the current state and then move on to migrating
-			// resources
-			err := sdk.Update(migration)
+	// Fail the migration if the volumes failed for all the destinations,
+	// otherwise move on to the next stage for the remaining ones
+	if len(getActiveClusterPairs(migration)) == 0 {
+		migration.Status.Stage = stork_api.MigrationStageFinal
+		migration.Status.FinishTimestamp = metav1.Now()
+		migration.Status.Status = stork_api.MigrationStatusFailed
+	} else if *migration.Spec.IncludeResources {
+		migration.Status.Stage = stork_api.MigrationStageApplications
+		migration.Status.Status = stork_api.MigrationStatusInProgress
+		// Update the current state and then move on to migrating
+		// resources
+		err := sdk.Update(migration)
+		if err != nil {
+			return err
+		}
+		err = m.migrateResources(migration)
+		if err != nil {
+			log.MigrationLog(migration).Errorf("Error migrating resources: %v", err)
+			return err
+		}
+	} else {
+		migration.Status.Stage = stork_api.MigrationStageFinal
+		migration.Status.FinishTimestamp = metav1.Now()
+		migration.Status.Status = getMigrationStatus(migration)
+	}
+
+	return sdk.Update(migration)
+}
+
+// setMigrationProgress aggregates the progress reported by the drivers for
+// the volumes. The ETA for the migration is the longest one for the volumes
+// still being migrated.
+func setMigrationProgress(migration *stork_api.Migration) {
+	migration.Status.TotalBytes = 0
+	migration.Status.BytesTransferred = 0
+	migration.Status.ETASeconds = 0
+	for _, vInfo := range migration.Status.Volumes {
+		migration.Status.TotalBytes += vInfo.TotalBytes
+		migration.Status.BytesTransferred += vInfo.BytesTransferred
+		if vInfo.Status == stork_api.MigrationStatusInProgress && vInfo.ETASeconds > migration.Status.ETASeconds {
+			migration.Status.ETASeconds = vInfo.ETASeconds
+		}
+	}
+}
+
+// getDriversForMigration returns the drivers that have volumes being migrated.
+// If the driver for a volume wasn't recorded, which is the case for migrations
+// started by older versions, it is looked up from the drivers and recorded in
+// the volume info.
+func (m *MigrationController) getDriversForMigration(migration *stork_api.Migration) ([]volume.Driver, error) {
+	drivers := make([]volume.Driver, 0)
+	found := make(map[string]bool)
+	for _, vInfo := range migration.Status.Volumes {
+		if vInfo.DriverName == "" {
+			driver, err := m.getVolumeDriver(vInfo)
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("error getting driver for volume %v: %v", vInfo.Volume, err)
+			}
+			vInfo.DriverName = driver.String()
+		}
+		if found[vInfo.DriverName] {
+			continue
+		}
+		driver, err := volume.GetDriverFromList(m.Drivers, vInfo.DriverName)
+		if err != nil {
+			continue
+		}
+		found[vInfo.DriverName] = true
+		drivers = append(drivers, driver)
+	}
+	return drivers, nil
+}
+
+// getVolumeDriver returns the driver that owns the PVC for the volume being
+// migrated. The only driver is returned if just one is configured.
+func (m *MigrationController) getVolumeDriver(vInfo *stork_api.MigrationVolumeInfo) (volume.Driver, error) {
+	if len(m.Drivers) == 1 {
+		return m.Drivers[0], nil
+	}
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(vInfo.PersistentVolumeClaim, vInfo.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return volume.GetPVCDriverFromList(m.Drivers, pvc)
+}
+
+// startVolumeMigrations starts migrating the volumes for all the drivers. If
+// the number of concurrent volumes is limited, the volumes for drivers that
+// support it are returned as pending so that they can be started in batches.
+// Warnings are returned for the options that the drivers don't support.
+func (m *MigrationController) startVolumeMigrations(
+	migration *stork_api.Migration,
+) ([]*stork_api.MigrationVolumeInfo, []string, error) {
+	volumeInfos := make([]*stork_api.MigrationVolumeInfo, 0)
+	warnings := make([]string, 0)
+	for _, driver := range m.Drivers {
+		if migration.Spec.MaxConcurrentVolumes > 0 {
+			driverVolumeInfos, err := driver.GetMigrationVolumes(migration)
+			if err == nil {
+				for _, vInfo := range driverVolumeInfos {
+					vInfo.Status = stork_api.MigrationStatusPending
+					vInfo.Reason = "Waiting for other volume migrations to complete"
+				}
+				volumeInfos = append(volumeInfos, driverVolumeInfos...)
+				warnings = append(warnings, getBandwidthWarning(migration, driver, driverVolumeInfos)...)
+				continue
+			}
+			if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+				return nil, nil, err
+			}
+		}
+		driverVolumeInfos, err := driver.StartMigration(migration)
+		if err != nil {
+			// Skip drivers that don't support migration
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				continue
+			}
+			return nil, nil, err
+		}
+		if migration.Spec.MaxConcurrentVolumes > 0 && len(driverVolumeInfos) > 0 {
+			warning := fmt.Sprintf("Driver %v doesn't support maxConcurrentVolumes, "+
+				"all of its volumes are migrated at the same time", driver.String())
+			log.MigrationLog(migration).Warn(warning)
+			warnings = append(warnings, warning)
+		}
+		volumeInfos = append(volumeInfos, driverVolumeInfos...)
+		warnings = append(warnings, getBandwidthWarning(migration, driver, driverVolumeInfos)...)
+	}
+	return volumeInfos, warnings, nil
+}
+
+// getBandwidthWarning returns a warning if a bandwidth limit is set for the
+// migration, since none of the drivers support limiting the bandwidth used
+// to migrate volumes
+func getBandwidthWarning(
+	migration *stork_api.Migration,
+	driver volume.Driver,
+	volumeInfos []*stork_api.MigrationVolumeInfo,
+) []string {
+	if migration.Spec.MaxBandwidthMBps <= 0 || len(volumeInfos) == 0 {
+		return nil
+	}
+	warning := fmt.Sprintf("Driver %v doesn't support maxBandwidthMBps, "+
+		"its volumes are migrated without a bandwidth limit", driver.String())
+	log.MigrationLog(migration).Warn(warning)
+	return []string{warning}
+}
+
+// addMigrationWarning adds a warning to the status of the migration if it
+// hasn't already been added
+func addMigrationWarning(migration *stork_api.Migration, warning string) {
+	for _, existing := range migration.Status.Warnings {
+		if existing == warning {
+			return
+		}
+	}
+	migration.Status.Warnings = append(migration.Status.Warnings, warning)
+}
+
+// startPendingVolumes starts migrating pending volumes until the maximum
+// number of volumes are being migrated to each destination. Volumes that fail
+// to start are marked as failed. No more volumes are started for a
+// destination once one of its volumes has failed.
+func (m *MigrationController) startPendingVolumes(migration *stork_api.Migration) {
+	limit := migration.Spec.MaxConcurrentVolumes
+	if limit <= 0 {
+		return
+	}
+	for _, clusterPair := range getClusterPairs(migration) {
+		running := 0
+		failed := false
+		for _, vInfo := range migration.Status.Volumes {
+			if getStatusClusterPair(migration, vInfo.ClusterPair) != clusterPair {
+				continue
+			}
+			if vInfo.Status == stork_api.MigrationStatusInProgress {
+				running++
+			} else if vInfo.Status == stork_api.MigrationStatusFailed {
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+
+		// Group the volumes to start by driver, keeping the order of the drivers
+		driverNames := make([]string, 0)
+		toStart := make(map[string][]*stork_api.MigrationVolumeInfo)
+		for _, vInfo := range migration.Status.Volumes {
+			if running >= limit {
+				break
+			}
+			if getStatusClusterPair(migration, vInfo.ClusterPair) != clusterPair ||
+				vInfo.Status != stork_api.MigrationStatusPending {
+				continue
+			}
+			if _, ok := toStart[vInfo.DriverName]; !ok {
+				driverNames = append(driverNames, vInfo.DriverName)
+			}
+			toStart[vInfo.DriverName] = append(toStart[vInfo.DriverName], vInfo)
+			running++
+		}
+
+		dest := getDestinationMigration(migration, clusterPair)
+		for _, driverName := range driverNames {
+			volumeInfos := toStart[driverName]
+			driver, err := volume.GetDriverFromList(m.Drivers, driverName)
+			if err == nil {
+				log.MigrationLog(migration).Infof("Starting migration for %v volumes with driver %v to cluster pair %v",
+					len(volumeInfos), driverName, clusterPair)
+				err = driver.StartVolumeMigration(dest, volumeInfos)
 			}
-			err = m.migrateResources(migration)
 			if err != nil {
-				log.MigrationLog(migration).Errorf("Error migrating resources: %v", err)
-				return err
+				for _, vInfo := range volumeInfos {
+					vInfo.Status = stork_api.MigrationStatusFailed
+					vInfo.Reason = fmt.Sprintf("Error starting volume migration: %v", err)
+				}
 			}
-		} else {
-			migration.Status.Stage = stork_api.MigrationStageFinal
-			migration.Status.FinishTimestamp = metav1.Now()
-			migration.Status.Status = stork_api.MigrationStatusSuccessful
 		}
 	}
+}
 
-	return sdk.Update(migration)
+// getStartedVolumesMigration returns a copy of the migration with only the
+// volumes that have been started, since those are the only ones that the
+// drivers are tracking
+func getStartedVolumesMigration(migration *stork_api.Migration) *stork_api.Migration {
+	started := migration.DeepCopy()
+	started.Status.Volumes = make([]*stork_api.MigrationVolumeInfo, 0)
+	for _, vInfo := range migration.Status.Volumes {
+		if vInfo.Status != stork_api.MigrationStatusPending {
+			started.Status.Volumes = append(started.Status.Volumes, vInfo)
+		}
+	}
+	return started
+}
+
+// cancelMigration cancels the volume migrations to all the destinations for
+// the drivers that have volumes being migrated
+func (m *MigrationController) cancelMigration(migration *stork_api.Migration) error {
+	var lastErr error
+	for _, clusterPair := range getClusterPairs(migration) {
+		started := getStartedVolumesMigration(getDestinationMigration(migration, clusterPair))
+		drivers, err := m.getDriversForMigration(started)
+		if err != nil {
+			log.MigrationLog(migration).Errorf("Error cancelling migration to cluster pair %v: %v", clusterPair, err)
+			lastErr = err
+			continue
+		}
+		for _, driver := range drivers {
+			if err := driver.CancelMigration(started); err != nil {
+				log.MigrationLog(migration).Errorf("Error cancelling migration for driver %v to cluster pair %v: %v",
+					driver.String(), clusterPair, err)
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+// getClusterDomains returns the cluster domains from the first driver that
+// supports them. Drivers that don't support cluster domains are skipped
+// without retrying.
+func getClusterDomains(drivers []volume.Driver) (*stork_api.ClusterDomains, error) {
+	err := fmt.Errorf("no driver supports cluster domains")
+	for _, driver := range drivers {
+		for i := 0; i < domainsMaxRetries; i++ {
+			var clusterDomains *stork_api.ClusterDomains
+			clusterDomains, err = driver.GetClusterDomains()
+			if err == nil {
+				return clusterDomains, nil
+			}
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				break
+			}
+			time.Sleep(domainsRetryInterval)
+		}
+	}
+	return nil, err
 }
 
 func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]chan bool, error) {
@@ -661,17 +1094,20 @@ func (m *MigrationController) runPostExecRule(migration *stork_api.Migration) er
 }
 
 func (m *MigrationController) migrateResources(migration *stork_api.Migration) error {
-	schedulerStatus, err := getClusterPairSchedulerStatus(migration.Spec.ClusterPair, migration.Namespace)
-	if err != nil {
-		return err
-	}
+	clusterPairs := getActiveClusterPairs(migration)
+	for _, clusterPair := range clusterPairs {
+		schedulerStatus, err := getClusterPairSchedulerStatus(clusterPair, migration.Namespace)
+		if err != nil {
+			return err
+		}
 
-	if schedulerStatus != stork_api.ClusterPairStatusReady {
-		return fmt.Errorf("scheduler Cluster pair is not ready. Status: %v", schedulerStatus)
+		if schedulerStatus != stork_api.ClusterPairStatusReady {
+			return fmt.Errorf("scheduler Cluster pair %v is not ready. Status: %v", clusterPair, schedulerStatus)
+		}
 	}
 
 	if migration.Spec.AdminClusterPair != "" {
-		schedulerStatus, err = getClusterPairSchedulerStatus(migration.Spec.AdminClusterPair, m.migrationAdminNamespace)
+		schedulerStatus, err := getClusterPairSchedulerStatus(migration.Spec.AdminClusterPair, m.migrationAdminNamespace)
 		if err != nil {
 			return err
 		}
@@ -680,7 +1116,12 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration) e
 		}
 	}
 
-	allObjects, err := m.ResourceCollector.GetResources(migration.Spec.Namespaces, migration.Spec.Selectors, false)
+	allObjects, err := m.ResourceCollector.GetResources(
+		migration.Spec.Namespaces,
+		migration.Spec.Selectors,
+		migration.Spec.IncludeResourceTypes,
+		migration.Spec.ExcludeResourceTypes,
+		false)
 	if err != nil {
 		m.Recorder.Event(migration,
 			v1.EventTypeWarning,
@@ -690,29 +1131,44 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration) e
 		return err
 	}
 
-	// Save the collected resources infos in the status
+	err = m.prepareResources(migration, allObjects)
+	if err != nil {
+		m.Recorder.Event(migration,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			fmt.Sprintf("Error preparing resource: %v", err))
+		log.MigrationLog(migration).Errorf("Error preparing resources: %v", err)
+		return err
+	}
+
+	// Save the collected resources infos in the status for each destination.
+	// The resources have already been prepared, so they have the namespaces
+	// on the destination.
 	resourceInfos := make([]*stork_api.MigrationResourceInfo, 0)
-	for _, obj := range allObjects {
-		metadata, err := meta.Accessor(obj)
-		if err != nil {
-			return err
-		}
+	for _, clusterPair := range clusterPairs {
+		for _, obj := range allObjects {
+			metadata, err := meta.Accessor(obj)
+			if err != nil {
+				return err
+			}
 
-		resourceInfo := &stork_api.MigrationResourceInfo{
-			Name:      metadata.GetName(),
-			Namespace: metadata.GetNamespace(),
-			Status:    stork_api.MigrationStatusInProgress,
-		}
-		gvk := obj.GetObjectKind().GroupVersionKind()
-		resourceInfo.Kind = gvk.Kind
-		resourceInfo.Group = gvk.Group
-		// core Group doesn't have a name, so override it
-		if resourceInfo.Group == "" {
-			resourceInfo.Group = "core"
-		}
-		resourceInfo.Version = gvk.Version
+			resourceInfo := &stork_api.MigrationResourceInfo{
+				Name:        metadata.GetName(),
+				Namespace:   metadata.GetNamespace(),
+				Status:      stork_api.MigrationStatusInProgress,
+				ClusterPair: clusterPair,
+			}
+			gvk := obj.GetObjectKind().GroupVersionKind()
+			resourceInfo.Kind = gvk.Kind
+			resourceInfo.Group = gvk.Group
+			// core Group doesn't have a name, so override it
+			if resourceInfo.Group == "" {
+				resourceInfo.Group = "core"
+			}
+			resourceInfo.Version = gvk.Version
 
-		resourceInfos = append(resourceInfos, resourceInfo)
+			resourceInfos = append(resourceInfos, resourceInfo)
+		}
 	}
 	migration.Status.Resources = resourceInfos
 	err = sdk.Update(migration)
@@ -720,53 +1176,120 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration) e
 		return err
 	}
 
-	err = m.prepareResources(migration, allObjects)
-	if err != nil {
+	// Apply the resources to all the destinations in parallel
+	failed := forEachDestination(migration, clusterPairs, func(dest *stork_api.Migration) error {
+		return m.migrateDestinationResources(dest, allObjects)
+	})
+	// Retry in the next cycle if the resources couldn't be applied to any of
+	// the destinations
+	if len(failed) != 0 && len(failed) == len(clusterPairs) {
+		var lastErr error
+		for clusterPair, err := range failed {
+			log.MigrationLog(migration).Errorf("Error applying resources to cluster pair %v: %v", clusterPair, err)
+			lastErr = err
+		}
 		m.Recorder.Event(migration,
 			v1.EventTypeWarning,
 			string(stork_api.MigrationStatusFailed),
-			fmt.Sprintf("Error preparing resource: %v", err))
-		log.MigrationLog(migration).Errorf("Error preparing resources: %v", err)
-		return err
+			fmt.Sprintf("Error applying resource: %v", lastErr))
+		return lastErr
 	}
-	err = m.applyResources(migration, allObjects)
-	if err != nil {
+	for clusterPair, err := range failed {
+		message := fmt.Sprintf("Error applying resources to cluster pair %v: %v", clusterPair, err)
+		log.MigrationLog(migration).Errorf(message)
 		m.Recorder.Event(migration,
 			v1.EventTypeWarning,
 			string(stork_api.MigrationStatusFailed),
-			fmt.Sprintf("Error applying resource: %v", err))
-		log.MigrationLog(migration).Errorf("Error applying resources: %v", err)
+			message)
+		failDestination(migration, clusterPair, message)
+	}
+
+	// Verify the resources on the destination before the migration is
+	// completed if requested
+	if *migration.Spec.Verify {
+		migration.Status.Stage = stork_api.MigrationStageVerify
+		migration.Status.Status = stork_api.MigrationStatusInProgress
+	} else {
+		migration.Status.Stage = stork_api.MigrationStageFinal
+		migration.Status.FinishTimestamp = metav1.Now()
+		migration.Status.Status = getMigrationStatus(migration)
+	}
+
+	err = sdk.Update(migration)
+	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	migration.Status.Stage = stork_api.MigrationStageFinal
-	migration.Status.FinishTimestamp = metav1.Now()
-	migration.Status.Status = stork_api.MigrationStatusSuccessful
-	for _, resource := range migration.Status.Resources {
-		if resource.Status != stork_api.MigrationStatusSuccessful {
-			migration.Status.Status = stork_api.MigrationStatusPartialSuccess
-			break
-		}
+// migrateDestinationResources applies the resources to the destination of the
+// migration, purges the resources that were deleted from the source if
+// requested and saves the state for the next migration. The objects are
+// copied since they are modified while being applied.
+func (m *MigrationController) migrateDestinationResources(
+	migration *stork_api.Migration,
+	srcObjects []runtime.Unstructured,
+) error {
+	objects := make([]runtime.Unstructured, 0, len(srcObjects))
+	for _, o := range srcObjects {
+		objects = append(objects, o.DeepCopyObject().(runtime.Unstructured))
+	}
+
+	state, err := m.loadMigrationState(migration)
+	if err != nil {
+		log.MigrationLog(migration).Warnf("Error loading state of previous migrations, migrating all resources: %v", err)
+		state = nil
+	}
+
+	err = m.applyResources(migration, objects, state)
+	if err != nil {
+		return err
 	}
+
 	if *migration.Spec.PurgeDeletedResources {
-		if err := m.purgeMigratedResources(migration); err != nil {
+		if err := m.purgeMigratedResources(migration, state, objects); err != nil {
 			message := fmt.Sprintf("Error cleaning up resources: %v", err)
 			log.MigrationLog(migration).Errorf(message)
 			m.Recorder.Event(migration,
 				v1.EventTypeWarning,
 				string(stork_api.MigrationStatusPartialSuccess),
 				message)
-			return nil
 		}
 	}
-
-	err = sdk.Update(migration)
-	if err != nil {
-		return err
+	if state != nil {
+		err := state.prune(objects)
+		if err == nil {
+			err = state.save()
+		}
+		if err != nil {
+			message := fmt.Sprintf("Error saving migration state, all resources will be migrated again next time: %v", err)
+			log.MigrationLog(migration).Warn(message)
+			m.Recorder.Event(migration,
+				v1.EventTypeWarning,
+				string(stork_api.MigrationStatusPartialSuccess),
+				message)
+			getDestinationInfo(migration, migration.Spec.ClusterPair).StateError = err.Error()
+		}
 	}
 	return nil
 }
 
+// getResourcesStatus returns the status of the migration based on the status
+// of the migrated resources and the result of verifying them
+func getResourcesStatus(migration *stork_api.Migration) stork_api.MigrationStatusType {
+	for _, resource := range migration.Status.Resources {
+		if resource.Status != stork_api.MigrationStatusSuccessful &&
+			resource.Status != stork_api.MigrationStatusSkipped &&
+			resource.Status != stork_api.MigrationStatusDrifted {
+			return stork_api.MigrationStatusPartialSuccess
+		}
+		if resource.VerifyStatus == stork_api.MigrationStatusFailed {
+			return stork_api.MigrationStatusPartialSuccess
+		}
+	}
+	return stork_api.MigrationStatusSuccessful
+}
+
 func (m *MigrationController) prepareResources(
 	migration *stork_api.Migration,
 	objects []runtime.Unstructured,
@@ -789,6 +1312,17 @@ func (m *MigrationController) prepareResources(
 				return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
 			}
 		}
+
+		// Update the namespaces for the destination and then apply the
+		// transforms so that they can override any of the changes
+		err = m.ResourceCollector.PrepareResourceForApply(o, migration.Spec.NamespaceMapping, nil)
+		if err != nil {
+			return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+		}
+		err = m.ResourceCollector.TransformResource(o, migration.Spec.Transforms)
+		if err != nil {
+			return fmt.Errorf("error transforming %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+		}
 	}
 	return nil
 }
@@ -812,8 +1346,13 @@ func (m *MigrationController) updateResourceStatus(
 			resource.Kind == gkv.Kind {
 			resource.Status = status
 			resource.Reason = reason
+			// Don't record events for resources that were skipped since
+			// there can be a lot of them
+			if status == stork_api.MigrationStatusSkipped {
+				return
+			}
 			eventType := v1.EventTypeNormal
-			if status == stork_api.MigrationStatusFailed {
+			if status == stork_api.MigrationStatusFailed || status == stork_api.MigrationStatusDrifted {
 				eventType = v1.EventTypeWarning
 			}
 			eventMessage := fmt.Sprintf("%v %v/%v: %v",
@@ -911,7 +1450,11 @@ func (m *MigrationController) preparePVResource(
 		pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimRetain
 	}
 
-	_, err := m.Driver.UpdateMigratedPersistentVolumeSpec(&pv)
+	driver, err := volume.GetPVDriverFromList(m.Drivers, &pv)
+	if err != nil {
+		return err
+	}
+	_, err = driver.UpdateMigratedPersistentVolumeSpec(&pv)
 	if err != nil {
 		return err
 	}
@@ -954,13 +1497,43 @@ func (m *MigrationController) prepareApplicationResource(
 	if !found {
 		annotations = make(map[string]string)
 	}
-	annotations[StorkMigrationReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+	// Apps that have been scaled down on the source, for example during a
+	// failover, already have their replicas stored in the annotation
+	if _, ok := annotations[StorkMigrationReplicasAnnotation]; !ok {
+		annotations[StorkMigrationReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+	}
 	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
 }
 
+// waitForCRDEstablished waits for the CRD to be established on the
+// destination cluster and records the error for its kind if it isn't
+func waitForCRDEstablished(
+	remoteInterface dynamic.Interface,
+	crd runtime.Unstructured,
+	crdErrors map[schema.GroupKind]error,
+) error {
+	groupKind, err := resourcecollector.GetCRDGroupKind(crd)
+	if err != nil {
+		return err
+	}
+	metadata, err := meta.Accessor(crd)
+	if err != nil {
+		return err
+	}
+	if err := resourcecollector.WaitForCRDEstablished(remoteInterface, metadata.GetName()); err != nil {
+		crdErrors[groupKind] = fmt.Errorf("CustomResourceDefinition %v wasn't established: %v", metadata.GetName(), err)
+		return crdErrors[groupKind]
+	}
+	return nil
+}
+
+// applyResources creates the resources on the destination cluster. If there is
+// state from previous migrations, resources that haven't changed on the source
+// or destination since the last migration are skipped.
 func (m *MigrationController) applyResources(
 	migration *stork_api.Migration,
 	objects []runtime.Unstructured,
+	state *migrationState,
 ) error {
 	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
 	if err != nil {
@@ -987,16 +1560,20 @@ func (m *MigrationController) applyResources(
 		if err != nil {
 			return err
 		}
+		destNamespace := namespace.Name
+		if mappedNamespace, ok := migration.Spec.NamespaceMapping[ns]; ok {
+			destNamespace = mappedNamespace
+		}
 
 		// Don't create if the namespace already exists on the remote cluster
-		_, err = adminClient.CoreV1().Namespaces().Get(namespace.Name, metav1.GetOptions{})
+		_, err = adminClient.CoreV1().Namespaces().Get(destNamespace, metav1.GetOptions{})
 		if err == nil {
 			continue
 		}
 
 		_, err = adminClient.CoreV1().Namespaces().Create(&v1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        namespace.Name,
+				Name:        destNamespace,
 				Labels:      namespace.Labels,
 				Annotations: namespace.Annotations,
 			},
@@ -1018,6 +1595,13 @@ func (m *MigrationController) applyResources(
 		}
 	}
 
+	// Resources on the destination cluster, listed once for each type and
+	// namespace, used to check if resources that haven't changed on the
+	// source have been modified on the destination
+	destResources := make(map[string]map[string]runtime.Unstructured)
+	// Errors for the CRDs that couldn't be established on the destination,
+	// custom resources of their kinds can't be applied
+	crdErrors := make(map[schema.GroupKind]error)
 	for _, o := range objects {
 		metadata, err := meta.Accessor(o)
 		if err != nil {
@@ -1027,6 +1611,14 @@ func (m *MigrationController) applyResources(
 		if err != nil {
 			return err
 		}
+		if crdErr, ok := crdErrors[o.GetObjectKind().GroupVersionKind().GroupKind()]; ok {
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error applying resource: %v", crdErr))
+			continue
+		}
 		resource := &metav1.APIResource{
 			Name:       inflect.Pluralize(strings.ToLower(objectType.GetKind())),
 			Namespaced: len(metadata.GetNamespace()) > 0,
@@ -1040,6 +1632,37 @@ func (m *MigrationController) applyResources(
 				o.GetObjectKind().GroupVersionKind().GroupVersion().WithResource(resource.Name))
 		}
 
+		var key, sourceHash string
+		drifted := false
+		if state != nil {
+			key, err = getResourceKey(o)
+			if err != nil {
+				return err
+			}
+			sourceHash, err = getSourceHash(o)
+			if err != nil {
+				return err
+			}
+			if previous, ok := state.resources[key]; ok && previous.SourceHash == sourceHash {
+				changed, err := isDestResourceChanged(destResources, dynamicClient, o, previous.DestHash)
+				if err != nil {
+					log.MigrationLog(migration).Warnf("Error checking %v %v on destination, migrating it again: %v",
+						objectType.GetKind(), metadata.GetName(), err)
+				} else if !changed {
+					m.updateResourceStatus(
+						migration,
+						o,
+						stork_api.MigrationStatusSkipped,
+						"Resource hasn't changed since the last migration")
+					continue
+				} else {
+					drifted = true
+				}
+			}
+		}
+
+		// Declared before the package name is shadowed below
+		var applied *unstructured.Unstructured
 		log.MigrationLog(migration).Infof("Applying %v %v", objectType.GetKind(), metadata.GetName())
 		unstructured, ok := o.(*unstructured.Unstructured)
 		if !ok {
@@ -1057,7 +1680,7 @@ func (m *MigrationController) applyResources(
 		unstructured.SetAnnotations(migrAnnot)
 		retries := 0
 		for {
-			_, err = dynamicClient.Create(unstructured)
+			applied, err = dynamicClient.Create(unstructured)
 			if err != nil && (errors.IsAlreadyExists(err) || strings.Contains(err.Error(), portallocator.ErrAllocated.Error())) {
 				switch objectType.GetKind() {
 				// Don't want to delete the Volume resources
@@ -1067,16 +1690,20 @@ func (m *MigrationController) applyResources(
 					if migration.Spec.IncludeVolumes == nil || *migration.Spec.IncludeVolumes {
 						err = nil
 					} else {
-						_, err = dynamicClient.Update(unstructured)
+						applied, err = dynamicClient.Update(unstructured)
 					}
 				case "ServiceAccount":
 					err = m.checkAndUpdateDefaultSA(migration, o)
+				// Don't want to delete the CRDs since that would delete
+				// all the custom resources using them on the destination
+				case "CustomResourceDefinition":
+					err = nil
 				default:
 					// Delete the resource if it already exists on the destination
 					// cluster and try creating again
 					err = dynamicClient.Delete(metadata.GetName(), &metav1.DeleteOptions{})
 					if err == nil {
-						_, err = dynamicClient.Create(unstructured)
+						applied, err = dynamicClient.Create(unstructured)
 					} else {
 						log.MigrationLog(migration).Errorf("Error deleting %v %v during migrate: %v", objectType.GetKind(), metadata.GetName(), err)
 					}
@@ -1090,12 +1717,30 @@ func (m *MigrationController) applyResources(
 			}
 			break
 		}
+		// Wait for new CRDs to be established before the custom resources
+		// using them are applied
+		if err == nil && resourcecollector.IsCRD(o) {
+			err = waitForCRDEstablished(remoteAdminInterface, o, crdErrors)
+		}
+		if state != nil {
+			if err == nil {
+				state.update(migration, dynamicClient, o, key, sourceHash, applied)
+			} else {
+				delete(state.resources, key)
+			}
+		}
 		if err != nil {
 			m.updateResourceStatus(
 				migration,
 				o,
 				stork_api.MigrationStatusFailed,
 				fmt.Sprintf("Error applying resource: %v", err))
+		} else if drifted {
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusDrifted,
+				"Resource was modified on the destination since the last migration and has been migrated again")
 		} else {
 			m.updateResourceStatus(
 				migration,
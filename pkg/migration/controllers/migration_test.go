@@ -0,0 +1,256 @@
+// +build unittest
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/drivers/volume/mock"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+// testMigrationDriver migrates a fixed list of volumes. Volumes can only be
+// migrated in batches if batches is set.
+type testMigrationDriver struct {
+	*mock.Driver
+	volumes  []string
+	batches  bool
+	startErr error
+	started  []string
+}
+
+func newTestMigrationDriver(name string, batches bool, volumes ...string) *testMigrationDriver {
+	return &testMigrationDriver{
+		Driver:  mock.NewDriver(name, name),
+		volumes: volumes,
+		batches: batches,
+	}
+}
+
+// OwnsPVC returns true for the PVCs with the same name as the volumes
+func (d *testMigrationDriver) OwnsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	for _, name := range d.volumes {
+		if pvc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *testMigrationDriver) getVolumeInfos(status stork_api.MigrationStatusType) []*stork_api.MigrationVolumeInfo {
+	volumeInfos := make([]*stork_api.MigrationVolumeInfo, 0)
+	for _, name := range d.volumes {
+		volumeInfos = append(volumeInfos, &stork_api.MigrationVolumeInfo{
+			Volume:     name,
+			DriverName: d.String(),
+			Status:     status,
+		})
+	}
+	return volumeInfos
+}
+
+func (d *testMigrationDriver) GetMigrationVolumes(*stork_api.Migration) ([]*stork_api.MigrationVolumeInfo, error) {
+	if !d.batches {
+		return nil, &storkerrors.ErrNotSupported{}
+	}
+	return d.getVolumeInfos(stork_api.MigrationStatusPending), nil
+}
+
+func (d *testMigrationDriver) StartMigration(*stork_api.Migration) ([]*stork_api.MigrationVolumeInfo, error) {
+	d.started = append(d.started, d.volumes...)
+	return d.getVolumeInfos(stork_api.MigrationStatusInProgress), nil
+}
+
+func (d *testMigrationDriver) StartVolumeMigration(
+	migration *stork_api.Migration,
+	volumeInfos []*stork_api.MigrationVolumeInfo,
+) error {
+	if d.startErr != nil {
+		return d.startErr
+	}
+	for _, vInfo := range volumeInfos {
+		d.started = append(d.started, vInfo.Volume)
+		vInfo.Status = stork_api.MigrationStatusInProgress
+	}
+	return nil
+}
+
+func TestNamespaceMigrationAllowed(t *testing.T) {
+	m := &MigrationController{migrationAdminNamespace: testAdminNamespace}
+	migration := &stork_api.Migration{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "migration",
+			Namespace: "app",
+		},
+		Spec: stork_api.MigrationSpec{
+			Namespaces: []string{"app"},
+		},
+	}
+	require.True(t, m.namespaceMigrationAllowed(migration), "Migration of current namespace should be allowed")
+
+	migration.Spec.Namespaces = []string{"app", "other"}
+	require.False(t, m.namespaceMigrationAllowed(migration), "Migration of other namespaces shouldn't be allowed")
+
+	migration.Spec.Namespaces = []string{"app"}
+	migration.Spec.NamespaceMapping = map[string]string{"app": "kube-system"}
+	require.False(t, m.namespaceMigrationAllowed(migration), "Migration to other namespaces shouldn't be allowed")
+
+	migration.Namespace = testAdminNamespace
+	migration.Spec.Namespaces = []string{"app", "other"}
+	require.True(t, m.namespaceMigrationAllowed(migration), "Migration from admin namespace should be allowed")
+}
+
+func TestStartVolumeMigrations(t *testing.T) {
+	batchDriver := newTestMigrationDriver("batch", true, "b1", "b2")
+	allDriver := newTestMigrationDriver("all", false, "a1")
+	m := &MigrationController{
+		Drivers: []volume.Driver{batchDriver, allDriver, mock.NewDriver("unsupported", "unsupported")},
+	}
+	migration := &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{
+			ClusterPair: "pair",
+		},
+	}
+
+	volumeInfos, warnings, err := m.startVolumeMigrations(migration)
+	require.NoError(t, err, "Error starting volume migrations")
+	require.Len(t, volumeInfos, 3, "Volumes from all supported drivers should be migrated")
+	for _, vInfo := range volumeInfos {
+		require.Equal(t, stork_api.MigrationStatusInProgress, vInfo.Status, "All volumes should be started without a limit")
+	}
+	require.Empty(t, warnings, "No warnings expected without limits")
+
+	batchDriver.started = nil
+	allDriver.started = nil
+	migration.Spec.MaxConcurrentVolumes = 1
+	migration.Spec.MaxBandwidthMBps = 100
+	volumeInfos, warnings, err = m.startVolumeMigrations(migration)
+	require.NoError(t, err, "Error starting volume migrations")
+	require.Len(t, volumeInfos, 3)
+	require.Equal(t, stork_api.MigrationStatusPending, volumeInfos[0].Status, "Volumes should be pending for drivers supporting batches")
+	require.Equal(t, stork_api.MigrationStatusPending, volumeInfos[1].Status, "Volumes should be pending for drivers supporting batches")
+	require.Equal(t, stork_api.MigrationStatusInProgress, volumeInfos[2].Status, "Volumes should be started for drivers not supporting batches")
+	require.Empty(t, batchDriver.started, "Volumes shouldn't be started for drivers supporting batches")
+	require.Equal(t, []string{"a1"}, allDriver.started)
+	require.Len(t, warnings, 3, "Expected warnings for unsupported options")
+	require.Contains(t, warnings[0], "batch doesn't support maxBandwidthMBps")
+	require.Contains(t, warnings[1], "all doesn't support maxConcurrentVolumes")
+	require.Contains(t, warnings[2], "all doesn't support maxBandwidthMBps")
+
+	// Warnings should only be added to the status once
+	for _, warning := range append(warnings, warnings...) {
+		addMigrationWarning(migration, warning)
+	}
+	require.Equal(t, warnings, migration.Status.Warnings)
+}
+
+func TestGetDriversForMigrationWithoutDriverName(t *testing.T) {
+	first := newTestMigrationDriver("first", false, "pvc1")
+	second := newTestMigrationDriver("second", false, "pvc2")
+	fakeKubeClient := kubernetes.NewSimpleClientset(&v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "pvc2",
+			Namespace: "app",
+		},
+	})
+	k8s.Instance().SetClient(fakeKubeClient, nil, fakeclient.NewSimpleClientset(), nil, nil, nil, nil, nil)
+
+	// Migrations started by older versions don't have the driver recorded
+	newMigration := func(pvc string) *stork_api.Migration {
+		return &stork_api.Migration{
+			Status: stork_api.MigrationStatus{
+				Volumes: []*stork_api.MigrationVolumeInfo{{
+					PersistentVolumeClaim: pvc,
+					Namespace:             "app",
+					Volume:                "vol-" + pvc,
+					Status:                stork_api.MigrationStatusInProgress,
+				}},
+			},
+		}
+	}
+
+	m := &MigrationController{
+		Drivers: []volume.Driver{first},
+	}
+	migration := newMigration("pvc3")
+	drivers, err := m.getDriversForMigration(migration)
+	require.NoError(t, err, "Error getting drivers with a single driver configured")
+	require.Equal(t, []volume.Driver{first}, drivers, "Only driver should be used")
+	require.Equal(t, "first", migration.Status.Volumes[0].DriverName, "Driver should be recorded for the volume")
+
+	m.Drivers = []volume.Driver{first, second}
+	migration = newMigration("pvc2")
+	drivers, err = m.getDriversForMigration(migration)
+	require.NoError(t, err, "Error getting drivers from the PVC")
+	require.Equal(t, []volume.Driver{second}, drivers, "Driver owning the PVC should be used")
+	require.Equal(t, "second", migration.Status.Volumes[0].DriverName, "Driver should be recorded for the volume")
+
+	_, err = m.getDriversForMigration(newMigration("pvc3"))
+	require.Error(t, err, "Getting drivers should fail if the PVC doesn't exist")
+}
+
+func TestStartPendingVolumes(t *testing.T) {
+	driver := newTestMigrationDriver("batch", true)
+	m := &MigrationController{
+		Drivers: []volume.Driver{driver},
+	}
+	migration := &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{
+			ClusterPair:          "pair1",
+			ClusterPairs:         []string{"pair2"},
+			MaxConcurrentVolumes: 2,
+		},
+	}
+	for _, clusterPair := range []string{"pair1", "pair2"} {
+		for i := 0; i < 3; i++ {
+			migration.Status.Volumes = append(migration.Status.Volumes, &stork_api.MigrationVolumeInfo{
+				Volume:      fmt.Sprintf("%v-v%v", clusterPair, i),
+				DriverName:  driver.String(),
+				ClusterPair: clusterPair,
+				Status:      stork_api.MigrationStatusPending,
+			})
+		}
+	}
+	migration.Status.Volumes[0].Status = stork_api.MigrationStatusInProgress
+
+	m.startPendingVolumes(migration)
+	require.Equal(t, []string{"pair1-v1", "pair2-v0", "pair2-v1"}, driver.started,
+		"Volumes should be started up to the limit for each destination")
+	require.Equal(t, stork_api.MigrationStatusPending, migration.Status.Volumes[2].Status)
+	require.Equal(t, stork_api.MigrationStatusPending, migration.Status.Volumes[5].Status)
+
+	// Nothing should be started until a volume is done
+	driver.started = nil
+	m.startPendingVolumes(migration)
+	require.Empty(t, driver.started, "No volumes should be started while at the limit")
+
+	migration.Status.Volumes[0].Status = stork_api.MigrationStatusSuccessful
+	migration.Status.Volumes[3].Status = stork_api.MigrationStatusFailed
+	m.startPendingVolumes(migration)
+	require.Equal(t, []string{"pair1-v2"}, driver.started,
+		"No volumes should be started for a destination with a failed volume")
+
+	// Volumes that fail to start should be marked as failed
+	migration.Status.Volumes[2].Status = stork_api.MigrationStatusPending
+	migration.Status.Volumes[1].Status = stork_api.MigrationStatusSuccessful
+	driver.startErr = fmt.Errorf("start failed")
+	m.startPendingVolumes(migration)
+	require.Equal(t, stork_api.MigrationStatusFailed, migration.Status.Volumes[2].Status)
+	require.Contains(t, migration.Status.Volumes[2].Reason, "start failed")
+
+	migration.Spec.MaxConcurrentVolumes = 0
+	migration.Status.Volumes[5].Status = stork_api.MigrationStatusPending
+	driver.startErr = nil
+	driver.started = nil
+	m.startPendingVolumes(migration)
+	require.Empty(t, driver.started, "Pending volumes shouldn't be started without a limit")
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+)
+
+// getClusterPairs returns the cluster pairs for all the destination clusters
+// of the migration, starting with Spec.ClusterPair
+func getClusterPairs(migration *stork_api.Migration) []string {
+	clusterPairs := make([]string, 0, len(migration.Spec.ClusterPairs)+1)
+	found := make(map[string]bool)
+	for _, clusterPair := range append([]string{migration.Spec.ClusterPair}, migration.Spec.ClusterPairs...) {
+		if clusterPair == "" || found[clusterPair] {
+			continue
+		}
+		found[clusterPair] = true
+		clusterPairs = append(clusterPairs, clusterPair)
+	}
+	return clusterPairs
+}
+
+// getStatusClusterPair returns the cluster pair recorded in the status of a
+// volume or resource. Migrations started before multiple destinations were
+// supported don't have one recorded, so Spec.ClusterPair is used for those.
+func getStatusClusterPair(migration *stork_api.Migration, clusterPair string) string {
+	if clusterPair == "" {
+		return migration.Spec.ClusterPair
+	}
+	return clusterPair
+}
+
+// getDestinationMigration returns a copy of the migration with only the
+// volumes, resources and verification for the destination of the cluster
+// pair. Spec.ClusterPair is set to the cluster pair so that the copy can be
+// used for the drivers and helpers that only deal with one destination.
+func getDestinationMigration(migration *stork_api.Migration, clusterPair string) *stork_api.Migration {
+	dest := migration.DeepCopy()
+	if clusterPair != migration.Spec.ClusterPair {
+		// The admin cluster pair is only for the destination of ClusterPair
+		dest.Spec.AdminClusterPair = ""
+	}
+	dest.Spec.ClusterPair = clusterPair
+
+	if migration.Status.Volumes != nil {
+		dest.Status.Volumes = make([]*stork_api.MigrationVolumeInfo, 0)
+		for _, vInfo := range migration.Status.Volumes {
+			if getStatusClusterPair(migration, vInfo.ClusterPair) == clusterPair {
+				dest.Status.Volumes = append(dest.Status.Volumes, vInfo.DeepCopy())
+			}
+		}
+	}
+	if migration.Status.Resources != nil {
+		dest.Status.Resources = make([]*stork_api.MigrationResourceInfo, 0)
+		for _, resource := range migration.Status.Resources {
+			if getStatusClusterPair(migration, resource.ClusterPair) == clusterPair {
+				dest.Status.Resources = append(dest.Status.Resources, resource.DeepCopy())
+			}
+		}
+	}
+	if migration.Status.Verification != nil {
+		dest.Status.Verification = make([]*stork_api.MigrationNamespaceVerification, 0)
+		for _, nsVerification := range migration.Status.Verification {
+			if getStatusClusterPair(migration, nsVerification.ClusterPair) == clusterPair {
+				dest.Status.Verification = append(dest.Status.Verification, nsVerification.DeepCopy())
+			}
+		}
+	}
+	return dest
+}
+
+// setDestinationStatus replaces the volumes, resources and verification for
+// the destination of the cluster pair with the ones from the copy returned by
+// getDestinationMigration. The error from saving the migration state is also
+// copied from the status of the destination.
+func setDestinationStatus(migration *stork_api.Migration, dest *stork_api.Migration) {
+	clusterPair := dest.Spec.ClusterPair
+
+	volumes := make([]*stork_api.MigrationVolumeInfo, 0)
+	for _, vInfo := range migration.Status.Volumes {
+		if getStatusClusterPair(migration, vInfo.ClusterPair) != clusterPair {
+			volumes = append(volumes, vInfo)
+		}
+	}
+	for _, vInfo := range dest.Status.Volumes {
+		vInfo.ClusterPair = clusterPair
+		volumes = append(volumes, vInfo)
+	}
+	if migration.Status.Volumes != nil || len(volumes) != 0 {
+		migration.Status.Volumes = volumes
+	}
+
+	resources := make([]*stork_api.MigrationResourceInfo, 0)
+	for _, resource := range migration.Status.Resources {
+		if getStatusClusterPair(migration, resource.ClusterPair) != clusterPair {
+			resources = append(resources, resource)
+		}
+	}
+	for _, resource := range dest.Status.Resources {
+		resource.ClusterPair = clusterPair
+		resources = append(resources, resource)
+	}
+	if migration.Status.Resources != nil || len(resources) != 0 {
+		migration.Status.Resources = resources
+	}
+
+	verification := make([]*stork_api.MigrationNamespaceVerification, 0)
+	for _, nsVerification := range migration.Status.Verification {
+		if getStatusClusterPair(migration, nsVerification.ClusterPair) != clusterPair {
+			verification = append(verification, nsVerification)
+		}
+	}
+	for _, nsVerification := range dest.Status.Verification {
+		nsVerification.ClusterPair = clusterPair
+		verification = append(verification, nsVerification)
+	}
+	if migration.Status.Verification != nil || len(verification) != 0 {
+		migration.Status.Verification = verification
+	}
+
+	for _, destInfo := range dest.Status.Destinations {
+		if destInfo.ClusterPair == clusterPair {
+			getDestinationInfo(migration, clusterPair).StateError = destInfo.StateError
+		}
+	}
+}
+
+// getDestinationInfo returns the status of the migration to the destination
+// of the cluster pair, adding it to the status if it doesn't exist
+func getDestinationInfo(migration *stork_api.Migration, clusterPair string) *stork_api.MigrationDestinationInfo {
+	for _, destInfo := range migration.Status.Destinations {
+		if destInfo.ClusterPair == clusterPair {
+			return destInfo
+		}
+	}
+	destInfo := &stork_api.MigrationDestinationInfo{
+		ClusterPair: clusterPair,
+		Status:      stork_api.MigrationStatusInProgress,
+	}
+	migration.Status.Destinations = append(migration.Status.Destinations, destInfo)
+	return destInfo
+}
+
+// failDestination marks the migration to the destination of the cluster pair
+// as failed. Nothing else is migrated to that destination.
+func failDestination(migration *stork_api.Migration, clusterPair string, reason string) {
+	destInfo := getDestinationInfo(migration, clusterPair)
+	destInfo.Status = stork_api.MigrationStatusFailed
+	destInfo.Reason = reason
+}
+
+// getActiveClusterPairs returns the cluster pairs for the destinations that
+// haven't failed
+func getActiveClusterPairs(migration *stork_api.Migration) []string {
+	clusterPairs := make([]string, 0)
+	for _, clusterPair := range getClusterPairs(migration) {
+		if getDestinationInfo(migration, clusterPair).Status != stork_api.MigrationStatusFailed {
+			clusterPairs = append(clusterPairs, clusterPair)
+		}
+	}
+	return clusterPairs
+}
+
+// forEachDestination calls fn in parallel for each of the cluster pairs with a
+// copy of the migration for that destination. The status from the copies is
+// merged back into the migration once all of them are done. Returns the
+// errors for the destinations that failed.
+func forEachDestination(
+	migration *stork_api.Migration,
+	clusterPairs []string,
+	fn func(dest *stork_api.Migration) error,
+) map[string]error {
+	dests := make([]*stork_api.Migration, 0, len(clusterPairs))
+	for _, clusterPair := range clusterPairs {
+		dests = append(dests, getDestinationMigration(migration, clusterPair))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(dests))
+	for i, dest := range dests {
+		wg.Add(1)
+		go func(i int, dest *stork_api.Migration) {
+			defer wg.Done()
+			errs[i] = fn(dest)
+		}(i, dest)
+	}
+	wg.Wait()
+
+	failed := make(map[string]error)
+	for i, dest := range dests {
+		setDestinationStatus(migration, dest)
+		if errs[i] != nil {
+			failed[dest.Spec.ClusterPair] = errs[i]
+		}
+	}
+	return failed
+}
+
+// getMigrationStatus returns the status of the migration based on the status
+// of the migration to each destination. The status of the destinations that
+// haven't failed is updated from the status of their resources.
+func getMigrationStatus(migration *stork_api.Migration) stork_api.MigrationStatusType {
+	successful := 0
+	failed := 0
+	clusterPairs := getClusterPairs(migration)
+	for _, clusterPair := range clusterPairs {
+		destInfo := getDestinationInfo(migration, clusterPair)
+		if destInfo.Status == stork_api.MigrationStatusFailed {
+			failed++
+			continue
+		}
+		destInfo.Status = getResourcesStatus(getDestinationMigration(migration, clusterPair))
+		if destInfo.Status == stork_api.MigrationStatusSuccessful {
+			destInfo.Reason = "Migration completed successfully"
+			successful++
+		} else {
+			destInfo.Reason = "Some resources weren't migrated or verified successfully"
+		}
+	}
+	switch {
+	case successful == len(clusterPairs):
+		return stork_api.MigrationStatusSuccessful
+	case failed == len(clusterPairs):
+		return stork_api.MigrationStatusFailed
+	}
+	return stork_api.MigrationStatusPartialSuccess
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"sort"
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// migrationQueue limits the number of migrations that run at the same time.
+// Migrations that are waiting are started in order of priority and then in
+// the order they were created.
+type migrationQueue struct {
+	sync.Mutex
+	// maxConcurrent is the maximum number of migrations that can run at the
+	// same time. There is no limit if it is 0.
+	maxConcurrent int
+	// admitted are the migrations that have been allowed to start but might
+	// not have moved out of the initial stage yet
+	admitted map[types.UID]bool
+}
+
+// admitMigration returns true if the migration can be started. Migrations
+// are considered to be running once they have moved out of the initial stage
+// until they reach the final stage, so the queue doesn't need to be rebuilt if
+// stork is restarted.
+func (m *MigrationController) admitMigration(migration *stork_api.Migration) (bool, error) {
+	q := &m.queue
+	if q.maxConcurrent <= 0 {
+		return true, nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+	if q.admitted == nil {
+		q.admitted = make(map[types.UID]bool)
+	}
+	if q.admitted[migration.UID] {
+		return true, nil
+	}
+
+	migrations, err := k8s.Instance().ListMigrations(v1.NamespaceAll)
+	if err != nil {
+		return false, err
+	}
+	running := 0
+	waiting := make([]*stork_api.Migration, 0)
+	current := make(map[types.UID]bool)
+	for i := range migrations.Items {
+		mig := &migrations.Items[i]
+		current[mig.UID] = true
+		switch mig.Status.Stage {
+		case stork_api.MigrationStageFinal:
+			delete(q.admitted, mig.UID)
+		case stork_api.MigrationStageInitial:
+			if q.admitted[mig.UID] {
+				running++
+			} else if len(getClusterPairs(mig)) != 0 && m.namespaceMigrationAllowed(mig) {
+				// Skip migrations that will never be started so that
+				// they don't hold up the queue
+				waiting = append(waiting, mig)
+			}
+		default:
+			delete(q.admitted, mig.UID)
+			running++
+		}
+	}
+	// Forget about migrations that have been deleted
+	for uid := range q.admitted {
+		if !current[uid] {
+			delete(q.admitted, uid)
+		}
+	}
+
+	sort.SliceStable(waiting, func(i, j int) bool {
+		if waiting[i].Spec.Priority != waiting[j].Spec.Priority {
+			return waiting[i].Spec.Priority > waiting[j].Spec.Priority
+		}
+		if !waiting[i].CreationTimestamp.Equal(&waiting[j].CreationTimestamp) {
+			return waiting[i].CreationTimestamp.Before(&waiting[j].CreationTimestamp)
+		}
+		return waiting[i].Namespace+"/"+waiting[i].Name < waiting[j].Namespace+"/"+waiting[j].Name
+	})
+	for i := 0; i < q.maxConcurrent-running && i < len(waiting); i++ {
+		if waiting[i].UID == migration.UID {
+			q.admitted[migration.UID] = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
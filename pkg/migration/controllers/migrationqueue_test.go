@@ -0,0 +1,95 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+func newQueuedMigration(
+	name string,
+	priority int32,
+	created time.Time,
+	stage stork_api.MigrationStageType,
+) *stork_api.Migration {
+	return &stork_api.Migration{
+		ObjectMeta: meta.ObjectMeta{
+			Name:              name,
+			Namespace:         testAdminNamespace,
+			UID:               types.UID(name),
+			CreationTimestamp: meta.NewTime(created),
+		},
+		Spec: stork_api.MigrationSpec{
+			ClusterPair: "pair",
+			Namespaces:  []string{"app"},
+			Priority:    priority,
+		},
+		Status: stork_api.MigrationStatus{
+			Stage: stage,
+		},
+	}
+}
+
+func setTestMigrations(t *testing.T, migrations ...*stork_api.Migration) {
+	fakeStorkClient := fakeclient.NewSimpleClientset()
+	for _, migration := range migrations {
+		_, err := fakeStorkClient.StorkV1alpha1().Migrations(migration.Namespace).Create(migration)
+		require.NoError(t, err, "Error creating migration")
+	}
+	k8s.Instance().SetClient(kubernetes.NewSimpleClientset(), nil, fakeStorkClient, nil, nil, nil, nil, nil)
+}
+
+func TestAdmitMigration(t *testing.T) {
+	now := time.Now()
+	low := newQueuedMigration("low", 0, now.Add(-time.Minute), stork_api.MigrationStageInitial)
+	high := newQueuedMigration("high", 10, now, stork_api.MigrationStageInitial)
+	older := newQueuedMigration("older", 10, now.Add(-time.Hour), stork_api.MigrationStageInitial)
+	noPair := newQueuedMigration("nopair", 20, now, stork_api.MigrationStageInitial)
+	noPair.Spec.ClusterPair = ""
+	running := newQueuedMigration("running", 0, now, stork_api.MigrationStageVolumes)
+	done := newQueuedMigration("done", 0, now, stork_api.MigrationStageFinal)
+	setTestMigrations(t, low, high, older, noPair, running, done)
+
+	m := &MigrationController{migrationAdminNamespace: testAdminNamespace}
+	m.queue.maxConcurrent = 2
+	admitted, err := m.admitMigration(low)
+	require.NoError(t, err, "Error admitting migration")
+	require.False(t, admitted, "Lower priority migration shouldn't be admitted")
+	admitted, err = m.admitMigration(high)
+	require.NoError(t, err, "Error admitting migration")
+	require.False(t, admitted, "Newer migration with the same priority shouldn't be admitted")
+	admitted, err = m.admitMigration(older)
+	require.NoError(t, err, "Error admitting migration")
+	require.True(t, admitted, "Older migration with the highest priority should be admitted")
+
+	// The admitted migration should count as running even though it is still
+	// in the initial stage
+	admitted, err = m.admitMigration(high)
+	require.NoError(t, err, "Error admitting migration")
+	require.False(t, admitted, "No more migrations should be admitted")
+	admitted, err = m.admitMigration(older)
+	require.NoError(t, err, "Error admitting migration")
+	require.True(t, admitted, "Admitted migration should stay admitted")
+
+	// Once the running migration is done the next one should be admitted
+	running.Status.Stage = stork_api.MigrationStageFinal
+	older.Status.Stage = stork_api.MigrationStageVolumes
+	setTestMigrations(t, low, high, older, noPair, running, done)
+	admitted, err = m.admitMigration(high)
+	require.NoError(t, err, "Error admitting migration")
+	require.True(t, admitted, "Migration should be admitted once another one is done")
+
+	m.queue.maxConcurrent = 0
+	admitted, err = m.admitMigration(low)
+	require.NoError(t, err, "Error admitting migration")
+	require.True(t, admitted, "All migrations should be admitted if there is no limit")
+}
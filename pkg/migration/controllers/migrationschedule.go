@@ -31,7 +31,7 @@ const (
 
 // MigrationScheduleController reconciles MigrationSchedule objects
 type MigrationScheduleController struct {
-	Driver   volume.Driver
+	Drivers  []volume.Driver
 	Recorder record.EventRecorder
 }
 
@@ -76,15 +76,7 @@ func (m *MigrationScheduleController) Handle(ctx context.Context, event sdk.Even
 
 		// Then check if any of the policies require a trigger if it is enabled
 		if migrationSchedule.Spec.Suspend == nil || !*migrationSchedule.Spec.Suspend {
-			var err error
-			var clusterDomains *stork_api.ClusterDomains
-			for i := 0; i < domainsMaxRetries; i++ {
-				clusterDomains, err = m.Driver.GetClusterDomains()
-				if err == nil {
-					break
-				}
-				time.Sleep(domainsRetryInterval)
-			}
+			clusterDomains, err := getClusterDomains(m.Drivers)
 			// Ignore errors
 			if err == nil {
 				for _, domainInfo := range clusterDomains.ClusterDomainInfos {
@@ -116,6 +108,17 @@ func (m *MigrationScheduleController) Handle(ctx context.Context, event sdk.Even
 
 			// Start a migration for a policy if required
 			if start {
+				if schedule.GetConcurrencyPolicy(migrationSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyReplace {
+					if err := m.replaceMigrations(migrationSchedule); err != nil {
+						msg := fmt.Sprintf("Error replacing migrations in progress for schedule(%v): %v", policyType, err)
+						m.Recorder.Event(migrationSchedule,
+							v1.EventTypeWarning,
+							string(stork_api.MigrationStatusFailed),
+							msg)
+						log.MigrationScheduleLog(migrationSchedule).Error(msg)
+						return err
+					}
+				}
 				err := m.startMigration(migrationSchedule, policyType)
 				if err != nil {
 					msg := fmt.Sprintf("Error triggering migration for schedule(%v): %v", policyType, err)
@@ -172,6 +175,7 @@ func (m *MigrationScheduleController) updateMigrationStatus(migrationSchedule *s
 				if m.isMigrationComplete(migration.Status) {
 					migration.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
 					if updatedStatus == stork_api.MigrationStatusSuccessful {
+						migrationSchedule.Status.LastSuccessfulTime = migration.FinishTimestamp
 						m.Recorder.Event(migrationSchedule,
 							v1.EventTypeNormal,
 							string(stork_api.MigrationStatusSuccessful),
@@ -207,22 +211,19 @@ func (m *MigrationScheduleController) isMigrationComplete(status stork_api.Migra
 
 // Returns if a migration should be triggered given the status and times of the
 // previous migrations. If a migration should be triggered it also returns the
-// type of polivy that should trigger it.
+// type of polivy that should trigger it. Runs that were missed are recorded in
+// the status of the schedule.
 func (m *MigrationScheduleController) shouldStartMigration(
 	migrationSchedule *stork_api.MigrationSchedule,
 ) (stork_api.SchedulePolicyType, bool, error) {
-	// Don't trigger a new migration if one is already in progress
-	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
-		policyMigration, present := migrationSchedule.Status.Items[policyType]
-		if present {
-			for _, migration := range policyMigration {
-				if !m.isMigrationComplete(migration.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
-				}
-			}
-		}
-	}
+	// Don't trigger a new migration if one is already in progress, unless
+	// the concurrency policy allows it
+	inProgress := m.getInProgressMigrations(migrationSchedule)
+	forbidden := len(inProgress) != 0 &&
+		schedule.GetConcurrencyPolicy(migrationSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyForbid
 
+	missedUpdated := false
+	triggerPolicyType := stork_api.SchedulePolicyTypeInvalid
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		var latestMigrationTimestamp meta.Time
 		policyMigration, present := migrationSchedule.Status.Items[policyType]
@@ -233,19 +234,91 @@ func (m *MigrationScheduleController) shouldStartMigration(
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, err := schedule.CheckTrigger(
 			migrationSchedule.Spec.SchedulePolicyName,
 			policyType,
 			latestMigrationTimestamp,
+			migrationSchedule.Spec.StartingDeadlineSeconds,
 		)
 		if err != nil {
 			return stork_api.SchedulePolicyTypeInvalid, false, err
 		}
-		if trigger {
-			return policyType, true, nil
+		// Runs scheduled before the schedule was created weren't missed
+		if trigger.Missed && !trigger.ScheduledTime.Before(migrationSchedule.CreationTimestamp.Time) {
+			reason := fmt.Sprintf("Migration wasn't started within the starting deadline of %v", trigger.Deadline)
+			if forbidden {
+				reason = fmt.Sprintf("%v since previous migrations were still in progress: %v", reason, strings.Join(inProgress, ", "))
+			}
+			var updated bool
+			migrationSchedule.Status.MissedRuns, updated = schedule.AddMissedRun(
+				migrationSchedule.Status.MissedRuns,
+				policyType,
+				trigger.ScheduledTime,
+				reason)
+			if updated {
+				msg := fmt.Sprintf("Missed migration for schedule(%v) at %v: %v", policyType, trigger.ScheduledTime, reason)
+				m.Recorder.Event(migrationSchedule,
+					v1.EventTypeWarning,
+					"Missed",
+					msg)
+				log.MigrationScheduleLog(migrationSchedule).Warn(msg)
+				missedUpdated = true
+			}
+		}
+		if trigger.Required && !forbidden && triggerPolicyType == stork_api.SchedulePolicyTypeInvalid {
+			triggerPolicyType = policyType
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+
+	if missedUpdated {
+		if err := sdk.Update(migrationSchedule); err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+	}
+	return triggerPolicyType, triggerPolicyType != stork_api.SchedulePolicyTypeInvalid, nil
+}
+
+// getInProgressMigrations returns the names of the migrations triggered by the
+// schedule that haven't completed
+func (m *MigrationScheduleController) getInProgressMigrations(
+	migrationSchedule *stork_api.MigrationSchedule,
+) []string {
+	inProgress := make([]string, 0)
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		for _, migration := range migrationSchedule.Status.Items[policyType] {
+			if !m.isMigrationComplete(migration.Status) {
+				inProgress = append(inProgress, migration.Name)
+			}
+		}
+	}
+	return inProgress
+}
+
+// replaceMigrations deletes the migrations triggered by the schedule that are
+// still in progress so that a new one can be started in their place
+func (m *MigrationScheduleController) replaceMigrations(
+	migrationSchedule *stork_api.MigrationSchedule,
+) error {
+	for _, policyMigration := range migrationSchedule.Status.Items {
+		for _, migration := range policyMigration {
+			if m.isMigrationComplete(migration.Status) {
+				continue
+			}
+			err := k8s.Instance().DeleteMigration(migration.Name, migrationSchedule.Namespace)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error deleting migration %v: %v", migration.Name, err)
+			}
+			migration.Status = stork_api.MigrationStatusFailed
+			migration.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+			msg := fmt.Sprintf("Scheduled migration (%v) replaced by a new migration", migration.Name)
+			m.Recorder.Event(migrationSchedule,
+				v1.EventTypeWarning,
+				"Replaced",
+				msg)
+			log.MigrationScheduleLog(migrationSchedule).Info(msg)
+		}
+	}
+	return nil
 }
 
 func (m *MigrationScheduleController) formatMigrationName(
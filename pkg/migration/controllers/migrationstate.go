@@ -0,0 +1,486 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// migrationStatePrefix is the prefix for the name of the config maps on
+	// the destination cluster used to store the state of the resources
+	// migrated by a schedule
+	migrationStatePrefix = "stork-migration-state-"
+	// migrationStateKey is the key in the config maps with the state of the
+	// migrated resources
+	migrationStateKey = "resources"
+	// migrationStateShardsKey is the key in the first config map with the
+	// number of config maps that the state is split into
+	migrationStateShardsKey = "shards"
+	// migrationStateMaxShardSize is the maximum size of the state stored in
+	// each config map. This is kept well below the 1MiB limit for objects.
+	migrationStateMaxShardSize = 512 * 1024
+)
+
+// migratedResource is the state of a resource when it was last migrated
+type migratedResource struct {
+	// SourceHash is the hash of the resource on the source cluster after it
+	// was prepared for the destination
+	SourceHash string `json:"sourceHash"`
+	// DestHash is the hash of the resource as it was created on the
+	// destination cluster
+	DestHash string `json:"destHash"`
+}
+
+// migrationState is used to skip resources that haven't changed since the
+// last migration and to detect resources that have been modified on the
+// destination cluster. It is only used for migrations created by a schedule,
+// since the resources are migrated repeatedly for those.
+//
+// The state is split across config maps so that it isn't limited by the
+// maximum size of an object. The first config map records the number of
+// config maps, the others are named with the index as the suffix.
+type migrationState struct {
+	client    kubernetes.Interface
+	name      string
+	namespace string
+	// configMaps are the existing config maps for the state, by name
+	configMaps map[string]*v1.ConfigMap
+	// shards is the number of config maps that the state was loaded from
+	shards int
+	// existing is set if the state was loaded from existing config maps
+	existing  bool
+	resources map[string]*migratedResource
+}
+
+// getMigrationStateName returns the name of the config map used to store the
+// state for the migration. Returns false if the migration wasn't created by a
+// schedule.
+func getMigrationStateName(migration *stork_api.Migration) (string, bool) {
+	for _, owner := range migration.OwnerReferences {
+		if owner.Kind == "MigrationSchedule" {
+			return migrationStatePrefix + owner.Name, true
+		}
+	}
+	return "", false
+}
+
+// getMigrationStateNamespace returns the namespace on the destination cluster
+// that the state is stored in
+func getMigrationStateNamespace(migration *stork_api.Migration) string {
+	if destNamespace, ok := migration.Spec.NamespaceMapping[migration.Namespace]; ok {
+		return destNamespace
+	}
+	return migration.Namespace
+}
+
+// loadMigrationState loads the state for the migration from the destination
+// cluster. Returns nil if the migration isn't incremental.
+func (m *MigrationController) loadMigrationState(migration *stork_api.Migration) (*migrationState, error) {
+	name, ok := getMigrationStateName(migration)
+	if !ok {
+		return nil, nil
+	}
+	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(remoteConfig)
+	if err != nil {
+		return nil, err
+	}
+	return readMigrationState(migration, client, name, getMigrationStateNamespace(migration))
+}
+
+// readMigrationState reads the state from the config maps in the namespace.
+// If the state is incomplete or can't be parsed it is discarded so that all
+// the resources are migrated again.
+func readMigrationState(
+	migration *stork_api.Migration,
+	client kubernetes.Interface,
+	name string,
+	namespace string,
+) (*migrationState, error) {
+	state := &migrationState{
+		client:     client,
+		name:       name,
+		namespace:  namespace,
+		configMaps: make(map[string]*v1.ConfigMap),
+		resources:  make(map[string]*migratedResource),
+	}
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	state.configMaps[name] = cm
+	// State saved before it was split only has one config map
+	state.shards = 1
+	if shards, ok := cm.Data[migrationStateShardsKey]; ok {
+		if state.shards, err = strconv.Atoi(shards); err != nil || state.shards < 1 {
+			log.MigrationLog(migration).Warnf("Invalid number of shards %q for migration state %v/%v, migrating all resources",
+				shards, namespace, name)
+			state.shards = 1
+			return state, nil
+		}
+	}
+
+	for i := 0; i < state.shards; i++ {
+		shardName := state.getShardName(i)
+		if i > 0 {
+			cm, err = client.CoreV1().ConfigMaps(namespace).Get(shardName, metav1.GetOptions{})
+			if err != nil {
+				if errors.IsNotFound(err) {
+					log.MigrationLog(migration).Warnf("Migration state %v/%v not found, migrating all resources",
+						namespace, shardName)
+					state.resources = make(map[string]*migratedResource)
+					return state, nil
+				}
+				return nil, err
+			}
+			state.configMaps[shardName] = cm
+		}
+		data, ok := cm.Data[migrationStateKey]
+		if !ok {
+			continue
+		}
+		resources := make(map[string]*migratedResource)
+		if err := json.Unmarshal([]byte(data), &resources); err != nil {
+			log.MigrationLog(migration).Warnf("Error parsing migration state from %v/%v, migrating all resources: %v",
+				namespace, shardName, err)
+			state.resources = make(map[string]*migratedResource)
+			return state, nil
+		}
+		for key, resource := range resources {
+			state.resources[key] = resource
+		}
+	}
+	state.existing = true
+	return state, nil
+}
+
+// getShardName returns the name of the config map for the shard of the state
+func (s *migrationState) getShardName(shard int) string {
+	if shard == 0 {
+		return s.name
+	}
+	return fmt.Sprintf("%v-%v", s.name, shard)
+}
+
+// getShards splits the state into shards that fit in a config map each.
+// Resources are sorted by key so that the shards only change for resources
+// that have changed.
+func (s *migrationState) getShards() ([]string, error) {
+	keys := make([]string, 0, len(s.resources))
+	for key := range s.resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	shards := make([]string, 0)
+	shard := make(map[string]*migratedResource)
+	size := 0
+	for _, key := range keys {
+		resource := s.resources[key]
+		// Approximate size of the entry once it's marshalled
+		entrySize := len(key) + len(resource.SourceHash) + len(resource.DestHash) + 64
+		if size+entrySize > migrationStateMaxShardSize && len(shard) > 0 {
+			data, err := json.Marshal(shard)
+			if err != nil {
+				return nil, err
+			}
+			shards = append(shards, string(data))
+			shard = make(map[string]*migratedResource)
+			size = 0
+		}
+		shard[key] = resource
+		size += entrySize
+	}
+	data, err := json.Marshal(shard)
+	if err != nil {
+		return nil, err
+	}
+	return append(shards, string(data)), nil
+}
+
+// save stores the state in the config maps on the destination cluster. The
+// first config map is written last since it has the number of shards, and
+// config maps for shards that are no longer needed are deleted afterwards.
+func (s *migrationState) save() error {
+	shards, err := s.getShards()
+	if err != nil {
+		return err
+	}
+	for i := len(shards) - 1; i >= 0; i-- {
+		data := map[string]string{
+			migrationStateKey: shards[i],
+		}
+		if i == 0 {
+			data[migrationStateShardsKey] = strconv.Itoa(len(shards))
+		}
+		if err := s.saveConfigMap(s.getShardName(i), data); err != nil {
+			return err
+		}
+	}
+	for i := len(shards); i < s.shards; i++ {
+		name := s.getShardName(i)
+		err := s.client.CoreV1().ConfigMaps(s.namespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		delete(s.configMaps, name)
+	}
+	s.shards = len(shards)
+	return nil
+}
+
+func (s *migrationState) saveConfigMap(name string, data map[string]string) error {
+	var err error
+	cm, ok := s.configMaps[name]
+	if !ok {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.namespace,
+			},
+			Data: data,
+		}
+		cm, err = s.client.CoreV1().ConfigMaps(s.namespace).Create(cm)
+	} else {
+		cm.Data = data
+		cm, err = s.client.CoreV1().ConfigMaps(s.namespace).Update(cm)
+	}
+	if err != nil {
+		return err
+	}
+	s.configMaps[name] = cm
+	return nil
+}
+
+// update records the state of a resource after it has been applied to the
+// destination cluster. If the applied resource isn't available it is fetched
+// from the destination. If the state can't be determined the resource is
+// removed from the state so that it is migrated again the next time.
+func (s *migrationState) update(
+	migration *stork_api.Migration,
+	dynamicClient dynamic.ResourceInterface,
+	object runtime.Unstructured,
+	key string,
+	sourceHash string,
+	applied *unstructured.Unstructured,
+) {
+	if applied == nil {
+		metadata, err := meta.Accessor(object)
+		if err != nil {
+			log.MigrationLog(migration).Warnf("Error getting metadata for %v: %v", key, err)
+			delete(s.resources, key)
+			return
+		}
+		applied, err = dynamicClient.Get(metadata.GetName(), metav1.GetOptions{})
+		if err != nil {
+			log.MigrationLog(migration).Warnf("Error getting %v from destination: %v", key, err)
+			delete(s.resources, key)
+			return
+		}
+	}
+	destHash, err := getDestHash(applied)
+	if err != nil {
+		log.MigrationLog(migration).Warnf("Error getting hash for %v: %v", key, err)
+		delete(s.resources, key)
+		return
+	}
+	s.resources[key] = &migratedResource{
+		SourceHash: sourceHash,
+		DestHash:   destHash,
+	}
+}
+
+// prune removes resources from the state that aren't in the objects being
+// migrated
+func (s *migrationState) prune(objects []runtime.Unstructured) error {
+	keys, err := getResourceKeys(objects)
+	if err != nil {
+		return err
+	}
+	for key := range s.resources {
+		if _, ok := keys[key]; !ok {
+			delete(s.resources, key)
+		}
+	}
+	return nil
+}
+
+// getRemovedResources returns the namespaced resources that were migrated
+// previously but aren't in the objects being migrated
+func (s *migrationState) getRemovedResources(objects []runtime.Unstructured) ([]runtime.Unstructured, error) {
+	keys, err := getResourceKeys(objects)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]runtime.Unstructured, 0)
+	for key := range s.resources {
+		if _, ok := keys[key]; ok {
+			continue
+		}
+		object, err := getObjectFromResourceKey(key)
+		if err != nil {
+			return nil, err
+		}
+		// Only purge namespaced resources, same as when comparing the
+		// resources on the source and destination
+		if object.GetNamespace() == "" {
+			continue
+		}
+		removed = append(removed, object)
+	}
+	return removed, nil
+}
+
+func getResourceKeys(objects []runtime.Unstructured) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	for _, o := range objects {
+		key, err := getResourceKey(o)
+		if err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// isDestResourceChanged checks if the resource on the destination cluster has
+// been modified or deleted since it was migrated
+func isDestResourceChanged(
+	cache map[string]map[string]runtime.Unstructured,
+	dynamicClient dynamic.ResourceInterface,
+	object runtime.Unstructured,
+	destHash string,
+) (bool, error) {
+	destObject, err := getDestResource(cache, dynamicClient, object)
+	if err != nil {
+		return false, err
+	}
+	if destObject == nil {
+		return true, nil
+	}
+	hash, err := getDestHash(destObject)
+	if err != nil {
+		return false, err
+	}
+	return hash != destHash, nil
+}
+
+// getDestResource returns the resource on the destination cluster, or nil if
+// it doesn't exist. The resources of each type are listed once for every
+// namespace and cached.
+func getDestResource(
+	cache map[string]map[string]runtime.Unstructured,
+	dynamicClient dynamic.ResourceInterface,
+	object runtime.Unstructured,
+) (runtime.Unstructured, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := object.GetObjectKind().GroupVersionKind().String() + "/" + metadata.GetNamespace()
+	destObjects, ok := cache[cacheKey]
+	if !ok {
+		list, err := dynamicClient.List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		destObjects = make(map[string]runtime.Unstructured)
+		for i := range list.Items {
+			destObjects[list.Items[i].GetName()] = &list.Items[i]
+		}
+		cache[cacheKey] = destObjects
+	}
+	return destObjects[metadata.GetName()], nil
+}
+
+// getResourceKey returns the key used to store the state of a resource. The
+// namespace should be the namespace on the destination cluster.
+func getResourceKey(object runtime.Unstructured) (string, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return "", err
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	return strings.Join([]string{gvk.Group, gvk.Version, gvk.Kind, metadata.GetNamespace(), metadata.GetName()}, "/"), nil
+}
+
+// getObjectFromResourceKey returns an object with the type and metadata for
+// the key
+func getObjectFromResourceKey(key string) (*unstructured.Unstructured, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid resource key %v", key)
+	}
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   parts[0],
+		Version: parts[1],
+		Kind:    parts[2],
+	})
+	object.SetNamespace(parts[3])
+	object.SetName(parts[4])
+	return object, nil
+}
+
+// getSourceHash returns the hash of the content of a resource prepared for
+// the destination cluster. The status is ignored since it isn't migrated.
+func getSourceHash(object runtime.Unstructured) (string, error) {
+	content := runtime.DeepCopyJSON(object.UnstructuredContent())
+	delete(content, "status")
+	return hashContent(content)
+}
+
+// getDestHash returns the hash of a resource on the destination cluster. The
+// metadata and status are ignored since they are updated by the cluster, along
+// with fields that are set by controllers after the resource is created.
+func getDestHash(object runtime.Unstructured) (string, error) {
+	return hashContent(getComparableContent(object))
+}
+
+// getComparableContent returns a copy of the content of a resource without
+// the fields that are updated by the cluster
+func getComparableContent(object runtime.Unstructured) map[string]interface{} {
+	content := runtime.DeepCopyJSON(object.UnstructuredContent())
+	delete(content, "metadata")
+	delete(content, "status")
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "PersistentVolume":
+		unstructured.RemoveNestedField(content, "spec", "claimRef")
+	case "ServiceAccount":
+		delete(content, "secrets")
+	}
+	return content
+}
+
+func hashContent(content map[string]interface{}) (string, error) {
+	// Maps are marshalled with sorted keys, so the hash is stable
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
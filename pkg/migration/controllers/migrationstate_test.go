@@ -0,0 +1,312 @@
+// +build unittest
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+// testResourceClient is a dynamic client for one type of resource in a
+// namespace. Only Get and List are implemented.
+type testResourceClient struct {
+	dynamic.ResourceInterface
+	objects map[string]*unstructured.Unstructured
+}
+
+func (c *testResourceClient) Get(name string, options meta.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	object, ok := c.objects[name]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return object.DeepCopy(), nil
+}
+
+func (c *testResourceClient) List(opts meta.ListOptions) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	for _, object := range c.objects {
+		list.Items = append(list.Items, *object.DeepCopy())
+	}
+	return list, nil
+}
+
+func newStateObject(kind, namespace, name string, data map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"data": data,
+		},
+	}
+	if namespace != "" {
+		object.SetNamespace(namespace)
+	}
+	return object
+}
+
+func newTestMigrationState(t *testing.T, resources map[string]*migratedResource) *migrationState {
+	state, err := readMigrationState(&stork_api.Migration{}, kubernetes.NewSimpleClientset(), "stork-migration-state-test", "ns1")
+	require.NoError(t, err, "Error reading migration state")
+	for key, resource := range resources {
+		state.resources[key] = resource
+	}
+	return state
+}
+
+func TestMigrationStateSaveLoad(t *testing.T) {
+	client := kubernetes.NewSimpleClientset()
+	state, err := readMigrationState(&stork_api.Migration{}, client, "stork-migration-state-test", "ns1")
+	require.NoError(t, err, "Error reading migration state")
+	require.False(t, state.existing, "State shouldn't exist")
+	require.Empty(t, state.resources)
+
+	// Add enough resources for the state to be split into multiple config
+	// maps
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("apps/v1/Deployment/ns1/%v-%v", strings.Repeat("d", 100), i)
+		state.resources[key] = &migratedResource{
+			SourceHash: strings.Repeat("a", 64),
+			DestHash:   strings.Repeat("b", 64),
+		}
+	}
+	require.NoError(t, state.save(), "Error saving migration state")
+	require.True(t, state.shards > 1, "State should be split into multiple config maps")
+	shards := state.shards
+	for i := 0; i < shards; i++ {
+		cm, err := client.CoreV1().ConfigMaps("ns1").Get(state.getShardName(i), meta.GetOptions{})
+		require.NoError(t, err, "Error getting config map for shard %v", i)
+		require.True(t, len(cm.Data[migrationStateKey]) <= migrationStateMaxShardSize, "Shard %v is too large", i)
+	}
+
+	loaded, err := readMigrationState(&stork_api.Migration{}, client, "stork-migration-state-test", "ns1")
+	require.NoError(t, err, "Error reading migration state")
+	require.True(t, loaded.existing, "State should exist")
+	require.Equal(t, shards, loaded.shards)
+	require.Equal(t, state.resources, loaded.resources)
+
+	// Shards that are no longer needed should be deleted
+	loaded.resources = map[string]*migratedResource{
+		"/v1/ConfigMap/ns1/cm": {SourceHash: "a", DestHash: "b"},
+	}
+	require.NoError(t, loaded.save(), "Error saving migration state")
+	require.Equal(t, 1, loaded.shards)
+	for i := 1; i < shards; i++ {
+		_, err := client.CoreV1().ConfigMaps("ns1").Get(loaded.getShardName(i), meta.GetOptions{})
+		require.True(t, errors.IsNotFound(err), "Config map for shard %v should have been deleted", i)
+	}
+	loaded, err = readMigrationState(&stork_api.Migration{}, client, "stork-migration-state-test", "ns1")
+	require.NoError(t, err, "Error reading migration state")
+	require.True(t, loaded.existing, "State should exist")
+	require.Len(t, loaded.resources, 1)
+}
+
+func TestMigrationStateLoadInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     map[string]string
+		existing bool
+	}{
+		{
+			name:     "state saved before it was split",
+			data:     map[string]string{migrationStateKey: `{"/v1/ConfigMap/ns1/cm":{"sourceHash":"a","destHash":"b"}}`},
+			existing: true,
+		},
+		{
+			name:     "invalid state",
+			data:     map[string]string{migrationStateKey: "invalid"},
+			existing: false,
+		},
+		{
+			name: "missing shard",
+			data: map[string]string{
+				migrationStateKey:       `{"/v1/ConfigMap/ns1/cm":{"sourceHash":"a","destHash":"b"}}`,
+				migrationStateShardsKey: "2",
+			},
+			existing: false,
+		},
+		{
+			name: "invalid number of shards",
+			data: map[string]string{
+				migrationStateKey:       `{}`,
+				migrationStateShardsKey: "invalid",
+			},
+			existing: false,
+		},
+	}
+	for _, tc := range testCases {
+		client := kubernetes.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      "stork-migration-state-test",
+				Namespace: "ns1",
+			},
+			Data: tc.data,
+		})
+		state, err := readMigrationState(&stork_api.Migration{}, client, "stork-migration-state-test", "ns1")
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.existing, state.existing, tc.name)
+		if !tc.existing {
+			require.Empty(t, state.resources, tc.name)
+		}
+		// The state should always be saved over the existing config maps
+		require.NoError(t, state.save(), tc.name)
+	}
+}
+
+func TestMigrationStateUpdate(t *testing.T) {
+	state := newTestMigrationState(t, map[string]*migratedResource{
+		"/v1/ConfigMap/ns1/missing": {SourceHash: "a", DestHash: "b"},
+	})
+	dest := newStateObject("ConfigMap", "ns1", "cm", map[string]interface{}{"key": "value"})
+	dynamicClient := &testResourceClient{
+		objects: map[string]*unstructured.Unstructured{"cm": dest},
+	}
+	destHash, err := getDestHash(dest)
+	require.NoError(t, err, "Error getting hash")
+
+	// The applied resource is used if it's available
+	state.update(&stork_api.Migration{}, dynamicClient, dest, "/v1/ConfigMap/ns1/applied", "source", dest)
+	require.Equal(t, &migratedResource{SourceHash: "source", DestHash: destHash}, state.resources["/v1/ConfigMap/ns1/applied"])
+
+	// Otherwise it's fetched from the destination
+	state.update(&stork_api.Migration{}, dynamicClient, dest, "/v1/ConfigMap/ns1/cm", "source", nil)
+	require.Equal(t, &migratedResource{SourceHash: "source", DestHash: destHash}, state.resources["/v1/ConfigMap/ns1/cm"])
+
+	// Resources that can't be fetched are removed so they are migrated again
+	missing := newStateObject("ConfigMap", "ns1", "missing", nil)
+	state.update(&stork_api.Migration{}, dynamicClient, missing, "/v1/ConfigMap/ns1/missing", "source", nil)
+	require.NotContains(t, state.resources, "/v1/ConfigMap/ns1/missing")
+}
+
+func TestMigrationStatePrune(t *testing.T) {
+	state := newTestMigrationState(t, map[string]*migratedResource{
+		"/v1/ConfigMap/ns1/cm1":             {SourceHash: "a", DestHash: "b"},
+		"/v1/ConfigMap/ns1/cm2":             {SourceHash: "a", DestHash: "b"},
+		"/v1/PersistentVolume//pv1":         {SourceHash: "a", DestHash: "b"},
+		"apps/v1/Deployment/ns1/deployment": {SourceHash: "a", DestHash: "b"},
+	})
+	objects := []runtime.Unstructured{
+		newStateObject("ConfigMap", "ns1", "cm1", nil),
+	}
+
+	removed, err := state.getRemovedResources(objects)
+	require.NoError(t, err, "Error getting removed resources")
+	removedKeys := make([]string, 0)
+	for _, o := range removed {
+		key, err := getResourceKey(o)
+		require.NoError(t, err, "Error getting resource key")
+		removedKeys = append(removedKeys, key)
+	}
+	require.ElementsMatch(t, []string{"/v1/ConfigMap/ns1/cm2", "apps/v1/Deployment/ns1/deployment"}, removedKeys,
+		"Only namespaced resources should be removed")
+
+	require.NoError(t, state.prune(objects), "Error pruning state")
+	require.Len(t, state.resources, 1)
+	require.Contains(t, state.resources, "/v1/ConfigMap/ns1/cm1")
+}
+
+func TestIsDestResourceChanged(t *testing.T) {
+	object := newStateObject("ConfigMap", "ns1", "cm", map[string]interface{}{"key": "value"})
+	destHash, err := getDestHash(object)
+	require.NoError(t, err, "Error getting hash")
+
+	testCases := []struct {
+		name    string
+		dest    *unstructured.Unstructured
+		changed bool
+	}{
+		{
+			name:    "unchanged",
+			dest:    object,
+			changed: false,
+		},
+		{
+			name: "metadata updated by the cluster",
+			dest: func() *unstructured.Unstructured {
+				dest := object.DeepCopy()
+				dest.SetResourceVersion("2")
+				return dest
+			}(),
+			changed: false,
+		},
+		{
+			name:    "modified",
+			dest:    newStateObject("ConfigMap", "ns1", "cm", map[string]interface{}{"key": "modified"}),
+			changed: true,
+		},
+		{
+			name:    "deleted",
+			dest:    nil,
+			changed: true,
+		},
+	}
+	for _, tc := range testCases {
+		dynamicClient := &testResourceClient{
+			objects: make(map[string]*unstructured.Unstructured),
+		}
+		if tc.dest != nil {
+			dynamicClient.objects["cm"] = tc.dest
+		}
+		changed, err := isDestResourceChanged(make(map[string]map[string]runtime.Unstructured), dynamicClient, object, destHash)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.changed, changed, tc.name)
+	}
+}
+
+func TestMigrationStateHashes(t *testing.T) {
+	object := newStateObject("ConfigMap", "ns1", "cm", map[string]interface{}{"key": "value"})
+	sourceHash, err := getSourceHash(object)
+	require.NoError(t, err, "Error getting source hash")
+	destHash, err := getDestHash(object)
+	require.NoError(t, err, "Error getting destination hash")
+
+	// The status is ignored for both hashes, the metadata only for the
+	// destination
+	updated := object.DeepCopy()
+	updated.Object["status"] = map[string]interface{}{"phase": "Active"}
+	hash, err := getSourceHash(updated)
+	require.NoError(t, err, "Error getting source hash")
+	require.Equal(t, sourceHash, hash)
+	updated.SetResourceVersion("2")
+	hash, err = getSourceHash(updated)
+	require.NoError(t, err, "Error getting source hash")
+	require.NotEqual(t, sourceHash, hash)
+	hash, err = getDestHash(updated)
+	require.NoError(t, err, "Error getting destination hash")
+	require.Equal(t, destHash, hash)
+
+	// Fields set by controllers on the destination are ignored
+	pv := newStateObject("PersistentVolume", "", "pv", nil)
+	pv.Object["spec"] = map[string]interface{}{"capacity": "1Gi"}
+	pvHash, err := getDestHash(pv)
+	require.NoError(t, err, "Error getting destination hash")
+	require.NoError(t, unstructured.SetNestedField(pv.Object, "uid", "spec", "claimRef", "uid"))
+	hash, err = getDestHash(pv)
+	require.NoError(t, err, "Error getting destination hash")
+	require.Equal(t, pvHash, hash)
+
+	// Keys can be converted back to objects
+	key, err := getResourceKey(pv)
+	require.NoError(t, err, "Error getting resource key")
+	require.Equal(t, "/v1/PersistentVolume//pv", key)
+	keyObject, err := getObjectFromResourceKey(key)
+	require.NoError(t, err, "Error getting object from key")
+	require.Equal(t, "PersistentVolume", keyObject.GetKind())
+	require.Equal(t, "pv", keyObject.GetName())
+	_, err = getObjectFromResourceKey("invalid")
+	require.Error(t, err, "Invalid keys should return an error")
+}
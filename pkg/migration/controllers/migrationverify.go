@@ -0,0 +1,637 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/inflect"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/portworx/sched-ops/k8s"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// pvcBoundTimeout is how long migrated PVCs have to be bound on the
+	// destination cluster after the verification starts
+	pvcBoundTimeout = 2 * time.Minute
+	// appReadyTimeout is how long migrated applications have to become
+	// ready on the destination cluster after the verification starts
+	appReadyTimeout = 5 * time.Minute
+
+	waitingForPVCReason = ", waiting for the PVC to be bound"
+	waitingForAppReason = ", waiting for the application to become ready"
+)
+
+// verifyMigration checks that the migrated resources on each destination
+// cluster match the resources on the source cluster, that the migrated PVCs
+// are bound and, if requested, that the applications become ready. PVCs and
+// applications are only checked once in each reconcile, so the migration
+// stays in the Verify stage until they are ready or the timeouts from when the
+// verification started expire. The results are stored in the status of each
+// resource and the migration is completed once nothing is pending.
+func (m *MigrationController) verifyMigration(migration *stork_api.Migration) error {
+	if migration.Status.VerifyStartTimestamp.IsZero() {
+		migration.Status.VerifyStartTimestamp = metav1.Now()
+		migration.Status.Verification = nil
+		for _, resource := range migration.Status.Resources {
+			setVerifyStatus(resource, stork_api.MigrationStatusInitial, "")
+		}
+	}
+
+	// Collect and prepare the resources again so that they can be compared
+	// with the resources that were created on the destination
+	objects, err := m.ResourceCollector.GetResources(
+		migration.Spec.Namespaces,
+		migration.Spec.Selectors,
+		migration.Spec.IncludeResourceTypes,
+		migration.Spec.ExcludeResourceTypes,
+		false)
+	if err != nil {
+		return fmt.Errorf("error getting resources: %v", err)
+	}
+	if err := m.prepareResources(migration, objects); err != nil {
+		return fmt.Errorf("error preparing resources: %v", err)
+	}
+	srcObjects := make(map[string]runtime.Unstructured)
+	for _, o := range objects {
+		key, err := getResourceKey(o)
+		if err != nil {
+			return err
+		}
+		srcObjects[key] = o
+	}
+
+	// Verify all the destinations in parallel
+	clusterPairs := getActiveClusterPairs(migration)
+	failed := forEachDestination(migration, clusterPairs, func(dest *stork_api.Migration) error {
+		return m.verifyDestination(dest, srcObjects)
+	})
+	// Retry in the next cycle if none of the destinations could be verified
+	if len(failed) != 0 && len(failed) == len(clusterPairs) {
+		var lastErr error
+		for clusterPair, err := range failed {
+			log.MigrationLog(migration).Errorf("Error verifying resources on cluster pair %v: %v", clusterPair, err)
+			lastErr = err
+		}
+		return lastErr
+	}
+	for clusterPair, err := range failed {
+		message := fmt.Sprintf("Error verifying resources on cluster pair %v: %v", clusterPair, err)
+		log.MigrationLog(migration).Errorf("%s", message)
+		m.Recorder.Event(migration,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			message)
+		failDestination(migration, clusterPair, message)
+	}
+
+	// Check the pending PVCs and applications again in the next cycle
+	if isVerifyPending(migration) {
+		return sdk.Update(migration)
+	}
+
+	migration.Status.Stage = stork_api.MigrationStageFinal
+	migration.Status.FinishTimestamp = metav1.Now()
+	migration.Status.Status = getMigrationStatus(migration)
+	return sdk.Update(migration)
+}
+
+// verifyDestination verifies the resources migrated to the destination of the
+// migration against the prepared source objects, which are keyed by
+// getResourceKey. The resources are compared in the first pass. PVCs and
+// applications that aren't ready yet are left in progress and checked again
+// in the following passes. The summary for each namespace is updated once
+// all the resources have been verified.
+func (m *MigrationController) verifyDestination(
+	migration *stork_api.Migration,
+	srcObjects map[string]runtime.Unstructured,
+) error {
+	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
+	if err != nil {
+		return err
+	}
+	remoteInterface, err := dynamic.NewForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+	remoteAdminInterface := remoteInterface
+	if migration.Spec.AdminClusterPair != "" {
+		remoteAdminConfig, err := getClusterPairSchedulerConfig(migration.Spec.AdminClusterPair, m.migrationAdminNamespace)
+		if err != nil {
+			return err
+		}
+		remoteAdminInterface, err = dynamic.NewForConfig(remoteAdminConfig)
+		if err != nil {
+			return err
+		}
+	}
+	var ops k8s.Ops
+	if *migration.Spec.VerifyApplications {
+		if ops, err = k8s.NewInstanceFromRestConfig(remoteConfig); err != nil {
+			return err
+		}
+	}
+
+	// The counts for each namespace are only collected in the first pass
+	firstPass := len(migration.Status.Verification) == 0
+	verification := make(map[string]*stork_api.MigrationNamespaceVerification)
+	if firstPass {
+		for _, ns := range getDestNamespaces(migration) {
+			verification[ns] = &stork_api.MigrationNamespaceVerification{
+				Namespace: ns,
+			}
+		}
+	} else {
+		for _, nsVerification := range migration.Status.Verification {
+			verification[nsVerification.Namespace] = nsVerification
+		}
+	}
+
+	elapsed := time.Since(migration.Status.VerifyStartTimestamp.Time)
+	destResources := make(map[string]map[string]runtime.Unstructured)
+	for _, resource := range migration.Status.Resources {
+		if !isResourceMigrated(resource) {
+			continue
+		}
+		nsVerification := verification[resource.Namespace]
+		if firstPass && nsVerification != nil {
+			nsVerification.Expected++
+		}
+		if resource.VerifyStatus == stork_api.MigrationStatusSuccessful ||
+			resource.VerifyStatus == stork_api.MigrationStatusFailed {
+			continue
+		}
+
+		src, ok := srcObjects[getResourceInfoKey(resource)]
+		if !ok {
+			setVerifyStatus(resource, stork_api.MigrationStatusFailed, "Resource no longer exists on the source cluster")
+			continue
+		}
+		dynamicClient, err := getRemoteResourceClient(remoteInterface, remoteAdminInterface, src)
+		if err != nil {
+			setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error getting client for the destination cluster: %v", err))
+			continue
+		}
+		dest, err := getDestResource(destResources, dynamicClient, src)
+		if err != nil {
+			setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error getting resource from the destination cluster: %v", err))
+			continue
+		}
+		if dest == nil {
+			setVerifyStatus(resource, stork_api.MigrationStatusFailed, "Resource not found on the destination cluster")
+			continue
+		}
+
+		if resource.VerifyStatus != stork_api.MigrationStatusInProgress {
+			if firstPass && nsVerification != nil {
+				nsVerification.Found++
+			}
+			if !m.compareResource(migration, ops, resource, src, dest) {
+				continue
+			}
+		}
+
+		switch {
+		case resource.Kind == "PersistentVolumeClaim":
+			checkPVC(resource, src, dest, elapsed)
+		case isVerifiedApplication(migration, resource):
+			m.checkApplication(migration, ops, resource, elapsed)
+		}
+	}
+
+	if isVerifyPending(migration) {
+		if firstPass {
+			migration.Status.Verification = getNamespaceVerification(migration, verification)
+		}
+		return nil
+	}
+
+	failed := 0
+	for _, nsVerification := range verification {
+		nsVerification.Verified = 0
+	}
+	for _, resource := range migration.Status.Resources {
+		if resource.VerifyStatus == stork_api.MigrationStatusFailed {
+			failed++
+			log.MigrationLog(migration).Warnf("Verification failed for %v %v/%v: %v",
+				resource.Kind, resource.Namespace, resource.Name, resource.VerifyReason)
+		} else if resource.VerifyStatus == stork_api.MigrationStatusSuccessful {
+			if nsVerification, ok := verification[resource.Namespace]; ok {
+				nsVerification.Verified++
+			}
+		}
+	}
+	migration.Status.Verification = getNamespaceVerification(migration, verification)
+	now := metav1.Now()
+	for _, nsVerification := range migration.Status.Verification {
+		nsVerification.Timestamp = now
+	}
+
+	if failed > 0 {
+		m.Recorder.Event(migration,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			fmt.Sprintf("Verification failed for %v resources on the destination cluster of cluster pair %v",
+				failed, migration.Spec.ClusterPair))
+	} else {
+		m.Recorder.Event(migration,
+			v1.EventTypeNormal,
+			string(stork_api.MigrationStatusSuccessful),
+			fmt.Sprintf("Verified all resources on the destination cluster of cluster pair %v", migration.Spec.ClusterPair))
+	}
+	return nil
+}
+
+// compareResource checks that the resource on the destination cluster matches
+// the source. Resources that need to be checked again in the following passes
+// are marked as in progress, and applications are started so that they can
+// become ready in parallel. Returns false if the resource failed verification
+// or has been verified.
+func (m *MigrationController) compareResource(
+	migration *stork_api.Migration,
+	ops k8s.Ops,
+	resource *stork_api.MigrationResourceInfo,
+	src runtime.Unstructured,
+	dest runtime.Unstructured,
+) bool {
+	srcHash, destHash, err := getVerifyHashes(src, dest)
+	if err != nil {
+		setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Error comparing resource with the source: %v", err))
+		return false
+	}
+	if srcHash != destHash {
+		setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Resource on the destination cluster doesn't match the source, source hash %v, destination hash %v",
+				srcHash, destHash))
+		return false
+	}
+	reason := fmt.Sprintf("Resource matches the source cluster, hash %v", srcHash)
+
+	switch {
+	case resource.Kind == "PersistentVolumeClaim":
+		setVerifyStatus(resource, stork_api.MigrationStatusInProgress, reason+waitingForPVCReason)
+		return true
+	case isVerifiedApplication(migration, resource):
+		log.MigrationLog(migration).Infof("Starting %v %v/%v for verification", resource.Kind, resource.Namespace, resource.Name)
+		if _, err := scaleApplication(ops, resource, true); err != nil {
+			setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error starting application on the destination cluster: %v", err))
+			return false
+		}
+		setVerifyStatus(resource, stork_api.MigrationStatusInProgress, reason+waitingForAppReason)
+		return true
+	}
+	setVerifyStatus(resource, stork_api.MigrationStatusSuccessful, reason)
+	return false
+}
+
+// checkPVC checks once if the PVC on the destination cluster has been bound to
+// the migrated volume. The PVC is failed if it hasn't been bound within
+// pvcBoundTimeout of the verification starting.
+func checkPVC(
+	resource *stork_api.MigrationResourceInfo,
+	src runtime.Unstructured,
+	dest runtime.Unstructured,
+	elapsed time.Duration,
+) {
+	bound, err := isPVCBound(src, dest)
+	if err != nil {
+		setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("PVC isn't bound to the migrated volume on the destination cluster: %v", err))
+	} else if bound {
+		setVerifyStatus(resource, stork_api.MigrationStatusSuccessful,
+			strings.TrimSuffix(resource.VerifyReason, waitingForPVCReason))
+	} else if elapsed > pvcBoundTimeout {
+		setVerifyStatus(resource, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("PVC wasn't bound on the destination cluster within %v", pvcBoundTimeout))
+	}
+}
+
+// checkApplication checks once if the application on the destination cluster
+// is ready. The application is scaled down again, if it was scaled down by the
+// migration, once it is ready or hasn't become ready within appReadyTimeout
+// of the verification starting.
+func (m *MigrationController) checkApplication(
+	migration *stork_api.Migration,
+	ops k8s.Ops,
+	app *stork_api.MigrationResourceInfo,
+	elapsed time.Duration,
+) {
+	ready, err := isApplicationReady(ops, app)
+	if err != nil {
+		setVerifyStatus(app, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Error checking application on the destination cluster: %v", err))
+	} else if ready {
+		setVerifyStatus(app, stork_api.MigrationStatusSuccessful,
+			strings.TrimSuffix(app.VerifyReason, waitingForAppReason)+", application became ready")
+	} else if elapsed > appReadyTimeout {
+		setVerifyStatus(app, stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Application didn't become ready on the destination cluster within %v", appReadyTimeout))
+	} else {
+		return
+	}
+
+	if _, err := scaleApplication(ops, app, false); err != nil {
+		message := fmt.Sprintf("Error scaling down %v %v/%v after verification: %v", app.Kind, app.Namespace, app.Name, err)
+		log.MigrationLog(migration).Errorf("%s", message)
+		m.Recorder.Event(migration,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			message)
+	}
+}
+
+// isVerifiedApplication returns true if the resource is an application that
+// should be started on the destination cluster to verify it
+func isVerifiedApplication(migration *stork_api.Migration, resource *stork_api.MigrationResourceInfo) bool {
+	return *migration.Spec.VerifyApplications &&
+		(resource.Kind == "Deployment" || resource.Kind == "StatefulSet")
+}
+
+// isVerifyPending returns true if any resources on the destinations that
+// haven't failed are still being verified
+func isVerifyPending(migration *stork_api.Migration) bool {
+	active := make(map[string]bool)
+	for _, clusterPair := range getActiveClusterPairs(migration) {
+		active[clusterPair] = true
+	}
+	for _, resource := range migration.Status.Resources {
+		if resource.VerifyStatus == stork_api.MigrationStatusInProgress &&
+			active[getStatusClusterPair(migration, resource.ClusterPair)] {
+			return true
+		}
+	}
+	return false
+}
+
+// getNamespaceVerification returns the summary for each of the destination
+// namespaces in order
+func getNamespaceVerification(
+	migration *stork_api.Migration,
+	verification map[string]*stork_api.MigrationNamespaceVerification,
+) []*stork_api.MigrationNamespaceVerification {
+	nsVerifications := make([]*stork_api.MigrationNamespaceVerification, 0)
+	for _, ns := range getDestNamespaces(migration) {
+		if nsVerification, ok := verification[ns]; ok {
+			nsVerifications = append(nsVerifications, nsVerification)
+		}
+	}
+	return nsVerifications
+}
+
+// scaleApplication scales a deployment or statefulset that was scaled down by
+// the migration up to the replicas stored in the migration replicas
+// annotation, or back down to 0. Returns false if the application wasn't
+// scaled down by the migration.
+func scaleApplication(ops k8s.Ops, app *stork_api.MigrationResourceInfo, up bool) (bool, error) {
+	switch app.Kind {
+	case "Deployment":
+		deployment, err := ops.GetDeployment(app.Name, app.Namespace)
+		if err != nil {
+			return false, err
+		}
+		replicas, ok, err := getReplicasAnnotation(deployment.Annotations)
+		if err != nil || !ok {
+			return false, err
+		}
+		if !up {
+			replicas = 0
+		}
+		deployment.Spec.Replicas = &replicas
+		if _, err := ops.UpdateDeployment(deployment); err != nil {
+			return false, err
+		}
+	case "StatefulSet":
+		statefulSet, err := ops.GetStatefulSet(app.Name, app.Namespace)
+		if err != nil {
+			return false, err
+		}
+		replicas, ok, err := getReplicasAnnotation(statefulSet.Annotations)
+		if err != nil || !ok {
+			return false, err
+		}
+		if !up {
+			replicas = 0
+		}
+		statefulSet.Spec.Replicas = &replicas
+		if _, err := ops.UpdateStatefulSet(statefulSet); err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("unsupported application kind %v", app.Kind)
+	}
+	return true, nil
+}
+
+// isApplicationReady checks if a deployment or statefulset is ready without
+// waiting for it. Applications without any replicas are considered to be
+// ready.
+func isApplicationReady(ops k8s.Ops, app *stork_api.MigrationResourceInfo) (bool, error) {
+	switch app.Kind {
+	case "Deployment":
+		deployment, err := ops.GetDeployment(app.Name, app.Namespace)
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if replicas == 0 {
+			return true, nil
+		}
+		return deployment.Status.ObservedGeneration >= deployment.Generation &&
+			deployment.Status.UpdatedReplicas >= replicas &&
+			deployment.Status.AvailableReplicas >= replicas, nil
+	case "StatefulSet":
+		statefulSet, err := ops.GetStatefulSet(app.Name, app.Namespace)
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		if replicas == 0 {
+			return true, nil
+		}
+		return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+			statefulSet.Status.ReadyReplicas >= replicas, nil
+	}
+	return false, fmt.Errorf("unsupported application kind %v", app.Kind)
+}
+
+// isPVCBound checks if a PVC on the destination cluster is bound to the volume
+// that it was bound to on the source cluster. Returns an error if it is bound
+// to a different volume.
+func isPVCBound(src runtime.Unstructured, dest runtime.Unstructured) (bool, error) {
+	volumeName, _, err := unstructured.NestedString(src.UnstructuredContent(), "spec", "volumeName")
+	if err != nil {
+		return false, err
+	}
+	phase, _, _ := unstructured.NestedString(dest.UnstructuredContent(), "status", "phase")
+	if phase != string(v1.ClaimBound) {
+		return false, nil
+	}
+	boundVolume, _, _ := unstructured.NestedString(dest.UnstructuredContent(), "spec", "volumeName")
+	if volumeName != "" && boundVolume != volumeName {
+		return false, fmt.Errorf("PVC is bound to %v instead of %v", boundVolume, volumeName)
+	}
+	return true, nil
+}
+
+// getVerifyHashes returns the hashes used to check that a resource on the
+// destination cluster matches the resource from the source cluster. Only the
+// fields that are set on the source are compared since the destination
+// cluster fills in defaults when the resource is created.
+func getVerifyHashes(src, dest runtime.Unstructured) (string, string, error) {
+	srcContent := getComparableContent(src)
+	destContent, ok := projectContent(srcContent, getComparableContent(dest)).(map[string]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("unexpected content for resource on the destination cluster")
+	}
+	srcHash, err := hashContent(srcContent)
+	if err != nil {
+		return "", "", err
+	}
+	destHash, err := hashContent(destContent)
+	if err != nil {
+		return "", "", err
+	}
+	return srcHash, destHash, nil
+}
+
+// projectContent returns the parts of the destination content that are also
+// set in the source content. Empty values in the source are left as is since
+// they are usually filled in by the cluster. Items in lists can be in any
+// order, and additional items on the destination are ignored, since some
+// resources are merged with existing resources on the destination.
+func projectContent(src, dest interface{}) interface{} {
+	if isEmptyContent(src) {
+		return src
+	}
+	switch srcValue := src.(type) {
+	case map[string]interface{}:
+		destValue, ok := dest.(map[string]interface{})
+		if !ok {
+			return dest
+		}
+		projected := make(map[string]interface{})
+		for k, v := range srcValue {
+			if d, ok := destValue[k]; ok {
+				projected[k] = projectContent(v, d)
+			} else if isEmptyContent(v) {
+				projected[k] = v
+			}
+		}
+		return projected
+	case []interface{}:
+		destValue, ok := dest.([]interface{})
+		if !ok {
+			return dest
+		}
+		projected := make([]interface{}, 0, len(srcValue))
+		for i, v := range srcValue {
+			if isEmptyContent(v) {
+				projected = append(projected, v)
+				continue
+			}
+			// Check the item at the same index first since lists are
+			// usually in the same order
+			var match interface{}
+			if i < len(destValue) {
+				if p := projectContent(v, destValue[i]); reflect.DeepEqual(p, v) {
+					match = p
+				}
+			}
+			for j := 0; match == nil && j < len(destValue); j++ {
+				if p := projectContent(v, destValue[j]); reflect.DeepEqual(p, v) {
+					match = p
+				}
+			}
+			if match == nil {
+				return dest
+			}
+			projected = append(projected, match)
+		}
+		return projected
+	}
+	return dest
+}
+
+func isEmptyContent(content interface{}) bool {
+	switch value := content.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// isResourceMigrated returns true if the resource exists on the destination
+// cluster after the migration
+func isResourceMigrated(resource *stork_api.MigrationResourceInfo) bool {
+	return resource.Status == stork_api.MigrationStatusSuccessful ||
+		resource.Status == stork_api.MigrationStatusSkipped ||
+		resource.Status == stork_api.MigrationStatusDrifted
+}
+
+func setVerifyStatus(
+	resource *stork_api.MigrationResourceInfo,
+	status stork_api.MigrationStatusType,
+	reason string,
+) {
+	resource.VerifyStatus = status
+	resource.VerifyReason = reason
+}
+
+// getResourceInfoKey returns the key used for the state of the resource, same
+// as getResourceKey
+func getResourceInfoKey(resource *stork_api.MigrationResourceInfo) string {
+	group := resource.Group
+	// The core group is stored as "core" in the status
+	if group == "core" {
+		group = ""
+	}
+	return strings.Join([]string{group, resource.Version, resource.Kind, resource.Namespace, resource.Name}, "/")
+}
+
+// getRemoteResourceClient returns the dynamic client for the resource on the
+// destination cluster. Cluster scoped resources use the admin client.
+func getRemoteResourceClient(
+	remoteInterface dynamic.Interface,
+	remoteAdminInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (dynamic.ResourceInterface, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	objectType, err := meta.TypeAccessor(object)
+	if err != nil {
+		return nil, err
+	}
+	gvr := object.GetObjectKind().GroupVersionKind().GroupVersion().WithResource(
+		inflect.Pluralize(strings.ToLower(objectType.GetKind())))
+	if len(metadata.GetNamespace()) > 0 {
+		return remoteInterface.Resource(gvr).Namespace(metadata.GetNamespace()), nil
+	}
+	return remoteAdminInterface.Resource(gvr), nil
+}
@@ -0,0 +1,278 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/stretchr/testify/require"
+	apps_api "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProjectContent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		src      interface{}
+		dest     interface{}
+		expected interface{}
+	}{
+		{
+			name:     "equal values",
+			src:      "a",
+			dest:     "a",
+			expected: "a",
+		},
+		{
+			name:     "different values",
+			src:      "a",
+			dest:     "b",
+			expected: "b",
+		},
+		{
+			name:     "empty source value is kept",
+			src:      "",
+			dest:     "defaulted",
+			expected: "",
+		},
+		{
+			name: "fields only set on destination are dropped",
+			src:  map[string]interface{}{"a": "1"},
+			dest: map[string]interface{}{
+				"a": "1",
+				"b": "2",
+			},
+			expected: map[string]interface{}{"a": "1"},
+		},
+		{
+			name: "empty source fields missing on destination are kept",
+			src: map[string]interface{}{
+				"a": "1",
+				"b": map[string]interface{}{},
+			},
+			dest:     map[string]interface{}{"a": "1"},
+			expected: map[string]interface{}{"a": "1", "b": map[string]interface{}{}},
+		},
+		{
+			name:     "fields missing on destination are dropped",
+			src:      map[string]interface{}{"a": "1", "b": "2"},
+			dest:     map[string]interface{}{"a": "1"},
+			expected: map[string]interface{}{"a": "1"},
+		},
+		{
+			name:     "nested maps are projected",
+			src:      map[string]interface{}{"spec": map[string]interface{}{"a": "1"}},
+			dest:     map[string]interface{}{"spec": map[string]interface{}{"a": "1", "b": "2"}},
+			expected: map[string]interface{}{"spec": map[string]interface{}{"a": "1"}},
+		},
+		{
+			name:     "type mismatch returns destination",
+			src:      map[string]interface{}{"a": "1"},
+			dest:     "a",
+			expected: "a",
+		},
+		{
+			name: "list items are matched in any order",
+			src: []interface{}{
+				map[string]interface{}{"name": "a"},
+				map[string]interface{}{"name": "b"},
+			},
+			dest: []interface{}{
+				map[string]interface{}{"name": "b", "default": "x"},
+				map[string]interface{}{"name": "a", "default": "y"},
+			},
+			expected: []interface{}{
+				map[string]interface{}{"name": "a"},
+				map[string]interface{}{"name": "b"},
+			},
+		},
+		{
+			name: "additional list items on destination are ignored",
+			src:  []interface{}{"a"},
+			dest: []interface{}{"b", "a"},
+			expected: []interface{}{
+				"a",
+			},
+		},
+		{
+			name: "missing list item returns destination",
+			src:  []interface{}{"a", "c"},
+			dest: []interface{}{"a", "b"},
+			expected: []interface{}{
+				"a",
+				"b",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, projectContent(tc.src, tc.dest), tc.name)
+	}
+}
+
+func newVerifyObject(kind string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":            "test",
+				"namespace":       "ns1",
+				"resourceVersion": "1",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestGetVerifyHashes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		src   *unstructured.Unstructured
+		dest  *unstructured.Unstructured
+		match bool
+	}{
+		{
+			name:  "same spec",
+			src:   newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"}),
+			dest:  newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"}),
+			match: true,
+		},
+		{
+			name:  "defaults on destination",
+			src:   newVerifyObject("Service", map[string]interface{}{"type": "ClusterIP"}),
+			dest:  newVerifyObject("Service", map[string]interface{}{"type": "ClusterIP", "clusterIP": "10.0.0.1"}),
+			match: true,
+		},
+		{
+			name:  "different value",
+			src:   newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"}),
+			dest:  newVerifyObject("ConfigMap", map[string]interface{}{"a": "2"}),
+			match: false,
+		},
+		{
+			name:  "missing on destination",
+			src:   newVerifyObject("ConfigMap", map[string]interface{}{"a": "1", "b": "2"}),
+			dest:  newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"}),
+			match: false,
+		},
+		{
+			name: "metadata and status are ignored",
+			src:  newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"}),
+			dest: func() *unstructured.Unstructured {
+				dest := newVerifyObject("ConfigMap", map[string]interface{}{"a": "1"})
+				dest.SetResourceVersion("2")
+				dest.Object["status"] = map[string]interface{}{"phase": "Bound"}
+				return dest
+			}(),
+			match: true,
+		},
+		{
+			name: "PV claimRef is ignored",
+			src: newVerifyObject("PersistentVolume", map[string]interface{}{
+				"claimRef": map[string]interface{}{"uid": "src"},
+			}),
+			dest: newVerifyObject("PersistentVolume", map[string]interface{}{
+				"claimRef": map[string]interface{}{"uid": "dest"},
+			}),
+			match: true,
+		},
+	}
+	for _, tc := range testCases {
+		srcHash, destHash, err := getVerifyHashes(tc.src, tc.dest)
+		require.NoError(t, err, tc.name)
+		require.NotEmpty(t, srcHash, tc.name)
+		if tc.match {
+			require.Equal(t, srcHash, destHash, tc.name)
+		} else {
+			require.NotEqual(t, srcHash, destHash, tc.name)
+		}
+	}
+}
+
+func TestIsPVCBound(t *testing.T) {
+	testCases := []struct {
+		name      string
+		phase     string
+		volume    string
+		bound     bool
+		expectErr bool
+	}{
+		{name: "pending", phase: "Pending", volume: "", bound: false},
+		{name: "bound", phase: "Bound", volume: "pv1", bound: true},
+		{name: "bound to other volume", phase: "Bound", volume: "pv2", bound: false, expectErr: true},
+	}
+	src := newVerifyObject("PersistentVolumeClaim", map[string]interface{}{"volumeName": "pv1"})
+	for _, tc := range testCases {
+		dest := newVerifyObject("PersistentVolumeClaim", map[string]interface{}{"volumeName": tc.volume})
+		dest.Object["status"] = map[string]interface{}{"phase": tc.phase}
+		bound, err := isPVCBound(src, dest)
+		require.Equal(t, tc.expectErr, err != nil, tc.name)
+		require.Equal(t, tc.bound, bound, tc.name)
+	}
+}
+
+func TestIsApplicationReady(t *testing.T) {
+	replicas := int32(2)
+	deployment := &apps_api.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:       "app",
+			Namespace:  "ns1",
+			Generation: 2,
+		},
+		Spec: apps_api.DeploymentSpec{
+			Replicas: &replicas,
+		},
+	}
+	fakeKubeClient := kubernetes.NewSimpleClientset(deployment)
+	k8s.Instance().SetClient(fakeKubeClient, nil, fakeclient.NewSimpleClientset(), nil, nil, nil, nil, nil)
+	app := &stork_api.MigrationResourceInfo{
+		Name:      "app",
+		Namespace: "ns1",
+		GroupVersionKind: meta.GroupVersionKind{
+			Kind: "Deployment",
+		},
+	}
+
+	ready, err := isApplicationReady(k8s.Instance(), app)
+	require.NoError(t, err, "Error checking deployment")
+	require.False(t, ready, "Deployment without available replicas shouldn't be ready")
+
+	deployment.Status = apps_api.DeploymentStatus{
+		ObservedGeneration: 2,
+		UpdatedReplicas:    2,
+		AvailableReplicas:  2,
+	}
+	_, err = fakeKubeClient.AppsV1().Deployments("ns1").Update(deployment)
+	require.NoError(t, err, "Error updating deployment")
+	ready, err = isApplicationReady(k8s.Instance(), app)
+	require.NoError(t, err, "Error checking deployment")
+	require.True(t, ready, "Deployment with available replicas should be ready")
+
+	app.Kind = "DaemonSet"
+	_, err = isApplicationReady(k8s.Instance(), app)
+	require.Error(t, err, "Unsupported kinds should return an error")
+}
+
+func TestIsVerifyPending(t *testing.T) {
+	migration := &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{
+			ClusterPair:  "pair1",
+			ClusterPairs: []string{"pair2"},
+		},
+		Status: stork_api.MigrationStatus{
+			Resources: []*stork_api.MigrationResourceInfo{
+				{Name: "cm", VerifyStatus: stork_api.MigrationStatusSuccessful, ClusterPair: "pair1"},
+				{Name: "pvc", VerifyStatus: stork_api.MigrationStatusInProgress, ClusterPair: "pair2"},
+			},
+		},
+	}
+	require.True(t, isVerifyPending(migration), "Verification should be pending for in progress resources")
+
+	failDestination(migration, "pair2", "failed")
+	require.False(t, isVerifyPending(migration), "Resources on failed destinations shouldn't be pending")
+}
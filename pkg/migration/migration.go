@@ -11,18 +11,20 @@ import (
 
 // Migration migration
 type Migration struct {
-	Driver                      volume.Driver
+	Drivers                     []volume.Driver
 	Recorder                    record.EventRecorder
 	ResourceCollector           resourcecollector.ResourceCollector
+	MaxConcurrentMigrations     int
 	clusterPairController       *controllers.ClusterPairController
 	migrationController         *controllers.MigrationController
 	migrationScheduleController *controllers.MigrationScheduleController
+	failoverController          *controllers.FailoverController
 }
 
 // Init init
 func (m *Migration) Init(migrationAdminNamespace string) error {
 	m.clusterPairController = &controllers.ClusterPairController{
-		Driver:   m.Driver,
+		Drivers:  m.Drivers,
 		Recorder: m.Recorder,
 	}
 	err := m.clusterPairController.Init()
@@ -31,21 +33,30 @@ func (m *Migration) Init(migrationAdminNamespace string) error {
 	}
 
 	m.migrationController = &controllers.MigrationController{
-		Driver:            m.Driver,
-		Recorder:          m.Recorder,
-		ResourceCollector: m.ResourceCollector,
+		Drivers:                 m.Drivers,
+		Recorder:                m.Recorder,
+		ResourceCollector:       m.ResourceCollector,
+		MaxConcurrentMigrations: m.MaxConcurrentMigrations,
 	}
 	err = m.migrationController.Init(migrationAdminNamespace)
 	if err != nil {
 		return fmt.Errorf("error initializing migration controller: %v", err)
 	}
 	m.migrationScheduleController = &controllers.MigrationScheduleController{
-		Driver:   m.Driver,
+		Drivers:  m.Drivers,
 		Recorder: m.Recorder,
 	}
 	err = m.migrationScheduleController.Init()
 	if err != nil {
 		return fmt.Errorf("error initializing migration schedule controller: %v", err)
 	}
+	m.failoverController = &controllers.FailoverController{
+		Drivers:  m.Drivers,
+		Recorder: m.Recorder,
+	}
+	err = m.failoverController.Init(migrationAdminNamespace)
+	if err != nil {
+		return fmt.Errorf("error initializing failover controller: %v", err)
+	}
 	return nil
 }
@@ -2,10 +2,13 @@ package monitor
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	storklog "github.com/libopenstorage/stork/pkg/log"
 	"github.com/portworx/sched-ops/k8s"
 	log "github.com/sirupsen/logrus"
@@ -14,6 +17,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/scheduler/algorithm"
 	"k8s.io/kubernetes/pkg/util/node"
 )
@@ -21,16 +26,56 @@ import (
 const (
 	defaultIntervalSec = 120
 	minimumIntervalSec = 30
+
+	// annotation on a namespace to disable fencing of the pods in it
+	disableFencingAnnotation = "stork.libopenstorage.org/disableFencing"
+
+	fencePodEventReason     = "FencingPod"
+	fenceNodeEventReason    = "FencingNode"
+	fenceSkippedEventReason = "FencingSkipped"
 )
 
 // Monitor Storage driver monitor
 type Monitor struct {
-	Driver      volume.Driver
+	Drivers     []volume.Driver
 	IntervalSec int64
-	lock        sync.Mutex
-	started     bool
-	stopChannel chan int
-	done        chan int
+	// Recorder is used to record events for the actions taken by the monitor
+	Recorder record.EventRecorder
+	// Policy is the initial fencing policy. Values that aren't set use the
+	// defaults.
+	Policy FencingPolicy
+	// ConfigMapName is the name of an optional config map that can be used
+	// to update the fencing policy at runtime
+	ConfigMapName string
+	// ConfigMapNamespace is the namespace of the config map
+	ConfigMapNamespace string
+	// KubeClient is used to keep an index of the pods on each node. If it
+	// isn't set all the pods in the cluster are listed when a storage node
+	// fails.
+	KubeClient kubernetes.Interface
+
+	lock         sync.Mutex
+	started      bool
+	watchStarted bool
+	stopChannel  chan struct{}
+	done         chan int
+	policyLock   sync.RWMutex
+	policy       FencingPolicy
+	podIndex     *podIndex
+	// checkLock serializes the checks for the storage nodes, since they can
+	// be triggered both by the interval and by the node status watches
+	checkLock sync.Mutex
+	// failures has the storage nodes that are currently failing, keyed by
+	// the driver name and storage ID
+	failures       map[string]*nodeFailure
+	evictionLock   sync.Mutex
+	evictionsCount int
+}
+
+// nodeFailure tracks the failed checks for a storage node
+type nodeFailure struct {
+	since time.Time
+	count int
 }
 
 // Start Starts the monitor
@@ -48,20 +93,114 @@ func (m *Monitor) Start() error {
 		return fmt.Errorf("minimum interval for health monitor is %v seconds", minimumIntervalSec)
 	}
 
-	m.stopChannel = make(chan int)
+	policy := m.Policy.withDefaults()
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	if m.ConfigMapName != "" {
+		cm, err := k8s.Instance().GetConfigMap(m.ConfigMapName, m.ConfigMapNamespace)
+		if err == nil {
+			if policy, err = policy.updateFromConfigMap(cm); err != nil {
+				return fmt.Errorf("error parsing health monitor config map %v/%v: %v",
+					m.ConfigMapNamespace, m.ConfigMapName, err)
+			}
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("error getting health monitor config map %v/%v: %v",
+				m.ConfigMapNamespace, m.ConfigMapName, err)
+		}
+	}
+	m.setPolicy(policy)
+	m.failures = make(map[string]*nodeFailure)
+
+	m.stopChannel = make(chan struct{})
 	m.done = make(chan int)
 
+	if m.KubeClient != nil {
+		m.podIndex = newPodIndex(m.KubeClient)
+		if err := m.podIndex.start(m.stopChannel); err != nil {
+			close(m.stopChannel)
+			return err
+		}
+	}
+
 	if err := m.podMonitor(); err != nil {
+		close(m.stopChannel)
 		return err
 	}
 
+	for _, driver := range m.Drivers {
+		nodeChannel, err := driver.WatchNodeStatus(m.stopChannel)
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+				log.Debugf("Driver %v doesn't support watching node status, relying on interval checks",
+					driver.String())
+			} else {
+				log.Errorf("Error watching node status for driver %v, relying on interval checks: %v",
+					driver.String(), err)
+			}
+			continue
+		}
+		go m.watchDriverNodes(driver, nodeChannel)
+	}
+
 	go m.driverMonitor()
 
+	if m.ConfigMapName != "" && !m.watchStarted {
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.ConfigMapName,
+				Namespace: m.ConfigMapNamespace,
+			},
+		}
+		if err := k8s.Instance().WatchConfigMap(cm, m.reloadPolicy); err != nil {
+			log.Errorf("Failed to watch health monitor config map %v/%v: %v",
+				m.ConfigMapNamespace, m.ConfigMapName, err)
+		} else {
+			m.watchStarted = true
+		}
+	}
+
 	m.started = true
 
 	return nil
 }
 
+func (m *Monitor) getPolicy() FencingPolicy {
+	m.policyLock.RLock()
+	defer m.policyLock.RUnlock()
+	return m.policy
+}
+
+func (m *Monitor) setPolicy(policy FencingPolicy) {
+	m.policyLock.Lock()
+	defer m.policyLock.Unlock()
+	m.policy = policy
+}
+
+// reloadPolicy is called when the config map is updated. The config map is
+// fetched again so that the defaults are restored if it has been deleted.
+func (m *Monitor) reloadPolicy(object runtime.Object) error {
+	policy := m.Policy.withDefaults()
+	cm, err := k8s.Instance().GetConfigMap(m.ConfigMapName, m.ConfigMapNamespace)
+	if err == nil {
+		if policy, err = policy.updateFromConfigMap(cm); err != nil {
+			log.Errorf("Error parsing health monitor config map %v/%v, keeping existing policy: %v",
+				m.ConfigMapNamespace, m.ConfigMapName, err)
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		log.Errorf("Error getting health monitor config map %v/%v: %v",
+			m.ConfigMapNamespace, m.ConfigMapName, err)
+		return err
+	}
+
+	if m.getPolicy() != policy {
+		log.Infof("Updating health monitor fencing policy to %+v", policy)
+		m.setPolicy(policy)
+	}
+	return nil
+}
+
 // Stop Stops the monitor
 func (m *Monitor) Stop() error {
 	m.lock.Lock()
@@ -119,29 +258,30 @@ func (m *Monitor) podMonitor() error {
 		}
 
 		if podUnknownState {
-			owns, err := m.doesDriverOwnPodVolumes(pod)
+			owns, err := m.doesAnyDriverOwnPodVolumes(pod)
 			if err != nil || !owns {
 				return nil
 			}
+			if m.isFencingDisabled(pod) {
+				return nil
+			}
 
-			storklog.PodLog(pod).Infof("Force deleting pod as it's in unknown state.")
+			policy := m.getPolicy()
+			if !m.allowEviction(policy) {
+				m.recordPodEvent(pod, v1.EventTypeWarning, fenceSkippedEventReason,
+					fmt.Sprintf("Not deleting pod %v in unknown state, limit of %v evictions per interval reached",
+						pod.Name, policy.MaxEvictionsPerInterval))
+				return nil
+			}
 
 			// delete volume attachments if the node is down for this pod
-			err = m.cleanupVolumeAttachmentsByPod(pod)
+			err = m.cleanupVolumeAttachmentsByPod(pod, policy.DryRun)
 			if err != nil {
 				storklog.PodLog(pod).Errorf("Error cleaning up volume attachments: %v", err)
 			}
 
 			// force delete the pod
-			err = k8s.Instance().DeletePods([]v1.Pod{*pod}, true)
-			if err != nil {
-				if errors.IsNotFound(err) {
-					return nil
-				}
-
-				storklog.PodLog(pod).Errorf("Error deleting pod: %v", err)
-				return err
-			}
+			return m.deletePod(pod, policy, "it is in unknown state")
 		}
 
 		return nil
@@ -161,46 +301,9 @@ func (m *Monitor) driverMonitor() {
 	for {
 		select {
 		default:
-			log.Debugf("Monitoring storage nodes")
-			nodes, err := m.Driver.GetNodes()
-			if err != nil {
-				log.Errorf("Error getting nodes: %v", err)
-				time.Sleep(2 * time.Second)
-			}
-			nodes = volume.RemoveDuplicateOfflineNodes(nodes)
-			for _, node := range nodes {
-				// Check if nodes are reported online by the storage driver
-				// If not online, look at all the pods on that node
-				// For any Running pod on that node using volume by the driver, kill the pod
-				if node.Status != volume.NodeOnline {
-					pods, err := k8s.Instance().GetPods("", nil)
-					if err != nil {
-						log.Errorf("Error getting pods: %v", err)
-						continue
-					}
-
-					// delete volume attachments if the node is down for this pod
-					err = m.cleanupVolumeAttachmentsByNode(node)
-					if err != nil {
-						log.Errorf("Error cleaning up volume attachments: %v", err)
-					}
-
-					for _, pod := range pods.Items {
-						owns, err := m.doesDriverOwnPodVolumes(&pod)
-						if err != nil || !owns {
-							continue
-						}
-
-						if m.isSameNode(pod.Spec.NodeName, node) {
-							storklog.PodLog(&pod).Infof("Deleting Pod from Node: %v", pod.Spec.NodeName)
-							err = k8s.Instance().DeletePods([]v1.Pod{pod}, true)
-							if err != nil {
-								storklog.PodLog(&pod).Errorf("Error deleting pod: %v", err)
-								continue
-							}
-						}
-					}
-				}
+			m.resetEvictions()
+			for _, driver := range m.Drivers {
+				m.monitorDriverNodes(driver)
 			}
 			time.Sleep(time.Duration(m.IntervalSec) * time.Second)
 		case <-m.stopChannel:
@@ -209,22 +312,345 @@ func (m *Monitor) driverMonitor() {
 	}
 }
 
-func (m *Monitor) doesDriverOwnPodVolumes(pod *v1.Pod) (bool, error) {
-	volumes, err := m.Driver.GetPodVolumes(&pod.Spec, pod.Namespace)
+// watchDriverNodes checks the storage nodes for the driver whenever the status
+// of one of them changes, so that pods are fenced without waiting for the
+// next interval
+func (m *Monitor) watchDriverNodes(driver volume.Driver, nodeChannel <-chan *volume.NodeInfo) {
+	for node := range nodeChannel {
+		log.Debugf("Status for storage node %v for driver %v changed to %v",
+			node.StorageID, driver.String(), node.Status)
+		if node.Status == volume.NodeOnline && !m.isNodeFailing(driver, node) {
+			continue
+		}
+		m.monitorDriverNodes(driver)
+	}
+	log.Debugf("Stopped watching node status for driver %v", driver.String())
+}
+
+// isNodeFailing returns true if the storage node was failing in the last check
+func (m *Monitor) isNodeFailing(driver volume.Driver, node *volume.NodeInfo) bool {
+	m.checkLock.Lock()
+	defer m.checkLock.Unlock()
+	_, ok := m.failures[driver.String()+"/"+node.StorageID]
+	return ok
+}
+
+// scheduleCheck checks the storage nodes for the driver again after the
+// delay, unless the monitor has been stopped
+func (m *Monitor) scheduleCheck(driver volume.Driver, delay time.Duration) {
+	stopChannel := m.stopChannel
+	if stopChannel == nil {
+		return
+	}
+	time.AfterFunc(delay, func() {
+		select {
+		case <-stopChannel:
+		default:
+			m.monitorDriverNodes(driver)
+		}
+	})
+}
+
+func (m *Monitor) monitorDriverNodes(driver volume.Driver) {
+	m.checkLock.Lock()
+	defer m.checkLock.Unlock()
+
+	log.Debugf("Monitoring storage nodes for driver %v", driver.String())
+	nodes, err := driver.GetNodes()
+	if err != nil {
+		log.Errorf("Error getting nodes for driver %v: %v", driver.String(), err)
+		return
+	}
+	policy := m.getPolicy()
+	nodes = removeDuplicateFailedNodes(nodes, policy)
+	failedNodes := make(map[string]bool)
+	for _, node := range nodes {
+		// Check if nodes are reported online by the storage driver
+		// If not online, look at all the pods on that node
+		// For any Running pod on that node using volume by the driver, kill the pod
+		if !isNodeFailed(node, policy) {
+			continue
+		}
+		key := driver.String() + "/" + node.StorageID
+		failedNodes[key] = true
+		failure, ok := m.failures[key]
+		if !ok {
+			failure = &nodeFailure{since: time.Now()}
+			m.failures[key] = failure
+		}
+		failure.count++
+		if failure.count < policy.FailureThreshold ||
+			time.Since(failure.since) < policy.GracePeriod {
+			log.Infof("Storage node %v for driver %v is %v since %v (%v failed checks), not fencing pods yet",
+				node.StorageID, driver.String(), node.Status, failure.since, failure.count)
+			if failure.count >= policy.FailureThreshold {
+				// Check again once the grace period has passed instead of
+				// waiting for the next interval
+				m.scheduleCheck(driver, policy.GracePeriod-time.Since(failure.since))
+			}
+			continue
+		}
+		m.fenceNode(driver, node, policy)
+	}
+
+	// Forget about nodes that have recovered
+	for key := range m.failures {
+		if strings.HasPrefix(key, driver.String()+"/") && !failedNodes[key] {
+			delete(m.failures, key)
+		}
+	}
+}
+
+// isNodeFailed returns true if the storage driver isn't healthy on the node
+func isNodeFailed(node *volume.NodeInfo, policy FencingPolicy) bool {
+	if node.Status == volume.NodeOnline {
+		return false
+	}
+	return node.Status != volume.NodeDegraded || !policy.IgnoreDegradedNodes
+}
+
+// removeDuplicateFailedNodes removes failed nodes that have the same IP as a
+// healthy node, since they have been replaced by the healthy node
+func removeDuplicateFailedNodes(nodes []*volume.NodeInfo, policy FencingPolicy) []*volume.NodeInfo {
+	healthyIPs := make(map[string]bool)
+	for _, node := range nodes {
+		if !isNodeFailed(node, policy) {
+			for _, ip := range node.IPs {
+				healthyIPs[ip] = true
+			}
+		}
+	}
+
+	updatedNodes := make([]*volume.NodeInfo, 0)
+	for _, node := range nodes {
+		duplicate := false
+		if isNodeFailed(node, policy) {
+			for _, ip := range node.IPs {
+				if healthyIPs[ip] {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if !duplicate {
+			updatedNodes = append(updatedNodes, node)
+		}
+	}
+	return updatedNodes
+}
+
+// fenceNode deletes the volume attachments and pods using the driver's
+// volumes on a failed storage node
+func (m *Monitor) fenceNode(driver volume.Driver, node *volume.NodeInfo, policy FencingPolicy) {
+	k8sNodes, err := m.getMatchingNodes(node)
+	if err != nil {
+		log.Errorf("Error getting nodes: %v", err)
+		return
+	}
+	pods, err := m.getPodsOnNodes(node, k8sNodes)
+	if err != nil {
+		log.Errorf("Error getting pods: %v", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Storage driver %v is %v on node, fencing pods using its volumes",
+		driver.String(), node.Status)
+	if policy.DryRun {
+		msg = "Dry run: " + msg
+	}
+	m.recordNodeEvent(k8sNodes, v1.EventTypeWarning, fenceNodeEventReason, msg)
+
+	for _, pod := range pods {
+		owns, err := m.doesDriverOwnPodVolumes(driver, pod)
+		if err != nil || !owns {
+			continue
+		}
+
+		if m.isFencingDisabled(pod) {
+			continue
+		}
+		if !m.allowEviction(policy) {
+			m.recordPodEvent(pod, v1.EventTypeWarning, fenceSkippedEventReason,
+				fmt.Sprintf("Not deleting pod %v, limit of %v evictions per interval reached",
+					pod.Name, policy.MaxEvictionsPerInterval))
+			continue
+		}
+		// Only delete the volume attachments for pods that are being
+		// evicted, so that pods that were skipped keep their volumes
+		if err := m.cleanupVolumeAttachmentsForPod(driver, pod, policy.DryRun); err != nil {
+			storklog.PodLog(pod).Errorf("Error cleaning up volume attachments: %v", err)
+		}
+		reason := fmt.Sprintf("storage driver %v is %v on node %v", driver.String(), node.Status, pod.Spec.NodeName)
+		if err := m.deletePod(pod, policy, reason); err != nil {
+			continue
+		}
+	}
+}
+
+// getMatchingNodes returns the Kubernetes nodes that match the storage node
+func (m *Monitor) getMatchingNodes(driverNode *volume.NodeInfo) ([]*v1.Node, error) {
+	nodes, err := k8s.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	matchingNodes := make([]*v1.Node, 0)
+	for _, node := range nodes.Items {
+		if node.Name == driverNode.Hostname || volume.IsNodeMatch(&node, driverNode) {
+			matchingNodes = append(matchingNodes, node.DeepCopy())
+		}
+	}
+	return matchingNodes, nil
+}
+
+// getPodsOnNodes returns the pods scheduled on the Kubernetes nodes. The pod
+// index is used if it has been started, otherwise all the pods in the cluster
+// are listed and checked against the storage node.
+func (m *Monitor) getPodsOnNodes(driverNode *volume.NodeInfo, k8sNodes []*v1.Node) ([]*v1.Pod, error) {
+	pods := make([]*v1.Pod, 0)
+	if m.podIndex != nil {
+		for _, node := range k8sNodes {
+			nodePods, err := m.podIndex.getPodsOnNode(node.Name)
+			if err != nil {
+				return nil, err
+			}
+			for _, pod := range nodePods {
+				pods = append(pods, pod.DeepCopy())
+			}
+		}
+		return pods, nil
+	}
+
+	podList, err := k8s.Instance().GetPods("", nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range podList.Items {
+		if m.isSameNode(podList.Items[i].Spec.NodeName, driverNode) {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// deletePod force deletes the pod and records events for the pod and its
+// node. Only the events are recorded for a dry run.
+func (m *Monitor) deletePod(pod *v1.Pod, policy FencingPolicy, reason string) error {
+	if policy.DryRun {
+		msg := fmt.Sprintf("Dry run: would have force deleted pod %v since %v", pod.Name, reason)
+		storklog.PodLog(pod).Info(msg)
+		m.recordPodEvent(pod, v1.EventTypeNormal, fencePodEventReason, msg)
+		return nil
+	}
+
+	msg := fmt.Sprintf("Force deleting pod %v since %v", pod.Name, reason)
+	storklog.PodLog(pod).Info(msg)
+	m.recordPodEvent(pod, v1.EventTypeWarning, fencePodEventReason, msg)
+	if err := k8s.Instance().DeletePods([]v1.Pod{*pod}, true); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		storklog.PodLog(pod).Errorf("Error deleting pod: %v", err)
+		return err
+	}
+	return nil
+}
+
+// isFencingDisabled returns true if fencing has been disabled for the
+// namespace of the pod
+func (m *Monitor) isFencingDisabled(pod *v1.Pod) bool {
+	ns, err := k8s.Instance().GetNamespace(pod.Namespace)
+	if err != nil {
+		storklog.PodLog(pod).Debugf("Error getting namespace for pod: %v", err)
+		return false
+	}
+	if value, ok := ns.Annotations[disableFencingAnnotation]; ok {
+		if disabled, err := strconv.ParseBool(value); err == nil && disabled {
+			storklog.PodLog(pod).Infof("Not fencing pod since fencing is disabled for namespace %v", ns.Name)
+			m.recordPodEvent(pod, v1.EventTypeNormal, fenceSkippedEventReason,
+				fmt.Sprintf("Not fencing pod %v since fencing is disabled for namespace %v", pod.Name, ns.Name))
+			return true
+		}
+	}
+	return false
+}
+
+// allowEviction returns false if the maximum number of pods have already been
+// evicted in the current interval
+func (m *Monitor) allowEviction(policy FencingPolicy) bool {
+	m.evictionLock.Lock()
+	defer m.evictionLock.Unlock()
+	if policy.MaxEvictionsPerInterval > 0 && m.evictionsCount >= policy.MaxEvictionsPerInterval {
+		return false
+	}
+	m.evictionsCount++
+	return true
+}
+
+func (m *Monitor) resetEvictions() {
+	m.evictionLock.Lock()
+	defer m.evictionLock.Unlock()
+	m.evictionsCount = 0
+}
+
+// recordPodEvent records an event for the pod and the node it is running on
+func (m *Monitor) recordPodEvent(pod *v1.Pod, eventType, reason, msg string) {
+	if m.Recorder == nil {
+		return
+	}
+	m.Recorder.Event(pod, eventType, reason, msg)
+	if pod.Spec.NodeName == "" {
+		return
+	}
+	node, err := k8s.Instance().GetNodeByName(pod.Spec.NodeName)
+	if err != nil {
+		log.Warnf("Error getting node %v to record event: %v", pod.Spec.NodeName, err)
+		return
+	}
+	m.Recorder.Event(node, eventType, reason, msg)
+}
+
+// recordNodeEvent records an event for the Kubernetes nodes that match the
+// storage node
+func (m *Monitor) recordNodeEvent(nodes []*v1.Node, eventType, reason, msg string) {
+	if m.Recorder == nil {
+		return
+	}
+	for _, node := range nodes {
+		m.Recorder.Event(node, eventType, reason, msg)
+	}
+}
+
+func (m *Monitor) doesAnyDriverOwnPodVolumes(pod *v1.Pod) (bool, error) {
+	var lastErr error
+	for _, driver := range m.Drivers {
+		owns, err := m.doesDriverOwnPodVolumes(driver, pod)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if owns {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
+func (m *Monitor) doesDriverOwnPodVolumes(driver volume.Driver, pod *v1.Pod) (bool, error) {
+	volumes, err := driver.GetPodVolumes(&pod.Spec, pod.Namespace)
 	if err != nil {
 		storklog.PodLog(pod).Errorf("Error getting volumes for pod: %v", err)
 		return false, err
 	}
 
 	if len(volumes) == 0 {
-		storklog.PodLog(pod).Debugf("Pod doesn't have any volumes by driver")
+		storklog.PodLog(pod).Debugf("Pod doesn't have any volumes by driver %v", driver.String())
 		return false, nil
 	}
 
 	return true, nil
 }
 
-func (m *Monitor) doesDriverOwnVolumeAttachment(va *storagev1beta1.VolumeAttachment) (bool, error) {
+func (m *Monitor) doesDriverOwnVolumeAttachment(driver volume.Driver, va *storagev1beta1.VolumeAttachment) (bool, error) {
 	pv, err := k8s.Instance().GetPersistentVolume(*va.Spec.Source.PersistentVolumeName)
 	if err != nil {
 		log.Errorf("Error getting persistent volume from volume attachment: %v", err)
@@ -237,62 +663,81 @@ func (m *Monitor) doesDriverOwnVolumeAttachment(va *storagev1beta1.VolumeAttachm
 		return false, err
 	}
 
-	return m.Driver.OwnsPVC(pvc), nil
+	return driver.OwnsPVC(pvc), nil
 }
 
-func (m *Monitor) cleanupVolumeAttachmentsByPod(pod *v1.Pod) error {
-	log.Infof("Cleaning up volume attachments for pod %s", pod.Name)
+// cleanupVolumeAttachmentsByPod deletes the volume attachments for the PVCs
+// used by the pod for all the drivers
+func (m *Monitor) cleanupVolumeAttachmentsByPod(pod *v1.Pod, dryRun bool) error {
+	for _, driver := range m.Drivers {
+		if err := m.cleanupVolumeAttachmentsForPod(driver, pod, dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupVolumeAttachmentsForPod deletes the volume attachments on the node
+// of the pod for the PVCs used by the pod that are owned by the driver
+func (m *Monitor) cleanupVolumeAttachmentsForPod(driver volume.Driver, pod *v1.Pod, dryRun bool) error {
+	claims := make(map[string]bool)
+	for _, podVolume := range pod.Spec.Volumes {
+		if podVolume.PersistentVolumeClaim != nil {
+			claims[podVolume.PersistentVolumeClaim.ClaimName] = true
+		}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+	storklog.PodLog(pod).Infof("Cleaning up volume attachments for pod %s", pod.Name)
 
-	// Get all vol attachments
 	vaList, err := k8s.Instance().ListVolumeAttachments()
 	if err != nil {
 		return err
 	}
-
-	if len(vaList.Items) > 0 {
-		for _, va := range vaList.Items {
-			// Delete attachments for this pod
-			if pod.Spec.NodeName == va.Spec.NodeName {
-				err := k8s.Instance().DeleteVolumeAttachment(va.Name)
-				if err != nil {
-					return err
-				}
-
-				log.Infof("Deleted volume attachment: %s", va.Name)
-			}
+	for _, va := range vaList.Items {
+		if va.Spec.NodeName != pod.Spec.NodeName || va.Spec.Source.PersistentVolumeName == nil {
+			continue
+		}
+		pv, err := k8s.Instance().GetPersistentVolume(*va.Spec.Source.PersistentVolumeName)
+		if err != nil {
+			log.Errorf("Error getting persistent volume from volume attachment: %v", err)
+			continue
+		}
+		if pv.Spec.ClaimRef == nil ||
+			pv.Spec.ClaimRef.Namespace != pod.Namespace ||
+			!claims[pv.Spec.ClaimRef.Name] {
+			continue
+		}
+		owns, err := m.doesDriverOwnVolumeAttachment(driver, &va)
+		if err != nil || !owns {
+			continue
+		}
+		if err := m.deleteVolumeAttachment(&va, dryRun); err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
-func (m *Monitor) cleanupVolumeAttachmentsByNode(node *volume.NodeInfo) error {
-	log.Infof("Cleaning up volume attachments for node %s", node.StorageID)
-
-	// Get all vol attachments
-	vaList, err := k8s.Instance().ListVolumeAttachments()
-	if err != nil {
+// deleteVolumeAttachment deletes the volume attachment and records an event
+// for the node it was attached to. Only the event is recorded for a dry run.
+func (m *Monitor) deleteVolumeAttachment(va *storagev1beta1.VolumeAttachment, dryRun bool) error {
+	msg := fmt.Sprintf("Deleting volume attachment %v", va.Name)
+	if dryRun {
+		msg = fmt.Sprintf("Dry run: would have deleted volume attachment %v", va.Name)
+	} else if err := k8s.Instance().DeleteVolumeAttachment(va.Name); err != nil {
 		return err
 	}
+	log.Info(msg)
 
-	if len(vaList.Items) > 0 {
-		for _, va := range vaList.Items {
-			owns, err := m.doesDriverOwnVolumeAttachment(&va)
-			if err != nil || !owns {
-				continue
-			}
-
-			// Delete attachments for this pod
-			if m.isSameNode(va.Spec.NodeName, node) {
-				err := k8s.Instance().DeleteVolumeAttachment(va.Name)
-				if err != nil {
-					return err
-				}
-
-				log.Infof("Deleted volume attachment: %s", va.Name)
-			}
+	if m.Recorder != nil {
+		node, err := k8s.Instance().GetNodeByName(va.Spec.NodeName)
+		if err != nil {
+			log.Warnf("Error getting node %v to record event: %v", va.Spec.NodeName, err)
+			return nil
 		}
+		m.Recorder.Event(node, v1.EventTypeWarning, fenceNodeEventReason, msg)
 	}
-
 	return nil
 }
@@ -3,6 +3,7 @@
 package monitor
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -15,9 +16,11 @@ import (
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/scheduler/algorithm"
 	"k8s.io/kubernetes/pkg/util/node"
 )
@@ -33,6 +36,7 @@ var (
 	unknownPodsVolumeName = "unknownPodsVolume"
 
 	fakeStorkClient *fakeclient.Clientset
+	fakeKubeClient  *kubernetes.Clientset
 	driver          *mock.Driver
 	monitor         *Monitor
 	nodes           *v1.NodeList
@@ -47,7 +51,19 @@ func TestMonitor(t *testing.T) {
 	t.Run("testOfflineStorageNode", testOfflineStorageNode)
 	t.Run("testOfflineStorageNodeDuplicateIP", testOfflineStorageNodeDuplicateIP)
 	t.Run("testVolumeAttachmentCleanup", testVolumeAttachmentCleanup)
+	t.Run("testPodIndex", testPodIndex)
+	t.Run("testStorageNodeStatusWatch", testStorageNodeStatusWatch)
 	t.Run("teardown", teardown)
+	// The fencing tests run after the monitor has been stopped so that it
+	// doesn't act on the failed storage nodes in the background
+	t.Run("testFencingFailureThreshold", testFencingFailureThreshold)
+	t.Run("testFencingGracePeriod", testFencingGracePeriod)
+	t.Run("testFencingDryRun", testFencingDryRun)
+	t.Run("testFencingNamespaceOptOut", testFencingNamespaceOptOut)
+	t.Run("testFencingOptOutVolumeAttachment", testFencingOptOutVolumeAttachment)
+	t.Run("testFencingMaxEvictions", testFencingMaxEvictions)
+	t.Run("testFencingIgnoreDegraded", testFencingIgnoreDegraded)
+	t.Run("testFencingPolicyConfigMap", testFencingPolicyConfigMap)
 }
 
 func setup(t *testing.T) {
@@ -57,7 +73,7 @@ func setup(t *testing.T) {
 	require.NoError(t, err, "Error adding stork scheme")
 
 	fakeStorkClient = fakeclient.NewSimpleClientset()
-	fakeKubeClient := kubernetes.NewSimpleClientset()
+	fakeKubeClient = kubernetes.NewSimpleClientset()
 
 	k8s.Instance().SetClient(fakeKubeClient, nil, fakeStorkClient, nil, nil, nil, nil, nil)
 
@@ -104,8 +120,9 @@ func setup(t *testing.T) {
 	require.NoError(t, err, "Error provisioning volume")
 
 	monitor = &Monitor{
-		Driver:      storkdriver,
+		Drivers:     []volume.Driver{storkdriver},
 		IntervalSec: 30,
+		KubeClient:  fakeKubeClient,
 	}
 
 	err = monitor.Start()
@@ -440,3 +457,351 @@ func testVolumeAttachmentCleanup(t *testing.T) {
 	require.Error(t, err, "expected error from get pod as pod should be deleted")
 
 }
+
+// The pod index should be updated as pods are created and deleted
+func testPodIndex(t *testing.T) {
+	pod := newPod("podIndexPod", nil)
+	pod.Namespace = "pod-index"
+	_, err := k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+
+	waitFor(t, func() bool { return isPodIndexed(pod, nodeForPod) }, "pod should be added to the index")
+	require.False(t, isPodIndexed(pod, "node2.domain"), "pod shouldn't be indexed for other nodes")
+
+	err = k8s.Instance().DeletePod(pod.Name, pod.Namespace, true)
+	require.NoError(t, err, "failed to delete pod")
+	waitFor(t, func() bool { return !isPodIndexed(pod, nodeForPod) }, "pod should be removed from the index")
+}
+
+func isPodIndexed(pod *v1.Pod, nodeName string) bool {
+	pods, err := monitor.podIndex.getPodsOnNode(nodeName)
+	if err != nil {
+		return false
+	}
+	for _, indexedPod := range pods {
+		if indexedPod.Name == pod.Name && indexedPod.Namespace == pod.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Pods should be fenced as soon as the driver reports that the storage node
+// has gone offline instead of waiting for the next interval
+func testStorageNodeStatusWatch(t *testing.T) {
+	pod := newPod("statusWatchPod", []string{driverVolumeName})
+	pod.Namespace = "fencing-enabled"
+	_, err := k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+	waitFor(t, func() bool { return isPodIndexed(pod, nodeForPod) }, "pod should be added to the index")
+
+	err = driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	defer func() {
+		err = driver.UpdateNodeStatus(0, volume.NodeOnline)
+		require.NoError(t, err, "Error setting node status to Online")
+	}()
+
+	waitFor(t, func() bool { return !isPodPresent(pod) }, "pod should be deleted once the storage node goes offline")
+}
+
+// waitFor waits up to 5 seconds for the condition to be true
+func waitFor(t *testing.T, condition func() bool, msg string) {
+	for i := 0; i < 50; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.FailNow(t, msg)
+}
+
+func newFencingMonitor(policy FencingPolicy) (*Monitor, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+	m := &Monitor{
+		Drivers:  monitor.Drivers,
+		Recorder: recorder,
+		failures: make(map[string]*nodeFailure),
+	}
+	m.setPolicy(policy.withDefaults())
+	return m, recorder
+}
+
+func createFencingPod(t *testing.T, name string, namespace string) *v1.Pod {
+	pod := newPod(name, []string{driverVolumeName})
+	pod.Namespace = namespace
+	pod, err := k8s.Instance().CreatePod(pod)
+	require.NoError(t, err, "failed to create pod")
+	return pod
+}
+
+func isPodPresent(pod *v1.Pod) bool {
+	_, err := k8s.Instance().GetPodByName(pod.Name, pod.Namespace)
+	return err == nil
+}
+
+func cleanupFencingTest(t *testing.T, pods ...*v1.Pod) {
+	err := driver.UpdateNodeStatus(0, volume.NodeOnline)
+	require.NoError(t, err, "Error setting node status to Online")
+	for _, pod := range pods {
+		err := k8s.Instance().DeletePod(pod.Name, pod.Namespace, true)
+		if err != nil && !errors.IsNotFound(err) {
+			require.NoError(t, err, "failed to delete pod")
+		}
+	}
+}
+
+func requireEvent(t *testing.T, recorder *record.FakeRecorder, reason string, contains string) {
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.Contains(event, reason) && strings.Contains(event, contains) {
+				return
+			}
+		default:
+			require.FailNow(t, "Event not recorded", "reason: %v message: %v", reason, contains)
+		}
+	}
+}
+
+// Pods on an offline storage node should only be deleted once the node has
+// failed the configured number of checks, and the node should be forgotten
+// once it comes back online.
+func testFencingFailureThreshold(t *testing.T) {
+	m, recorder := newFencingMonitor(FencingPolicy{FailureThreshold: 2})
+	pod := createFencingPod(t, "fencingThresholdPod", "fencing-enabled")
+	defer cleanupFencingTest(t, pod)
+
+	err := driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(pod), "pod shouldn't be deleted after first failed check")
+
+	err = driver.UpdateNodeStatus(0, volume.NodeOnline)
+	require.NoError(t, err, "Error setting node status to Online")
+	m.monitorDriverNodes(driver)
+	require.Empty(t, m.failures, "failures should be reset once node is online")
+
+	err = driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(pod), "pod shouldn't be deleted after first failed check")
+	m.monitorDriverNodes(driver)
+	require.False(t, isPodPresent(pod), "pod should be deleted after second failed check")
+	requireEvent(t, recorder, fenceNodeEventReason, "Storage driver")
+	requireEvent(t, recorder, fencePodEventReason, "Force deleting pod "+pod.Name)
+}
+
+// Pods on an offline storage node should only be deleted once the grace
+// period has passed since the first failed check
+func testFencingGracePeriod(t *testing.T) {
+	m, recorder := newFencingMonitor(FencingPolicy{GracePeriod: 2 * time.Second})
+	pod := createFencingPod(t, "fencingGracePeriodPod", "fencing-enabled")
+	defer cleanupFencingTest(t, pod)
+
+	err := driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(pod), "pod shouldn't be deleted before grace period")
+
+	time.Sleep(2 * time.Second)
+	m.monitorDriverNodes(driver)
+	require.False(t, isPodPresent(pod), "pod should be deleted after grace period")
+	requireEvent(t, recorder, fencePodEventReason, "Force deleting pod "+pod.Name)
+}
+
+// Only events should be recorded in dry run mode
+func testFencingDryRun(t *testing.T) {
+	m, recorder := newFencingMonitor(FencingPolicy{DryRun: true})
+	pod := createFencingPod(t, "fencingDryRunPod", "fencing-enabled")
+	defer cleanupFencingTest(t, pod)
+
+	err := driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(pod), "pod shouldn't be deleted in dry run mode")
+	requireEvent(t, recorder, fenceNodeEventReason, "Dry run")
+	requireEvent(t, recorder, fencePodEventReason, "Dry run: would have force deleted pod "+pod.Name)
+}
+
+// Pods in namespaces that have opted out of fencing shouldn't be deleted
+func testFencingNamespaceOptOut(t *testing.T) {
+	_, err := fakeKubeClient.CoreV1().Namespaces().Create(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "fencing-disabled",
+			Annotations: map[string]string{disableFencingAnnotation: "true"},
+		},
+	})
+	require.NoError(t, err, "failed to create namespace")
+
+	m, recorder := newFencingMonitor(FencingPolicy{})
+	disabledPod := createFencingPod(t, "fencingDisabledPod", "fencing-disabled")
+	pod := createFencingPod(t, "fencingEnabledPod", "fencing-enabled")
+	defer cleanupFencingTest(t, disabledPod, pod)
+
+	err = driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(disabledPod), "pod in namespace with fencing disabled shouldn't be deleted")
+	require.False(t, isPodPresent(pod), "pod should be deleted")
+	requireEvent(t, recorder, fenceSkippedEventReason, "fencing is disabled for namespace fencing-disabled")
+}
+
+func createFencingVolumeAttachment(t *testing.T, name string, namespace string) {
+	_, err := k8s.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      driverVolumeName,
+			Namespace: namespace,
+		},
+	})
+	require.NoError(t, err, "failed to create pvc")
+	_, err = k8s.Instance().CreatePersistentVolume(&v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1.PersistentVolumeSpec{
+			ClaimRef: &v1.ObjectReference{
+				Name:      driverVolumeName,
+				Namespace: namespace,
+			},
+		},
+	})
+	require.NoError(t, err, "failed to create pv")
+	_, err = k8s.Instance().CreateVolumeAttachment(&storagev1beta1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: storagev1beta1.VolumeAttachmentSpec{
+			NodeName: nodeForPod,
+			Source: storagev1beta1.VolumeAttachmentSource{
+				PersistentVolumeName: &name,
+			},
+		},
+	})
+	require.NoError(t, err, "failed to create volume attachment")
+}
+
+func isVolumeAttachmentPresent(name string) bool {
+	_, err := fakeKubeClient.StorageV1beta1().VolumeAttachments().Get(name, metav1.GetOptions{})
+	return err == nil
+}
+
+// Volume attachments should only be deleted for the pods that are evicted
+// from an offline node
+func testFencingOptOutVolumeAttachment(t *testing.T) {
+	m, _ := newFencingMonitor(FencingPolicy{})
+	disabledPod := createFencingPod(t, "fencingDisabledAttachedPod", "fencing-disabled")
+	pod := createFencingPod(t, "fencingEnabledAttachedPod", "fencing-enabled")
+	defer cleanupFencingTest(t, disabledPod, pod)
+	createFencingVolumeAttachment(t, "va-fencing-disabled", "fencing-disabled")
+	createFencingVolumeAttachment(t, "va-fencing-enabled", "fencing-enabled")
+	defer func() {
+		for _, namespace := range []string{"fencing-disabled", "fencing-enabled"} {
+			_ = k8s.Instance().DeleteVolumeAttachment("va-" + namespace)
+			_ = k8s.Instance().DeletePersistentVolume("va-" + namespace)
+			_ = k8s.Instance().DeletePersistentVolumeClaim(driverVolumeName, namespace)
+		}
+	}()
+
+	err := driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(disabledPod), "pod in namespace with fencing disabled shouldn't be deleted")
+	require.True(t, isVolumeAttachmentPresent("va-fencing-disabled"),
+		"volume attachment for pod with fencing disabled shouldn't be deleted")
+	require.False(t, isPodPresent(pod), "pod should be deleted")
+	require.False(t, isVolumeAttachmentPresent("va-fencing-enabled"),
+		"volume attachment for deleted pod should be deleted")
+}
+
+// Only the configured number of pods should be deleted in an interval
+func testFencingMaxEvictions(t *testing.T) {
+	m, recorder := newFencingMonitor(FencingPolicy{MaxEvictionsPerInterval: 1})
+	pod1 := createFencingPod(t, "fencingMaxEvictionsPod1", "fencing-enabled")
+	pod2 := createFencingPod(t, "fencingMaxEvictionsPod2", "fencing-enabled")
+	defer cleanupFencingTest(t, pod1, pod2)
+
+	err := driver.UpdateNodeStatus(0, volume.NodeOffline)
+	require.NoError(t, err, "Error setting node status to Offline")
+	m.monitorDriverNodes(driver)
+	require.NotEqual(t, isPodPresent(pod1), isPodPresent(pod2), "only one pod should be deleted")
+	requireEvent(t, recorder, fenceSkippedEventReason, "limit of 1 evictions per interval reached")
+
+	m.resetEvictions()
+	m.monitorDriverNodes(driver)
+	require.False(t, isPodPresent(pod1), "pod should be deleted in next interval")
+	require.False(t, isPodPresent(pod2), "pod should be deleted in next interval")
+}
+
+// Pods on degraded storage nodes should only be deleted if degraded nodes
+// aren't ignored
+func testFencingIgnoreDegraded(t *testing.T) {
+	m, _ := newFencingMonitor(FencingPolicy{IgnoreDegradedNodes: true})
+	pod := createFencingPod(t, "fencingDegradedPod", "fencing-enabled")
+	defer cleanupFencingTest(t, pod)
+
+	err := driver.UpdateNodeStatus(0, volume.NodeDegraded)
+	require.NoError(t, err, "Error setting node status to Degraded")
+	m.monitorDriverNodes(driver)
+	require.True(t, isPodPresent(pod), "pod on degraded node shouldn't be deleted")
+
+	m.setPolicy(FencingPolicy{}.withDefaults())
+	m.monitorDriverNodes(driver)
+	require.False(t, isPodPresent(pod), "pod on degraded node should be deleted")
+}
+
+// The fencing policy should be updated from the config map, and invalid
+// values should be ignored
+func testFencingPolicyConfigMap(t *testing.T) {
+	m := &Monitor{
+		Drivers:            monitor.Drivers,
+		IntervalSec:        30,
+		Policy:             FencingPolicy{FailureThreshold: 3},
+		ConfigMapName:      "stork-monitor-config",
+		ConfigMapNamespace: "kube-system",
+	}
+	err := m.Start()
+	require.NoError(t, err, "failed to start monitor")
+	defer func() {
+		err := m.Stop()
+		require.NoError(t, err, "Error stopping monitor")
+	}()
+	require.Equal(t, FencingPolicy{FailureThreshold: 3}, m.getPolicy(), "Unexpected initial policy")
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.ConfigMapName,
+			Namespace: m.ConfigMapNamespace,
+		},
+		Data: map[string]string{
+			gracePeriodKey:             "5m",
+			maxEvictionsPerIntervalKey: "10",
+			dryRunKey:                  "true",
+		},
+	}
+	_, err = k8s.Instance().CreateConfigMap(cm)
+	require.NoError(t, err, "failed to create config map")
+	expected := FencingPolicy{
+		GracePeriod:             5 * time.Minute,
+		FailureThreshold:        3,
+		MaxEvictionsPerInterval: 10,
+		DryRun:                  true,
+	}
+	for i := 0; i < 50 && m.getPolicy() != expected; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, expected, m.getPolicy(), "policy not updated from config map")
+
+	cm.Data[failureThresholdKey] = "-1"
+	_, err = k8s.Instance().UpdateConfigMap(cm)
+	require.NoError(t, err, "failed to update config map")
+	time.Sleep(time.Second)
+	require.Equal(t, expected, m.getPolicy(), "policy shouldn't be updated with invalid values")
+
+	err = k8s.Instance().DeleteConfigMap(cm.Name, cm.Namespace)
+	require.NoError(t, err, "failed to delete config map")
+	for i := 0; i < 50 && m.getPolicy().DryRun; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, FencingPolicy{FailureThreshold: 3}, m.getPolicy(), "policy should be reset once config map is deleted")
+}
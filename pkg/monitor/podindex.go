@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// podIndexResyncPeriod is the resync period for the pod informer
+	podIndexResyncPeriod = 10 * time.Minute
+	// nodeNameIndex is the name of the index of pods by the node they are
+	// scheduled on
+	nodeNameIndex = "nodeName"
+)
+
+// podIndex keeps an informer backed index of the pods in the cluster by the
+// node they are scheduled on, so that the pods on a failed node can be found
+// without listing all the pods in the cluster
+type podIndex struct {
+	informer cache.SharedIndexInformer
+}
+
+func newPodIndex(client kubernetes.Interface) *podIndex {
+	watchlist := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Pods(metav1.NamespaceAll).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Pods(metav1.NamespaceAll).Watch(options)
+		},
+	}
+	informer := cache.NewSharedIndexInformer(watchlist, &v1.Pod{}, podIndexResyncPeriod,
+		cache.Indexers{
+			nodeNameIndex: func(obj interface{}) ([]string, error) {
+				pod, ok := obj.(*v1.Pod)
+				if !ok || pod.Spec.NodeName == "" {
+					return []string{}, nil
+				}
+				return []string{pod.Spec.NodeName}, nil
+			},
+		},
+	)
+	return &podIndex{
+		informer: informer,
+	}
+}
+
+// start runs the informer until the stop channel is closed and waits for the
+// index to be populated
+func (i *podIndex) start(stopChannel <-chan struct{}) error {
+	go i.informer.Run(stopChannel)
+	if !cache.WaitForCacheSync(stopChannel, i.informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for pod index to sync")
+	}
+	return nil
+}
+
+// getPodsOnNode returns the pods scheduled on the node. The returned pods are
+// from the informer's cache and shouldn't be modified.
+func (i *podIndex) getPodsOnNode(nodeName string) ([]*v1.Pod, error) {
+	objs, err := i.informer.GetIndexer().ByIndex(nodeNameIndex, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
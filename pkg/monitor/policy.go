@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultFailureThreshold is the default number of consecutive failed
+	// checks after which pods on a storage node are fenced
+	defaultFailureThreshold = 1
+
+	// Keys in the config map used to configure the fencing policy
+	gracePeriodKey             = "gracePeriod"
+	failureThresholdKey        = "failureThreshold"
+	maxEvictionsPerIntervalKey = "maxEvictionsPerInterval"
+	ignoreDegradedNodesKey     = "ignoreDegradedNodes"
+	dryRunKey                  = "dryRun"
+)
+
+// FencingPolicy controls when the monitor deletes pods and volume attachments
+// for nodes on which the storage driver isn't healthy. Zero values are
+// replaced with the defaults.
+type FencingPolicy struct {
+	// GracePeriod is the time for which a storage node has to be failing
+	// before pods on it are fenced
+	GracePeriod time.Duration
+	// FailureThreshold is the number of consecutive checks for which a
+	// storage node has to be failing before pods on it are fenced
+	FailureThreshold int
+	// MaxEvictionsPerInterval is the maximum number of pods that are deleted
+	// in one monitor interval. No limit is applied if it is 0.
+	MaxEvictionsPerInterval int
+	// IgnoreDegradedNodes if set doesn't treat storage nodes that are
+	// degraded as failed
+	IgnoreDegradedNodes bool
+	// DryRun if set only records events for the actions that would have been
+	// taken without deleting anything
+	DryRun bool
+}
+
+// withDefaults returns a copy of the policy with defaults set for any values
+// that haven't been configured
+func (p FencingPolicy) withDefaults() FencingPolicy {
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = defaultFailureThreshold
+	}
+	return p
+}
+
+// validate checks that none of the values are negative
+func (p FencingPolicy) validate() error {
+	if p.GracePeriod < 0 {
+		return fmt.Errorf("invalid %v %v, should not be negative", gracePeriodKey, p.GracePeriod)
+	}
+	for name, value := range map[string]int{
+		failureThresholdKey:        p.FailureThreshold,
+		maxEvictionsPerIntervalKey: p.MaxEvictionsPerInterval,
+	} {
+		if value < 0 {
+			return fmt.Errorf("invalid %v %v, should not be negative", name, value)
+		}
+	}
+	return nil
+}
+
+// updateFromConfigMap overrides the policy with the values set in the config
+// map
+func (p FencingPolicy) updateFromConfigMap(cm *v1.ConfigMap) (FencingPolicy, error) {
+	if value, ok := cm.Data[gracePeriodKey]; ok && value != "" {
+		gracePeriod, err := time.ParseDuration(value)
+		if err != nil {
+			return p, fmt.Errorf("invalid value for %v in config map: %v", gracePeriodKey, err)
+		}
+		p.GracePeriod = gracePeriod
+	}
+	for key, setting := range map[string]*int{
+		failureThresholdKey:        &p.FailureThreshold,
+		maxEvictionsPerIntervalKey: &p.MaxEvictionsPerInterval,
+	} {
+		value, ok := cm.Data[key]
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return p, fmt.Errorf("invalid value for %v in config map: %v", key, err)
+		}
+		*setting = parsed
+	}
+	for key, setting := range map[string]*bool{
+		ignoreDegradedNodesKey: &p.IgnoreDegradedNodes,
+		dryRunKey:              &p.DryRun,
+	} {
+		value, ok := cm.Data[key]
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return p, fmt.Errorf("invalid value for %v in config map: %v", key, err)
+		}
+		*setting = parsed
+	}
+	p = p.withDefaults()
+	return p, p.validate()
+}
@@ -0,0 +1,350 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/crypto"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// DataKeyObjectName is the name of the object with the wrapped data key for
+// a backup. It isn't included in the backup manifest since it is rewritten
+// when the keys are rotated, and a modified key fails to unwrap anyway.
+const DataKeyObjectName = "datakey.json"
+
+// BackupEncryption encrypts and decrypts the objects for one backup. A nil
+// BackupEncryption leaves the objects unencrypted.
+type BackupEncryption struct {
+	dataKey *crypto.DataKey
+	// provider is the key provider that the data key was wrapped with
+	provider string
+	// passphrases are used to decrypt objects from backups that were taken
+	// before they were encrypted with data keys
+	passphrases []string
+}
+
+// Provider returns the name of the key provider that the data key for the
+// backup was wrapped with. It is empty if the backup isn't encrypted with a
+// data key.
+func (e *BackupEncryption) Provider() string {
+	if e == nil || e.dataKey == nil {
+		return ""
+	}
+	return e.provider
+}
+
+// Encrypt the data for the backup
+func (e *BackupEncryption) Encrypt(data []byte) ([]byte, error) {
+	if e == nil {
+		return data, nil
+	}
+	if e.dataKey == nil {
+		return nil, fmt.Errorf("no data key to encrypt backup with")
+	}
+	return e.dataKey.Encrypt(data)
+}
+
+// Decrypt the data for the backup
+func (e *BackupEncryption) Decrypt(data []byte) ([]byte, error) {
+	if crypto.IsEnvelopeEncrypted(data) {
+		if e == nil || e.dataKey == nil {
+			return nil, fmt.Errorf("backup is encrypted but no encryption is configured for the backupLocation")
+		}
+		return e.dataKey.Decrypt(data)
+	}
+	if e != nil && e.dataKey != nil {
+		return nil, fmt.Errorf("data isn't encrypted with the data key for the backup")
+	}
+	if e == nil || len(e.passphrases) == 0 {
+		return data, nil
+	}
+	var err error
+	for _, passphrase := range e.passphrases {
+		var decrypted []byte
+		if decrypted, err = crypto.Decrypt(data, passphrase); err == nil {
+			return decrypted, nil
+		}
+	}
+	return nil, err
+}
+
+// getKeyProvider returns the key provider configured for the backup location,
+// or nil if backups shouldn't be encrypted
+func getKeyProvider(backupLocation *stork_api.BackupLocation) (crypto.KeyProvider, error) {
+	location := backupLocation.Location
+	name := crypto.PassphraseKeyProvider
+	config := &crypto.KeyProviderConfig{
+		Passphrase: location.EncryptionKey,
+	}
+	if location.EncryptionConfig != nil {
+		if location.EncryptionConfig.Provider != "" {
+			name = location.EncryptionConfig.Provider
+		}
+		config.KeyID = location.EncryptionConfig.KeyID
+		config.PreviousPassphrases = location.EncryptionConfig.PreviousEncryptionKeys
+		config.Options = location.EncryptionConfig.Options
+	}
+	if name == crypto.PassphraseKeyProvider && location.EncryptionKey == "" {
+		return nil, nil
+	}
+	return crypto.GetKeyProvider(name, config)
+}
+
+// getPreviousKeyProviders returns the key providers that were configured for
+// the backup location before the current one. Data keys wrapped by them can
+// be unwrapped but new data keys aren't wrapped with them.
+func getPreviousKeyProviders(backupLocation *stork_api.BackupLocation) ([]crypto.KeyProvider, error) {
+	config := backupLocation.Location.EncryptionConfig
+	if config == nil {
+		return nil, nil
+	}
+	providers := make([]crypto.KeyProvider, 0)
+	// The current passphrase provider already uses the previous passphrases
+	if config.Provider != "" && config.Provider != crypto.PassphraseKeyProvider {
+		passphrases := getPassphrases(backupLocation)
+		if len(passphrases) > 0 {
+			provider, err := crypto.GetKeyProvider(crypto.PassphraseKeyProvider, &crypto.KeyProviderConfig{
+				Passphrase:          passphrases[0],
+				PreviousPassphrases: passphrases[1:],
+			})
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+	}
+	for _, previous := range config.PreviousProviders {
+		if previous.Provider == "" || previous.Provider == crypto.PassphraseKeyProvider {
+			continue
+		}
+		provider, err := crypto.GetKeyProvider(previous.Provider, &crypto.KeyProviderConfig{
+			KeyID:   previous.KeyID,
+			Options: previous.Options,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting previous key provider %v: %v", previous.Provider, err)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// getPassphrases returns the current and previous passphrases for the backup
+// location
+func getPassphrases(backupLocation *stork_api.BackupLocation) []string {
+	passphrases := make([]string, 0)
+	if backupLocation.Location.EncryptionKey != "" {
+		passphrases = append(passphrases, backupLocation.Location.EncryptionKey)
+	}
+	if backupLocation.Location.EncryptionConfig != nil {
+		for _, passphrase := range backupLocation.Location.EncryptionConfig.PreviousEncryptionKeys {
+			if passphrase != "" {
+				passphrases = append(passphrases, passphrase)
+			}
+		}
+	}
+	return passphrases
+}
+
+// getUnwrapKeyProviders returns the current and previous key providers for
+// the backup location, which data keys can be unwrapped with
+func getUnwrapKeyProviders(
+	current crypto.KeyProvider,
+	backupLocation *stork_api.BackupLocation,
+) ([]crypto.KeyProvider, error) {
+	previous, err := getPreviousKeyProviders(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return previous, nil
+	}
+	return append([]crypto.KeyProvider{current}, previous...), nil
+}
+
+// unwrapDataKey unwraps the data key with the first of the providers that
+// has the key-encryption key it was wrapped with
+func unwrapDataKey(wrapped *crypto.WrappedKey, providers []crypto.KeyProvider) (*crypto.DataKey, error) {
+	err := fmt.Errorf("data key was wrapped by key provider %v which isn't configured for the backupLocation", wrapped.Provider)
+	for _, provider := range providers {
+		if provider.Name() != wrapped.Provider {
+			continue
+		}
+		var dataKey *crypto.DataKey
+		if dataKey, err = crypto.UnwrapDataKey(wrapped, provider); err == nil {
+			return dataKey, nil
+		}
+	}
+	return nil, err
+}
+
+// NewBackupEncryption generates a data key for a new backup under path in the
+// bucket and uploads it wrapped with the key provider for the backup
+// location. Returns nil if encryption isn't configured.
+func NewBackupEncryption(
+	bucket *blob.Bucket,
+	path string,
+	backupLocation *stork_api.BackupLocation,
+) (*BackupEncryption, error) {
+	provider, err := getKeyProvider(backupLocation)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+	dataKey, err := crypto.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := uploadDataKey(bucket, path, dataKey, provider); err != nil {
+		return nil, err
+	}
+	return &BackupEncryption{dataKey: dataKey, provider: provider.Name()}, nil
+}
+
+// GetBackupEncryption returns the encryption for an existing backup under
+// path in the bucket, unwrapping its data key with the key providers for the
+// backup location. backup should be nil if the backup object isn't known, in
+// which case a missing data key is only accepted if the backup location
+// doesn't use a key provider other than the passphrase.
+func GetBackupEncryption(
+	bucket *blob.Bucket,
+	path string,
+	backupLocation *stork_api.BackupLocation,
+	backup *stork_api.ApplicationBackup,
+) (*BackupEncryption, error) {
+	wrapped, err := getDataKey(bucket, path)
+	if err != nil {
+		if gcerrors.Code(err) != gcerrors.NotFound {
+			return nil, err
+		}
+		return getLegacyBackupEncryption(backupLocation, backup)
+	}
+	provider, err := getKeyProvider(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := getUnwrapKeyProviders(provider, backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := unwrapDataKey(wrapped, providers)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupEncryption{dataKey: dataKey, provider: wrapped.Provider}, nil
+}
+
+// getLegacyBackupEncryption returns the encryption for a backup without a
+// data key. Backups taken before data keys were added are either unencrypted
+// or encrypted with the passphrase directly. Backups that were encrypted with
+// a data key are rejected, so that deleting the data key doesn't cause the
+// objects for the backup to be read without being decrypted.
+func getLegacyBackupEncryption(
+	backupLocation *stork_api.BackupLocation,
+	backup *stork_api.ApplicationBackup,
+) (*BackupEncryption, error) {
+	if backup != nil && backup.Status.EncryptionProvider != "" {
+		return nil, fmt.Errorf("data key for backup is missing, it was encrypted with key provider %v",
+			backup.Status.EncryptionProvider)
+	}
+	passphrases := getPassphrases(backupLocation)
+	if len(passphrases) > 0 {
+		return &BackupEncryption{passphrases: passphrases}, nil
+	}
+	provider, err := getKeyProvider(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	// Without the backup object it isn't known whether the backup was taken
+	// before encryption was configured
+	if provider != nil && backup == nil {
+		return nil, fmt.Errorf("data key for backup is missing and backupLocation uses key provider %v",
+			provider.Name())
+	}
+	return nil, nil
+}
+
+// RotateDataKeys wraps the data keys for all the backups in the backup
+// location with the current key-encryption key. The data itself doesn't
+// need to be encrypted again. Returns the number of data keys that were
+// rotated.
+func RotateDataKeys(bucket *blob.Bucket, backupLocation *stork_api.BackupLocation) (int, error) {
+	provider, err := getKeyProvider(backupLocation)
+	if err != nil {
+		return 0, err
+	}
+	if provider == nil {
+		return 0, fmt.Errorf("encryption isn't configured for the backupLocation")
+	}
+	providers, err := getUnwrapKeyProviders(provider, backupLocation)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	iterator := bucket.List(nil)
+	for {
+		object, err := iterator.Next(context.TODO())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rotated, err
+		}
+		if object.Key != DataKeyObjectName && !strings.HasSuffix(object.Key, "/"+DataKeyObjectName) {
+			continue
+		}
+		path := strings.TrimSuffix(strings.TrimSuffix(object.Key, DataKeyObjectName), "/")
+		wrapped, err := getDataKey(bucket, path)
+		if err != nil {
+			return rotated, err
+		}
+		if wrapped.Provider == provider.Name() && wrapped.KeyID == provider.CurrentKeyID() {
+			continue
+		}
+		dataKey, err := unwrapDataKey(wrapped, providers)
+		if err != nil {
+			return rotated, fmt.Errorf("error rotating data key for %v: %v", path, err)
+		}
+		if err := uploadDataKey(bucket, path, dataKey, provider); err != nil {
+			return rotated, fmt.Errorf("error rotating data key for %v: %v", path, err)
+		}
+		rotated++
+	}
+	return rotated, nil
+}
+
+func uploadDataKey(
+	bucket *blob.Bucket,
+	path string,
+	dataKey *crypto.DataKey,
+	provider crypto.KeyProvider,
+) error {
+	wrapped, err := dataKey.Wrap(provider)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(wrapped, "", " ")
+	if err != nil {
+		return err
+	}
+	return bucket.WriteAll(context.TODO(), filepath.Join(path, DataKeyObjectName), data, nil)
+}
+
+func getDataKey(bucket *blob.Bucket, path string) (*crypto.WrappedKey, error) {
+	data, err := bucket.ReadAll(context.TODO(), filepath.Join(path, DataKeyObjectName))
+	if err != nil {
+		return nil, err
+	}
+	wrapped := &crypto.WrappedKey{}
+	if err := json.Unmarshal(data, wrapped); err != nil {
+		return nil, fmt.Errorf("error parsing data key: %v", err)
+	}
+	return wrapped, nil
+}
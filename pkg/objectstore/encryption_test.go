@@ -0,0 +1,212 @@
+// +build unittest
+
+package objectstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupEncryption(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	encryption, err := NewBackupEncryption(bucket, testBackupPath, location)
+	require.NoError(t, err, "Error creating encryption without key")
+	require.Nil(t, encryption, "Encryption should be nil without key")
+
+	location.Location.EncryptionKey = "testkey"
+	encryption, err = NewBackupEncryption(bucket, testBackupPath, location)
+	require.NoError(t, err, "Error creating encryption")
+	encrypted, err := encryption.Encrypt([]byte("data"))
+	require.NoError(t, err, "Error encrypting data")
+	require.True(t, crypto.IsEnvelopeEncrypted(encrypted), "Data should be encrypted with data key")
+
+	encryption, err = GetBackupEncryption(bucket, testBackupPath, location, nil)
+	require.NoError(t, err, "Error getting encryption")
+	decrypted, err := encryption.Decrypt(encrypted)
+	require.NoError(t, err, "Error decrypting data")
+	require.Equal(t, []byte("data"), decrypted)
+
+	location.Location.EncryptionKey = ""
+	_, err = GetBackupEncryption(bucket, testBackupPath, location, nil)
+	require.Error(t, err, "Expected error getting encryption without key")
+}
+
+func TestRotateDataKeys(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	location.Location.EncryptionKey = "oldkey"
+	paths := []string{"ns1/backup1/uid", "ns1/backup2/uid"}
+	encrypted := make(map[string][]byte)
+	for _, path := range paths {
+		encryption, err := NewBackupEncryption(bucket, path, location)
+		require.NoError(t, err, "Error creating encryption")
+		encrypted[path], err = encryption.Encrypt([]byte(path))
+		require.NoError(t, err, "Error encrypting data")
+	}
+	// Legacy backups without a data key should be skipped
+	legacy, err := crypto.Encrypt([]byte("legacy"), "oldkey")
+	require.NoError(t, err, "Error encrypting data")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join("ns1/backup3/uid", LegacyResourcesObjectName), legacy, nil))
+
+	_, err = RotateDataKeys(bucket, &stork_api.BackupLocation{})
+	require.Error(t, err, "Expected error rotating without encryption")
+
+	location.Location.EncryptionKey = "newkey"
+	location.Location.EncryptionConfig = &stork_api.EncryptionConfig{
+		PreviousEncryptionKeys: []string{"oldkey"},
+	}
+	rotated, err := RotateDataKeys(bucket, location)
+	require.NoError(t, err, "Error rotating data keys")
+	require.Equal(t, len(paths), rotated)
+
+	rotated, err = RotateDataKeys(bucket, location)
+	require.NoError(t, err, "Error rotating data keys")
+	require.Equal(t, 0, rotated, "Rotated data keys should be skipped")
+
+	// Backups should be readable with only the new key after rotation
+	location.Location.EncryptionConfig = nil
+	for _, path := range paths {
+		encryption, err := GetBackupEncryption(bucket, path, location, nil)
+		require.NoError(t, err, "Error getting encryption after rotation")
+		decrypted, err := encryption.Decrypt(encrypted[path])
+		require.NoError(t, err, "Error decrypting data after rotation")
+		require.Equal(t, []byte(path), decrypted)
+	}
+}
+
+func TestBackupEncryptionMissingDataKey(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	location.Location.EncryptionKey = "testkey"
+	encryption, err := NewBackupEncryption(bucket, testBackupPath, location)
+	require.NoError(t, err, "Error creating encryption")
+	require.Equal(t, crypto.PassphraseKeyProvider, encryption.Provider())
+	encrypted, err := encryption.Encrypt([]byte("data"))
+	require.NoError(t, err, "Error encrypting data")
+	_, err = encryption.Decrypt([]byte("data"))
+	require.Error(t, err, "Expected error decrypting data that isn't encrypted with the data key")
+
+	require.NoError(t, bucket.Delete(context.TODO(), filepath.Join(testBackupPath, DataKeyObjectName)))
+	backup := &stork_api.ApplicationBackup{}
+	backup.Status.EncryptionProvider = crypto.PassphraseKeyProvider
+	_, err = GetBackupEncryption(bucket, testBackupPath, location, backup)
+	require.Error(t, err, "Expected error for backup with missing data key")
+
+	// Without the backup only the passphrase can be tried, which doesn't
+	// decrypt data that was encrypted with the data key or isn't encrypted
+	encryption, err = GetBackupEncryption(bucket, testBackupPath, location, nil)
+	require.NoError(t, err, "Error getting encryption")
+	_, err = encryption.Decrypt(encrypted)
+	require.Error(t, err, "Expected error decrypting without data key")
+	_, err = encryption.Decrypt([]byte("data"))
+	require.Error(t, err, "Expected error for data that isn't encrypted")
+
+	keyDir := newTestKeyDir(t, "key1")
+	defer os.RemoveAll(keyDir) // nolint: errcheck
+	defer crypto.SetFileKeyProviderRoot("")
+	location.Location.EncryptionKey = ""
+	location.Location.EncryptionConfig = &stork_api.EncryptionConfig{
+		Provider: crypto.FileKeyProvider,
+		KeyID:    "key1",
+		Options:  map[string]string{crypto.FileKeyProviderDirOption: keyDir},
+	}
+	_, err = GetBackupEncryption(bucket, testBackupPath, location, nil)
+	require.Error(t, err, "Expected error for missing data key with file key provider")
+	_, err = GetBackupEncryption(bucket, testBackupPath, location, backup)
+	require.Error(t, err, "Expected error for backup with missing data key")
+
+	// Backups taken before encryption was configured can still be read
+	encryption, err = GetBackupEncryption(bucket, testBackupPath, location, &stork_api.ApplicationBackup{})
+	require.NoError(t, err, "Error getting encryption for backup taken before encryption")
+	require.Nil(t, encryption, "Backup taken before encryption shouldn't be encrypted")
+}
+
+func newTestKeyDir(t *testing.T, keyIDs ...string) string {
+	dir, err := ioutil.TempDir("", "keys")
+	require.NoError(t, err, "Error creating key dir")
+	for _, keyID := range keyIDs {
+		key := make([]byte, 32)
+		_, err := io.ReadFull(rand.Reader, key)
+		require.NoError(t, err, "Error generating key")
+		err = ioutil.WriteFile(filepath.Join(dir, keyID), []byte(base64.StdEncoding.EncodeToString(key)), 0600)
+		require.NoError(t, err, "Error writing key")
+	}
+	crypto.SetFileKeyProviderRoot(dir)
+	return dir
+}
+
+func TestRotateDataKeysBetweenProviders(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+	keyDir := newTestKeyDir(t, "key1", "key2")
+	defer os.RemoveAll(keyDir) // nolint: errcheck
+	defer crypto.SetFileKeyProviderRoot("")
+
+	location.Location.EncryptionKey = "oldkey"
+	encryption, err := NewBackupEncryption(bucket, testBackupPath, location)
+	require.NoError(t, err, "Error creating encryption")
+	encrypted, err := encryption.Encrypt([]byte("data"))
+	require.NoError(t, err, "Error encrypting data")
+
+	// Rotate from the passphrase to the file provider
+	location.Location.EncryptionKey = ""
+	location.Location.EncryptionConfig = &stork_api.EncryptionConfig{
+		Provider:               crypto.FileKeyProvider,
+		KeyID:                  "key1",
+		Options:                map[string]string{crypto.FileKeyProviderDirOption: keyDir},
+		PreviousEncryptionKeys: []string{"oldkey"},
+	}
+	rotated, err := RotateDataKeys(bucket, location)
+	require.NoError(t, err, "Error rotating data keys")
+	require.Equal(t, 1, rotated)
+
+	// Rotate to a file provider with a different directory
+	newKeyDir := filepath.Join(keyDir, "new")
+	require.NoError(t, os.Mkdir(newKeyDir, 0700))
+	require.NoError(t, os.Rename(filepath.Join(keyDir, "key2"), filepath.Join(newKeyDir, "key2")))
+	location.Location.EncryptionConfig = &stork_api.EncryptionConfig{
+		Provider: crypto.FileKeyProvider,
+		KeyID:    "key2",
+		Options:  map[string]string{crypto.FileKeyProviderDirOption: newKeyDir},
+	}
+	_, err = RotateDataKeys(bucket, location)
+	require.Error(t, err, "Expected error rotating without the previous provider")
+	location.Location.EncryptionConfig.PreviousProviders = []stork_api.KeyProviderConfig{{
+		Provider: crypto.FileKeyProvider,
+		KeyID:    "key1",
+		Options:  map[string]string{crypto.FileKeyProviderDirOption: keyDir},
+	}}
+	rotated, err = RotateDataKeys(bucket, location)
+	require.NoError(t, err, "Error rotating data keys")
+	require.Equal(t, 1, rotated)
+
+	location.Location.EncryptionConfig.PreviousProviders = nil
+	encryption, err = GetBackupEncryption(bucket, testBackupPath, location, nil)
+	require.NoError(t, err, "Error getting encryption after rotation")
+	require.Equal(t, crypto.FileKeyProvider, encryption.Provider())
+	decrypted, err := encryption.Decrypt(encrypted)
+	require.NoError(t, err, "Error decrypting data after rotation")
+	require.Equal(t, []byte("data"), decrypted)
+}
@@ -0,0 +1,429 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	// Suffix used for the temporary files that objects are written to before
+	// being renamed in place. These are skipped when listing.
+	fileTempSuffix = ".storktmp"
+	// Content type reported for all objects since it isn't stored on disk
+	fileContentType = "application/octet-stream"
+
+	// FileBackupLocationRootEnv is the environment variable with the default
+	// root directory for filesystem backupLocations
+	FileBackupLocationRootEnv = "STORK_FILE_BACKUP_LOCATION_ROOT"
+)
+
+var (
+	fileBackupLocationRoot     = os.Getenv(FileBackupLocationRootEnv)
+	fileBackupLocationRootLock sync.Mutex
+)
+
+// SetFileBackupLocationRoot sets the directory that the paths for filesystem
+// backupLocations need to be under. Filesystem backupLocations can't be used
+// if it isn't set.
+func SetFileBackupLocationRoot(root string) {
+	fileBackupLocationRootLock.Lock()
+	defer fileBackupLocationRootLock.Unlock()
+	fileBackupLocationRoot = root
+}
+
+func getFileBackupLocationRoot() string {
+	fileBackupLocationRootLock.Lock()
+	defer fileBackupLocationRootLock.Unlock()
+	return fileBackupLocationRoot
+}
+
+// fileBucket is a driver.Bucket that stores objects as files under a
+// directory, for example an NFS share mounted into the stork pod. Keys are
+// always "/" separated and are mapped to paths relative to the directory.
+type fileBucket struct {
+	dir string
+}
+
+func getFileBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
+	return openFileBucket(backupLocation.Location.Path)
+}
+
+func openFileBucket(dir string) (*blob.Bucket, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("path is required for filesystem backupLocation")
+	}
+	dir, err := checkFileBackupLocationPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path for backupLocation: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path %v for backupLocation is not a directory", dir)
+	}
+	return blob.NewBucket(&fileBucket{dir: dir}), nil
+}
+
+// checkFileBackupLocationPath returns the path with any symlinks resolved, or
+// an error if it isn't under the root for filesystem backupLocations
+func checkFileBackupLocationPath(dir string) (string, error) {
+	root := getFileBackupLocationRoot()
+	if root == "" {
+		return "", fmt.Errorf("filesystem backupLocations are disabled since no root directory is configured for them")
+	}
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("error resolving root directory for filesystem backupLocations: %v", err)
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("path %v for backupLocation should be an absolute path", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("error accessing path for backupLocation: %v", err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %v for backupLocation should be under %v", dir, root)
+	}
+	return resolved, nil
+}
+
+// path returns the path of the file for the key, making sure that it doesn't
+// point outside the directory
+func (b *fileBucket) path(key string) (string, error) {
+	path := filepath.Join(b.dir, filepath.FromSlash(key))
+	if path == b.dir || !strings.HasPrefix(path, b.dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid key %v", key)
+	}
+	if strings.HasSuffix(path, fileTempSuffix) {
+		return "", fmt.Errorf("invalid key %v, keys can't end with %v", key, fileTempSuffix)
+	}
+	return path, nil
+}
+
+func (b *fileBucket) ErrorCode(err error) gcerrors.ErrorCode {
+	switch {
+	case os.IsNotExist(err):
+		return gcerrors.NotFound
+	case os.IsPermission(err):
+		return gcerrors.PermissionDenied
+	default:
+		return gcerrors.Unknown
+	}
+}
+
+func (b *fileBucket) As(i interface{}) bool {
+	return false
+}
+
+func (b *fileBucket) ErrorAs(err error, i interface{}) bool {
+	return false
+}
+
+func (b *fileBucket) Attributes(ctx context.Context, key string) (*driver.Attributes, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	return &driver.Attributes{
+		ContentType: fileContentType,
+		ModTime:     info.ModTime(),
+		Size:        info.Size(),
+	}, nil
+}
+
+func (b *fileBucket) ListPaged(ctx context.Context, opts *driver.ListOptions) (*driver.ListPage, error) {
+	if opts.BeforeList != nil {
+		if err := opts.BeforeList(b.As); err != nil {
+			return nil, err
+		}
+	}
+
+	// The prefix doesn't need to end at a path separator, so start walking
+	// from the deepest directory that is fully part of the prefix
+	start := b.dir
+	if i := strings.LastIndex(opts.Prefix, "/"); i != -1 {
+		start = filepath.Join(b.dir, filepath.FromSlash(opts.Prefix[:i]))
+		if start != b.dir && !strings.HasPrefix(start, b.dir+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("invalid prefix %v", opts.Prefix)
+		}
+	}
+	var objects []*driver.ListObject
+	err := filepath.Walk(start, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Nothing matches the prefix if its directory doesn't exist
+			if path == start && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(b.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if info.IsDir() {
+			// Skip directories that can't contain any keys with the prefix
+			if path != start && !strings.HasPrefix(key+"/", opts.Prefix) && !strings.HasPrefix(opts.Prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, fileTempSuffix) || !strings.HasPrefix(key, opts.Prefix) {
+			return nil
+		}
+		objects = append(objects, &driver.ListObject{
+			Key:     key,
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	// Collapse everything after the delimiter into a single "directory"
+	if opts.Delimiter != "" {
+		collapsed := make([]*driver.ListObject, 0, len(objects))
+		var lastDir string
+		for _, object := range objects {
+			suffix := strings.TrimPrefix(object.Key, opts.Prefix)
+			if i := strings.Index(suffix, opts.Delimiter); i != -1 {
+				dir := opts.Prefix + suffix[:i+len(opts.Delimiter)]
+				if dir == lastDir {
+					continue
+				}
+				lastDir = dir
+				object = &driver.ListObject{
+					Key:   dir,
+					IsDir: true,
+				}
+			}
+			collapsed = append(collapsed, object)
+		}
+		objects = collapsed
+	}
+
+	// The page token is the last key returned in the previous page
+	if len(opts.PageToken) > 0 {
+		token := string(opts.PageToken)
+		start := sort.Search(len(objects), func(i int) bool {
+			return objects[i].Key > token
+		})
+		objects = objects[start:]
+	}
+	page := &driver.ListPage{}
+	if opts.PageSize > 0 && len(objects) > opts.PageSize {
+		objects = objects[:opts.PageSize]
+		page.NextPageToken = []byte(objects[len(objects)-1].Key)
+	}
+	page.Objects = objects
+	return page, nil
+}
+
+func (b *fileBucket) NewRangeReader(ctx context.Context, key string, offset, length int64, opts *driver.ReaderOptions) (driver.Reader, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	var r io.Reader = file
+	if length >= 0 {
+		r = io.LimitReader(file, length)
+	}
+	return &fileReader{
+		r:      r,
+		closer: file,
+		attrs: driver.ReaderAttributes{
+			ContentType: fileContentType,
+			ModTime:     info.ModTime(),
+			Size:        info.Size(),
+		},
+	}, nil
+}
+
+func (b *fileBucket) NewTypedWriter(ctx context.Context, key, contentType string, opts *driver.WriterOptions) (driver.Writer, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*"+fileTempSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BeforeWrite != nil {
+		if err := opts.BeforeWrite(b.As); err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+			return nil, err
+		}
+	}
+	return &fileWriter{
+		ctx:  ctx,
+		file: file,
+		path: path,
+	}, nil
+}
+
+func (b *fileBucket) Copy(ctx context.Context, dstKey, srcKey string, opts *driver.CopyOptions) error {
+	if opts.BeforeCopy != nil {
+		if err := opts.BeforeCopy(b.As); err != nil {
+			return err
+		}
+	}
+	srcPath, err := b.path(srcKey)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close() // nolint: errcheck
+
+	writer, err := b.NewTypedWriter(ctx, dstKey, fileContentType, &driver.WriterOptions{})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.(*fileWriter).abort()
+		return err
+	}
+	return writer.Close()
+}
+
+func (b *fileBucket) Delete(ctx context.Context, key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &os.PathError{Op: "remove", Path: path, Err: os.ErrNotExist}
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	// Clean up the directories that are now empty so that they don't show
+	// up when listing with a delimiter
+	for dir := filepath.Dir(path); dir != b.dir; dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (b *fileBucket) SignedURL(ctx context.Context, key string, opts *driver.SignedURLOptions) (string, error) {
+	return "", fmt.Errorf("signed URLs are not supported for filesystem backupLocation")
+}
+
+func (b *fileBucket) Close() error {
+	return nil
+}
+
+type fileReader struct {
+	r      io.Reader
+	closer io.Closer
+	attrs  driver.ReaderAttributes
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *fileReader) Close() error {
+	return r.closer.Close()
+}
+
+func (r *fileReader) Attributes() *driver.ReaderAttributes {
+	return &r.attrs
+}
+
+func (r *fileReader) As(i interface{}) bool {
+	return false
+}
+
+// fileWriter writes to a temporary file which is renamed to the object's
+// path on Close, so that partially written objects are never visible
+type fileWriter struct {
+	ctx  context.Context
+	file *os.File
+	path string
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+func (w *fileWriter) Close() error {
+	// The context is canceled if the write was aborted
+	if err := w.ctx.Err(); err != nil {
+		w.abort()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.abort()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		_ = os.Remove(w.file.Name())
+		return err
+	}
+	if err := os.Rename(w.file.Name(), w.path); err != nil {
+		_ = os.Remove(w.file.Name())
+		return err
+	}
+	return nil
+}
+
+func (w *fileWriter) abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
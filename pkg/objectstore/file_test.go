@@ -0,0 +1,170 @@
+// +build unittest
+
+package objectstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+func newFileBackupLocation(t *testing.T) *stork_api.BackupLocation {
+	dir, err := ioutil.TempDir("", "stork-objectstore")
+	require.NoError(t, err, "Error creating temp dir")
+	SetFileBackupLocationRoot(filepath.Dir(dir))
+	return &stork_api.BackupLocation{
+		Location: stork_api.BackupLocationItem{
+			Type: stork_api.BackupLocationFile,
+			Path: dir,
+		},
+	}
+}
+
+func listKeys(t *testing.T, bucket *blob.Bucket, prefix string) []string {
+	keys := make([]string, 0)
+	iterator := bucket.List(&blob.ListOptions{
+		Prefix:    prefix,
+		Delimiter: "/",
+	})
+	for {
+		object, err := iterator.Next(context.TODO())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "Error listing objects")
+		keys = append(keys, object.Key)
+	}
+	return keys
+}
+
+func TestFileBucket(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup1/uid1/resources.json", []byte("resources"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup1/uid1/metadata.json", []byte("metadata"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup2/uid2/metadata.json", []byte("metadata2"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns2/backup3/uid3/metadata.json", []byte("metadata3"), nil))
+
+	data, err := bucket.ReadAll(context.TODO(), "ns1/backup1/uid1/resources.json")
+	require.NoError(t, err, "Error reading object")
+	require.Equal(t, "resources", string(data))
+
+	require.Equal(t, []string{"ns1/backup1/", "ns1/backup2/"}, listKeys(t, bucket, "ns1/"))
+	require.Equal(t, []string{"ns1/backup1/uid1/"}, listKeys(t, bucket, "ns1/backup1/"))
+	require.Equal(t, []string{"ns1/backup1/uid1/metadata.json", "ns1/backup1/uid1/resources.json"},
+		listKeys(t, bucket, "ns1/backup1/uid1/"))
+
+	// Overwriting an object should replace it
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup1/uid1/resources.json", []byte("new"), nil))
+	data, err = bucket.ReadAll(context.TODO(), "ns1/backup1/uid1/resources.json")
+	require.NoError(t, err, "Error reading object")
+	require.Equal(t, "new", string(data))
+
+	// Deleting all the objects for a backup should remove it from the listing
+	require.NoError(t, bucket.Delete(context.TODO(), "ns1/backup1/uid1/resources.json"))
+	require.NoError(t, bucket.Delete(context.TODO(), "ns1/backup1/uid1/metadata.json"))
+	require.Equal(t, []string{"ns1/backup2/"}, listKeys(t, bucket, "ns1/"))
+
+	err = bucket.Delete(context.TODO(), "ns1/backup1/uid1/metadata.json")
+	require.Equal(t, gcerrors.NotFound, gcerrors.Code(err))
+	_, err = bucket.ReadAll(context.TODO(), "ns1/backup1/uid1/metadata.json")
+	require.Equal(t, gcerrors.NotFound, gcerrors.Code(err))
+}
+
+func TestFileBucketInvalidKey(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	err = bucket.WriteAll(context.TODO(), "../outside", []byte("data"), nil)
+	require.Error(t, err, "Expected error writing outside the directory")
+}
+
+func TestFileBucketInvalidPath(t *testing.T) {
+	location := newFileBackupLocation(t)
+	require.NoError(t, os.RemoveAll(location.Location.Path))
+
+	_, err := GetBucket(location)
+	require.Error(t, err, "Expected error for missing directory")
+
+	location.Location.Path = ""
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error for empty path")
+}
+
+func TestFileBucketRoot(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	outside, err := ioutil.TempDir("", "stork-objectstore-outside")
+	require.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(outside) // nolint: errcheck
+
+	// Only paths under the root should be allowed
+	SetFileBackupLocationRoot(location.Location.Path)
+	_, err = GetBucket(location)
+	require.NoError(t, err, "Error getting bucket for the root")
+	subdir := filepath.Join(location.Location.Path, "subdir")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+	location.Location.Path = subdir
+	_, err = GetBucket(location)
+	require.NoError(t, err, "Error getting bucket under the root")
+
+	location.Location.Path = outside
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error for path outside the root")
+
+	location.Location.Path = filepath.Join(subdir, "..", "..")
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error for path escaping the root")
+
+	link := filepath.Join(subdir, "link")
+	require.NoError(t, os.Symlink(outside, link))
+	location.Location.Path = link
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error for symlink pointing outside the root")
+
+	location.Location.Path = "subdir"
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error for relative path")
+
+	SetFileBackupLocationRoot("")
+	location.Location.Path = subdir
+	_, err = GetBucket(location)
+	require.Error(t, err, "Expected error if no root is configured")
+}
+
+func TestFileBucketListPrefix(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup1/uid1/metadata.json", []byte("metadata"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/backup10/uid2/metadata.json", []byte("metadata"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns1/other/uid3/metadata.json", []byte("metadata"), nil))
+	require.NoError(t, bucket.WriteAll(context.TODO(), "ns2/backup1/uid4/metadata.json", []byte("metadata"), nil))
+
+	// Prefixes don't need to end at a directory
+	require.Equal(t, []string{"ns1/backup1/", "ns1/backup10/"}, listKeys(t, bucket, "ns1/backup1"))
+	require.Equal(t, []string{"ns1/", "ns2/"}, listKeys(t, bucket, "ns"))
+	require.Equal(t, []string{}, listKeys(t, bucket, "ns3/"))
+	require.Equal(t, []string{}, listKeys(t, bucket, "ns1/backup2/uid1/"))
+
+	iterator := bucket.List(&blob.ListOptions{Prefix: "../"})
+	_, err = iterator.Next(context.TODO())
+	require.Error(t, err, "Expected error for prefix outside the directory")
+}
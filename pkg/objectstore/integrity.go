@@ -0,0 +1,258 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	// BackupManifestName is the name of the manifest with the checksums of
+	// all the objects for a backup. It is uploaded after all the other
+	// objects and isn't encrypted so that backups can be verified without
+	// the encryption key.
+	BackupManifestName = "backup-manifest.json"
+	// BackupManifestVersion is the version of the backup manifest format.
+	// Version 2 added the backup path to the manifest.
+	BackupManifestVersion = 2
+)
+
+// BackupManifest lists the checksums of all the objects uploaded for a backup
+type BackupManifest struct {
+	Version int `json:"version"`
+	// BackupPath is the path that the backup was uploaded to. It includes the
+	// UID of the backup and is covered by the signature, so a signed manifest
+	// can't be copied to another backup along with its objects.
+	BackupPath string            `json:"backupPath,omitempty"`
+	Objects    []*ObjectChecksum `json:"objects"`
+	// Signature is the hex encoded HMAC-SHA256 of the manifest with an empty
+	// signature. It is only set if a signing key was configured.
+	Signature string `json:"signature,omitempty"`
+}
+
+// ObjectChecksum is the checksum of one object as it is stored in the bucket,
+// ie after it has been encrypted. Path is relative to the backup path.
+type ObjectChecksum struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewBackupManifest returns an empty manifest to add checksums to while
+// uploading a backup
+func NewBackupManifest() *BackupManifest {
+	return &BackupManifest{
+		Version: BackupManifestVersion,
+		Objects: make([]*ObjectChecksum, 0),
+	}
+}
+
+// Add records the checksum of the data uploaded to path, replacing any
+// previous checksum for it
+func (m *BackupManifest) Add(path string, data []byte) {
+	checksum := sha256.Sum256(data)
+	object := &ObjectChecksum{
+		Path:   path,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(checksum[:]),
+	}
+	for i, o := range m.Objects {
+		if o.Path == path {
+			m.Objects[i] = object
+			return
+		}
+	}
+	m.Objects = append(m.Objects, object)
+}
+
+// Sign sets the signature for the manifest using the key
+func (m *BackupManifest) Sign(signingKey string) error {
+	signature, err := m.signature(signingKey)
+	if err != nil {
+		return err
+	}
+	m.Signature = hex.EncodeToString(signature)
+	return nil
+}
+
+func (m *BackupManifest) signature(signingKey string) ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	if _, err := mac.Write(data); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+func (m *BackupManifest) verifySignature(signingKey string) error {
+	if m.Signature == "" {
+		return fmt.Errorf("backup manifest is not signed")
+	}
+	actual, err := hex.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature for backup manifest: %v", err)
+	}
+	expected, err := m.signature(signingKey)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(actual, expected) {
+		return fmt.Errorf("signature mismatch for backup manifest")
+	}
+	return nil
+}
+
+// Verify checks that data read from path, relative to the backup path,
+// matches its checksum in the manifest. Objects that aren't in the manifest
+// are rejected.
+func (m *BackupManifest) Verify(path string, data []byte) error {
+	object := m.getObject(path)
+	if object == nil {
+		return fmt.Errorf("%v not found in backup manifest", path)
+	}
+	checksum := sha256.Sum256(data)
+	return object.verify(int64(len(data)), hex.EncodeToString(checksum[:]))
+}
+
+func (m *BackupManifest) getObject(path string) *ObjectChecksum {
+	for _, o := range m.Objects {
+		if o.Path == path {
+			return o
+		}
+	}
+	return nil
+}
+
+func (o *ObjectChecksum) verify(size int64, checksum string) error {
+	if size != o.Size {
+		return fmt.Errorf("size mismatch for %v, expected %v found %v", o.Path, o.Size, size)
+	}
+	if checksum != o.SHA256 {
+		return fmt.Errorf("checksum mismatch for %v, expected %v found %v", o.Path, o.SHA256, checksum)
+	}
+	return nil
+}
+
+// UploadBackupManifest signs the manifest if a signing key is provided and
+// uploads it under path in the bucket
+func UploadBackupManifest(
+	bucket *blob.Bucket,
+	path string,
+	signingKey string,
+	manifest *BackupManifest,
+) error {
+	sort.Slice(manifest.Objects, func(i, j int) bool {
+		return manifest.Objects[i].Path < manifest.Objects[j].Path
+	})
+	manifest.BackupPath = path
+	manifest.Signature = ""
+	if signingKey != "" {
+		if err := manifest.Sign(signingKey); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		return err
+	}
+	return bucket.WriteAll(context.TODO(), filepath.Join(path, BackupManifestName), data, nil)
+}
+
+// GetBackupManifest returns the manifest for the backup uploaded under path
+// in the bucket
+func GetBackupManifest(bucket *blob.Bucket, path string) (*BackupManifest, error) {
+	data, err := bucket.ReadAll(context.TODO(), filepath.Join(path, BackupManifestName))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BackupManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing backup manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// LoadBackupManifest returns the manifest for the backup uploaded under path
+// in the bucket after checking that it was uploaded for that path, and its
+// signature if a signing key is provided. The objects aren't verified, they
+// should be checked with Verify as they are read.
+//
+// Backups taken before manifests were added don't have one. A nil manifest
+// is returned for them if no signing key is provided, otherwise it's an
+// error since the manifest could have been removed to skip verification.
+func LoadBackupManifest(bucket *blob.Bucket, path string, signingKey string) (*BackupManifest, error) {
+	manifest, err := GetBackupManifest(bucket, path)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound && signingKey == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting backup manifest: %v", err)
+	}
+	if manifest.Version > BackupManifestVersion {
+		return nil, fmt.Errorf("unsupported backup manifest version %v", manifest.Version)
+	}
+	if signingKey != "" {
+		if err := manifest.verifySignature(signingKey); err != nil {
+			return nil, err
+		}
+	}
+	// Manifests from version 1 weren't bound to a path, so they are only
+	// accepted when they don't need to be trusted
+	if manifest.BackupPath == "" && signingKey != "" {
+		return nil, fmt.Errorf("backup manifest isn't bound to a backup path")
+	}
+	if manifest.BackupPath != "" && manifest.BackupPath != path {
+		return nil, fmt.Errorf("backup manifest was uploaded for %v, not %v", manifest.BackupPath, path)
+	}
+	return manifest, nil
+}
+
+// VerifyBackup loads the manifest for the backup uploaded under path in the
+// bucket, and checks that every object listed in it matches its checksum.
+// The objects are streamed so they don't need to fit in memory.
+//
+// This only reports whether the backup was intact when it was checked.
+// Anything that reads objects from the backup to use them should also check
+// them against the manifest, since they could have been replaced after the
+// check.
+func VerifyBackup(bucket *blob.Bucket, path string, signingKey string) (*BackupManifest, error) {
+	manifest, err := LoadBackupManifest(bucket, path, signingKey)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+	for _, object := range manifest.Objects {
+		if err := verifyObject(bucket, path, object); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+func verifyObject(bucket *blob.Bucket, path string, object *ObjectChecksum) error {
+	reader, err := bucket.NewReader(context.TODO(), filepath.Join(path, object.Path), nil)
+	if err != nil {
+		return fmt.Errorf("error reading %v: %v", object.Path, err)
+	}
+	defer reader.Close() // nolint: errcheck
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, reader)
+	if err != nil {
+		return fmt.Errorf("error reading %v: %v", object.Path, err)
+	}
+	return object.verify(size, hex.EncodeToString(hash.Sum(nil)))
+}
@@ -0,0 +1,177 @@
+// +build unittest
+
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const testBackupPath = "ns1/backup/uid"
+
+func TestVerifyBackup(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	checksums := NewBackupManifest()
+	require.NoError(t, UploadResources(bucket, testBackupPath, nil, newResources(), checksums))
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, "metadata.json"), []byte("metadata"), nil))
+	checksums.Add("metadata.json", []byte("metadata"))
+	require.NoError(t, UploadBackupManifest(bucket, testBackupPath, "", checksums))
+
+	manifest, err := VerifyBackup(bucket, testBackupPath, "")
+	require.NoError(t, err, "Error verifying backup")
+	require.Len(t, manifest.Objects, 5, "Manifest should have the resource chunks, their manifest and the metadata")
+	require.Equal(t, "metadata.json", manifest.Objects[0].Path, "Objects should be sorted by path")
+	require.Equal(t, testBackupPath, manifest.BackupPath, "Manifest should record the backup path")
+	require.Empty(t, manifest.Signature, "Manifest shouldn't be signed without a key")
+
+	_, err = VerifyBackup(bucket, testBackupPath, "signingkey")
+	require.EqualError(t, err, "backup manifest is not signed")
+
+	// Truncate one of the objects
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, "metadata.json"), []byte("meta"), nil))
+	_, err = VerifyBackup(bucket, testBackupPath, "")
+	require.EqualError(t, err, "size mismatch for metadata.json, expected 8 found 4")
+
+	// Modify one of the objects without changing the size
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, "metadata.json"), []byte("METADATA"), nil))
+	_, err = VerifyBackup(bucket, testBackupPath, "")
+	require.Error(t, err, "Expected checksum mismatch")
+	require.Contains(t, err.Error(), "checksum mismatch for metadata.json")
+}
+
+func TestVerifySignedBackup(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	checksums := NewBackupManifest()
+	require.NoError(t, UploadResources(bucket, testBackupPath, nil, newResources(), checksums))
+	require.NoError(t, UploadBackupManifest(bucket, testBackupPath, "signingkey", checksums))
+
+	manifest, err := VerifyBackup(bucket, testBackupPath, "signingkey")
+	require.NoError(t, err, "Error verifying signed backup")
+	require.NotEmpty(t, manifest.Signature, "Manifest should be signed")
+
+	// Checksums can still be verified without the key
+	_, err = VerifyBackup(bucket, testBackupPath, "")
+	require.NoError(t, err, "Error verifying signed backup without key")
+
+	_, err = VerifyBackup(bucket, testBackupPath, "otherkey")
+	require.EqualError(t, err, "signature mismatch for backup manifest")
+
+	// Replace an object along with its checksum in the manifest
+	data := []byte("tampered")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, ResourcesDirName, "00000.json.gz"), data, nil))
+	manifest.Add(filepath.Join(ResourcesDirName, "00000.json.gz"), data)
+	manifestData, err := json.Marshal(manifest)
+	require.NoError(t, err, "Error marshalling manifest")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, BackupManifestName), manifestData, nil))
+	_, err = VerifyBackup(bucket, testBackupPath, "")
+	require.NoError(t, err, "Checksums should match without the key")
+	_, err = VerifyBackup(bucket, testBackupPath, "signingkey")
+	require.EqualError(t, err, "signature mismatch for backup manifest")
+}
+
+func TestVerifyBackupNoManifest(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+	require.NoError(t, UploadResources(bucket, testBackupPath, nil, newResources(), nil))
+
+	manifest, err := VerifyBackup(bucket, testBackupPath, "")
+	require.NoError(t, err, "Backups without manifest should be allowed without a signing key")
+	require.Nil(t, manifest)
+
+	_, err = VerifyBackup(bucket, testBackupPath, "signingkey")
+	require.Error(t, err, "Backups without manifest shouldn't be allowed with a signing key")
+}
+
+func TestBackupManifestBoundToPath(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	checksums := NewBackupManifest()
+	require.NoError(t, UploadResources(bucket, testBackupPath, nil, newResources(), checksums))
+	require.NoError(t, UploadBackupManifest(bucket, testBackupPath, "signingkey", checksums))
+
+	// Copy the backup along with its signed manifest to another path
+	otherPath := "ns1/backup/otheruid"
+	for _, object := range append(checksums.Objects, &ObjectChecksum{Path: BackupManifestName}) {
+		data, err := bucket.ReadAll(context.TODO(), filepath.Join(testBackupPath, object.Path))
+		require.NoError(t, err, "Error reading object")
+		require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(otherPath, object.Path), data, nil))
+	}
+	_, err = VerifyBackup(bucket, otherPath, "signingkey")
+	require.EqualError(t, err, "backup manifest was uploaded for ns1/backup/uid, not ns1/backup/otheruid")
+
+	// Changing the path in the manifest should break the signature
+	manifest, err := GetBackupManifest(bucket, otherPath)
+	require.NoError(t, err, "Error getting backup manifest")
+	manifest.BackupPath = otherPath
+	manifestData, err := json.Marshal(manifest)
+	require.NoError(t, err, "Error marshalling manifest")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(otherPath, BackupManifestName), manifestData, nil))
+	_, err = VerifyBackup(bucket, otherPath, "signingkey")
+	require.EqualError(t, err, "signature mismatch for backup manifest")
+
+	// Manifests without a path can't be trusted with a signing key
+	manifest.BackupPath = ""
+	require.NoError(t, manifest.Sign("signingkey"))
+	manifestData, err = json.Marshal(manifest)
+	require.NoError(t, err, "Error marshalling manifest")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(otherPath, BackupManifestName), manifestData, nil))
+	_, err = VerifyBackup(bucket, otherPath, "signingkey")
+	require.EqualError(t, err, "backup manifest isn't bound to a backup path")
+	_, err = VerifyBackup(bucket, otherPath, "")
+	require.NoError(t, err, "Manifests without a path should be allowed without a signing key")
+}
+
+func TestReadResourcesVerifiesChecksums(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	checksums := NewBackupManifest()
+	require.NoError(t, UploadResources(bucket, testBackupPath, nil, newResources(), checksums))
+	require.NoError(t, UploadBackupManifest(bucket, testBackupPath, "signingkey", checksums))
+	manifest, err := VerifyBackup(bucket, testBackupPath, "signingkey")
+	require.NoError(t, err, "Error verifying backup")
+	objects, err := DownloadResources(bucket, testBackupPath, nil, manifest)
+	require.NoError(t, err, "Error downloading resources")
+	require.Len(t, objects, len(newResources()))
+
+	// Replace a chunk with a valid one from another backup after the backup
+	// was verified
+	otherPath := "ns1/otherbackup/uid"
+	otherResources := []runtime.Unstructured{newResource("v1", "ConfigMap", "tampered")}
+	require.NoError(t, UploadResources(bucket, otherPath, nil, otherResources, nil))
+	chunk := filepath.Join(ResourcesDirName, "00000.json.gz")
+	data, err := bucket.ReadAll(context.TODO(), filepath.Join(otherPath, chunk))
+	require.NoError(t, err, "Error reading chunk")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join(testBackupPath, chunk), data, nil))
+
+	_, err = DownloadResources(bucket, testBackupPath, nil, manifest)
+	require.Error(t, err, "Expected checksum mismatch for replaced chunk")
+	require.Contains(t, err.Error(), "mismatch for resources/00000.json.gz")
+	objects, err = DownloadResources(bucket, testBackupPath, nil, nil)
+	require.Error(t, err, "Expected object count mismatch without checksums")
+	require.Nil(t, objects)
+
+	// Objects that aren't in the manifest are rejected
+	require.EqualError(t, manifest.Verify("missing.json", nil), "missing.json not found in backup manifest")
+}
@@ -32,6 +32,8 @@ func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 		return getAzureBucket(backupLocation)
 	case stork_api.BackupLocationS3:
 		return getS3Bucket(backupLocation)
+	case stork_api.BackupLocationFile, stork_api.BackupLocationNFS:
+		return getFileBucket(backupLocation)
 	default:
 		return nil, fmt.Errorf("invalid backupLocation type: %v", backupLocation.Location.Type)
 	}
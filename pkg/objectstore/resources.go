@@ -0,0 +1,406 @@
+package objectstore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// LegacyResourcesObjectName is the object that all the resources were
+	// uploaded to before they were split into chunks
+	LegacyResourcesObjectName = "resources.json"
+	// ResourcesDirName is the directory under the backup path that the
+	// resource chunks and their manifest are uploaded to
+	ResourcesDirName = "resources"
+	// ResourcesManifestName is the name of the manifest for the resource
+	// chunks. It is uploaded last, so the resources are only complete if it
+	// exists.
+	ResourcesManifestName = "manifest.json"
+
+	// ResourcesFormatVersion is the version of the chunked resource format
+	ResourcesFormatVersion = 1
+	// CompressionGzip compresses the chunks with gzip
+	CompressionGzip = "gzip"
+	// CompressionNone doesn't compress the chunks
+	CompressionNone = "none"
+
+	// Maximum number of objects that are stored in one chunk
+	defaultObjectsPerChunk = 500
+)
+
+// ResourcesManifest describes the chunks that the resources for a backup are
+// stored in. Only gzip is used to compress the chunks for now. Since the
+// compression is recorded for each backup, other algorithms can be added
+// without changing the format version.
+type ResourcesManifest struct {
+	Version     int              `json:"version"`
+	Compression string           `json:"compression"`
+	Chunks      []*ResourceChunk `json:"chunks"`
+}
+
+// ResourceChunk is a chunk of objects of the same kind. The objects are
+// stored as newline delimited JSON.
+type ResourceChunk struct {
+	Name    string `json:"name"`
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	Count   int    `json:"count"`
+}
+
+// ResourceWriter uploads objects in chunks so that only one chunk needs to be
+// serialized in memory at a time
+type ResourceWriter struct {
+	bucket          *blob.Bucket
+	path            string
+	encryption      *BackupEncryption
+	objectsPerChunk int
+	manifest        *ResourcesManifest
+	checksums       *BackupManifest
+
+	chunk   *ResourceChunk
+	buf     *bytes.Buffer
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+// NewResourceWriter returns a writer that uploads the resources under path in
+// the bucket. The chunks are encrypted if encryption is provided. The
+// checksums of the uploaded objects are added to checksums if it isn't nil.
+func NewResourceWriter(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+) *ResourceWriter {
+	return &ResourceWriter{
+		bucket:          bucket,
+		path:            path,
+		encryption:      encryption,
+		objectsPerChunk: defaultObjectsPerChunk,
+		checksums:       checksums,
+		manifest: &ResourcesManifest{
+			Version:     ResourcesFormatVersion,
+			Compression: CompressionGzip,
+			Chunks:      make([]*ResourceChunk, 0),
+		},
+	}
+}
+
+// Write adds the object to the current chunk. A new chunk is started when
+// the kind of the objects changes or the current chunk is full.
+func (w *ResourceWriter) Write(object runtime.Unstructured) error {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if w.chunk != nil &&
+		(w.chunk.Count >= w.objectsPerChunk ||
+			w.chunk.Group != gvk.Group ||
+			w.chunk.Version != gvk.Version ||
+			w.chunk.Kind != gvk.Kind) {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if w.chunk == nil {
+		w.startChunk(gvk)
+	}
+	if err := w.encoder.Encode(object); err != nil {
+		return err
+	}
+	w.chunk.Count++
+	return nil
+}
+
+// Close uploads the last chunk and then the manifest
+func (w *ResourceWriter) Close() error {
+	if w.chunk != nil {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(w.manifest, "", " ")
+	if err != nil {
+		return err
+	}
+	return w.upload(ResourcesManifestName, data)
+}
+
+func (w *ResourceWriter) startChunk(gvk schema.GroupVersionKind) {
+	w.chunk = &ResourceChunk{
+		Name:    fmt.Sprintf("%05d.json.gz", len(w.manifest.Chunks)),
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+	}
+	if w.buf == nil {
+		w.buf = &bytes.Buffer{}
+		w.gzip = gzip.NewWriter(w.buf)
+	} else {
+		w.buf.Reset()
+		w.gzip.Reset(w.buf)
+	}
+	w.encoder = json.NewEncoder(w.gzip)
+}
+
+func (w *ResourceWriter) flush() error {
+	if err := w.gzip.Close(); err != nil {
+		return err
+	}
+	if err := w.upload(w.chunk.Name, w.buf.Bytes()); err != nil {
+		return err
+	}
+	w.manifest.Chunks = append(w.manifest.Chunks, w.chunk)
+	w.chunk = nil
+	return nil
+}
+
+func (w *ResourceWriter) upload(name string, data []byte) error {
+	data, err := w.encryption.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	name = filepath.Join(ResourcesDirName, name)
+	if err := w.bucket.WriteAll(context.TODO(), filepath.Join(w.path, name), data, nil); err != nil {
+		return err
+	}
+	if w.checksums != nil {
+		w.checksums.Add(name, data)
+	}
+	return nil
+}
+
+// UploadResources uploads the objects under path in the bucket in chunks
+func UploadResources(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	objects []runtime.Unstructured,
+	checksums *BackupManifest,
+) error {
+	writer := NewResourceWriter(bucket, path, encryption, checksums)
+	for _, o := range objects {
+		if err := writer.Write(o); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// DownloadResources downloads the objects that were uploaded under path in
+// the bucket. Resources uploaded in the legacy format are also supported. If
+// checksums isn't nil every object that is downloaded is checked against it.
+func DownloadResources(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+) ([]runtime.Unstructured, error) {
+	objects := make([]runtime.Unstructured, 0)
+	err := ReadResources(bucket, path, encryption, checksums, func(object runtime.Unstructured) error {
+		objects = append(objects, object)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// ReadResources calls objectFunc for each object that was uploaded under
+// path in the bucket. Only one chunk is read into memory at a time. If
+// checksums isn't nil each chunk is checked against it before any objects
+// from it are used.
+func ReadResources(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+	objectFunc func(runtime.Unstructured) error,
+) error {
+	manifest, err := getResourcesManifest(bucket, path, encryption, checksums)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return readLegacyResources(bucket, path, encryption, checksums, objectFunc)
+		}
+		return err
+	}
+	if manifest.Version > ResourcesFormatVersion {
+		return fmt.Errorf("unsupported resources format version %v", manifest.Version)
+	}
+	for _, chunk := range manifest.Chunks {
+		if err := readChunk(bucket, path, encryption, checksums, manifest.Compression, chunk, objectFunc); err != nil {
+			return fmt.Errorf("error reading resources from %v: %v", chunk.Name, err)
+		}
+	}
+	return nil
+}
+
+// IsLegacyResources returns true if the resources under path in the bucket
+// were uploaded in the legacy format, before they were split into chunks
+func IsLegacyResources(bucket *blob.Bucket, path string) (bool, error) {
+	exists, err := bucket.Exists(context.TODO(), filepath.Join(path, ResourcesDirName, ResourcesManifestName))
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// GetResourcesManifest returns the manifest for the resource chunks uploaded
+// under path in the bucket
+func GetResourcesManifest(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+) (*ResourcesManifest, error) {
+	return getResourcesManifest(bucket, path, encryption, nil)
+}
+
+func getResourcesManifest(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+) (*ResourcesManifest, error) {
+	data, err := download(bucket, path, filepath.Join(ResourcesDirName, ResourcesManifestName), encryption, checksums)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ResourcesManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing resources manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// DeleteResources deletes the resources that were uploaded under path in the
+// bucket in either format
+func DeleteResources(bucket *blob.Bucket, path string) error {
+	iterator := bucket.List(&blob.ListOptions{
+		Prefix: filepath.Join(path, ResourcesDirName) + "/",
+	})
+	for {
+		object, err := iterator.Next(context.TODO())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := bucket.Delete(context.TODO(), object.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return err
+		}
+	}
+	if err := bucket.Delete(context.TODO(), filepath.Join(path, LegacyResourcesObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+	return nil
+}
+
+func readChunk(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+	compression string,
+	chunk *ResourceChunk,
+	objectFunc func(runtime.Unstructured) error,
+) error {
+	data, err := download(bucket, path, filepath.Join(ResourcesDirName, chunk.Name), encryption, checksums)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	switch compression {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close() // nolint: errcheck
+		reader = gzipReader
+	case CompressionNone, "":
+	default:
+		return fmt.Errorf("unsupported compression %v", compression)
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+	count := 0
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		// Unmarshal through the object so that numbers are decoded as
+		// integers and not floats
+		object := &unstructured.Unstructured{}
+		if err := object.UnmarshalJSON(raw); err != nil {
+			return err
+		}
+		if err := objectFunc(object); err != nil {
+			return err
+		}
+		count++
+	}
+	if count != chunk.Count {
+		return fmt.Errorf("expected %v objects, found %v", chunk.Count, count)
+	}
+	return nil
+}
+
+func readLegacyResources(
+	bucket *blob.Bucket,
+	path string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+	objectFunc func(runtime.Unstructured) error,
+) error {
+	data, err := download(bucket, path, LegacyResourcesObjectName, encryption, checksums)
+	if err != nil {
+		return err
+	}
+	objects := make([]*unstructured.Unstructured, 0)
+	if err = json.Unmarshal(data, &objects); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err := objectFunc(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Downloads name from under path in the bucket. The data is checked against
+// checksums, if provided, as it was read so that it can't be replaced between
+// verifying the backup and using it.
+func download(
+	bucket *blob.Bucket,
+	path string,
+	name string,
+	encryption *BackupEncryption,
+	checksums *BackupManifest,
+) ([]byte, error) {
+	data, err := bucket.ReadAll(context.TODO(), filepath.Join(path, name))
+	if err != nil {
+		return nil, err
+	}
+	if checksums != nil {
+		if err := checksums.Verify(name, data); err != nil {
+			return nil, err
+		}
+	}
+	return encryption.Decrypt(data)
+}
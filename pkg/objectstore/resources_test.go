@@ -0,0 +1,135 @@
+// +build unittest
+
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/crypto"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/gcerrors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newResource(apiVersion, kind, name string) runtime.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "ns1",
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+			},
+		},
+	}
+}
+
+func newResources() []runtime.Unstructured {
+	objects := make([]runtime.Unstructured, 0)
+	for i := 0; i < 5; i++ {
+		objects = append(objects, newResource("v1", "ConfigMap", fmt.Sprintf("cm%v", i)))
+	}
+	objects = append(objects, newResource("v1", "PersistentVolumeClaim", "pvc"))
+	objects = append(objects, newResource("apps/v1", "Deployment", "deployment"))
+	return objects
+}
+
+func TestResourcesUploadDownload(t *testing.T) {
+	for _, encryptionKey := range []string{"", "testkey"} {
+		location := newFileBackupLocation(t)
+		location.Location.EncryptionKey = encryptionKey
+		bucket, err := GetBucket(location)
+		require.NoError(t, err, "Error getting bucket")
+		encryption, err := NewBackupEncryption(bucket, "ns1/backup/uid", location)
+		require.NoError(t, err, "Error creating data key")
+
+		objects := newResources()
+		writer := NewResourceWriter(bucket, "ns1/backup/uid", encryption, nil)
+		writer.objectsPerChunk = 2
+		for _, o := range objects {
+			require.NoError(t, writer.Write(o), "Error writing resource")
+		}
+		require.NoError(t, writer.Close(), "Error closing writer")
+
+		encryption, err = GetBackupEncryption(bucket, "ns1/backup/uid", location, nil)
+		require.NoError(t, err, "Error getting data key")
+		manifest, err := GetResourcesManifest(bucket, "ns1/backup/uid", encryption)
+		require.NoError(t, err, "Error getting manifest")
+		require.Equal(t, ResourcesFormatVersion, manifest.Version)
+		require.Equal(t, CompressionGzip, manifest.Compression)
+		counts := make([]int, 0)
+		for _, chunk := range manifest.Chunks {
+			counts = append(counts, chunk.Count)
+		}
+		require.Equal(t, []int{2, 2, 1, 1, 1}, counts, "Chunks should be split by count and kind")
+		require.Equal(t, "PersistentVolumeClaim", manifest.Chunks[3].Kind)
+		require.Equal(t, "apps", manifest.Chunks[4].Group)
+		legacy, err := IsLegacyResources(bucket, "ns1/backup/uid")
+		require.NoError(t, err, "Error checking resources format")
+		require.False(t, legacy, "Chunked resources shouldn't be in the legacy format")
+
+		downloaded, err := DownloadResources(bucket, "ns1/backup/uid", encryption, nil)
+		require.NoError(t, err, "Error downloading resources")
+		require.Equal(t, objects, downloaded, "Downloaded resources should match uploaded")
+
+		require.NoError(t, DeleteResources(bucket, "ns1/backup/uid"), "Error deleting resources")
+		_, err = GetResourcesManifest(bucket, "ns1/backup/uid", encryption)
+		require.Equal(t, gcerrors.NotFound, gcerrors.Code(err))
+		require.NoError(t, os.RemoveAll(location.Location.Path))
+	}
+}
+
+func TestResourcesDownloadLegacy(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	objects := newResources()
+	data, err := json.MarshalIndent(objects, "", " ")
+	require.NoError(t, err, "Error marshalling resources")
+	data, err = crypto.Encrypt(data, "testkey")
+	require.NoError(t, err, "Error encrypting resources")
+	require.NoError(t, bucket.WriteAll(context.TODO(), filepath.Join("ns1/backup/uid", LegacyResourcesObjectName), data, nil))
+
+	location.Location.EncryptionKey = "newkey"
+	location.Location.EncryptionConfig = &stork_api.EncryptionConfig{
+		PreviousEncryptionKeys: []string{"testkey"},
+	}
+	legacy, err := IsLegacyResources(bucket, "ns1/backup/uid")
+	require.NoError(t, err, "Error checking resources format")
+	require.True(t, legacy, "Resources without a manifest should be in the legacy format")
+	encryption, err := GetBackupEncryption(bucket, "ns1/backup/uid", location, nil)
+	require.NoError(t, err, "Error getting encryption for legacy backup")
+	downloaded, err := DownloadResources(bucket, "ns1/backup/uid", encryption, nil)
+	require.NoError(t, err, "Error downloading legacy resources")
+	require.Equal(t, objects, downloaded, "Downloaded resources should match uploaded")
+
+	require.NoError(t, DeleteResources(bucket, "ns1/backup/uid"), "Error deleting resources")
+	exists, err := bucket.Exists(context.TODO(), filepath.Join("ns1/backup/uid", LegacyResourcesObjectName))
+	require.NoError(t, err, "Error checking legacy resources")
+	require.False(t, exists, "Legacy resources should be deleted")
+}
+
+func TestResourcesDownloadMissingChunk(t *testing.T) {
+	location := newFileBackupLocation(t)
+	defer os.RemoveAll(location.Location.Path) // nolint: errcheck
+	bucket, err := GetBucket(location)
+	require.NoError(t, err, "Error getting bucket")
+
+	require.NoError(t, UploadResources(bucket, "ns1/backup/uid", nil, newResources(), nil))
+	require.NoError(t, bucket.Delete(context.TODO(), filepath.Join("ns1/backup/uid", ResourcesDirName, "00001.json.gz")))
+
+	_, err = DownloadResources(bucket, "ns1/backup/uid", nil, nil)
+	require.Error(t, err, "Expected error for missing chunk")
+}
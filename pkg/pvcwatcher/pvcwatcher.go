@@ -30,7 +30,7 @@ const (
 
 // PVCWatcher watches for changes in PVCs
 type PVCWatcher struct {
-	Driver   volume.Driver
+	Drivers  []volume.Driver
 	Recorder record.EventRecorder
 }
 
@@ -91,8 +91,11 @@ func (p *PVCWatcher) handleSnapshotScheduleUpdates(pvc *v1.PersistentVolumeClaim
 		return nil
 	}
 
-	// Do nothing if the driver doesn't own the PVC or if it isn't bound yet
-	if !p.Driver.OwnsPVC(pvc) || pvc.Status.Phase != v1.ClaimBound {
+	// Do nothing if none of the drivers own the PVC or if it isn't bound yet
+	if pvc.Status.Phase != v1.ClaimBound {
+		return nil
+	}
+	if _, err := volume.GetPVCDriverFromList(p.Drivers, pvc); err != nil {
 		return nil
 	}
 
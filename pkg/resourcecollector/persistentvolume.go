@@ -42,9 +42,9 @@ func (r *ResourceCollector) pvToBeCollected(
 			return false, err
 		}
 
-		// Don't collect PVCs not owned by the driver if collecting for a specific
-		// driver
-		if !allDrivers && !r.Driver.OwnsPVC(pvc) {
+		// Don't collect PVCs not owned by the configured drivers if collecting
+		// only for those drivers
+		if !allDrivers && !r.ownsPVC(pvc) {
 			return false, nil
 		}
 		// Else collect PVCs for all supported drivers
@@ -78,9 +78,12 @@ func (r *ResourceCollector) preparePVResourceForCollection(
 }
 
 // Updates the PV by pointing to the new volume. Also updated the name of the PV
-// itself. The restored PVC will point to this new PV name.
+// itself. The restored PVC will point to this new PV name. If there are no PV
+// name mappings or the PV isn't owned by any of the configured drivers, only
+// the namespace of the claim is updated.
 func (r *ResourceCollector) preparePVResourceForApply(
 	object runtime.Unstructured,
+	namespaceMappings map[string]string,
 	pvNameMappings map[string]string,
 ) error {
 	var updatedName string
@@ -91,18 +94,25 @@ func (r *ResourceCollector) preparePVResourceForApply(
 		return fmt.Errorf("error converting to persistent volume: %v", err)
 	}
 
+	if pv.Spec.ClaimRef != nil {
+		if destNamespace, ok := namespaceMappings[pv.Spec.ClaimRef.Namespace]; ok {
+			pv.Spec.ClaimRef.Namespace = destNamespace
+		}
+	}
+	driver, err := volume.GetPVDriverFromList(r.Drivers, &pv)
+	if pvNameMappings == nil || err != nil {
+		o, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pv)
+		if err != nil {
+			return err
+		}
+		object.SetUnstructuredContent(o)
+		return nil
+	}
+
 	if updatedName, present = pvNameMappings[pv.Name]; !present {
 		return fmt.Errorf("PV name mapping not found for %v", pv.Name)
 	}
 	pv.Name = updatedName
-	driverName, err := volume.GetPVDriver(&pv)
-	if err != nil {
-		return err
-	}
-	driver, err := volume.Get(driverName)
-	if err != nil {
-		return err
-	}
 	_, err = driver.UpdateMigratedPersistentVolumeSpec(&pv)
 	if err != nil {
 		return err
@@ -29,9 +29,9 @@ func (r *ResourceCollector) pvcToBeCollected(
 		return false, nil
 	}
 
-	// Don't collect PVCs not owned by the driver if collecting for a specific
-	// driver
-	if !allDrivers && !r.Driver.OwnsPVC(pvc) {
+	// Don't collect PVCs not owned by the configured drivers if collecting
+	// only for those drivers
+	if !allDrivers && !r.ownsPVC(pvc) {
 		return false, nil
 	}
 	// Else collect PVCs for all supported drivers
@@ -44,11 +44,16 @@ func (r *ResourceCollector) pvcToBeCollected(
 }
 
 // Updates the PVC by pointing to the new PV that it should refer to
-// pvNameMappings has the map of the original PV name to the new PV name
+// pvNameMappings has the map of the original PV name to the new PV name. The
+// PVC isn't updated if there are no mappings.
 func (r *ResourceCollector) preparePVCResourceForApply(
 	object runtime.Unstructured,
 	pvNameMappings map[string]string,
 ) error {
+	if pvNameMappings == nil {
+		return nil
+	}
+
 	var pvc v1.PersistentVolumeClaim
 	var updatedName string
 	var present bool
@@ -62,6 +67,11 @@ func (r *ResourceCollector) preparePVCResourceForApply(
 		return fmt.Errorf("error converting PVC object: %v: %v", object, err)
 	}
 
+	// PVCs that aren't owned by any of the configured drivers keep pointing
+	// to the same PV since it is applied as is
+	if !r.ownsPVC(&pvc) {
+		return nil
+	}
 	if updatedName, present = pvNameMappings[pvc.Spec.VolumeName]; !present {
 		return fmt.Errorf("PV name mapping not found for %v", metadata.GetName())
 	}
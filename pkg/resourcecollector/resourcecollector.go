@@ -8,8 +8,11 @@ import (
 
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -34,10 +37,11 @@ const (
 
 // ResourceCollector is used to collect and process unstructured objects in namespaces and using label selectors
 type ResourceCollector struct {
-	Driver           volume.Driver
+	Drivers          []volume.Driver
 	discoveryHelper  discovery.Helper
 	dynamicInterface dynamic.Interface
 	k8sOps           k8s.Ops
+	storkClient      storkclientset.Interface
 }
 
 // Init initializes the resource collector
@@ -71,9 +75,20 @@ func (r *ResourceCollector) Init(config *restclient.Config) error {
 	if err != nil {
 		return err
 	}
+
+	r.storkClient, err = storkclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// ownsPVC returns true if any of the configured drivers owns the PVC
+func (r *ResourceCollector) ownsPVC(pvc *v1.PersistentVolumeClaim) bool {
+	_, err := volume.GetPVCDriverFromList(r.Drivers, pvc)
+	return err == nil
+}
+
 func resourceToBeCollected(resource metav1.APIResource) bool {
 	switch resource.Kind {
 	case "PersistentVolumeClaim",
@@ -104,12 +119,27 @@ func resourceToBeCollected(resource metav1.APIResource) bool {
 	}
 }
 
-// GetResources gets all the resources in the given list of namespaces which match the labelSelectors
-func (r *ResourceCollector) GetResources(namespaces []string, labelSelectors map[string]string, allDrivers bool) ([]runtime.Unstructured, error) {
+// GetResources gets all the resources in the given list of namespaces which
+// match the labelSelectors. The resource types in includeResourceTypes and
+// excludeResourceTypes override the types collected by default and the
+// ResourceCollectorConfigs. The CRDs for the collected custom resources are
+// also returned, and the resources are sorted in the order in which they
+// should be applied.
+func (r *ResourceCollector) GetResources(
+	namespaces []string,
+	labelSelectors map[string]string,
+	includeResourceTypes []stork_api.ResourceType,
+	excludeResourceTypes []stork_api.ResourceType,
+	allDrivers bool,
+) ([]runtime.Unstructured, error) {
 	err := r.discoveryHelper.Refresh()
 	if err != nil {
 		return nil, err
 	}
+	filter, err := r.getResourceTypeFilter(includeResourceTypes, excludeResourceTypes)
+	if err != nil {
+		return nil, err
+	}
 	allObjects := make([]runtime.Unstructured, 0)
 
 	// Map to prevent collection of duplicate objects
@@ -127,7 +157,7 @@ func (r *ResourceCollector) GetResources(namespaces []string, labelSelectors map
 		}
 
 		for _, resource := range group.APIResources {
-			if !resourceToBeCollected(resource) {
+			if !filter.collect(groupVersion.WithKind(resource.Kind), resourceToBeCollected(resource)) {
 				continue
 			}
 			for _, ns := range namespaces {
@@ -188,10 +218,17 @@ func (r *ResourceCollector) GetResources(namespaces []string, labelSelectors map
 		return nil, err
 	}
 
+	crds, err := r.getCRDs(allObjects, resourceMap, filter)
+	if err != nil {
+		return nil, err
+	}
+	allObjects = append(allObjects, crds...)
+
 	err = r.prepareResourcesForCollection(allObjects, namespaces)
 	if err != nil {
 		return nil, err
 	}
+	SortResources(allObjects)
 	return allObjects, nil
 }
 
@@ -353,7 +390,9 @@ func (r *ResourceCollector) prepareResourcesForCollection(
 
 // PrepareResourceForApply prepares the resource for apply including update
 // namespace and any PV name updates. Should be called before DeleteResources
-// and ApplyResource
+// and ApplyResource. Namespaces without a mapping aren't updated. pvNameMappings
+// can be nil if the PVs aren't being renamed, in which case only the
+// namespaces in the PVs are updated.
 func (r *ResourceCollector) PrepareResourceForApply(
 	object runtime.Unstructured,
 	namespaceMappings map[string]string,
@@ -368,14 +407,14 @@ func (r *ResourceCollector) PrepareResourceForApply(
 	if err != nil {
 		return err
 	}
-	if metadata.GetNamespace() != "" {
+	if destNamespace, ok := namespaceMappings[metadata.GetNamespace()]; ok && metadata.GetNamespace() != "" {
 		// Update the namespace of the object, will be no-op for clustered resources
-		metadata.SetNamespace(namespaceMappings[metadata.GetNamespace()])
+		metadata.SetNamespace(destNamespace)
 	}
 
 	switch objectType.GetKind() {
 	case "PersistentVolume":
-		return r.preparePVResourceForApply(object, pvNameMappings)
+		return r.preparePVResourceForApply(object, namespaceMappings, pvNameMappings)
 	case "PersistentVolumeClaim":
 		return r.preparePVCResourceForApply(object, pvNameMappings)
 	case "ClusterRoleBinding":
@@ -383,7 +422,8 @@ func (r *ResourceCollector) PrepareResourceForApply(
 		if err != nil {
 			return err
 		}
-
+	case "RoleBinding":
+		return r.prepareRoleBindingForApply(object, namespaceMappings)
 	}
 	return nil
 }
@@ -396,7 +436,8 @@ func (r *ResourceCollector) mergeSupportedForResource(
 		return false
 	}
 	switch objectType.GetKind() {
-	case "ClusterRoleBinding":
+	case "ClusterRoleBinding",
+		"CustomResourceDefinition":
 		return true
 	}
 	return false
@@ -413,6 +454,10 @@ func (r *ResourceCollector) mergeAndUpdateResource(
 	switch objectType.GetKind() {
 	case "ClusterRoleBinding":
 		return r.mergeAndUpdateClusterRoleBinding(object)
+	case "CustomResourceDefinition":
+		// Existing CRDs aren't updated or deleted since that could affect
+		// the custom resources using them
+		return nil
 	}
 	return nil
 }
@@ -0,0 +1,389 @@
+package resourcecollector
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s"
+	"github.com/portworx/sched-ops/task"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	validateCRDInterval time.Duration = 5 * time.Second
+	validateCRDTimeout  time.Duration = 1 * time.Minute
+)
+
+var crdGroupVersionResource = schema.GroupVersionResource{
+	Group:    apiextensionsv1beta1.GroupName,
+	Version:  "v1beta1",
+	Resource: "customresourcedefinitions",
+}
+
+// Order in which resources are applied so that the resources they depend on
+// are created first
+const (
+	applyOrderCRD = iota
+	applyOrderNamespace
+	applyOrderRBAC
+	applyOrderConfig
+	applyOrderStorage
+	applyOrderWorkload
+	applyOrderCustomResource
+)
+
+// resourceTypeFilter is used to check if a resource type should be collected
+type resourceTypeFilter struct {
+	// Resource types from the ResourceCollectorConfigs
+	include []stork_api.ResourceType
+	exclude []stork_api.ResourceType
+	// Resource types from the Migration or ApplicationBackup spec
+	includeOverride []stork_api.ResourceType
+	excludeOverride []stork_api.ResourceType
+}
+
+// CreateCRD creates the CRD for the cluster-wide ResourceCollectorConfig
+func (r *ResourceCollector) CreateCRD() error {
+	resource := k8s.CustomResource{
+		Name:    stork_api.ResourceCollectorConfigResourceName,
+		Plural:  stork_api.ResourceCollectorConfigResourcePlural,
+		Group:   stork.GroupName,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   apiextensionsv1beta1.ClusterScoped,
+		Kind:    reflect.TypeOf(stork_api.ResourceCollectorConfig{}).Name(),
+	}
+	err := r.k8sOps.CreateCRD(resource)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return r.k8sOps.ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+}
+
+// getResourceTypeFilter returns the filter for the resource types to be
+// collected using the ResourceCollectorConfigs in the cluster and the
+// resource types from the spec of the object being collected for
+func (r *ResourceCollector) getResourceTypeFilter(
+	includeResourceTypes []stork_api.ResourceType,
+	excludeResourceTypes []stork_api.ResourceType,
+) (*resourceTypeFilter, error) {
+	filter := &resourceTypeFilter{
+		includeOverride: includeResourceTypes,
+		excludeOverride: excludeResourceTypes,
+	}
+	configs, err := r.storkClient.Stork().ResourceCollectorConfigs().List(metav1.ListOptions{})
+	if err != nil {
+		// The config is optional and the CRD might not have been created,
+		// for example on the destination cluster of a migration
+		if apierrors.IsNotFound(err) {
+			return filter, nil
+		}
+		return nil, fmt.Errorf("error getting ResourceCollectorConfigs: %v", err)
+	}
+	for _, config := range configs.Items {
+		filter.include = append(filter.include, config.Spec.IncludeResourceTypes...)
+		filter.exclude = append(filter.exclude, config.Spec.ExcludeResourceTypes...)
+	}
+	return filter, nil
+}
+
+// collect returns true if resources with the given group, version and kind
+// should be collected. The types from the spec take precedence over the
+// cluster-wide config, which takes precedence over the defaults. Excluded
+// types take precedence over included types at the same level.
+func (f *resourceTypeFilter) collect(gvk schema.GroupVersionKind, collectByDefault bool) bool {
+	switch {
+	case resourceTypesMatch(f.excludeOverride, gvk):
+		return false
+	case resourceTypesMatch(f.includeOverride, gvk):
+		return true
+	case resourceTypesMatch(f.exclude, gvk):
+		return false
+	case resourceTypesMatch(f.include, gvk):
+		return true
+	}
+	return collectByDefault
+}
+
+// resourceTypesMatch returns true if any of the resource types match the
+// group, version and kind
+func resourceTypesMatch(resourceTypes []stork_api.ResourceType, gvk schema.GroupVersionKind) bool {
+	for _, resourceType := range resourceTypes {
+		if resourceTypeMatches(resourceType, gvk) {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceTypeMatches(resourceType stork_api.ResourceType, gvk schema.GroupVersionKind) bool {
+	group := gvk.Group
+	// core Group doesn't have a name, so override it
+	if group == "" {
+		group = "core"
+	}
+	return (resourceType.Group == "" || resourceType.Group == group) &&
+		(resourceType.Version == "" || resourceType.Version == gvk.Version) &&
+		(resourceType.Kind == "" || resourceType.Kind == gvk.Kind)
+}
+
+// restoreFiltersMatch returns true if any of the filters match the resource
+func restoreFiltersMatch(
+	filters []stork_api.RestoreResourceFilter,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	name string,
+) bool {
+	for _, filter := range filters {
+		if resourceTypeMatches(filter.ResourceType, gvk) &&
+			(filter.Namespace == "" || filter.Namespace == namespace) &&
+			(filter.Name == "" || filter.Name == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreResourceSelected returns true if the resource in a backup should be
+// restored with the include and exclude filters from an ApplicationRestore.
+// Excluded resources take precedence over included resources, and all
+// resources are included if there aren't any include filters.
+func RestoreResourceSelected(
+	include []stork_api.RestoreResourceFilter,
+	exclude []stork_api.RestoreResourceFilter,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	name string,
+) bool {
+	if restoreFiltersMatch(exclude, gvk, namespace, name) {
+		return false
+	}
+	return len(include) == 0 || restoreFiltersMatch(include, gvk, namespace, name)
+}
+
+// RestoreObjectSelected returns true if the object from a backup should be
+// restored with the include and exclude filters from an ApplicationRestore.
+// PersistentVolumes are selected if their PersistentVolumeClaim is selected,
+// since they can't be used without it.
+func RestoreObjectSelected(
+	object runtime.Unstructured,
+	include []stork_api.RestoreResourceFilter,
+	exclude []stork_api.RestoreResourceFilter,
+) (bool, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return true, nil
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "PersistentVolume" {
+		claimName, found, err := unstructured.NestedString(object.UnstructuredContent(), "spec", "claimRef", "name")
+		if err != nil {
+			return false, err
+		}
+		claimNamespace, _, err := unstructured.NestedString(object.UnstructuredContent(), "spec", "claimRef", "namespace")
+		if err != nil {
+			return false, err
+		}
+		pvcGVK := schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
+		return found && RestoreResourceSelected(include, exclude, pvcGVK, claimNamespace, claimName), nil
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	return RestoreResourceSelected(include, exclude, gvk, metadata.GetNamespace(), metadata.GetName()), nil
+}
+
+// FilterResourcesForRestore returns the objects from a backup that should be
+// restored with the include and exclude filters from an ApplicationRestore
+func FilterResourcesForRestore(
+	objects []runtime.Unstructured,
+	include []stork_api.RestoreResourceFilter,
+	exclude []stork_api.RestoreResourceFilter,
+) ([]runtime.Unstructured, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return objects, nil
+	}
+	filtered := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		selected, err := RestoreObjectSelected(o, include, exclude)
+		if err != nil {
+			return nil, err
+		}
+		if selected {
+			filtered = append(filtered, o)
+		}
+	}
+	return filtered, nil
+}
+
+// getCRDs returns the CRDs for the custom resources that are being collected
+// so that they can be created before the custom resources are applied
+func (r *ResourceCollector) getCRDs(
+	objects []runtime.Unstructured,
+	resourceMap map[types.UID]bool,
+	filter *resourceTypeFilter,
+) ([]runtime.Unstructured, error) {
+	crdGVK := crdGroupVersionResource.GroupVersion().WithKind("CustomResourceDefinition")
+	if len(objects) == 0 || !filter.collect(crdGVK, true) {
+		return nil, nil
+	}
+
+	crdList, err := r.dynamicInterface.Resource(crdGroupVersionResource).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting CustomResourceDefinitions: %v", err)
+	}
+	// Map from the group and kind of the custom resources to their CRD
+	crds := make(map[schema.GroupKind]*unstructured.Unstructured)
+	for i := range crdList.Items {
+		groupKind, err := GetCRDGroupKind(&crdList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		crds[groupKind] = &crdList.Items[i]
+	}
+
+	collected := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		crd, ok := crds[o.GetObjectKind().GroupVersionKind().GroupKind()]
+		if !ok {
+			continue
+		}
+		if _, ok := resourceMap[crd.GetUID()]; ok {
+			continue
+		}
+		collected = append(collected, crd)
+		resourceMap[crd.GetUID()] = true
+	}
+	return collected, nil
+}
+
+// getApplyOrder returns the order in which the object should be applied:
+// CRDs, namespaces, RBAC, config, storage, workloads and finally custom
+// resources
+func getApplyOrder(object runtime.Unstructured) int {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	switch gvk.Kind {
+	case "CustomResourceDefinition":
+		return applyOrderCRD
+	case "Namespace":
+		return applyOrderNamespace
+	case "ServiceAccount",
+		"ClusterRole",
+		"ClusterRoleBinding",
+		"Role",
+		"RoleBinding":
+		return applyOrderRBAC
+	case "ConfigMap",
+		"Secret":
+		return applyOrderConfig
+	case "StorageClass",
+		"PersistentVolume",
+		"PersistentVolumeClaim":
+		return applyOrderStorage
+	}
+	if isCustomResource(gvk) {
+		return applyOrderCustomResource
+	}
+	return applyOrderWorkload
+}
+
+// isCustomResource returns true if the group of the resource isn't one of
+// the groups served by Kubernetes or OpenShift
+func isCustomResource(gvk schema.GroupVersionKind) bool {
+	return strings.Contains(gvk.Group, ".") &&
+		!strings.HasSuffix(gvk.Group, ".k8s.io") &&
+		!strings.HasSuffix(gvk.Group, ".openshift.io")
+}
+
+// SortResources sorts the objects in the order in which they should be
+// applied so that the resources they depend on are created first. The order
+// of objects of the same type is retained.
+func SortResources(objects []runtime.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return getApplyOrder(objects[i]) < getApplyOrder(objects[j])
+	})
+}
+
+// IsCRD returns true if the object is a CustomResourceDefinition
+func IsCRD(object runtime.Unstructured) bool {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	return gvk.Group == crdGroupVersionResource.Group && gvk.Kind == "CustomResourceDefinition"
+}
+
+// GetCRDGroupKind returns the group and kind of the custom resources defined
+// by a CustomResourceDefinition
+func GetCRDGroupKind(crd runtime.Unstructured) (schema.GroupKind, error) {
+	content := crd.UnstructuredContent()
+	group, _, err := unstructured.NestedString(content, "spec", "group")
+	if err != nil {
+		return schema.GroupKind{}, err
+	}
+	kind, _, err := unstructured.NestedString(content, "spec", "names", "kind")
+	if err != nil {
+		return schema.GroupKind{}, err
+	}
+	return schema.GroupKind{Group: group, Kind: kind}, nil
+}
+
+// isCRDEstablished returns true if the Established condition of the
+// CustomResourceDefinition is true
+func isCRDEstablished(crd *unstructured.Unstructured) (bool, error) {
+	conditions, _, err := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == string(apiextensionsv1beta1.Established) {
+			return condition["status"] == string(apiextensionsv1beta1.ConditionTrue), nil
+		}
+	}
+	return false, nil
+}
+
+// WaitForCRDEstablished waits for a CustomResourceDefinition to be
+// established by the API server so that custom resources of its kind can be
+// created
+func WaitForCRDEstablished(dynamicInterface dynamic.Interface, name string) error {
+	return waitForCRDEstablished(dynamicInterface, name, validateCRDTimeout, validateCRDInterval)
+}
+
+func waitForCRDEstablished(
+	dynamicInterface dynamic.Interface,
+	name string,
+	timeout time.Duration,
+	retryInterval time.Duration,
+) error {
+	t := func() (interface{}, bool, error) {
+		crd, err := dynamicInterface.Resource(crdGroupVersionResource).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, true, err
+		}
+		established, err := isCRDEstablished(crd)
+		if err != nil {
+			return nil, false, err
+		}
+		if !established {
+			return nil, true, fmt.Errorf("CustomResourceDefinition %v hasn't been established", name)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, retryInterval)
+	return err
+}
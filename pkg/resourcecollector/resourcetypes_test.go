@@ -0,0 +1,202 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newObject(apiVersion, kind, name string) runtime.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+		},
+	}
+}
+
+func TestResourceTypeFilter(t *testing.T) {
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	database := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}
+	cache := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Cache"}
+
+	filter := &resourceTypeFilter{}
+	require.True(t, filter.collect(secret, true), "Default resource type should be collected")
+	require.False(t, filter.collect(database, false), "Other resource types shouldn't be collected")
+
+	// Cluster-wide config
+	filter.include = []stork_api.ResourceType{{Group: "example.com"}}
+	filter.exclude = []stork_api.ResourceType{{Group: "core", Kind: "Secret"}, {Kind: "Cache"}}
+	require.False(t, filter.collect(secret, true), "Excluded default resource type shouldn't be collected")
+	require.True(t, filter.collect(database, false), "Included resource type should be collected")
+	require.False(t, filter.collect(cache, false), "Exclude should take precedence over include")
+
+	// Overrides from the spec
+	filter.includeOverride = []stork_api.ResourceType{{Version: "v1", Kind: "Secret"}, {Kind: "Cache"}}
+	filter.excludeOverride = []stork_api.ResourceType{{Group: "example.com", Kind: "Database"}, {Kind: "Cache"}}
+	require.True(t, filter.collect(secret, true), "Included override should take precedence over config")
+	require.False(t, filter.collect(database, false), "Excluded override should take precedence over config")
+	require.False(t, filter.collect(cache, false), "Excluded override should take precedence over included override")
+}
+
+func TestSortResources(t *testing.T) {
+	objects := []runtime.Unstructured{
+		newObject("example.com/v1", "Database", "db"),
+		newObject("apps/v1", "Deployment", "app"),
+		newObject("v1", "PersistentVolumeClaim", "data"),
+		newObject("v1", "Service", "app"),
+		newObject("v1", "ConfigMap", "config"),
+		newObject("rbac.authorization.k8s.io/v1", "RoleBinding", "app"),
+		newObject("v1", "ServiceAccount", "app"),
+		newObject("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "databases.example.com"),
+		newObject("route.openshift.io/v1", "Route", "app"),
+		newObject("v1", "PersistentVolume", "pv"),
+	}
+	SortResources(objects)
+
+	expected := []string{
+		"CustomResourceDefinition",
+		"RoleBinding",
+		"ServiceAccount",
+		"ConfigMap",
+		"PersistentVolumeClaim",
+		"PersistentVolume",
+		"Deployment",
+		"Service",
+		"Route",
+		"Database",
+	}
+	kinds := make([]string, 0, len(objects))
+	for _, o := range objects {
+		kinds = append(kinds, o.GetObjectKind().GroupVersionKind().Kind)
+	}
+	require.Equal(t, expected, kinds, "Resources not sorted in dependency order")
+}
+
+func TestRestoreResourceSelected(t *testing.T) {
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	require.True(t, RestoreResourceSelected(nil, nil, configMap, "ns1", "cm"), "Everything should be restored without filters")
+
+	include := []stork_api.RestoreResourceFilter{
+		{ResourceType: stork_api.ResourceType{Group: "core", Kind: "ConfigMap"}, Name: "cm"},
+		{ResourceType: stork_api.ResourceType{Kind: "Deployment"}, Namespace: "ns1"},
+	}
+	require.True(t, RestoreResourceSelected(include, nil, configMap, "ns1", "cm"), "Included resource should be restored")
+	require.True(t, RestoreResourceSelected(include, nil, configMap, "ns2", "cm"), "Filter without namespace should match all namespaces")
+	require.False(t, RestoreResourceSelected(include, nil, configMap, "ns1", "other"), "Resource with other name shouldn't be restored")
+	require.True(t, RestoreResourceSelected(include, nil, deployment, "ns1", "app"), "Included resource should be restored")
+	require.False(t, RestoreResourceSelected(include, nil, deployment, "ns2", "app"), "Resource in other namespace shouldn't be restored")
+
+	exclude := []stork_api.RestoreResourceFilter{{Namespace: "ns1", Name: "app"}}
+	require.False(t, RestoreResourceSelected(include, exclude, deployment, "ns1", "app"), "Exclude should take precedence over include")
+	require.False(t, RestoreResourceSelected(nil, exclude, deployment, "ns1", "app"), "Excluded resource shouldn't be restored")
+	require.True(t, RestoreResourceSelected(nil, exclude, configMap, "ns1", "cm"), "Resources that aren't excluded should be restored")
+}
+
+func TestFilterResourcesForRestore(t *testing.T) {
+	pvc := newObject("v1", "PersistentVolumeClaim", "data")
+	pv := newObject("v1", "PersistentVolume", "pv")
+	otherPV := newObject("v1", "PersistentVolume", "otherpv")
+	configMap := newObject("v1", "ConfigMap", "config")
+	for _, o := range []runtime.Unstructured{pvc, configMap} {
+		o.UnstructuredContent()["metadata"].(map[string]interface{})["namespace"] = "ns1"
+	}
+	pv.UnstructuredContent()["spec"] = map[string]interface{}{
+		"claimRef": map[string]interface{}{
+			"name":      "data",
+			"namespace": "ns1",
+		},
+	}
+	otherPV.UnstructuredContent()["spec"] = map[string]interface{}{
+		"claimRef": map[string]interface{}{
+			"name":      "other",
+			"namespace": "ns1",
+		},
+	}
+	objects := []runtime.Unstructured{pvc, pv, otherPV, configMap}
+
+	filtered, err := FilterResourcesForRestore(objects, nil, nil)
+	require.NoError(t, err, "Error filtering resources")
+	require.Equal(t, objects, filtered, "All resources should be restored without filters")
+
+	include := []stork_api.RestoreResourceFilter{
+		{ResourceType: stork_api.ResourceType{Kind: "PersistentVolumeClaim"}, Namespace: "ns1", Name: "data"},
+	}
+	filtered, err = FilterResourcesForRestore(objects, include, nil)
+	require.NoError(t, err, "Error filtering resources")
+	require.Equal(t, []runtime.Unstructured{pvc, pv}, filtered, "PV should be restored with its PVC")
+
+	exclude := []stork_api.RestoreResourceFilter{
+		{ResourceType: stork_api.ResourceType{Kind: "PersistentVolumeClaim"}},
+	}
+	filtered, err = FilterResourcesForRestore(objects, nil, exclude)
+	require.NoError(t, err, "Error filtering resources")
+	require.Equal(t, []runtime.Unstructured{configMap}, filtered, "PVs should be excluded with their PVCs")
+}
+
+func newCRD(name string, established bool) *unstructured.Unstructured {
+	status := "False"
+	if established {
+		status = "True"
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1beta1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"group": "example.com",
+				"names": map[string]interface{}{
+					"kind": "Database",
+				},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":   "NamesAccepted",
+						"status": "True",
+					},
+					map[string]interface{}{
+						"type":   "Established",
+						"status": status,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWaitForCRDEstablished(t *testing.T) {
+	crd := newCRD("databases.example.com", false)
+	require.True(t, IsCRD(crd), "CRD should be detected")
+	require.False(t, IsCRD(newObject("example.com/v1", "Database", "db")), "Custom resource isn't a CRD")
+	groupKind, err := GetCRDGroupKind(crd)
+	require.NoError(t, err, "Error getting group and kind from CRD")
+	require.Equal(t, schema.GroupKind{Group: "example.com", Kind: "Database"}, groupKind)
+
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), crd)
+	err = waitForCRDEstablished(client, "databases.example.com", 100*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, err, "CRD that isn't established should time out")
+	err = waitForCRDEstablished(client, "missing.example.com", 100*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, err, "Missing CRD should time out")
+
+	_, err = client.Resource(crdGroupVersionResource).Update(newCRD("databases.example.com", true))
+	require.NoError(t, err, "Error updating CRD")
+	err = waitForCRDEstablished(client, "databases.example.com", time.Second, 10*time.Millisecond)
+	require.NoError(t, err, "Established CRD shouldn't return an error")
+}
@@ -3,8 +3,10 @@ package resourcecollector
 import (
 	"strings"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 )
 
 func (r *ResourceCollector) roleBindingToBeCollected(
@@ -30,3 +32,49 @@ func (r *ResourceCollector) roleToBeCollected(
 	name := metadata.GetName()
 	return !strings.HasPrefix(name, "system:"), nil
 }
+
+// Updates the subjects of the RoleBinding that are in the namespaces being
+// mapped to point to the destination namespaces
+func (r *ResourceCollector) prepareRoleBindingForApply(
+	object runtime.Unstructured,
+	namespaceMappings map[string]string,
+) error {
+	var rb rbacv1.RoleBinding
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &rb); err != nil {
+		return err
+	}
+	for i := range rb.Subjects {
+		subject := &rb.Subjects[i]
+		for sourceNamespace, destNamespace := range namespaceMappings {
+			if sourceNamespace == destNamespace {
+				continue
+			}
+			inNamespace, err := r.subjectInNamespace(subject, sourceNamespace)
+			if err != nil {
+				return err
+			}
+			if !inNamespace {
+				continue
+			}
+			switch subject.Kind {
+			case rbacv1.UserKind:
+				_, username, err := serviceaccount.SplitUsername(subject.Name)
+				if err != nil {
+					return err
+				}
+				subject.Name = serviceaccount.MakeUsername(destNamespace, username)
+			case rbacv1.GroupKind:
+				subject.Name = serviceaccount.MakeNamespaceGroupName(destNamespace)
+			case rbacv1.ServiceAccountKind:
+				subject.Namespace = destNamespace
+			}
+			break
+		}
+	}
+	o, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rb)
+	if err != nil {
+		return err
+	}
+	object.SetUnstructuredContent(o)
+	return nil
+}
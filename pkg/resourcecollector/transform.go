@@ -0,0 +1,120 @@
+package resourcecollector
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// protectedPaths can't be modified by transforms since they would change the
+// namespace or name that the resource is created with
+var protectedPaths = []string{"", "/metadata", "/metadata/namespace", "/metadata/name"}
+
+// ValidateTransforms checks that the patches for all the transforms are valid
+// JSON patch documents and that they don't modify the name or namespace of
+// the resources
+func ValidateTransforms(transforms []stork_api.ResourceTransform) error {
+	for i, transform := range transforms {
+		if _, err := jsonpatch.DecodePatch([]byte(transform.Patch)); err != nil {
+			return fmt.Errorf("invalid patch for transform %v: %v", i, err)
+		}
+		operations := make([]map[string]interface{}, 0)
+		if err := json.Unmarshal([]byte(transform.Patch), &operations); err != nil {
+			return fmt.Errorf("invalid patch for transform %v: %v", i, err)
+		}
+		for _, operation := range operations {
+			for _, field := range []string{"path", "from"} {
+				path, ok := operation[field].(string)
+				if !ok {
+					continue
+				}
+				for _, protected := range protectedPaths {
+					if path == protected {
+						return fmt.Errorf("transform %v can't modify %v", i, path)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TransformResource applies the patches from all the transforms that match
+// the resource, in the order that they are specified. Should be called after
+// PrepareResourceForApply. Returns an error if a transform changes the
+// namespace or name of the resource.
+func (r *ResourceCollector) TransformResource(
+	object runtime.Unstructured,
+	transforms []stork_api.ResourceTransform,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	namespace := metadata.GetNamespace()
+	name := metadata.GetName()
+	for i, transform := range transforms {
+		match, err := transformMatches(object, &transform)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+
+		patch, err := jsonpatch.DecodePatch([]byte(transform.Patch))
+		if err != nil {
+			return fmt.Errorf("invalid patch for transform %v: %v", i, err)
+		}
+		content, err := json.Marshal(object.UnstructuredContent())
+		if err != nil {
+			return err
+		}
+		patched, err := patch.Apply(content)
+		if err != nil {
+			return fmt.Errorf("error applying transform %v: %v", i, err)
+		}
+		updated := &unstructured.Unstructured{}
+		if err := updated.UnmarshalJSON(patched); err != nil {
+			return fmt.Errorf("error decoding object after applying transform %v: %v", i, err)
+		}
+		// The namespace was already mapped and checked for the destination,
+		// so transforms aren't allowed to move the resource
+		if updated.GetNamespace() != namespace || updated.GetName() != name {
+			return fmt.Errorf("transform %v can't change the namespace or name of %v/%v", i, namespace, name)
+		}
+		object.SetUnstructuredContent(updated.UnstructuredContent())
+	}
+	return nil
+}
+
+// transformMatches returns true if the group, version, kind and labels of the
+// object match the transform
+func transformMatches(
+	object runtime.Unstructured,
+	transform *stork_api.ResourceTransform,
+) (bool, error) {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	group := gvk.Group
+	// core Group doesn't have a name, so override it
+	if group == "" {
+		group = "core"
+	}
+	if (transform.Group != "" && transform.Group != group) ||
+		(transform.Version != "" && transform.Version != gvk.Version) ||
+		(transform.Kind != "" && transform.Kind != gvk.Kind) {
+		return false, nil
+	}
+
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	return labels.SelectorFromSet(labels.Set(transform.Selectors)).Matches(labels.Set(metadata.GetLabels())), nil
+}
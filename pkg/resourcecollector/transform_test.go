@@ -0,0 +1,177 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newDeployment(labels map[string]interface{}) runtime.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "app",
+				"namespace": "prod",
+				"labels":    labels,
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":  "app",
+								"image": "registry.prod/app:1.0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTransformResource(t *testing.T) {
+	r := &ResourceCollector{}
+	transforms := []stork_api.ResourceTransform{
+		{
+			Group:     "apps",
+			Kind:      "Deployment",
+			Selectors: map[string]string{"app": "web"},
+			Patch:     `[{"op": "replace", "path": "/spec/template/spec/containers/0/image", "value": "registry.dr/app:1.0"}]`,
+		},
+		{
+			Kind:  "Service",
+			Patch: `[{"op": "remove", "path": "/spec/clusterIP"}]`,
+		},
+	}
+	require.NoError(t, ValidateTransforms(transforms), "Error validating transforms")
+
+	object := newDeployment(map[string]interface{}{"app": "web"})
+	err := r.TransformResource(object, transforms)
+	require.NoError(t, err, "Error transforming resource")
+	containers, _, err := unstructured.NestedSlice(object.UnstructuredContent(), "spec", "template", "spec", "containers")
+	require.NoError(t, err, "Error getting containers")
+	require.Equal(t, "registry.dr/app:1.0", containers[0].(map[string]interface{})["image"], "Image not replaced")
+	replicas, _, err := unstructured.NestedInt64(object.UnstructuredContent(), "spec", "replicas")
+	require.NoError(t, err, "Error getting replicas")
+	require.Equal(t, int64(3), replicas, "Replicas changed")
+
+	// Transforms shouldn't be applied to objects that don't match the
+	// selectors
+	object = newDeployment(map[string]interface{}{"app": "db"})
+	err = r.TransformResource(object, transforms)
+	require.NoError(t, err, "Error transforming resource")
+	containers, _, err = unstructured.NestedSlice(object.UnstructuredContent(), "spec", "template", "spec", "containers")
+	require.NoError(t, err, "Error getting containers")
+	require.Equal(t, "registry.prod/app:1.0", containers[0].(map[string]interface{})["image"], "Image shouldn't be replaced")
+
+	err = ValidateTransforms([]stork_api.ResourceTransform{{Patch: "{}"}})
+	require.Error(t, err, "Expected error for invalid patch")
+}
+
+func TestTransformResourceNamespace(t *testing.T) {
+	for _, patch := range []string{
+		`[{"op": "replace", "path": "/metadata/namespace", "value": "kube-system"}]`,
+		`[{"op": "replace", "path": "/metadata/name", "value": "other"}]`,
+		`[{"op": "replace", "path": "/metadata", "value": {"name": "app", "namespace": "kube-system"}}]`,
+		`[{"op": "move", "from": "/metadata/namespace", "path": "/spec/namespace"}]`,
+	} {
+		err := ValidateTransforms([]stork_api.ResourceTransform{{Patch: patch}})
+		require.Error(t, err, "Expected error for transform modifying name or namespace: %v", patch)
+	}
+	require.NoError(t, ValidateTransforms([]stork_api.ResourceTransform{
+		{Patch: `[{"op": "add", "path": "/metadata/labels/namespace", "value": "kube-system"}]`},
+	}), "Labels should be allowed to be transformed")
+
+	// Transforms that weren't validated still can't move the resource
+	r := &ResourceCollector{}
+	object := newDeployment(nil)
+	err := r.TransformResource(object, []stork_api.ResourceTransform{
+		{Patch: `[{"op": "replace", "path": "/metadata/namespace", "value": "kube-system"}]`},
+	})
+	require.Error(t, err, "Expected error for transform changing namespace")
+	require.Equal(t, "prod", object.(*unstructured.Unstructured).GetNamespace(), "Namespace shouldn't be changed")
+}
+
+func TestPrepareResourceForApplyNamespaceMapping(t *testing.T) {
+	r := &ResourceCollector{}
+	namespaceMappings := map[string]string{"prod": "dr-prod"}
+
+	object := newDeployment(nil)
+	err := r.PrepareResourceForApply(object, namespaceMappings, nil)
+	require.NoError(t, err, "Error preparing resource")
+	require.Equal(t, "dr-prod", object.(*unstructured.Unstructured).GetNamespace(), "Namespace not mapped")
+
+	rb := &rbacv1.RoleBinding{
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "prod"},
+			{Kind: rbacv1.ServiceAccountKind, Name: "monitoring", Namespace: "monitoring"},
+		},
+	}
+	rb.Name = "app"
+	rb.Namespace = "prod"
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rb)
+	require.NoError(t, err, "Error converting role binding")
+	object = &unstructured.Unstructured{Object: content}
+	object.GetObjectKind().SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("RoleBinding"))
+
+	err = r.PrepareResourceForApply(object, namespaceMappings, nil)
+	require.NoError(t, err, "Error preparing resource")
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), rb)
+	require.NoError(t, err, "Error converting role binding")
+	require.Equal(t, "dr-prod", rb.Namespace, "Namespace not mapped")
+	require.Equal(t, "dr-prod", rb.Subjects[0].Namespace, "Subject namespace not mapped")
+	require.Equal(t, "monitoring", rb.Subjects[1].Namespace, "Subject in other namespace shouldn't be mapped")
+}
+
+func TestPrepareResourceForApplyUnownedPV(t *testing.T) {
+	r := &ResourceCollector{}
+	namespaceMappings := map[string]string{"prod": "dr-prod"}
+	pvNameMappings := map[string]string{"owned": "owned-restored"}
+
+	pv := &v1.PersistentVolume{
+		Spec: v1.PersistentVolumeSpec{
+			ClaimRef: &v1.ObjectReference{Name: "data", Namespace: "prod"},
+		},
+	}
+	pv.Name = "unowned"
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pv)
+	require.NoError(t, err, "Error converting PV")
+	object := &unstructured.Unstructured{Object: content}
+	object.GetObjectKind().SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("PersistentVolume"))
+
+	err = r.PrepareResourceForApply(object, namespaceMappings, pvNameMappings)
+	require.NoError(t, err, "PV not owned by any driver should be applied as is")
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), pv)
+	require.NoError(t, err, "Error converting PV")
+	require.Equal(t, "unowned", pv.Name, "PV not owned by any driver shouldn't be renamed")
+	require.Equal(t, "dr-prod", pv.Spec.ClaimRef.Namespace, "Claim namespace not mapped")
+
+	pvc := &v1.PersistentVolumeClaim{
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName: "unowned",
+		},
+	}
+	pvc.Name = "data"
+	pvc.Namespace = "prod"
+	content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(pvc)
+	require.NoError(t, err, "Error converting PVC")
+	object = &unstructured.Unstructured{Object: content}
+	object.GetObjectKind().SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"))
+
+	err = r.PrepareResourceForApply(object, namespaceMappings, pvNameMappings)
+	require.NoError(t, err, "PVC not owned by any driver should be applied as is")
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), pvc)
+	require.NoError(t, err, "Error converting PVC")
+	require.Equal(t, "unowned", pvc.Spec.VolumeName, "PVC not owned by any driver should point to the same PV")
+}
@@ -28,6 +28,11 @@ const (
 	MockTimeConfigMapNamespace = "kube-system"
 	// MockTimeConfigMapKey is the key name in the config map data that contains the time
 	MockTimeConfigMapKey = "time"
+	// defaultStartingDeadline is the deadline for starting daily, weekly and
+	// monthly policies if none is specified
+	defaultStartingDeadline = 1 * time.Hour
+	// maxMissedRuns is the number of missed runs that are kept in the status
+	maxMissedRuns = 10
 )
 
 var mockTime *time.Time
@@ -52,95 +57,193 @@ func TriggerRequired(
 	policyType stork_api.SchedulePolicyType,
 	lastTrigger meta.Time,
 ) (bool, error) {
-	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	trigger, err := CheckTrigger(policyName, policyType, lastTrigger, nil)
 	if err != nil {
 		return false, err
 	}
+	return trigger.Required, nil
+}
+
+// Trigger is the result of checking if a policy needs to be triggered
+type Trigger struct {
+	// ScheduledTime is the latest time the policy was scheduled to be
+	// triggered at
+	ScheduledTime time.Time
+	// Required is set if the policy should be triggered now
+	Required bool
+	// Missed is set if the policy wasn't triggered for the scheduled time
+	// before the starting deadline passed
+	Missed bool
+	// Deadline is the starting deadline that was used, zero if there wasn't
+	// one
+	Deadline time.Duration
+}
+
+// CheckTrigger checks if a trigger is required for a policy given the last
+// trigger time and the deadline in seconds for starting a trigger after the
+// time it was scheduled for. If no deadline is specified it defaults to one
+// hour for daily, weekly and monthly policies and no deadline for interval
+// policies.
+func CheckTrigger(
+	policyName string,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	startingDeadlineSeconds *int64,
+) (*Trigger, error) {
+	schedulePolicy, err := k8s.Instance().GetSchedulePolicy(policyName)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
-		return false, err
+		return nil, err
 	}
 
 	now := GetCurrentTime()
+	scheduledTime, err := getScheduledTime(schedulePolicy, policyType, lastTrigger.Time, now)
+	if err != nil {
+		return nil, err
+	}
+	trigger := &Trigger{
+		ScheduledTime: scheduledTime,
+	}
+	// Nothing to do if the policy isn't configured, isn't due yet or was
+	// already triggered for the scheduled time
+	if scheduledTime.IsZero() || scheduledTime.After(now) || !lastTrigger.Time.Before(scheduledTime) {
+		return trigger, nil
+	}
+	// Interval policies are only triggered once more than the interval has
+	// passed since the last trigger
+	if policyType == stork_api.SchedulePolicyTypeInterval && !lastTrigger.IsZero() && !scheduledTime.Before(now) {
+		return trigger, nil
+	}
+
+	if startingDeadlineSeconds != nil {
+		trigger.Deadline = time.Duration(*startingDeadlineSeconds) * time.Second
+	} else if policyType != stork_api.SchedulePolicyTypeInterval {
+		trigger.Deadline = defaultStartingDeadline
+	}
+	if trigger.Deadline != 0 && now.Sub(scheduledTime) >= trigger.Deadline {
+		trigger.Missed = true
+		return trigger, nil
+	}
+	trigger.Required = true
+	return trigger, nil
+}
+
+// getScheduledTime returns the latest time at or before now that the policy
+// was scheduled to be triggered at. Returns zero time if the policy type
+// isn't configured.
+func getScheduledTime(
+	schedulePolicy *stork_api.SchedulePolicy,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger time.Time,
+	now time.Time,
+) (time.Time, error) {
 	switch policyType {
 	case stork_api.SchedulePolicyTypeInterval:
 		if schedulePolicy.Policy.Interval == nil {
-			return false, nil
+			return time.Time{}, nil
+		}
+		// Trigger right away if it has never been triggered
+		if lastTrigger.IsZero() {
+			return now, nil
 		}
 		duration := time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute
-		// Trigger if more than intervalMinutes has passed since
-		// last trigger
-		if lastTrigger.Add(duration).Before(now) {
-			return true, nil
+		scheduledTime := lastTrigger.Add(duration)
+		// Move on to the latest interval if any were missed
+		if scheduledTime.Before(now) {
+			scheduledTime = scheduledTime.Add(now.Sub(scheduledTime) / duration * duration)
 		}
-		return false, nil
+		return scheduledTime, nil
 
 	case stork_api.SchedulePolicyTypeDaily:
 		if schedulePolicy.Policy.Daily == nil {
-			return false, nil
+			return time.Time{}, nil
 		}
 
 		policyHour, policyMinute, err := schedulePolicy.Policy.Daily.GetHourMinute()
 		if err != nil {
-			return false, err
+			return time.Time{}, err
 		}
 
-		nextTrigger := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, time.Local)
-
-		return checkTrigger(lastTrigger.Time, nextTrigger, now)
+		scheduledTime := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, time.Local)
+		if scheduledTime.After(now) {
+			scheduledTime = scheduledTime.AddDate(0, 0, -1)
+		}
+		return scheduledTime, nil
 
 	case stork_api.SchedulePolicyTypeWeekly:
 		if schedulePolicy.Policy.Weekly == nil {
-			return false, nil
+			return time.Time{}, nil
 		}
 		currentDay := now.Weekday()
 		scheduledDay := stork_api.Days[schedulePolicy.Policy.Weekly.Day]
 		policyHour, policyMinute, err := schedulePolicy.Policy.Weekly.GetHourMinute()
 		if err != nil {
-			return false, err
+			return time.Time{}, err
 		}
-		nextTrigger := time.Date(now.Year(), now.Month(), now.Day(), policyHour, policyMinute, 0, 0, time.Local)
-		// Figure out how many days to add to get to the next
-		// trigger week day
-		if currentDay < scheduledDay {
-			nextTrigger = nextTrigger.Add(time.Hour * time.Duration((scheduledDay-currentDay)*24))
-		} else if currentDay > scheduledDay {
-			nextTrigger = nextTrigger.Add(time.Duration((7-(currentDay-scheduledDay))*24) * time.Hour)
+		// Go back to the last scheduled week day
+		daysBack := (int(currentDay) - int(scheduledDay) + 7) % 7
+		scheduledTime := time.Date(now.Year(), now.Month(), now.Day()-daysBack, policyHour, policyMinute, 0, 0, time.Local)
+		if scheduledTime.After(now) {
+			scheduledTime = scheduledTime.AddDate(0, 0, -7)
 		}
+		return scheduledTime, nil
 
-		return checkTrigger(lastTrigger.Time, nextTrigger, now)
 	case stork_api.SchedulePolicyTypeMonthly:
 		if schedulePolicy.Policy.Monthly == nil {
-			return false, nil
+			return time.Time{}, nil
 		}
 		policyHour, policyMinute, err := schedulePolicy.Policy.Monthly.GetHourMinute()
 		if err != nil {
-			return false, err
+			return time.Time{}, err
 		}
-		nextTrigger := time.Date(now.Year(), now.Month(), schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
-
-		return checkTrigger(lastTrigger.Time, nextTrigger, now)
+		scheduledTime := time.Date(now.Year(), now.Month(), schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
+		if scheduledTime.After(now) {
+			scheduledTime = time.Date(now.Year(), now.Month()-1, schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
+		}
+		return scheduledTime, nil
 	}
-	return false, nil
+	return time.Time{}, nil
 }
 
-func checkTrigger(
-	lastTrigger time.Time,
-	nextTrigger time.Time,
-	now time.Time,
-) (bool, error) {
-	// If we had triggered after the scheduled time this month, don't
-	// triggered again
-	if lastTrigger.After(nextTrigger) || lastTrigger.Equal(nextTrigger) {
-		return false, nil
+// AddMissedRun records a run of a policy that was missed for the scheduled
+// time. The reason is updated if the run was already recorded. Only the most
+// recent maxMissedRuns are kept. Returns true if the missed runs were updated.
+func AddMissedRun(
+	missedRuns []*stork_api.ScheduleMissedRun,
+	policyType stork_api.SchedulePolicyType,
+	scheduledTime time.Time,
+	reason string,
+) ([]*stork_api.ScheduleMissedRun, bool) {
+	for _, missedRun := range missedRuns {
+		if missedRun.PolicyType == policyType && missedRun.ScheduledTime.Time.Equal(scheduledTime) {
+			if missedRun.Reason == reason {
+				return missedRuns, false
+			}
+			missedRun.Reason = reason
+			return missedRuns, true
+		}
+	}
+	missedRuns = append(missedRuns, &stork_api.ScheduleMissedRun{
+		PolicyType:    policyType,
+		ScheduledTime: meta.NewTime(scheduledTime),
+		Reason:        reason,
+	})
+	if len(missedRuns) > maxMissedRuns {
+		missedRuns = missedRuns[len(missedRuns)-maxMissedRuns:]
 	}
+	return missedRuns, true
+}
 
-	// If we are within one hour after/at the next trigger time, trigger a new
-	// schedule
-	if now.Equal(nextTrigger) || (now.After(nextTrigger) && now.Sub(nextTrigger).Hours() < 1) {
-		return true, nil
+// GetConcurrencyPolicy returns the concurrency policy to use for a schedule,
+// defaulting to Forbid if none is specified
+func GetConcurrencyPolicy(concurrencyPolicy stork_api.ConcurrencyPolicyType) stork_api.ConcurrencyPolicyType {
+	if concurrencyPolicy == "" {
+		return stork_api.ConcurrencyPolicyForbid
 	}
-	return false, nil
+	return concurrencyPolicy
 }
 
 // ValidateSchedulePolicy Validate if a given schedule policy is valid
@@ -30,6 +30,8 @@ func TestSchedule(t *testing.T) {
 	t.Run("triggerDailyRequiredTest", triggerDailyRequiredTest)
 	t.Run("triggerWeeklyRequiredTest", triggerWeeklyRequiredTest)
 	t.Run("triggerMonthlyRequiredTest", triggerMonthlyRequiredTest)
+	t.Run("checkTriggerDeadlineTest", checkTriggerDeadlineTest)
+	t.Run("addMissedRunTest", addMissedRunTest)
 	t.Run("validateSchedulePolicyTest", validateSchedulePolicyTest)
 	t.Run("policyRetainTest", policyRetainTest)
 }
@@ -223,6 +225,86 @@ func triggerMonthlyRequiredTest(t *testing.T) {
 	require.False(t, required, "Trigger should not have been required")
 }
 
+func checkTriggerDeadlineTest(t *testing.T) {
+	defer func() {
+		err := k8s.Instance().DeleteSchedulePolicy("deadlinepolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := k8s.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "deadlinepolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	// Three hours after the daily schedule
+	mockNow := time.Date(2019, time.February, 8, 2, 15, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	lastTrigger := meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local)
+	trigger, err := CheckTrigger("deadlinepolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, nil)
+	require.NoError(t, err, "Error checking trigger")
+	require.False(t, trigger.Required, "Trigger should not have been required after default deadline")
+	require.True(t, trigger.Missed, "Trigger should have been missed after default deadline")
+	require.Equal(t, time.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local), trigger.ScheduledTime, "Wrong scheduled time")
+	require.Equal(t, time.Hour, trigger.Deadline, "Wrong default deadline")
+
+	// Catch up if the deadline hasn't passed
+	deadline := int64(4 * 60 * 60)
+	trigger, err = CheckTrigger("deadlinepolicy", stork_api.SchedulePolicyTypeDaily, lastTrigger, &deadline)
+	require.NoError(t, err, "Error checking trigger")
+	require.True(t, trigger.Required, "Trigger should have been required within deadline")
+	require.False(t, trigger.Missed, "Trigger should not have been missed within deadline")
+
+	// Interval policies don't have a deadline by default. The scheduled time
+	// is for the latest interval.
+	lastTrigger = meta.Date(2019, time.February, 7, 22, 45, 0, 0, time.Local)
+	trigger, err = CheckTrigger("deadlinepolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, nil)
+	require.NoError(t, err, "Error checking trigger")
+	require.True(t, trigger.Required, "Trigger should have been required without deadline")
+	require.Equal(t, time.Date(2019, time.February, 8, 1, 45, 0, 0, time.Local), trigger.ScheduledTime, "Wrong scheduled time")
+
+	deadline = int64(10 * 60)
+	trigger, err = CheckTrigger("deadlinepolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, &deadline)
+	require.NoError(t, err, "Error checking trigger")
+	require.True(t, trigger.Missed, "Trigger should have been missed after deadline")
+
+	// Nothing to do once triggered for the scheduled time
+	lastTrigger = meta.NewTime(trigger.ScheduledTime)
+	trigger, err = CheckTrigger("deadlinepolicy", stork_api.SchedulePolicyTypeInterval, lastTrigger, &deadline)
+	require.NoError(t, err, "Error checking trigger")
+	require.False(t, trigger.Required, "Trigger should not have been required")
+	require.False(t, trigger.Missed, "Trigger should not have been missed")
+}
+
+func addMissedRunTest(t *testing.T) {
+	scheduledTime := time.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local)
+	missedRuns, updated := AddMissedRun(nil, stork_api.SchedulePolicyTypeDaily, scheduledTime, "reason1")
+	require.True(t, updated, "Missed runs should have been updated")
+	require.Len(t, missedRuns, 1, "Missed run should have been added")
+
+	missedRuns, updated = AddMissedRun(missedRuns, stork_api.SchedulePolicyTypeDaily, scheduledTime, "reason1")
+	require.False(t, updated, "Missed runs should not have been updated for the same run")
+	missedRuns, updated = AddMissedRun(missedRuns, stork_api.SchedulePolicyTypeDaily, scheduledTime, "reason2")
+	require.True(t, updated, "Missed runs should have been updated for a new reason")
+	require.Len(t, missedRuns, 1, "Missed run shouldn't have been duplicated")
+	require.Equal(t, "reason2", missedRuns[0].Reason, "Reason should have been updated")
+
+	for i := 1; i <= maxMissedRuns; i++ {
+		missedRuns, _ = AddMissedRun(missedRuns, stork_api.SchedulePolicyTypeDaily, scheduledTime.AddDate(0, 0, i), "reason")
+	}
+	require.Len(t, missedRuns, maxMissedRuns, "Only the most recent missed runs should be kept")
+	require.Equal(t, scheduledTime.AddDate(0, 0, 1), missedRuns[0].ScheduledTime.Time, "Oldest missed run should have been removed")
+}
+
 func validateSchedulePolicyTest(t *testing.T) {
 	policy := &stork_api.SchedulePolicy{
 		ObjectMeta: meta.ObjectMeta{
@@ -25,7 +25,7 @@ const (
 
 // Snapshotter Snapshot Controller
 type Snapshotter struct {
-	Driver  volume.Driver
+	Drivers []volume.Driver
 	lock    sync.Mutex
 	started bool
 }
@@ -74,7 +74,12 @@ func (s *Snapshotter) Start(stopChannel <-chan struct{}) error {
 	}
 
 	plugins := make(map[string]snapshotvolume.Plugin)
-	plugins[s.Driver.String()] = s.Driver.GetSnapshotPlugin()
+	for _, driver := range s.Drivers {
+		// Skip drivers that don't support snapshots
+		if plugin := driver.GetSnapshotPlugin(); plugin != nil {
+			plugins[driver.String()] = plugin
+		}
+	}
 
 	snapController := snapshotcontroller.NewSnapshotController(snapshotClient, snapshotScheme,
 		clientset, &plugins, defaultSyncDuration)
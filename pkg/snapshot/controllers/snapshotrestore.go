@@ -33,7 +33,7 @@ const (
 
 // SnapshotRestoreController controller to watch over In-Place snap restore CRD's
 type SnapshotRestoreController struct {
-	Driver   volume.Driver
+	Drivers  []volume.Driver
 	Recorder record.EventRecorder
 }
 
@@ -92,7 +92,7 @@ func (c *SnapshotRestoreController) Handle(ctx context.Context, event sdk.Event)
 					"Snapshot in-Place  Restore completed")
 			}
 		case stork_api.VolumeSnapshotRestoreStatusFailed:
-			err = c.Driver.CleanupSnapshotRestoreObjects(snapRestore)
+			err = c.cleanupSnapshotRestoreObjects(snapRestore)
 		case stork_api.VolumeSnapshotRestoreStatusSuccessful:
 			return nil
 		default:
@@ -183,7 +183,10 @@ func (c *SnapshotRestoreController) handleFinal(snapRestore *stork_api.VolumeSna
 		return err
 	}
 	// Do driver volume snapshot restore here
-	err = c.Driver.CompleteVolumeSnapshotRestore(snapRestore)
+	driver, err := c.getDriver(snapRestore)
+	if err == nil {
+		err = driver.CompleteVolumeSnapshotRestore(snapRestore)
+	}
 	if err != nil {
 		if err := unmarkPVCForRestore(snapRestore.Status.Volumes); err != nil {
 			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to umark pvc for restore %v", err)
@@ -315,14 +318,44 @@ func (c *SnapshotRestoreController) createCRD() error {
 }
 
 func (c *SnapshotRestoreController) handleDelete(snapRestore *stork_api.VolumeSnapshotRestore) error {
-	return c.Driver.CleanupSnapshotRestoreObjects(snapRestore)
+	return c.cleanupSnapshotRestoreObjects(snapRestore)
+}
+
+// getDriver returns the driver that owns the volumes being restored. All the
+// volumes in a restore come from snapshots taken by the same driver, so the
+// first volume is used to look it up.
+func (c *SnapshotRestoreController) getDriver(snapRestore *stork_api.VolumeSnapshotRestore) (volume.Driver, error) {
+	if len(snapRestore.Status.Volumes) == 0 {
+		return nil, fmt.Errorf("no volumes found for snapshot restore")
+	}
+	vInfo := snapRestore.Status.Volumes[0]
+	pvc, err := k8s.Instance().GetPersistentVolumeClaim(vInfo.PVC, vInfo.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pvc details %v", err)
+	}
+	return volume.GetPVCDriverFromList(c.Drivers, pvc)
+}
+
+func (c *SnapshotRestoreController) cleanupSnapshotRestoreObjects(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	// Nothing to cleanup if the volumes weren't populated
+	if len(snapRestore.Status.Volumes) == 0 {
+		return nil
+	}
+	driver, err := c.getDriver(snapRestore)
+	if err != nil {
+		return err
+	}
+	return driver.CleanupSnapshotRestoreObjects(snapRestore)
 }
 
 func (c *SnapshotRestoreController) waitForRestoreToReady(
 	snapRestore *stork_api.VolumeSnapshotRestore,
 ) (bool, error) {
 	if snapRestore.Status.Status == stork_api.VolumeSnapshotRestoreStatusPending {
-		err := c.Driver.StartVolumeSnapshotRestore(snapRestore)
+		driver, err := c.getDriver(snapRestore)
+		if err == nil {
+			err = driver.StartVolumeSnapshotRestore(snapRestore)
+		}
 		if err != nil {
 			message := fmt.Sprintf("Error starting snapshot restore for volumes: %v", err)
 			log.VolumeSnapshotRestoreLog(snapRestore).Errorf(message)
@@ -344,7 +377,11 @@ func (c *SnapshotRestoreController) waitForRestoreToReady(
 	continueProcessing := false
 	// Skip checking status if no volumes are being restored
 	if len(snapRestore.Status.Volumes) != 0 {
-		err := c.Driver.GetVolumeSnapshotRestoreStatus(snapRestore)
+		driver, err := c.getDriver(snapRestore)
+		if err != nil {
+			return continueProcessing, err
+		}
+		err = driver.GetVolumeSnapshotRestoreStatus(snapRestore)
 		if err != nil {
 			return continueProcessing, err
 		}
@@ -96,6 +96,17 @@ func (s *SnapshotScheduleController) Handle(ctx context.Context, event sdk.Event
 
 			// Start a snapshot for a policy if required
 			if start {
+				if schedule.GetConcurrencyPolicy(snapshotSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyReplace {
+					if err := s.replaceVolumeSnapshots(snapshotSchedule); err != nil {
+						msg := fmt.Sprintf("Error replacing snapshots in progress for schedule(%v): %v", policyType, err)
+						s.Recorder.Event(snapshotSchedule,
+							v1.EventTypeWarning,
+							string(snapv1.VolumeSnapshotConditionError),
+							msg)
+						log.VolumeSnapshotScheduleLog(snapshotSchedule).Error(msg)
+						return err
+					}
+				}
 				err := s.startVolumeSnapshot(snapshotSchedule, policyType)
 				if err != nil {
 					msg := fmt.Sprintf("Error triggering snapshot for schedule(%v): %v", policyType, err)
@@ -171,6 +182,7 @@ func (s *SnapshotScheduleController) updateVolumeSnapshotStatus(snapshotSchedule
 				if s.isVolumeSnapshotComplete(snapshot.Status) {
 					snapshot.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
 					if pendingVolumeSnapshotStatus == snapv1.VolumeSnapshotConditionReady {
+						snapshotSchedule.Status.LastSuccessfulTime = snapshot.FinishTimestamp
 						s.Recorder.Event(snapshotSchedule,
 							v1.EventTypeNormal,
 							string(snapv1.VolumeSnapshotConditionReady),
@@ -200,18 +212,14 @@ func (s *SnapshotScheduleController) isVolumeSnapshotComplete(status snapv1.Volu
 }
 
 func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule) (stork_api.SchedulePolicyType, bool, error) {
-	// Don't trigger a new snapshot if one is already in progress
-	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
-		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
-		if present {
-			for _, snapshot := range policyVolumeSnapshot {
-				if !s.isVolumeSnapshotComplete(snapshot.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
-				}
-			}
-		}
-	}
+	// Don't trigger a new snapshot if one is already in progress, unless the
+	// concurrency policy allows it
+	inProgress := s.getInProgressVolumeSnapshots(snapshotSchedule)
+	forbidden := len(inProgress) != 0 &&
+		schedule.GetConcurrencyPolicy(snapshotSchedule.Spec.ConcurrencyPolicy) == stork_api.ConcurrencyPolicyForbid
 
+	missedUpdated := false
+	triggerPolicyType := stork_api.SchedulePolicyTypeInvalid
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		var latestVolumeSnapshotTimestamp meta.Time
 		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
@@ -222,19 +230,87 @@ func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, err := schedule.CheckTrigger(
 			snapshotSchedule.Spec.SchedulePolicyName,
 			policyType,
 			latestVolumeSnapshotTimestamp,
+			snapshotSchedule.Spec.StartingDeadlineSeconds,
 		)
 		if err != nil {
 			return stork_api.SchedulePolicyTypeInvalid, false, err
 		}
-		if trigger {
-			return policyType, true, nil
+		// Runs scheduled before the schedule was created weren't missed
+		if trigger.Missed && !trigger.ScheduledTime.Before(snapshotSchedule.CreationTimestamp.Time) {
+			reason := fmt.Sprintf("Snapshot wasn't started within the starting deadline of %v", trigger.Deadline)
+			if forbidden {
+				reason = fmt.Sprintf("%v since previous snapshots were still in progress: %v", reason, strings.Join(inProgress, ", "))
+			}
+			var updated bool
+			snapshotSchedule.Status.MissedRuns, updated = schedule.AddMissedRun(
+				snapshotSchedule.Status.MissedRuns,
+				policyType,
+				trigger.ScheduledTime,
+				reason)
+			if updated {
+				msg := fmt.Sprintf("Missed snapshot for schedule(%v) at %v: %v", policyType, trigger.ScheduledTime, reason)
+				s.Recorder.Event(snapshotSchedule,
+					v1.EventTypeWarning,
+					"Missed",
+					msg)
+				log.VolumeSnapshotScheduleLog(snapshotSchedule).Warn(msg)
+				missedUpdated = true
+			}
+		}
+		if trigger.Required && !forbidden && triggerPolicyType == stork_api.SchedulePolicyTypeInvalid {
+			triggerPolicyType = policyType
+		}
+	}
+
+	if missedUpdated {
+		if err := sdk.Update(snapshotSchedule); err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+	}
+	return triggerPolicyType, triggerPolicyType != stork_api.SchedulePolicyTypeInvalid, nil
+}
+
+// getInProgressVolumeSnapshots returns the names of the snapshots triggered by
+// the schedule that haven't completed
+func (s *SnapshotScheduleController) getInProgressVolumeSnapshots(snapshotSchedule *stork_api.VolumeSnapshotSchedule) []string {
+	inProgress := make([]string, 0)
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		for _, snapshot := range snapshotSchedule.Status.Items[policyType] {
+			if !s.isVolumeSnapshotComplete(snapshot.Status) {
+				inProgress = append(inProgress, snapshot.Name)
+			}
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+	return inProgress
+}
+
+// replaceVolumeSnapshots deletes the snapshots triggered by the schedule that
+// are still in progress so that a new one can be started in their place
+func (s *SnapshotScheduleController) replaceVolumeSnapshots(snapshotSchedule *stork_api.VolumeSnapshotSchedule) error {
+	for _, policyVolumeSnapshot := range snapshotSchedule.Status.Items {
+		for _, snapshot := range policyVolumeSnapshot {
+			if s.isVolumeSnapshotComplete(snapshot.Status) {
+				continue
+			}
+			err := k8s.Instance().DeleteSnapshot(snapshot.Name, snapshotSchedule.Namespace)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("error deleting snapshot %v: %v", snapshot.Name, err)
+			}
+			snapshot.Status = snapv1.VolumeSnapshotConditionError
+			snapshot.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+			msg := fmt.Sprintf("Scheduled snapshot (%v) replaced by a new snapshot", snapshot.Name)
+			s.Recorder.Event(snapshotSchedule,
+				v1.EventTypeWarning,
+				"Replaced",
+				msg)
+			log.VolumeSnapshotScheduleLog(snapshotSchedule).Info(msg)
+		}
+	}
+	return nil
 }
 
 func (s *SnapshotScheduleController) formatVolumeSnapshotName(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) string {
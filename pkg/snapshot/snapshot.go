@@ -30,7 +30,7 @@ type Snapshot struct {
 	snapshotScheduleController *controllers.SnapshotScheduleController
 	snapshotRestoreController  *controllers.SnapshotRestoreController
 	provisioner                *controller.ProvisionController
-	Driver                     volume.Driver
+	Drivers                    []volume.Driver
 	Recorder                   record.EventRecorder
 }
 
@@ -51,7 +51,7 @@ func (s *Snapshot) Start() error {
 
 	// Start the snapshot controller first so that the CRD gets registered
 	s.snapshotController = &controllers.Snapshotter{
-		Driver: s.Driver,
+		Drivers: s.Drivers,
 	}
 	err := s.snapshotController.Start(s.stopChannel)
 	if err != nil {
@@ -88,7 +88,12 @@ func (s *Snapshot) Start() error {
 	}
 
 	plugins := make(map[string]snapshotvolume.Plugin)
-	plugins[s.Driver.String()] = s.Driver.GetSnapshotPlugin()
+	for _, driver := range s.Drivers {
+		// Skip drivers that don't support snapshots
+		if plugin := driver.GetSnapshotPlugin(); plugin != nil {
+			plugins[driver.String()] = plugin
+		}
+	}
 
 	snapProvisioner := controllers.NewSnapshotProvisioner(clientset, snapshotClient, plugins, snapshotProvisionerID)
 
@@ -116,7 +121,7 @@ func (s *Snapshot) Start() error {
 	}
 
 	s.snapshotRestoreController = &controllers.SnapshotRestoreController{
-		Driver:   s.Driver,
+		Drivers:  s.Drivers,
 		Recorder: s.Recorder,
 	}
 	err = s.snapshotRestoreController.Init()
@@ -5,12 +5,16 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"strings"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/portworx/sched-ops/task"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 	"k8s.io/kubernetes/pkg/printers"
@@ -22,6 +26,7 @@ var (
 )
 
 var applicationBackupColumns = []string{"NAME", "STAGE", "STATUS", "VOLUMES", "RESOURCES", "CREATED", "ELAPSED"}
+var applicationBackupContentsColumns = []string{"NAMESPACE", "KIND", "NAME", "API-VERSION"}
 var applicationBackupSubcommand = "applicationbackups"
 var applicationBackupAliases = []string{"applicationbackup", "backup", "backups"}
 
@@ -32,6 +37,8 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 	var postExecRule string
 	var waitForCompletion bool
 	var backupLocation string
+	var includeResourceTypes []string
+	var excludeResourceTypes []string
 
 	createApplicationBackupCommand := &cobra.Command{
 		Use:     applicationBackupSubcommand,
@@ -51,17 +58,29 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 				util.CheckErr(fmt.Errorf("need to provide BackupLocation to use for backup"))
 				return
 			}
+			includeTypes, err := parseResourceTypes(includeResourceTypes)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			excludeTypes, err := parseResourceTypes(excludeResourceTypes)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
 			applicationBackup := &storkv1.ApplicationBackup{
 				Spec: storkv1.ApplicationBackupSpec{
-					Namespaces:     namespaceList,
-					PreExecRule:    preExecRule,
-					PostExecRule:   postExecRule,
-					BackupLocation: backupLocation,
+					Namespaces:           namespaceList,
+					PreExecRule:          preExecRule,
+					PostExecRule:         postExecRule,
+					BackupLocation:       backupLocation,
+					IncludeResourceTypes: includeTypes,
+					ExcludeResourceTypes: excludeTypes,
 				},
 			}
 			applicationBackup.Name = applicationBackupName
 			applicationBackup.Namespace = cmdFactory.GetNamespace()
-			_, err := k8s.Instance().CreateApplicationBackup(applicationBackup)
+			_, err = k8s.Instance().CreateApplicationBackup(applicationBackup)
 			if err != nil {
 				util.CheckErr(err)
 				return
@@ -85,16 +104,41 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 	createApplicationBackupCommand.Flags().StringVarP(&preExecRule, "preExecRule", "", "", "Rule to run before executing applicationbackup")
 	createApplicationBackupCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing applicationbackup")
 	createApplicationBackupCommand.Flags().StringVarP(&backupLocation, "backupLocation", "b", "", "BackupLocation to use for the backup")
+	createApplicationBackupCommand.Flags().StringSliceVarP(&includeResourceTypes, "includeResourceTypes", "", nil, "Comma separated list of resource types to backup in addition to the defaults, specified as <group>/<version>/<kind> or <kind>")
+	createApplicationBackupCommand.Flags().StringSliceVarP(&excludeResourceTypes, "excludeResourceTypes", "", nil, "Comma separated list of resource types that shouldn't be backed up, specified as <group>/<version>/<kind> or <kind>")
 
 	return createApplicationBackupCommand
 }
 
 func newGetApplicationBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var watch bool
+	var contents bool
 	getApplicationBackupCommand := &cobra.Command{
 		Use:     applicationBackupSubcommand,
 		Aliases: applicationBackupAliases,
 		Short:   "Get applicationbackup resources",
 		Run: func(c *cobra.Command, args []string) {
+			if contents {
+				if len(args) != 1 {
+					util.CheckErr(fmt.Errorf("exactly one name needs to be provided to get the contents of the applicationbackup"))
+					return
+				}
+				if err := printApplicationBackupContents(args[0], cmdFactory.GetNamespace(), ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+			if watch {
+				if len(args) != 1 {
+					util.CheckErr(fmt.Errorf("exactly one name needs to be provided to watch the applicationbackup"))
+					return
+				}
+				if err := watchProgress(getApplicationBackupProgress(args[0], cmdFactory.GetNamespace()), false, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+
 			var applicationBackups *storkv1.ApplicationBackupList
 			var err error
 
@@ -139,11 +183,59 @@ func newGetApplicationBackupCommand(cmdFactory Factory, ioStreams genericcliopti
 			}
 		},
 	}
+	getApplicationBackupCommand.Flags().BoolVarP(&watch, "watch", "w", false, "Watch the progress of the volumes until the applicationbackup is done")
+	getApplicationBackupCommand.Flags().BoolVarP(&contents, "contents", "", false, "List the resources in the applicationbackup from the backup location")
 	cmdFactory.BindGetFlags(getApplicationBackupCommand.Flags())
 
 	return getApplicationBackupCommand
 }
 
+// printApplicationBackupContents lists the resources that were uploaded to
+// the backup location for the backup
+func printApplicationBackupContents(name string, namespace string, out io.Writer) error {
+	backup, err := k8s.Instance().GetApplicationBackup(name, namespace)
+	if err != nil {
+		return err
+	}
+	if backup.Status.BackupPath == "" {
+		return fmt.Errorf("ApplicationBackup %v hasn't uploaded any resources to the backup location", name)
+	}
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	encryption, err := objectstore.GetBackupEncryption(bucket, backup.Status.BackupPath, backupLocation, backup)
+	if err != nil {
+		return err
+	}
+
+	writer := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintf(writer, "%v\n", strings.Join(applicationBackupContentsColumns, "\t")); err != nil {
+		return err
+	}
+	err = objectstore.ReadResources(bucket, backup.Status.BackupPath, encryption, nil, func(object runtime.Unstructured) error {
+		metadata, err := meta.Accessor(object)
+		if err != nil {
+			return err
+		}
+		gvk := object.GetObjectKind().GroupVersionKind()
+		_, err = fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n",
+			metadata.GetNamespace(),
+			gvk.Kind,
+			metadata.GetName(),
+			gvk.GroupVersion().String())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error reading resources for ApplicationBackup %v: %v", name, err)
+	}
+	return writer.Flush()
+}
+
 func newDeleteApplicationBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	deleteApplicationBackupCommand := &cobra.Command{
 		Use:     applicationBackupSubcommand,
@@ -177,6 +269,61 @@ func deleteApplicationBackups(applicationBackups []string, namespace string, ioS
 	}
 }
 
+func newVerifyApplicationBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	verifyApplicationBackupCommand := &cobra.Command{
+		Use:     applicationBackupSubcommand,
+		Aliases: applicationBackupAliases,
+		Short:   "Verify the checksums and signature of applicationbackups in the backup location",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) == 0 {
+				util.CheckErr(fmt.Errorf("at least one argument needs to be provided for applicationbackup name"))
+				return
+			}
+
+			for _, name := range args {
+				if err := verifyApplicationBackup(name, cmdFactory.GetNamespace(), ioStreams); err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+		},
+	}
+
+	return verifyApplicationBackupCommand
+}
+
+func verifyApplicationBackup(name string, namespace string, ioStreams genericclioptions.IOStreams) error {
+	backup, err := k8s.Instance().GetApplicationBackup(name, namespace)
+	if err != nil {
+		return err
+	}
+	if backup.Status.BackupPath == "" {
+		return fmt.Errorf("ApplicationBackup %v hasn't uploaded any resources to the backup location", name)
+	}
+	backupLocation, err := k8s.Instance().GetBackupLocation(backup.Spec.BackupLocation, namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	manifest, err := objectstore.VerifyBackup(bucket, backup.Status.BackupPath, backupLocation.Location.SigningKey)
+	if err != nil {
+		return fmt.Errorf("ApplicationBackup %v failed verification: %v", name, err)
+	}
+	if manifest == nil {
+		printMsg(fmt.Sprintf("ApplicationBackup %v doesn't have a manifest and can't be verified", name), ioStreams.Out)
+		return nil
+	}
+	signed := ""
+	if backupLocation.Location.SigningKey != "" {
+		signed = " and signature"
+	}
+	printMsg(fmt.Sprintf("ApplicationBackup %v verified successfully, checked %v objects%v", name, len(manifest.Objects), signed), ioStreams.Out)
+	return nil
+}
+
 func applicationBackupPrinter(applicationBackupList *storkv1.ApplicationBackupList, writer io.Writer, options printers.PrintOptions) error {
 	if applicationBackupList == nil {
 		return nil
@@ -262,3 +409,39 @@ func waitForApplicationBackup(name, namespace string, ioStreams genericclioption
 
 	return msg, err
 }
+
+// getApplicationBackupProgress returns a function to get the progress of the
+// volumes for the applicationbackup
+func getApplicationBackupProgress(name, namespace string) progressFunc {
+	return func() (*progressSummary, []*volumeProgress, error) {
+		applicationBackup, err := k8s.Instance().GetApplicationBackup(name, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := &progressSummary{
+			stage:  string(applicationBackup.Status.Stage),
+			status: string(applicationBackup.Status.Status),
+			done:   applicationBackup.Status.Stage == storkv1.ApplicationBackupStageFinal,
+		}
+		volumes := make([]*volumeProgress, 0, len(applicationBackup.Status.Volumes))
+		for _, vInfo := range applicationBackup.Status.Volumes {
+			inProgress := vInfo.Status == storkv1.ApplicationBackupStatusInProgress
+			summary.totalBytes += vInfo.TotalBytes
+			summary.bytesTransferred += vInfo.BytesTransferred
+			if inProgress && vInfo.ETASeconds > summary.etaSeconds {
+				summary.etaSeconds = vInfo.ETASeconds
+			}
+			volumes = append(volumes, &volumeProgress{
+				namespace:        vInfo.Namespace,
+				pvc:              vInfo.PersistentVolumeClaim,
+				volume:           vInfo.Volume,
+				status:           string(vInfo.Status),
+				totalBytes:       vInfo.TotalBytes,
+				bytesTransferred: vInfo.BytesTransferred,
+				etaSeconds:       vInfo.ETASeconds,
+				inProgress:       inProgress,
+			})
+		}
+		return summary, volumes, nil
+	}
+}
@@ -3,14 +3,23 @@
 package storkctl
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestGetBackupsNoBackup(t *testing.T) {
@@ -121,6 +130,35 @@ func TestGetApplicationBackupsWithStatusAndProgress(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestGetApplicationBackupsWatch(t *testing.T) {
+	defer resetTest()
+	createApplicationBackupAndVerify(t, "watchbackuptest", "default", []string{"namespace1"}, "backuplocation", "", "")
+	backup, err := k8s.Instance().GetApplicationBackup("watchbackuptest", "default")
+	require.NoError(t, err, "Error getting backup")
+
+	backup.Status.Stage = storkv1.ApplicationBackupStageFinal
+	backup.Status.Status = storkv1.ApplicationBackupStatusSuccessful
+	backup.Status.Volumes = []*storkv1.ApplicationBackupVolumeInfo{
+		{
+			Namespace:             "namespace1",
+			PersistentVolumeClaim: "pvc1",
+			Volume:                "volume1",
+			Status:                storkv1.ApplicationBackupStatusSuccessful,
+			TotalBytes:            1024 * 1024,
+			BytesTransferred:      1024 * 1024,
+		},
+	}
+	_, err = k8s.Instance().UpdateApplicationBackup(backup)
+	require.NoError(t, err, "Error updating backup")
+
+	expected := "STAGE: Final   STATUS: Successful   PROGRESS: 1.0MiB/1.0MiB (100%)   ETA: \n" +
+		"NAMESPACE    PVC       VOLUME    STATUS       PROGRESS               ETA\n" +
+		"namespace1   pvc1      volume1   Successful   1.0MiB/1.0MiB (100%)   \n" +
+		"\n"
+	cmdArgs := []string{"get", "backups", "watchbackuptest", "--watch"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
 func TestCreateApplicationBackupsNoNamespace(t *testing.T) {
 	cmdArgs := []string{"create", "backups", "backup1"}
 
@@ -230,3 +268,141 @@ func setApplicationBackupStatus(name, namespace string, isFail bool, t *testing.
 	_, err = k8s.Instance().UpdateApplicationBackup(backup)
 	require.NoError(t, err, "Error updating ApplicationBackups")
 }
+
+// createUploadedApplicationBackup creates a backup in a file backup location
+// with the given objects uploaded to it. Returns the bucket and the directory
+// for the backup location.
+func createUploadedApplicationBackup(
+	t *testing.T,
+	name string,
+	signingKey string,
+	objects []runtime.Unstructured,
+) (*blob.Bucket, string) {
+	dir, err := ioutil.TempDir("", "storkctl-backup")
+	require.NoError(t, err, "Error creating temp dir")
+	objectstore.SetFileBackupLocationRoot(filepath.Dir(dir))
+	backupLocation := &storkv1.BackupLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "filelocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type:       storkv1.BackupLocationFile,
+			Path:       dir,
+			SigningKey: signingKey,
+		},
+	}
+	_, err = k8s.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	backup := &storkv1.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: storkv1.ApplicationBackupSpec{
+			BackupLocation: "filelocation",
+		},
+		Status: storkv1.ApplicationBackupStatus{
+			BackupPath: "default/" + name + "/uid",
+		},
+	}
+	_, err = k8s.Instance().CreateApplicationBackup(backup)
+	require.NoError(t, err, "Error creating backup")
+
+	bucket, err := objectstore.GetBucket(backupLocation)
+	require.NoError(t, err, "Error getting bucket")
+	checksums := objectstore.NewBackupManifest()
+	err = objectstore.UploadResources(bucket, backup.Status.BackupPath, nil, objects, checksums)
+	require.NoError(t, err, "Error uploading resources")
+	err = objectstore.UploadBackupManifest(bucket, backup.Status.BackupPath, signingKey, checksums)
+	require.NoError(t, err, "Error uploading manifest")
+	return bucket, dir
+}
+
+func TestVerifyApplicationBackups(t *testing.T) {
+	defer resetTest()
+	objects := []runtime.Unstructured{
+		&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "cm",
+					"namespace": "namespace1",
+				},
+			},
+		},
+	}
+	bucket, dir := createUploadedApplicationBackup(t, "verifybackup", "signingkey", objects)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	cmdArgs := []string{"verify", "backups", "verifybackup"}
+	expected := "ApplicationBackup verifybackup verified successfully, checked 2 objects and signature\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	// Corrupt one of the objects
+	chunk := "default/verifybackup/uid/resources/00000.json.gz"
+	data, err := bucket.ReadAll(context.TODO(), chunk)
+	require.NoError(t, err, "Error reading backup")
+	err = bucket.WriteAll(context.TODO(), chunk, []byte("corrupt"), nil)
+	require.NoError(t, err, "Error corrupting backup")
+	expected = fmt.Sprintf("error: ApplicationBackup verifybackup failed verification: "+
+		"size mismatch for resources/00000.json.gz, expected %v found 7", len(data))
+	testCommon(t, cmdArgs, nil, expected, true)
+	err = bucket.WriteAll(context.TODO(), chunk, data, nil)
+	require.NoError(t, err, "Error restoring backup")
+
+	// Signing key mismatch
+	backupLocation, err := k8s.Instance().GetBackupLocation("filelocation", "default")
+	require.NoError(t, err, "Error getting backuplocation")
+	backupLocation.Location.SigningKey = "otherkey"
+	_, err = k8s.Instance().UpdateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error updating backuplocation")
+	expected = "error: ApplicationBackup verifybackup failed verification: signature mismatch for backup manifest"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestVerifyApplicationBackupsNoName(t *testing.T) {
+	cmdArgs := []string{"verify", "backups"}
+	expected := "error: at least one argument needs to be provided for applicationbackup name"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestGetApplicationBackupContents(t *testing.T) {
+	defer resetTest()
+	objects := []runtime.Unstructured{
+		&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "cm",
+					"namespace": "namespace1",
+				},
+			},
+		},
+		&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "app",
+					"namespace": "namespace1",
+				},
+			},
+		},
+	}
+	_, dir := createUploadedApplicationBackup(t, "contentsbackup", "", objects)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	cmdArgs := []string{"get", "backups", "contentsbackup", "--contents"}
+	expected := "NAMESPACE    KIND         NAME      API-VERSION\n" +
+		"namespace1   ConfigMap    cm        v1\n" +
+		"namespace1   Deployment   app       apps/v1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"get", "backups", "--contents"}
+	expected = "error: exactly one name needs to be provided to get the contents of the applicationbackup"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
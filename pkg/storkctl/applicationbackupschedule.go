@@ -25,6 +25,8 @@ func newCreateApplicationBackupScheduleCommand(cmdFactory Factory, ioStreams gen
 	var postExecRule string
 	var schedulePolicyName string
 	var suspend bool
+	var startingDeadlineSeconds int64
+	var concurrencyPolicy string
 
 	createApplicationBackupScheduleCommand := &cobra.Command{
 		Use:     applicationBackupScheduleSubcommand,
@@ -49,7 +51,13 @@ func newCreateApplicationBackupScheduleCommand(cmdFactory Factory, ioStreams gen
 				return
 			}
 
-			_, err := k8s.Instance().GetSchedulePolicy(schedulePolicyName)
+			deadline, concurrency, err := getScheduleRunOptions(c, startingDeadlineSeconds, concurrencyPolicy)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			_, err = k8s.Instance().GetSchedulePolicy(schedulePolicyName)
 			if err != nil {
 				util.CheckErr(fmt.Errorf("error getting schedulepolicy %v: %v", schedulePolicyName, err))
 				return
@@ -65,8 +73,10 @@ func newCreateApplicationBackupScheduleCommand(cmdFactory Factory, ioStreams gen
 							PostExecRule:   postExecRule,
 						},
 					},
-					SchedulePolicyName: schedulePolicyName,
-					Suspend:            &suspend,
+					SchedulePolicyName:      schedulePolicyName,
+					Suspend:                 &suspend,
+					StartingDeadlineSeconds: deadline,
+					ConcurrencyPolicy:       concurrency,
 				},
 			}
 			applicationBackupSchedule.Name = applicationBackupScheduleName
@@ -86,6 +96,8 @@ func newCreateApplicationBackupScheduleCommand(cmdFactory Factory, ioStreams gen
 	createApplicationBackupScheduleCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing applicationBackup")
 	createApplicationBackupScheduleCommand.Flags().StringVarP(&schedulePolicyName, "schedulePolicyName", "s", "default-applicationbackup-policy", "Name of the schedule policy to use")
 	createApplicationBackupScheduleCommand.Flags().BoolVar(&suspend, "suspend", false, "Flag to denote whether schedule should be suspended on creation")
+	createApplicationBackupScheduleCommand.Flags().Int64VarP(&startingDeadlineSeconds, "startingDeadlineSeconds", "", 0, "Deadline in seconds for starting a backup after the time it was scheduled for, after which it is recorded as missed")
+	createApplicationBackupScheduleCommand.Flags().StringVarP(&concurrencyPolicy, "concurrencyPolicy", "", "", "How to handle a scheduled backup when a previous one is still in progress (Allow, Forbid or Replace). Defaults to Forbid")
 
 	return createApplicationBackupScheduleCommand
 }
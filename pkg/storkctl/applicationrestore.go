@@ -88,11 +88,23 @@ func newCreateApplicationRestoreCommand(cmdFactory Factory, ioStreams genericcli
 }
 
 func newGetApplicationRestoreCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var watch bool
 	getApplicationRestoreCommand := &cobra.Command{
 		Use:     applicationRestoreSubcommand,
 		Aliases: applicationRestoreAliases,
 		Short:   "Get applicationrestore resources",
 		Run: func(c *cobra.Command, args []string) {
+			if watch {
+				if len(args) != 1 {
+					util.CheckErr(fmt.Errorf("exactly one name needs to be provided to watch the applicationrestore"))
+					return
+				}
+				if err := watchProgress(getApplicationRestoreProgress(args[0], cmdFactory.GetNamespace()), false, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+
 			var applicationRestores *storkv1.ApplicationRestoreList
 			var err error
 
@@ -137,6 +149,7 @@ func newGetApplicationRestoreCommand(cmdFactory Factory, ioStreams genericcliopt
 			}
 		},
 	}
+	getApplicationRestoreCommand.Flags().BoolVarP(&watch, "watch", "w", false, "Watch the progress of the volumes until the applicationrestore is done")
 	cmdFactory.BindGetFlags(getApplicationRestoreCommand.Flags())
 
 	return getApplicationRestoreCommand
@@ -258,3 +271,39 @@ func waitForApplicationRestore(name, namespace string, ioStreams genericclioptio
 
 	return msg, err
 }
+
+// getApplicationRestoreProgress returns a function to get the progress of the
+// volumes for the applicationrestore
+func getApplicationRestoreProgress(name, namespace string) progressFunc {
+	return func() (*progressSummary, []*volumeProgress, error) {
+		applicationRestore, err := k8s.Instance().GetApplicationRestore(name, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := &progressSummary{
+			stage:  string(applicationRestore.Status.Stage),
+			status: string(applicationRestore.Status.Status),
+			done:   applicationRestore.Status.Stage == storkv1.ApplicationRestoreStageFinal,
+		}
+		volumes := make([]*volumeProgress, 0, len(applicationRestore.Status.Volumes))
+		for _, vInfo := range applicationRestore.Status.Volumes {
+			inProgress := vInfo.Status == storkv1.ApplicationRestoreStatusInProgress
+			summary.totalBytes += vInfo.TotalBytes
+			summary.bytesTransferred += vInfo.BytesTransferred
+			if inProgress && vInfo.ETASeconds > summary.etaSeconds {
+				summary.etaSeconds = vInfo.ETASeconds
+			}
+			volumes = append(volumes, &volumeProgress{
+				namespace:        vInfo.SourceNamespace,
+				pvc:              vInfo.PersistentVolumeClaim,
+				volume:           vInfo.RestoreVolume,
+				status:           string(vInfo.Status),
+				totalBytes:       vInfo.TotalBytes,
+				bytesTransferred: vInfo.BytesTransferred,
+				etaSeconds:       vInfo.ETASeconds,
+				inProgress:       inProgress,
+			})
+		}
+		return summary, volumes, nil
+	}
+}
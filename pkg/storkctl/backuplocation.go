@@ -3,10 +3,13 @@ package storkctl
 import (
 	"fmt"
 	"io"
+	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/spf13/cobra"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
 	"k8s.io/kubernetes/pkg/printers"
@@ -20,6 +23,7 @@ const (
 var s3BackupLocationColumns = []string{"NAME", "PATH", "ACCESS-KEY-ID", "SECRET-ACCESS-KEY", "REGION", "ENDPOINT", "SSL-DISABLED"}
 var azureBackupLocationColumns = []string{"NAME", "PATH", "STORAGE-ACCOUNT-NAME", "STORAGE-ACCOUNT-KEY"}
 var googleBackupLocationColumns = []string{"NAME", "PATH", "PROJECT-ID"}
+var fileBackupLocationColumns = []string{"NAME", "TYPE", "PATH"}
 
 func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var showSecrets bool
@@ -73,6 +77,7 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 			s3BackupLocations := &storkv1.BackupLocationList{}
 			azureBackupLocations := &storkv1.BackupLocationList{}
 			googleBackupLocations := &storkv1.BackupLocationList{}
+			fileBackupLocations := &storkv1.BackupLocationList{}
 			unknownBackupLocations := &storkv1.BackupLocationList{}
 			for _, bl := range backupLocations.Items {
 				switch bl.Location.Type {
@@ -91,6 +96,8 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 						bl.Location.GoogleConfig.AccountKey = hiddenString
 					}
 					googleBackupLocations.Items = append(googleBackupLocations.Items, bl)
+				case storkv1.BackupLocationFile, storkv1.BackupLocationNFS:
+					fileBackupLocations.Items = append(fileBackupLocations.Items, bl)
 				default:
 					unknownBackupLocations.Items = append(unknownBackupLocations.Items, bl)
 				}
@@ -132,6 +139,16 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 						return
 					}
 				}
+				if len(fileBackupLocations.Items) != 0 {
+					if _, err := fmt.Fprintf(ioStreams.Out, "\nFilesystem:\n-----------\n"); err != nil {
+						util.CheckErr(err)
+						return
+					}
+					if err := printObjects(c, fileBackupLocations, cmdFactory, fileBackupLocationColumns, fileBackupLocationPrinter, ioStreams.Out); err != nil {
+						util.CheckErr(err)
+						return
+					}
+				}
 			} else {
 				if err := printObjects(c, backupLocations, cmdFactory, nil, nil, ioStreams.Out); err != nil {
 					util.CheckErr(err)
@@ -147,6 +164,57 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 	return getBackupLocationCommand
 }
 
+func newRotateBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	rotateBackupLocationCommand := &cobra.Command{
+		Use:     backupLocationSubcommand,
+		Aliases: []string{"bl"},
+		Short:   "Request the data keys for backups in BackupLocations to be re-wrapped with the current encryption key",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) == 0 {
+				util.CheckErr(fmt.Errorf("at least one argument needs to be provided for backuplocation name"))
+				return
+			}
+
+			for _, name := range args {
+				if err := rotateBackupLocation(cmdFactory, name, cmdFactory.GetNamespace(), ioStreams); err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+		},
+	}
+
+	return rotateBackupLocationCommand
+}
+
+// rotateBackupLocation annotates the BackupLocation so that stork rotates the
+// data keys for it. The keys are rotated by stork and not here since the key
+// providers might only be available in the cluster.
+func rotateBackupLocation(
+	cmdFactory Factory,
+	name string,
+	namespace string,
+	ioStreams genericclioptions.IOStreams,
+) error {
+	storkClient, err := cmdFactory.GetStorkClient()
+	if err != nil {
+		return err
+	}
+	backupLocation, err := storkClient.StorkV1alpha1().BackupLocations(namespace).Get(name, meta.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if backupLocation.Annotations == nil {
+		backupLocation.Annotations = make(map[string]string)
+	}
+	backupLocation.Annotations[controllers.BackupLocationRotateDataKeysAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if _, err := storkClient.StorkV1alpha1().BackupLocations(namespace).Update(backupLocation); err != nil {
+		return err
+	}
+	printMsg(fmt.Sprintf("Requested rotation of data keys for BackupLocation %v", name), ioStreams.Out)
+	return nil
+}
+
 func s3BackupLocationPrinter(backupLocationList *storkv1.BackupLocationList, writer io.Writer, options printers.PrintOptions) error {
 	if backupLocationList == nil {
 		return nil
@@ -219,3 +287,26 @@ func googleBackupLocationPrinter(backupLocationList *storkv1.BackupLocationList,
 	}
 	return nil
 }
+
+func fileBackupLocationPrinter(backupLocationList *storkv1.BackupLocationList, writer io.Writer, options printers.PrintOptions) error {
+	if backupLocationList == nil {
+		return nil
+	}
+	for _, backupLocation := range backupLocationList.Items {
+		name := printers.FormatResourceName(options.Kind, backupLocation.Name, options.WithKind)
+		if options.WithNamespace {
+			if _, err := fmt.Fprintf(writer, "%v\t", backupLocation.Namespace); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\n",
+			name,
+			backupLocation.Location.Type,
+			backupLocation.Location.Path,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/stretchr/testify/require"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -165,6 +166,43 @@ func TestGoogleBackupLocation(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestFileBackupLocation(t *testing.T) {
+	defer resetTest()
+
+	backupLocation := &storkv1.BackupLocation{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "filelocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type: storkv1.BackupLocationFile,
+			Path: "/mnt/backups",
+		},
+	}
+	_, err := k8s.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	backupLocation = &storkv1.BackupLocation{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "nfslocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type: storkv1.BackupLocationNFS,
+			Path: "/mnt/nfs",
+		},
+	}
+	_, err = k8s.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	expected := "\nFilesystem:\n-----------\n" +
+		"NAME           TYPE      PATH\n" +
+		"filelocation   file      /mnt/backups\n" +
+		"nfslocation    nfs       /mnt/nfs\n"
+	cmdArgs := []string{"get", "backuplocation", "filelocation", "nfslocation"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
 func TestAllBackupLocation(t *testing.T) {
 	_, err := k8s.Instance().CreateNamespace("s3", nil)
 	require.NoError(t, err, "Error creating s3 namespace")
@@ -258,3 +296,35 @@ func TestAllBackupLocation(t *testing.T) {
 	cmdArgs = []string{"get", "backuplocation", "--all-namespaces"}
 	testCommon(t, cmdArgs, nil, expected, false)
 }
+
+func TestRotateBackupLocation(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"rotate", "backuplocation", "filelocation"}
+	expected := `Error from server (NotFound): backuplocations.stork.libopenstorage.org "filelocation" not found`
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	backupLocation := &storkv1.BackupLocation{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "filelocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type:          storkv1.BackupLocationFile,
+			Path:          "/tmp/backups",
+			EncryptionKey: "newkey",
+		},
+	}
+	_, err := k8s.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	expected = "Requested rotation of data keys for BackupLocation filelocation\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	backupLocation, err = k8s.Instance().GetBackupLocation("filelocation", "default")
+	require.NoError(t, err, "Error getting backuplocation")
+	require.Contains(t, backupLocation.Annotations, controllers.BackupLocationRotateDataKeysAnnotation,
+		"BackupLocation should be annotated for rotation")
+
+	cmdArgs = []string{"rotate", "backuplocation"}
+	expected = "error: at least one argument needs to be provided for backuplocation name"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
@@ -8,11 +8,18 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
+	"github.com/portworx/sched-ops/task"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/kubernetes/pkg/apis/core/validation"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
 	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
@@ -24,10 +31,87 @@ const (
 	cmdPathKey            = "cmd-path"
 	gcloudPath            = "./google-cloud-sdk/bin/gcloud"
 	gcloudBinaryName      = "gcloud"
+
+	// clusterPairBootstrapPrefix is the prefix for the service account,
+	// token, cluster role and cluster role binding created on the remote
+	// cluster
+	clusterPairBootstrapPrefix        = "stork-clusterpair-"
+	clusterPairBootstrapTimeout       = 1 * time.Minute
+	clusterPairBootstrapRetryInterval = 2 * time.Second
 )
 
 var clusterPairColumns = []string{"NAME", "STORAGE-STATUS", "SCHEDULER-STATUS", "CREATED"}
 
+// clusterPairBootstrapRules are the permissions given to the service account
+// created on the remote cluster. They cover the resources that are migrated
+// and the objects stork manages on the destination. Cluster roles and
+// cluster role bindings can only be read, since cluster scoped resources are
+// applied with the admin cluster pair, and secrets can be written but not
+// read so that the tokens of other service accounts can't be used. The
+// account can still create workloads in any namespace, which can run as any
+// service account in that namespace, so the role should be treated as highly
+// privileged.
+var clusterPairBootstrapRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{
+			"namespaces", "persistentvolumes", "persistentvolumeclaims", "services",
+			"configmaps", "serviceaccounts",
+		},
+		Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"secrets"},
+		Verbs:     []string{"create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments", "statefulsets", "daemonsets", "replicasets"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"apps.openshift.io"},
+		Resources: []string{"deploymentconfigs"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"batch"},
+		Resources: []string{"jobs", "cronjobs"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"extensions", "networking.k8s.io"},
+		Resources: []string{"ingresses"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"rbac.authorization.k8s.io"},
+		Resources: []string{"roles", "rolebindings"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{"rbac.authorization.k8s.io"},
+		Resources: []string{"clusterroles", "clusterrolebindings"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{"apiextensions.k8s.io"},
+		Resources: []string{"customresourcedefinitions"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
+	},
+	{
+		APIGroups: []string{"storage.k8s.io"},
+		Resources: []string{"storageclasses"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{storkv1.SchemeGroupVersion.Group},
+		Resources: []string{"*"},
+		Verbs:     []string{"*"},
+	},
+}
+
 func newGetClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	getClusterPairCommand := &cobra.Command{
 		Use:     clusterPairSubcommand,
@@ -156,75 +240,10 @@ func newGenerateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptio
 				return
 			}
 
-			// Prune out all but the current-context and related
-			// info
-			currentContext := config.CurrentContext
-			for context := range config.Contexts {
-				if context != currentContext {
-					delete(config.Contexts, context)
-				}
-			}
-			if config.Contexts[currentContext] != nil {
-				currentCluster := config.Contexts[currentContext].Cluster
-				for cluster := range config.Clusters {
-					if cluster != currentCluster {
-						delete(config.Clusters, cluster)
-					}
-				}
-				currentAuthInfo := config.Contexts[currentContext].AuthInfo
-				for authInfo := range config.AuthInfos {
-					if authInfo != currentAuthInfo {
-						delete(config.AuthInfos, authInfo)
-					}
-				}
-
-				if config.AuthInfos[currentAuthInfo] != nil {
-					// Replace gcloud paths in the config
-					if config.AuthInfos[currentAuthInfo].AuthProvider != nil &&
-						config.AuthInfos[currentAuthInfo].AuthProvider.Config != nil {
-						if cmdPath, present := config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey]; present {
-							if strings.HasSuffix(cmdPath, gcloudBinaryName) {
-								config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey] = gcloudPath
-							}
-						}
-					}
-
-					// Replace file paths with inline data
-					if config.AuthInfos[currentAuthInfo].ClientCertificate != "" && len(config.AuthInfos[currentAuthInfo].ClientCertificateData) == 0 {
-						config.AuthInfos[currentAuthInfo].ClientCertificateData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientCertificate)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].ClientCertificate = ""
-					}
-					if config.AuthInfos[currentAuthInfo].ClientKey != "" && len(config.AuthInfos[currentAuthInfo].ClientKeyData) == 0 {
-						config.AuthInfos[currentAuthInfo].ClientKeyData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientKey)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].ClientKey = ""
-					}
-					if config.AuthInfos[currentAuthInfo].TokenFile != "" && len(config.AuthInfos[currentAuthInfo].Token) == 0 {
-						config.AuthInfos[currentAuthInfo].Token, err = getStringData(config.AuthInfos[currentAuthInfo].TokenFile)
-						if err != nil {
-							util.CheckErr(err)
-							return
-						}
-						config.AuthInfos[currentAuthInfo].TokenFile = ""
-					}
-				}
-				if config.Clusters[currentCluster] != nil &&
-					config.Clusters[currentCluster].CertificateAuthority != "" &&
-					len(config.Clusters[currentCluster].CertificateAuthorityData) == 0 {
-
-					config.Clusters[currentCluster].CertificateAuthorityData, err = getByteData(config.Clusters[currentCluster].CertificateAuthority)
-					if err != nil {
-						util.CheckErr(err)
-						return
-					}
-					config.Clusters[currentCluster].CertificateAuthority = ""
+			if config.Contexts[config.CurrentContext] != nil {
+				if err := pruneClusterPairConfig(&config); err != nil {
+					util.CheckErr(err)
+					return
 				}
 
 				clusterPair := &storkv1.ClusterPair{
@@ -254,3 +273,365 @@ func newGenerateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptio
 
 	return generateClusterPairCommand
 }
+
+// pruneClusterPairConfig prunes out all but the current context and related
+// info from the config, and replaces file paths with inline data so that the
+// config can be used from another cluster
+func pruneClusterPairConfig(config *clientcmdapi.Config) error {
+	var err error
+	currentContext := config.CurrentContext
+	for context := range config.Contexts {
+		if context != currentContext {
+			delete(config.Contexts, context)
+		}
+	}
+	if config.Contexts[currentContext] == nil {
+		return fmt.Errorf("context %v not found in config", currentContext)
+	}
+	currentCluster := config.Contexts[currentContext].Cluster
+	for cluster := range config.Clusters {
+		if cluster != currentCluster {
+			delete(config.Clusters, cluster)
+		}
+	}
+	currentAuthInfo := config.Contexts[currentContext].AuthInfo
+	for authInfo := range config.AuthInfos {
+		if authInfo != currentAuthInfo {
+			delete(config.AuthInfos, authInfo)
+		}
+	}
+
+	if config.AuthInfos[currentAuthInfo] != nil {
+		// Replace gcloud paths in the config
+		if config.AuthInfos[currentAuthInfo].AuthProvider != nil &&
+			config.AuthInfos[currentAuthInfo].AuthProvider.Config != nil {
+			if cmdPath, present := config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey]; present {
+				if strings.HasSuffix(cmdPath, gcloudBinaryName) {
+					config.AuthInfos[currentAuthInfo].AuthProvider.Config[cmdPathKey] = gcloudPath
+				}
+			}
+		}
+
+		// Replace file paths with inline data
+		if config.AuthInfos[currentAuthInfo].ClientCertificate != "" && len(config.AuthInfos[currentAuthInfo].ClientCertificateData) == 0 {
+			config.AuthInfos[currentAuthInfo].ClientCertificateData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientCertificate)
+			if err != nil {
+				return err
+			}
+			config.AuthInfos[currentAuthInfo].ClientCertificate = ""
+		}
+		if config.AuthInfos[currentAuthInfo].ClientKey != "" && len(config.AuthInfos[currentAuthInfo].ClientKeyData) == 0 {
+			config.AuthInfos[currentAuthInfo].ClientKeyData, err = getByteData(config.AuthInfos[currentAuthInfo].ClientKey)
+			if err != nil {
+				return err
+			}
+			config.AuthInfos[currentAuthInfo].ClientKey = ""
+		}
+		if config.AuthInfos[currentAuthInfo].TokenFile != "" && len(config.AuthInfos[currentAuthInfo].Token) == 0 {
+			config.AuthInfos[currentAuthInfo].Token, err = getStringData(config.AuthInfos[currentAuthInfo].TokenFile)
+			if err != nil {
+				return err
+			}
+			config.AuthInfos[currentAuthInfo].TokenFile = ""
+		}
+	}
+	if config.Clusters[currentCluster] != nil &&
+		config.Clusters[currentCluster].CertificateAuthority != "" &&
+		len(config.Clusters[currentCluster].CertificateAuthorityData) == 0 {
+
+		config.Clusters[currentCluster].CertificateAuthorityData, err = getByteData(config.Clusters[currentCluster].CertificateAuthority)
+		if err != nil {
+			return err
+		}
+		config.Clusters[currentCluster].CertificateAuthority = ""
+	}
+	return nil
+}
+
+func newCreateClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var remoteKubeconfig string
+	var remoteContext string
+	var secretName string
+	var options map[string]string
+	var bootstrap bool
+	var bootstrapNamespace string
+	var bootstrapClusterAdmin bool
+
+	createClusterPairCommand := &cobra.Command{
+		Use:     clusterPairSubcommand,
+		Aliases: []string{"cp"},
+		Short:   "Create a cluster pair with the config for the remote cluster stored in a secret",
+		Long: "Create a cluster pair with the config for the remote cluster stored in a secret. " +
+			"If the cluster pair already exists the secret is updated, which can be used to rotate " +
+			"the credentials for the remote cluster.",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for clusterpair name"))
+				return
+			}
+			clusterPairName := args[0]
+			if errors := validation.NameIsDNSSubdomain(clusterPairName, false); len(errors) != 0 {
+				util.CheckErr(fmt.Errorf("the Name \"%v\" is not valid: %v", clusterPairName, errors))
+				return
+			}
+			if remoteKubeconfig == "" {
+				util.CheckErr(fmt.Errorf("path to the kubeconfig for the remote cluster needs to be provided"))
+				return
+			}
+			config, err := clientcmd.LoadFromFile(remoteKubeconfig)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if remoteContext != "" {
+				config.CurrentContext = remoteContext
+			}
+			if err := pruneClusterPairConfig(config); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if bootstrap {
+				if bootstrapClusterAdmin {
+					printMsg("Warning: the service account on the remote cluster will have cluster-admin access", ioStreams.ErrOut)
+				}
+				config, err = bootstrapClusterPair(cmdFactory, config, clusterPairName, bootstrapNamespace, bootstrapClusterAdmin)
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+
+			if secretName == "" {
+				secretName = clusterPairName + "-kubeconfig"
+			}
+			data, err := clientcmd.Write(*config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := saveClusterPairSecret(secretName, cmdFactory.GetNamespace(), data); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			msg, err := saveClusterPair(clusterPairName, cmdFactory.GetNamespace(), secretName, options)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			printMsg(msg, ioStreams.Out)
+		},
+	}
+	createClusterPairCommand.Flags().StringVarP(&remoteKubeconfig, "remote-kubeconfig", "", "", "Path to the kubeconfig for the remote cluster")
+	createClusterPairCommand.Flags().StringVarP(&remoteContext, "remote-context", "", "", "Context to use from the kubeconfig for the remote cluster, defaults to the current context")
+	createClusterPairCommand.Flags().StringVarP(&secretName, "secret", "", "", "Name of the secret to store the config for the remote cluster in, defaults to <name>-kubeconfig")
+	createClusterPairCommand.Flags().StringToStringVarP(&options, "options", "", nil, "Comma separated list of storage options for the pair, in key=value format")
+	createClusterPairCommand.Flags().BoolVarP(&bootstrap, "bootstrap", "", false, "Create a service account on the remote cluster and use its token instead of the credentials from the kubeconfig")
+	createClusterPairCommand.Flags().StringVarP(&bootstrapNamespace, "bootstrap-namespace", "", "kube-system", "Namespace on the remote cluster to create the service account in")
+	createClusterPairCommand.Flags().BoolVarP(&bootstrapClusterAdmin, "bootstrap-cluster-admin", "", false, "Give the service account created on the remote cluster cluster-admin access instead of only the permissions needed for migrations")
+
+	return createClusterPairCommand
+}
+
+// bootstrapClusterPair creates a service account on the remote cluster and
+// returns a config that uses its token. The service account is bound to a
+// cluster role with the permissions needed for migrations, or to
+// cluster-admin if clusterAdmin is set. Existing resources are reused so that
+// the command can be run again.
+func bootstrapClusterPair(
+	cmdFactory Factory,
+	config *clientcmdapi.Config,
+	clusterPairName string,
+	namespace string,
+	clusterAdmin bool,
+) (*clientcmdapi.Config, error) {
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := cmdFactory.GetRemoteKubeClient(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	name := clusterPairBootstrapPrefix + clusterPairName
+	_, err = client.CoreV1().ServiceAccounts(namespace).Create(&v1.ServiceAccount{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("error creating service account on remote cluster: %v", err)
+	}
+
+	roleName := "cluster-admin"
+	if !clusterAdmin {
+		roleName = name
+		clusterRole := &rbacv1.ClusterRole{
+			ObjectMeta: meta.ObjectMeta{
+				Name: name,
+			},
+			Rules: clusterPairBootstrapRules,
+		}
+		_, err = client.RbacV1().ClusterRoles().Create(clusterRole)
+		if k8serrors.IsAlreadyExists(err) {
+			_, err = client.RbacV1().ClusterRoles().Update(clusterRole)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating cluster role on remote cluster: %v", err)
+		}
+	}
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{
+			Name: name,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     roleName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: namespace,
+			},
+		},
+	}
+	_, err = client.RbacV1().ClusterRoleBindings().Create(clusterRoleBinding)
+	if k8serrors.IsAlreadyExists(err) {
+		// The role of a binding can't be changed, so it needs to be
+		// recreated if it was bound to a different role before
+		var existing *rbacv1.ClusterRoleBinding
+		existing, err = client.RbacV1().ClusterRoleBindings().Get(name, meta.GetOptions{})
+		if err == nil && existing.RoleRef != clusterRoleBinding.RoleRef {
+			if err = client.RbacV1().ClusterRoleBindings().Delete(name, &meta.DeleteOptions{}); err == nil {
+				_, err = client.RbacV1().ClusterRoleBindings().Create(clusterRoleBinding)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating cluster role binding on remote cluster: %v", err)
+	}
+
+	// Tokens aren't created automatically for service accounts on all
+	// versions, so create the token secret and wait for it to be populated
+	tokenName := name + "-token"
+	_, err = client.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      tokenName,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				v1.ServiceAccountNameKey: name,
+			},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("error creating token for service account on remote cluster: %v", err)
+	}
+
+	var token, caData []byte
+	t := func() (interface{}, bool, error) {
+		secret, err := client.CoreV1().Secrets(namespace).Get(tokenName, meta.GetOptions{})
+		if err != nil {
+			return nil, true, err
+		}
+		if secret.Type != v1.SecretTypeServiceAccountToken || secret.Annotations[v1.ServiceAccountNameKey] != name {
+			return nil, false, fmt.Errorf("secret %v/%v isn't a token for service account %v", namespace, tokenName, name)
+		}
+		if len(secret.Data[v1.ServiceAccountTokenKey]) == 0 {
+			return nil, true, fmt.Errorf("token hasn't been created for service account %v/%v", namespace, name)
+		}
+		token = secret.Data[v1.ServiceAccountTokenKey]
+		caData = secret.Data[v1.ServiceAccountRootCAKey]
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, clusterPairBootstrapTimeout, clusterPairBootstrapRetryInterval); err != nil {
+		return nil, err
+	}
+
+	cluster := config.Clusters[config.Contexts[config.CurrentContext].Cluster]
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster for context %v not found in config", config.CurrentContext)
+	}
+	bootstrapCluster := cluster.DeepCopy()
+	if len(caData) > 0 {
+		bootstrapCluster.CertificateAuthorityData = caData
+	}
+	bootstrapConfig := clientcmdapi.NewConfig()
+	bootstrapConfig.Clusters[clusterPairName] = bootstrapCluster
+	bootstrapConfig.AuthInfos[clusterPairName] = &clientcmdapi.AuthInfo{
+		Token: string(token),
+	}
+	bootstrapConfig.Contexts[clusterPairName] = &clientcmdapi.Context{
+		Cluster:  clusterPairName,
+		AuthInfo: clusterPairName,
+	}
+	bootstrapConfig.CurrentContext = clusterPairName
+	return bootstrapConfig, nil
+}
+
+// saveClusterPairSecret creates the secret with the config for the remote
+// cluster, or updates it if it already exists
+func saveClusterPairSecret(name string, namespace string, data []byte) error {
+	secret, err := k8s.Instance().GetSecret(name, namespace)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		_, err = k8s.Instance().CreateSecret(&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				storkv1.ClusterPairConfigSecretKey: data,
+			},
+		})
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[storkv1.ClusterPairConfigSecretKey] = data
+	_, err = k8s.Instance().UpdateSecret(secret)
+	return err
+}
+
+// saveClusterPair creates the cluster pair referencing the config secret. If
+// it already exists it is updated to use the secret and the scheduler status
+// is reset so that the connectivity is validated again.
+func saveClusterPair(name string, namespace string, secretName string, options map[string]string) (string, error) {
+	clusterPair, err := k8s.Instance().GetClusterPair(name, namespace)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+		_, err = k8s.Instance().CreateClusterPair(&storkv1.ClusterPair{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: storkv1.ClusterPairSpec{
+				ConfigSecret: secretName,
+				Options:      options,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		return "ClusterPair " + name + " created successfully", nil
+	}
+
+	clusterPair.Spec.ConfigSecret = secretName
+	clusterPair.Spec.Config = clientcmdapi.Config{}
+	if len(options) > 0 {
+		clusterPair.Spec.Options = options
+	}
+	clusterPair.Status.SchedulerStatus = storkv1.ClusterPairStatusPending
+	if _, err := k8s.Instance().UpdateClusterPair(clusterPair); err != nil {
+		return "", err
+	}
+	return "ClusterPair " + name + " updated successfully", nil
+}
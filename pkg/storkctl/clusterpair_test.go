@@ -3,12 +3,17 @@
 package storkctl
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func createClusterPairAndVerify(t *testing.T, name string, namespace string) {
@@ -123,3 +128,184 @@ func TestGenerateClusterPairInvalidNamespace(t *testing.T) {
 	expected := "error: the Namespace \"test_namespace\" is not valid: [a DNS-1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')]"
 	testCommon(t, cmdArgs, nil, expected, true)
 }
+
+const remoteKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote:6443
+- name: other
+  cluster:
+    server: https://other:6443
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote
+- name: other
+  context:
+    cluster: other
+current-context: remote
+users:
+- name: remote
+  user:
+    token: remotetoken
+`
+
+func writeRemoteKubeconfig(t *testing.T) string {
+	file, err := ioutil.TempFile("", "remote-kubeconfig")
+	require.NoError(t, err, "Error creating kubeconfig file")
+	_, err = file.WriteString(remoteKubeconfig)
+	require.NoError(t, err, "Error writing kubeconfig file")
+	require.NoError(t, file.Close(), "Error closing kubeconfig file")
+	return file.Name()
+}
+
+func TestCreateClusterPairNoKubeconfig(t *testing.T) {
+	cmdArgs := []string{"create", "clusterpair", "pair1"}
+
+	expected := "error: path to the kubeconfig for the remote cluster needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestCreateClusterPairInvalidContext(t *testing.T) {
+	kubeconfig := writeRemoteKubeconfig(t)
+	defer os.Remove(kubeconfig)
+	cmdArgs := []string{"create", "clusterpair", "pair1", "--remote-kubeconfig", kubeconfig, "--remote-context", "missing"}
+
+	expected := "error: context missing not found in config"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestPruneClusterPairConfig(t *testing.T) {
+	config, err := clientcmd.Load([]byte(remoteKubeconfig))
+	require.NoError(t, err, "Error loading kubeconfig")
+	require.NoError(t, pruneClusterPairConfig(config), "Error pruning config")
+	require.Len(t, config.Contexts, 1, "Other contexts should have been pruned")
+	require.Len(t, config.Clusters, 1, "Other clusters should have been pruned")
+	require.Equal(t, "remotetoken", config.AuthInfos["remote"].Token, "Token mismatch")
+}
+
+func TestSaveClusterPair(t *testing.T) {
+	defer resetTest()
+	err := saveClusterPairSecret("pair1-kubeconfig", "default", []byte("config1"))
+	require.NoError(t, err, "Error saving secret")
+	msg, err := saveClusterPair("pair1", "default", "pair1-kubeconfig", map[string]string{"ip": "10.0.0.1"})
+	require.NoError(t, err, "Error saving Clusterpair")
+	require.Equal(t, "ClusterPair pair1 created successfully", msg, "Message mismatch")
+
+	clusterPair, err := k8s.Instance().GetClusterPair("pair1", "default")
+	require.NoError(t, err, "Error getting Clusterpair")
+	require.Equal(t, "pair1-kubeconfig", clusterPair.Spec.ConfigSecret, "Clusterpair config secret mismatch")
+	require.Equal(t, map[string]string{"ip": "10.0.0.1"}, clusterPair.Spec.Options, "Clusterpair options mismatch")
+
+	// Saving it again should update the secret and reset the status
+	clusterPair.Status.SchedulerStatus = storkv1.ClusterPairStatusError
+	_, err = k8s.Instance().UpdateClusterPair(clusterPair)
+	require.NoError(t, err, "Error updating Clusterpair")
+
+	err = saveClusterPairSecret("pair1-kubeconfig", "default", []byte("config2"))
+	require.NoError(t, err, "Error saving secret")
+	msg, err = saveClusterPair("pair1", "default", "pair1-kubeconfig", nil)
+	require.NoError(t, err, "Error saving Clusterpair")
+	require.Equal(t, "ClusterPair pair1 updated successfully", msg, "Message mismatch")
+
+	clusterPair, err = k8s.Instance().GetClusterPair("pair1", "default")
+	require.NoError(t, err, "Error getting Clusterpair")
+	require.Equal(t, storkv1.ClusterPairStatusPending, clusterPair.Status.SchedulerStatus, "Scheduler status should be reset")
+	require.Equal(t, map[string]string{"ip": "10.0.0.1"}, clusterPair.Spec.Options, "Clusterpair options mismatch")
+	secret, err := k8s.Instance().GetSecret("pair1-kubeconfig", "default")
+	require.NoError(t, err, "Error getting secret")
+	require.Equal(t, []byte("config2"), secret.Data[storkv1.ClusterPairConfigSecretKey], "Secret data mismatch")
+}
+
+func TestBootstrapClusterPair(t *testing.T) {
+	defer resetTest()
+	// The token is populated by the remote cluster, so create the secret
+	// with the token ahead of time
+	_, err := fakeRemoteKubeClient.CoreV1().Secrets("kube-system").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "stork-clusterpair-pair1-token",
+			Namespace:   "kube-system",
+			Annotations: map[string]string{v1.ServiceAccountNameKey: "stork-clusterpair-pair1"},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+		Data: map[string][]byte{
+			v1.ServiceAccountTokenKey:  []byte("satoken"),
+			v1.ServiceAccountRootCAKey: []byte("saca"),
+		},
+	})
+	require.NoError(t, err, "Error creating token secret")
+
+	config, err := clientcmd.Load([]byte(remoteKubeconfig))
+	require.NoError(t, err, "Error loading kubeconfig")
+	bootstrapConfig, err := bootstrapClusterPair(testFactory, config, "pair1", "kube-system", false)
+	require.NoError(t, err, "Error bootstrapping Clusterpair")
+
+	_, err = fakeRemoteKubeClient.CoreV1().ServiceAccounts("kube-system").Get("stork-clusterpair-pair1", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting service account")
+	clusterRole, err := fakeRemoteKubeClient.RbacV1().ClusterRoles().Get("stork-clusterpair-pair1", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting cluster role")
+	require.Equal(t, clusterPairBootstrapRules, clusterRole.Rules, "Cluster role rules mismatch")
+	require.False(t, isRuleAllowed(clusterRole.Rules, rbacv1.GroupName, "clusterrolebindings", "create"), "Cluster role bindings shouldn't be writable")
+	require.False(t, isRuleAllowed(clusterRole.Rules, rbacv1.GroupName, "clusterroles", "update"), "Cluster roles shouldn't be writable")
+	require.False(t, isRuleAllowed(clusterRole.Rules, "", "secrets", "get"), "Secrets shouldn't be readable")
+	require.True(t, isRuleAllowed(clusterRole.Rules, "", "secrets", "create"), "Secrets should be writable")
+	binding, err := fakeRemoteKubeClient.RbacV1().ClusterRoleBindings().Get("stork-clusterpair-pair1", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting cluster role binding")
+	require.Equal(t, "stork-clusterpair-pair1", binding.RoleRef.Name, "Service account shouldn't be cluster-admin by default")
+
+	context := bootstrapConfig.Contexts[bootstrapConfig.CurrentContext]
+	require.NotNil(t, context, "Current context not found")
+	require.Equal(t, "satoken", bootstrapConfig.AuthInfos[context.AuthInfo].Token, "Token mismatch")
+	require.Equal(t, "https://remote:6443", bootstrapConfig.Clusters[context.Cluster].Server, "Server mismatch")
+	require.Equal(t, []byte("saca"), bootstrapConfig.Clusters[context.Cluster].CertificateAuthorityData, "CA mismatch")
+
+	// Bootstrapping again with cluster-admin should rebind the service account
+	_, err = bootstrapClusterPair(testFactory, config, "pair1", "kube-system", true)
+	require.NoError(t, err, "Error bootstrapping Clusterpair")
+	binding, err = fakeRemoteKubeClient.RbacV1().ClusterRoleBindings().Get("stork-clusterpair-pair1", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting cluster role binding")
+	require.Equal(t, "cluster-admin", binding.RoleRef.Name, "Service account should be bound to cluster-admin")
+}
+
+func isRuleAllowed(rules []rbacv1.PolicyRule, apiGroup string, resource string, verb string) bool {
+	for _, rule := range rules {
+		groupMatch, resourceMatch, verbMatch := false, false, false
+		for _, g := range rule.APIGroups {
+			groupMatch = groupMatch || g == apiGroup || g == "*"
+		}
+		for _, r := range rule.Resources {
+			resourceMatch = resourceMatch || r == resource || r == "*"
+		}
+		for _, v := range rule.Verbs {
+			verbMatch = verbMatch || v == verb || v == "*"
+		}
+		if groupMatch && resourceMatch && verbMatch {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBootstrapClusterPairInvalidToken(t *testing.T) {
+	defer resetTest()
+	// A secret that isn't a token for the service account shouldn't be used
+	_, err := fakeRemoteKubeClient.CoreV1().Secrets("kube-system").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stork-clusterpair-pair1-token",
+			Namespace: "kube-system",
+		},
+		Data: map[string][]byte{
+			v1.ServiceAccountTokenKey: []byte("other"),
+		},
+	})
+	require.NoError(t, err, "Error creating secret")
+
+	config, err := clientcmd.Load([]byte(remoteKubeconfig))
+	require.NoError(t, err, "Error loading kubeconfig")
+	_, err = bootstrapClusterPair(testFactory, config, "pair1", "kube-system", false)
+	require.Error(t, err, "Bootstrap should fail if the secret isn't a token")
+	require.Contains(t, err.Error(), "isn't a token for service account")
+}
@@ -3,7 +3,11 @@ package storkctl
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/spf13/cobra"
 )
 
 func toTimeString(t time.Time) string {
@@ -23,3 +27,47 @@ func printMsg(msg string, out io.Writer) {
 		fmt.Println(msg)
 	}
 }
+
+// parseResourceTypes parses resource types specified as either
+// <group>/<version>/<kind> or just <kind>. Empty fields match all values.
+func parseResourceTypes(resourceTypes []string) ([]storkv1.ResourceType, error) {
+	parsed := make([]storkv1.ResourceType, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		fields := strings.Split(resourceType, "/")
+		switch len(fields) {
+		case 1:
+			parsed = append(parsed, storkv1.ResourceType{Kind: fields[0]})
+		case 3:
+			parsed = append(parsed, storkv1.ResourceType{
+				Group:   fields[0],
+				Version: fields[1],
+				Kind:    fields[2],
+			})
+		default:
+			return nil, fmt.Errorf("invalid resource type %v, should be <group>/<version>/<kind> or <kind>", resourceType)
+		}
+	}
+	return parsed, nil
+}
+
+// getScheduleRunOptions returns the starting deadline and concurrency policy
+// for a schedule. The starting deadline is only set if the flag was
+// specified.
+func getScheduleRunOptions(
+	c *cobra.Command,
+	startingDeadlineSeconds int64,
+	concurrencyPolicy string,
+) (*int64, storkv1.ConcurrencyPolicyType, error) {
+	var deadline *int64
+	if c.Flags().Changed("startingDeadlineSeconds") {
+		if startingDeadlineSeconds < 0 {
+			return nil, "", fmt.Errorf("startingDeadlineSeconds can't be negative")
+		}
+		deadline = &startingDeadlineSeconds
+	}
+	switch policy := storkv1.ConcurrencyPolicyType(concurrencyPolicy); policy {
+	case "", storkv1.ConcurrencyPolicyAllow, storkv1.ConcurrencyPolicyForbid, storkv1.ConcurrencyPolicyReplace:
+		return deadline, policy, nil
+	}
+	return nil, "", fmt.Errorf("invalid concurrencyPolicy %v, should be Allow, Forbid or Replace", concurrencyPolicy)
+}
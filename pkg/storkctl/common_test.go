@@ -27,6 +27,8 @@ var codec runtime.Codec
 var fakeStorkClient *fakeclient.Clientset
 var fakeOCPClient *fakeocpclient.Clientset
 var fakeRestClient *fake.RESTClient
+var fakeKubeClient *kubernetes.Clientset
+var fakeRemoteKubeClient *kubernetes.Clientset
 var testFactory *TestFactory
 
 func init() {
@@ -62,7 +64,8 @@ func resetTest() {
 	testFactory.setOutputFormat(outputFormatTable)
 	tf := testFactory.TestFactory
 	tf.Client = fakeRestClient
-	fakeKubeClient := kubernetes.NewSimpleClientset()
+	fakeKubeClient = kubernetes.NewSimpleClientset()
+	fakeRemoteKubeClient = kubernetes.NewSimpleClientset()
 
 	k8s.Instance().SetClient(fakeKubeClient, fakeRestClient, fakeStorkClient, nil, nil, fakeOCPClient, nil, nil)
 }
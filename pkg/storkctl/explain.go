@@ -0,0 +1,146 @@
+package storkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/spf13/cobra"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+	"k8s.io/kubernetes/pkg/printers"
+)
+
+const (
+	defaultStorkServiceName      = "stork-service"
+	defaultStorkServiceNamespace = "kube-system"
+	defaultStorkExtenderPort     = "8099"
+)
+
+var explainSchedulingColumns = []string{"NODE", "FILTERED", "SCORE", "DETAILS"}
+var explainSchedulingSubcommand = "scheduling"
+var explainSchedulingAliases = []string{"sched"}
+
+func newExplainCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	explainCommands := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain decisions made by stork",
+	}
+
+	explainCommands.AddCommand(
+		newExplainSchedulingCommand(cmdFactory, ioStreams),
+	)
+
+	return explainCommands
+}
+
+func newExplainSchedulingCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var serviceName string
+	var serviceNamespace string
+	var servicePort string
+	explainSchedulingCommand := &cobra.Command{
+		Use:     explainSchedulingSubcommand + " <pod>",
+		Aliases: explainSchedulingAliases,
+		Short:   "Explain how the stork scheduler extender filters and scores nodes for a pod",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for the pod"))
+				return
+			}
+			outputFormat, err := cmdFactory.GetOutputFormat()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			client, err := cmdFactory.GetKubeClient()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			params := map[string]string{
+				extender.ExplainNamespaceParam: cmdFactory.GetNamespace(),
+				extender.ExplainNameParam:      args[0],
+			}
+			contents, err := client.CoreV1().Services(serviceNamespace).ProxyGet(
+				"http", serviceName, servicePort, extender.ExplainPath, params).DoRaw()
+			if err != nil {
+				util.CheckErr(fmt.Errorf("error getting scheduling explanation for pod %v: %v", args[0], err))
+				return
+			}
+			var explanation extender.SchedulingExplanation
+			if err := json.Unmarshal(contents, &explanation); err != nil {
+				util.CheckErr(fmt.Errorf("error parsing scheduling explanation: %v", err))
+				return
+			}
+			if err := printExplanation(&explanation, outputFormat, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	explainSchedulingCommand.Flags().StringVar(&serviceName, "service", defaultStorkServiceName, "Name of the stork service")
+	explainSchedulingCommand.Flags().StringVar(&serviceNamespace, "service-namespace", defaultStorkServiceNamespace, "Namespace of the stork service")
+	explainSchedulingCommand.Flags().StringVar(&servicePort, "service-port", defaultStorkExtenderPort, "Port of the scheduler extender on the stork service")
+
+	return explainSchedulingCommand
+}
+
+func printExplanation(explanation *extender.SchedulingExplanation, outputFormat string, out io.Writer) error {
+	switch outputFormat {
+	case outputFormatJSON:
+		contents, err := json.MarshalIndent(explanation, "", "    ")
+		if err != nil {
+			return err
+		}
+		printMsg(string(contents), out)
+		return nil
+	case outputFormatYaml:
+		contents, err := yaml.Marshal(explanation)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(contents)
+		return err
+	}
+
+	printMsg(fmt.Sprintf("Pod: %v/%v", explanation.Namespace, explanation.Pod), out)
+	printMsg(fmt.Sprintf("Strategy: %v", explanation.Strategy), out)
+	if explanation.FilterError != "" {
+		printMsg(fmt.Sprintf("Error: %v", explanation.FilterError), out)
+	}
+	printMsg("", out)
+
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(explainSchedulingColumns, "\t")); err != nil {
+		return err
+	}
+	for _, node := range explanation.Nodes {
+		filtered := "false"
+		score := fmt.Sprintf("%v", node.Score)
+		details := ""
+		if node.Filtered {
+			filtered = "true"
+			score = ""
+			details = node.FilterReason
+		} else {
+			volumeScores := make([]string, 0)
+			for _, volumeScore := range node.VolumeScores {
+				locality := volumeScore.Locality
+				if locality == "" {
+					locality = "none"
+				}
+				volumeScores = append(volumeScores,
+					fmt.Sprintf("%v(%v)=%v", volumeScore.Volume, locality, volumeScore.Score))
+			}
+			details = strings.Join(volumeScores, ",")
+		}
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", node.Name, filtered, score, details); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,200 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeResponseWrapper struct {
+	contents []byte
+	err      error
+}
+
+func (f *fakeResponseWrapper) DoRaw() ([]byte, error) {
+	return f.contents, f.err
+}
+
+func (f *fakeResponseWrapper) Stream() (io.ReadCloser, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func setExplainResponse(t *testing.T, explanation *extender.SchedulingExplanation, err error) {
+	var contents []byte
+	if explanation != nil {
+		var marshalErr error
+		contents, marshalErr = json.Marshal(explanation)
+		require.NoError(t, marshalErr, "Error marshalling explanation")
+	}
+	fakeKubeClient.PrependProxyReactor("services", func(action k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+		proxyAction := action.(k8stesting.ProxyGetAction)
+		require.Equal(t, defaultStorkServiceNamespace, proxyAction.GetNamespace(), "Unexpected service namespace")
+		require.Equal(t, defaultStorkServiceName, proxyAction.GetName(), "Unexpected service name")
+		require.Equal(t, defaultStorkExtenderPort, proxyAction.GetPort(), "Unexpected service port")
+		require.Equal(t, extender.ExplainPath, proxyAction.GetPath(), "Unexpected path")
+		require.Equal(t, "test", proxyAction.GetParams()[extender.ExplainNamespaceParam], "Unexpected pod namespace")
+		return true, &fakeResponseWrapper{contents: contents, err: err}, nil
+	})
+}
+
+func newTestExplanation() *extender.SchedulingExplanation {
+	return &extender.SchedulingExplanation{
+		Pod:       "explainpod",
+		Namespace: "test",
+		Strategy:  extender.PackStrategy,
+		Nodes: []*extender.NodeExplanation{
+			{
+				Name:  "node1",
+				Score: 200,
+				VolumeScores: []*extender.VolumeScore{
+					{Driver: "pxd", Volume: "vol1", Locality: "node", Score: 100},
+					{Driver: "pxd", Volume: "vol2", Locality: "node", Score: 100},
+				},
+			},
+			{
+				Name:  "node2",
+				Score: 150,
+				VolumeScores: []*extender.VolumeScore{
+					{Driver: "pxd", Volume: "vol1", Locality: "node", Score: 100},
+					{Driver: "pxd", Volume: "vol2", Locality: "rack", Score: 50},
+				},
+			},
+			{
+				Name:  "node3",
+				Score: 5,
+				VolumeScores: []*extender.VolumeScore{
+					{Driver: "pxd", Volume: "vol1", Score: 0},
+					{Driver: "pxd", Volume: "vol2", Score: 0},
+				},
+			},
+			{
+				Name:         "node4",
+				Filtered:     true,
+				FilterReason: "storage driver not online on node",
+			},
+		},
+	}
+}
+
+func TestExplainSchedulingNoPod(t *testing.T) {
+	cmdArgs := []string{"explain", "scheduling", "-n", "test"}
+	expected := "error: exactly one name needs to be provided for the pod"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestExplainScheduling(t *testing.T) {
+	defer resetTest()
+	setExplainResponse(t, newTestExplanation(), nil)
+	cmdArgs := []string{"explain", "scheduling", "explainpod", "-n", "test"}
+	expected := "Pod: test/explainpod\n" +
+		"Strategy: pack\n" +
+		"\n" +
+		"NODE      FILTERED   SCORE     DETAILS\n" +
+		"node1     false      200       vol1(node)=100,vol2(node)=100\n" +
+		"node2     false      150       vol1(node)=100,vol2(rack)=50\n" +
+		"node3     false      5         vol1(none)=0,vol2(none)=0\n" +
+		"node4     true                 storage driver not online on node\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestExplainSchedulingFilterError(t *testing.T) {
+	defer resetTest()
+	explanation := &extender.SchedulingExplanation{
+		Pod:         "explainpod",
+		Namespace:   "test",
+		Strategy:    extender.SpreadStrategy,
+		FilterError: "No online node found with volume replica",
+		Nodes: []*extender.NodeExplanation{
+			{
+				Name:         "node1",
+				Filtered:     true,
+				FilterReason: "No online node found with volume replica",
+			},
+		},
+	}
+	setExplainResponse(t, explanation, nil)
+	cmdArgs := []string{"explain", "scheduling", "explainpod", "-n", "test"}
+	expected := "Pod: test/explainpod\n" +
+		"Strategy: spread\n" +
+		"Error: No online node found with volume replica\n" +
+		"\n" +
+		"NODE      FILTERED   SCORE     DETAILS\n" +
+		"node1     true                 No online node found with volume replica\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestExplainSchedulingJSON(t *testing.T) {
+	defer resetTest()
+	explanation := newTestExplanation()
+	setExplainResponse(t, explanation, nil)
+	cmdArgs := []string{"explain", "scheduling", "explainpod", "-n", "test", "-o", "json"}
+	expected, err := json.MarshalIndent(explanation, "", "    ")
+	require.NoError(t, err, "Error marshalling explanation")
+	testCommon(t, cmdArgs, nil, string(expected)+"\n", false)
+}
+
+func TestExplainSchedulingYaml(t *testing.T) {
+	defer resetTest()
+	setExplainResponse(t, newTestExplanation(), nil)
+	cmdArgs := []string{"explain", "scheduling", "explainpod", "-n", "test", "-o", "yaml"}
+	expected := `namespace: test
+nodes:
+- filtered: false
+  name: node1
+  score: 200
+  volumeScores:
+  - driver: pxd
+    locality: node
+    score: 100
+    volume: vol1
+  - driver: pxd
+    locality: node
+    score: 100
+    volume: vol2
+- filtered: false
+  name: node2
+  score: 150
+  volumeScores:
+  - driver: pxd
+    locality: node
+    score: 100
+    volume: vol1
+  - driver: pxd
+    locality: rack
+    score: 50
+    volume: vol2
+- filtered: false
+  name: node3
+  score: 5
+  volumeScores:
+  - driver: pxd
+    score: 0
+    volume: vol1
+  - driver: pxd
+    score: 0
+    volume: vol2
+- filterReason: storage driver not online on node
+  filtered: true
+  name: node4
+  score: 0
+pod: explainpod
+strategy: pack
+`
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestExplainSchedulingError(t *testing.T) {
+	defer resetTest()
+	setExplainResponse(t, nil, fmt.Errorf("pods \"explainpod\" not found"))
+	cmdArgs := []string{"explain", "scheduling", "explainpod", "-n", "test"}
+	expected := "error: error getting scheduling explanation for pod explainpod: pods \"explainpod\" not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
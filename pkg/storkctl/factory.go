@@ -3,8 +3,10 @@ package storkctl
 import (
 	"fmt"
 
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
 	"github.com/portworx/sched-ops/k8s"
 	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -39,6 +41,12 @@ type Factory interface {
 	GetAllNamespaces() ([]string, error)
 	// GetConfig Get the merged config for the server
 	GetConfig() (*rest.Config, error)
+	// GetKubeClient Get a Kubernetes client for the server
+	GetKubeClient() (kubernetes.Interface, error)
+	// GetStorkClient Get a client for stork resources on the server
+	GetStorkClient() (storkclientset.Interface, error)
+	// GetRemoteKubeClient Get a Kubernetes client for a remote cluster
+	GetRemoteKubeClient(config *rest.Config) (kubernetes.Interface, error)
 	// RawConfig Gets the raw merged config for the server
 	RawConfig() (clientcmdapi.Config, error)
 	// UpdateConfig Updates the config to be used for API calls
@@ -106,6 +114,26 @@ func (f *factory) GetConfig() (*rest.Config, error) {
 	return f.getKubeconfig().ClientConfig()
 }
 
+func (f *factory) GetKubeClient() (kubernetes.Interface, error) {
+	config, err := f.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+func (f *factory) GetStorkClient() (storkclientset.Interface, error) {
+	config, err := f.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return storkclientset.NewForConfig(config)
+}
+
+func (f *factory) GetRemoteKubeClient(config *rest.Config) (kubernetes.Interface, error) {
+	return kubernetes.NewForConfig(config)
+}
+
 func (f *factory) UpdateConfig() error {
 	config, err := f.GetConfig()
 	if err != nil {
@@ -3,6 +3,8 @@
 package storkctl
 
 import (
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	cmdtesting "k8s.io/kubernetes/pkg/kubectl/cmd/testing"
 )
@@ -26,3 +28,15 @@ func (t *TestFactory) GetConfig() (*rest.Config, error) {
 func (t *TestFactory) UpdateConfig() error {
 	return nil
 }
+
+func (t *TestFactory) GetKubeClient() (kubernetes.Interface, error) {
+	return fakeKubeClient, nil
+}
+
+func (t *TestFactory) GetStorkClient() (storkclientset.Interface, error) {
+	return fakeStorkClient, nil
+}
+
+func (t *TestFactory) GetRemoteKubeClient(config *rest.Config) (kubernetes.Interface, error) {
+	return fakeRemoteKubeClient, nil
+}
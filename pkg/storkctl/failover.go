@@ -0,0 +1,266 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/task"
+	"github.com/spf13/cobra"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+	"k8s.io/kubernetes/pkg/printers"
+)
+
+var failoverColumns = []string{"NAME", "TYPE", "CLUSTERPAIR", "STEP", "STATUS", "CREATED"}
+var failoverSubcommand = "failover"
+var failoverAliases = []string{"failovers", "fo"}
+var failbackSubcommand = "failback"
+
+func newPerformFailoverCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var clusterPair string
+	var namespaceList []string
+	var clusterDomain string
+	var includeVolumes bool
+	var waitForCompletion bool
+
+	performFailoverCommand := &cobra.Command{
+		Use:   failoverSubcommand,
+		Short: "Fail over applications to the destination cluster of a cluster pair",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one name needs to be provided for failover name"))
+				return
+			}
+			if len(clusterPair) == 0 {
+				util.CheckErr(fmt.Errorf("ClusterPair name needs to be provided for failover"))
+				return
+			}
+			if len(namespaceList) == 0 {
+				util.CheckErr(fmt.Errorf("need to provide atleast one namespace to fail over"))
+				return
+			}
+			failover := &storkv1.Failover{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      args[0],
+					Namespace: cmdFactory.GetNamespace(),
+				},
+				Spec: storkv1.FailoverSpec{
+					ClusterPair:    clusterPair,
+					Namespaces:     namespaceList,
+					ClusterDomain:  clusterDomain,
+					IncludeVolumes: &includeVolumes,
+				},
+			}
+			createFailover(cmdFactory, failover, waitForCompletion, ioStreams)
+		},
+	}
+	performFailoverCommand.Flags().StringSliceVarP(&namespaceList, "namespaces", "", nil, "Comma separated list of namespaces to fail over")
+	performFailoverCommand.Flags().StringVarP(&clusterPair, "clusterPair", "c", "", "ClusterPair name for the final migration")
+	performFailoverCommand.Flags().StringVarP(&clusterDomain, "clusterDomain", "", "", "Cluster domain of the source cluster to deactivate")
+	performFailoverCommand.Flags().BoolVarP(&includeVolumes, "includeVolumes", "", true, "Include volumes in the final migration")
+	performFailoverCommand.Flags().BoolVarP(&waitForCompletion, "wait", "w", false, "Wait for failover to complete")
+
+	return performFailoverCommand
+}
+
+func newPerformFailbackCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var name string
+	var waitForCompletion bool
+
+	performFailbackCommand := &cobra.Command{
+		Use:   failbackSubcommand,
+		Short: "Fail back applications by reversing the steps of a failover",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.CheckErr(fmt.Errorf("exactly one failover name needs to be provided to fail back"))
+				return
+			}
+			if len(name) == 0 {
+				name = args[0] + "-" + failbackSubcommand
+			}
+			failover := &storkv1.Failover{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      name,
+					Namespace: cmdFactory.GetNamespace(),
+				},
+				Spec: storkv1.FailoverSpec{
+					Failback: args[0],
+				},
+			}
+			createFailover(cmdFactory, failover, waitForCompletion, ioStreams)
+		},
+	}
+	performFailbackCommand.Flags().StringVar(&name, "name", "", "Name for the failback, defaults to <failover>-failback")
+	performFailbackCommand.Flags().BoolVarP(&waitForCompletion, "wait", "w", false, "Wait for failback to complete")
+
+	return performFailbackCommand
+}
+
+func createFailover(
+	cmdFactory Factory,
+	failover *storkv1.Failover,
+	waitForCompletion bool,
+	ioStreams genericclioptions.IOStreams,
+) {
+	storkClient, err := cmdFactory.GetStorkClient()
+	if err != nil {
+		util.CheckErr(err)
+		return
+	}
+	_, err = storkClient.StorkV1alpha1().Failovers(failover.Namespace).Create(failover)
+	if err != nil {
+		util.CheckErr(err)
+		return
+	}
+
+	if waitForCompletion {
+		msg, err := waitForFailover(cmdFactory, failover.Name, failover.Namespace, ioStreams)
+		if err != nil {
+			util.CheckErr(err)
+			return
+		}
+		printMsg(msg, ioStreams.Out)
+	} else {
+		msg := fmt.Sprintf("%v %v started successfully", getFailoverType(failover), failover.Name)
+		printMsg(msg, ioStreams.Out)
+	}
+}
+
+func newGetFailoverCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	getFailoverCommand := &cobra.Command{
+		Use:     failoverSubcommand,
+		Aliases: failoverAliases,
+		Short:   "Get failovers and failbacks",
+		Run: func(c *cobra.Command, args []string) {
+			storkClient, err := cmdFactory.GetStorkClient()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespaces, err := cmdFactory.GetAllNamespaces()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			failovers := new(storkv1.FailoverList)
+			for _, ns := range namespaces {
+				if len(args) > 0 {
+					for _, name := range args {
+						failover, err := storkClient.StorkV1alpha1().Failovers(ns).Get(name, meta.GetOptions{})
+						if err != nil {
+							util.CheckErr(err)
+							return
+						}
+						failovers.Items = append(failovers.Items, *failover)
+					}
+				} else {
+					list, err := storkClient.StorkV1alpha1().Failovers(ns).List(meta.ListOptions{})
+					if err != nil {
+						util.CheckErr(err)
+						return
+					}
+					failovers.Items = append(failovers.Items, list.Items...)
+				}
+			}
+
+			if len(failovers.Items) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+			if err := printObjects(c, failovers, cmdFactory, failoverColumns, failoverPrinter, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	cmdFactory.BindGetFlags(getFailoverCommand.Flags())
+
+	return getFailoverCommand
+}
+
+func failoverPrinter(failoverList *storkv1.FailoverList, writer io.Writer, options printers.PrintOptions) error {
+	if failoverList == nil {
+		return nil
+	}
+	for _, failover := range failoverList.Items {
+		name := printers.FormatResourceName(options.Kind, failover.Name, options.WithKind)
+
+		if options.WithNamespace {
+			if _, err := fmt.Fprintf(writer, "%v\t", failover.Namespace); err != nil {
+				return err
+			}
+		}
+
+		creationTime := toTimeString(failover.CreationTimestamp.Time)
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			name,
+			getFailoverType(&failover),
+			failover.Spec.ClusterPair,
+			getFailoverStep(&failover),
+			failover.Status.Status,
+			creationTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getFailoverType(failover *storkv1.Failover) string {
+	if failover.Spec.Failback != "" {
+		return "Failback"
+	}
+	return "Failover"
+}
+
+// getFailoverStep returns the step that is in progress or the last step that
+// was performed
+func getFailoverStep(failover *storkv1.Failover) storkv1.FailoverStepType {
+	var step storkv1.FailoverStepType
+	for _, s := range failover.Status.Steps {
+		if s.Status == storkv1.FailoverStatusInitial {
+			break
+		}
+		step = s.Step
+	}
+	return step
+}
+
+func waitForFailover(
+	cmdFactory Factory,
+	name string,
+	namespace string,
+	ioStreams genericclioptions.IOStreams,
+) (string, error) {
+	var msg string
+	storkClient, err := cmdFactory.GetStorkClient()
+	if err != nil {
+		return "", err
+	}
+
+	printMsg("STEP\t\t\t\tSTATUS", ioStreams.Out)
+	t := func() (interface{}, bool, error) {
+		failover, err := storkClient.StorkV1alpha1().Failovers(namespace).Get(name, meta.GetOptions{})
+		if err != nil {
+			return "", false, err
+		}
+		stat := fmt.Sprintf("%-24s\t%-20s", getFailoverStep(failover), failover.Status.Status)
+		printMsg(stat, ioStreams.Out)
+		switch failover.Status.Status {
+		case storkv1.FailoverStatusSuccessful:
+			msg = fmt.Sprintf("%v %v completed successfully", getFailoverType(failover), name)
+			return "", false, nil
+		case storkv1.FailoverStatusFailed:
+			msg = fmt.Sprintf("%v %v failed: %v", getFailoverType(failover), name, failover.Status.Reason)
+			return "", false, nil
+		}
+		return "", true, fmt.Errorf("%v", failover.Status.Status)
+	}
+	if _, err = task.DoRetryWithTimeout(t, migrTimeout, migrRetryTimeout); err != nil {
+		msg = "Timed out performing task"
+	}
+
+	return msg, err
+}
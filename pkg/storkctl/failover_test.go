@@ -0,0 +1,86 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetFailoverNoFailovers(t *testing.T) {
+	cmdArgs := []string{"get", "failover"}
+
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestPerformFailoverNoClusterPair(t *testing.T) {
+	cmdArgs := []string{"perform", "failover", "failover1", "--namespaces", "ns1"}
+
+	expected := "error: ClusterPair name needs to be provided for failover"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestPerformFailoverNoNamespaces(t *testing.T) {
+	cmdArgs := []string{"perform", "failover", "failover1", "-c", "clusterpair1"}
+
+	expected := "error: need to provide atleast one namespace to fail over"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestPerformFailover(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"perform", "failover", "failover1", "-c", "clusterpair1", "--namespaces", "ns1,ns2", "--clusterDomain", "zone1"}
+
+	expected := "Failover failover1 started successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	failover, err := fakeStorkClient.StorkV1alpha1().Failovers("default").Get("failover1", meta.GetOptions{})
+	require.NoError(t, err, "Error getting failover")
+	require.Equal(t, "clusterpair1", failover.Spec.ClusterPair, "ClusterPair mismatch")
+	require.Equal(t, []string{"ns1", "ns2"}, failover.Spec.Namespaces, "Namespaces mismatch")
+	require.Equal(t, "zone1", failover.Spec.ClusterDomain, "ClusterDomain mismatch")
+	require.True(t, *failover.Spec.IncludeVolumes, "IncludeVolumes should be true by default")
+
+	cmdArgs = []string{"get", "failover", "failover1"}
+	expected = "NAME        TYPE       CLUSTERPAIR    STEP      STATUS    CREATED\n" +
+		"failover1   Failover   clusterpair1                       \n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	failover.Status.Status = storkv1.FailoverStatusInProgress
+	failover.Status.Steps = []*storkv1.FailoverStepInfo{
+		{Step: storkv1.FailoverStepScaleDownSource, Status: storkv1.FailoverStatusSuccessful},
+		{Step: storkv1.FailoverStepMigrate, Status: storkv1.FailoverStatusInProgress},
+		{Step: storkv1.FailoverStepActivateDestination, Status: storkv1.FailoverStatusInitial},
+	}
+	_, err = fakeStorkClient.StorkV1alpha1().Failovers("default").Update(failover)
+	require.NoError(t, err, "Error updating failover")
+
+	expected = "NAME        TYPE       CLUSTERPAIR    STEP      STATUS       CREATED\n" +
+		"failover1   Failover   clusterpair1   Migrate   InProgress   \n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestPerformFailback(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"perform", "failback", "failover1"}
+
+	expected := "Failback failover1-failback started successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	failover, err := fakeStorkClient.StorkV1alpha1().Failovers("default").Get("failover1-failback", meta.GetOptions{})
+	require.NoError(t, err, "Error getting failback")
+	require.Equal(t, "failover1", failover.Spec.Failback, "Failback mismatch")
+
+	cmdArgs = []string{"perform", "failback", "failover1", "--name", "failback2"}
+	expected = "Failback failback2 started successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	cmdArgs = []string{"get", "failover", "failback2"}
+	expected = "NAME        TYPE       CLUSTERPAIR   STEP      STATUS    CREATED\n" +
+		"failback2   Failback                                     \n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
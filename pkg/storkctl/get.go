@@ -32,6 +32,7 @@ func newGetCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *c
 		newGetApplicationRestoreCommand(cmdFactory, ioStreams),
 		newGetApplicationCloneCommand(cmdFactory, ioStreams),
 		newGetBackupLocationCommand(cmdFactory, ioStreams),
+		newGetFailoverCommand(cmdFactory, ioStreams),
 	)
 
 	return getCommands
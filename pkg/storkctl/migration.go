@@ -46,6 +46,14 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 	var postExecRule string
 	var includeVolumes bool
 	var waitForCompletion bool
+	var priority int32
+	var maxConcurrentVolumes int
+	var maxBandwidthMBps int64
+	var verify bool
+	var verifyApplications bool
+	var includeResourceTypes []string
+	var excludeResourceTypes []string
+	var clusterPairs []string
 
 	createMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
@@ -57,7 +65,7 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 				return
 			}
 			migrationName = args[0]
-			if len(clusterPair) == 0 {
+			if len(clusterPair) == 0 && len(clusterPairs) == 0 {
 				util.CheckErr(fmt.Errorf("ClusterPair name needs to be provided for migration"))
 				return
 			}
@@ -65,20 +73,46 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 				util.CheckErr(fmt.Errorf("need to provide atleast one namespace to migrate"))
 				return
 			}
+			if maxConcurrentVolumes < 0 {
+				util.CheckErr(fmt.Errorf("maxConcurrentVolumes can't be negative"))
+				return
+			}
+			if maxBandwidthMBps < 0 {
+				util.CheckErr(fmt.Errorf("maxBandwidthMBps can't be negative"))
+				return
+			}
+			includeTypes, err := parseResourceTypes(includeResourceTypes)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			excludeTypes, err := parseResourceTypes(excludeResourceTypes)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
 			migration := &storkv1.Migration{
 				Spec: storkv1.MigrationSpec{
-					ClusterPair:       clusterPair,
-					Namespaces:        namespaceList,
-					IncludeResources:  &includeResources,
-					IncludeVolumes:    &includeVolumes,
-					StartApplications: &startApplications,
-					PreExecRule:       preExecRule,
-					PostExecRule:      postExecRule,
+					ClusterPair:          clusterPair,
+					ClusterPairs:         clusterPairs,
+					Namespaces:           namespaceList,
+					IncludeResources:     &includeResources,
+					IncludeVolumes:       &includeVolumes,
+					StartApplications:    &startApplications,
+					PreExecRule:          preExecRule,
+					PostExecRule:         postExecRule,
+					Priority:             priority,
+					MaxConcurrentVolumes: maxConcurrentVolumes,
+					MaxBandwidthMBps:     maxBandwidthMBps,
+					Verify:               &verify,
+					VerifyApplications:   &verifyApplications,
+					IncludeResourceTypes: includeTypes,
+					ExcludeResourceTypes: excludeTypes,
 				},
 			}
 			migration.Name = migrationName
 			migration.Namespace = cmdFactory.GetNamespace()
-			_, err := k8s.Instance().CreateMigration(migration)
+			_, err = k8s.Instance().CreateMigration(migration)
 			if err != nil {
 				util.CheckErr(err)
 				return
@@ -99,12 +133,20 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 	}
 	createMigrationCommand.Flags().StringSliceVarP(&namespaceList, "namespaces", "", nil, "Comma separated list of namespaces to migrate")
 	createMigrationCommand.Flags().StringVarP(&clusterPair, "clusterPair", "c", "", "ClusterPair name for migration")
+	createMigrationCommand.Flags().StringSliceVarP(&clusterPairs, "clusterPairs", "", nil, "Comma separated list of additional ClusterPairs to migrate to in parallel")
 	createMigrationCommand.Flags().BoolVarP(&includeResources, "includeResources", "r", true, "Include resources in the migration")
 	createMigrationCommand.Flags().BoolVarP(&includeVolumes, "includeVolumes", "", true, "Include volumees in the migration")
 	createMigrationCommand.Flags().BoolVarP(&waitForCompletion, "wait", "w", false, "Wait for migration to complete")
 	createMigrationCommand.Flags().BoolVarP(&startApplications, "startApplications", "a", true, "Start applications on the destination cluster after migration")
 	createMigrationCommand.Flags().StringVarP(&preExecRule, "preExecRule", "", "", "Rule to run before executing migration")
 	createMigrationCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing migration")
+	createMigrationCommand.Flags().Int32VarP(&priority, "priority", "", 0, "Priority of the migration when it is waiting to be started, higher priority migrations are started first")
+	createMigrationCommand.Flags().IntVarP(&maxConcurrentVolumes, "maxConcurrentVolumes", "", 0, "Maximum number of volumes to migrate at the same time, no limit if 0")
+	createMigrationCommand.Flags().Int64VarP(&maxBandwidthMBps, "maxBandwidthMBps", "", 0, "Hint for the maximum bandwidth in MB/s to use for migrating volumes, no limit if 0. A warning is added to the migration status for drivers that don't support it")
+	createMigrationCommand.Flags().BoolVarP(&verify, "verify", "", false, "Verify the migrated resources on the destination cluster")
+	createMigrationCommand.Flags().BoolVarP(&verifyApplications, "verifyApplications", "", false, "Start the applications on the destination cluster during verification to check that they become ready")
+	createMigrationCommand.Flags().StringSliceVarP(&includeResourceTypes, "includeResourceTypes", "", nil, "Comma separated list of resource types to migrate in addition to the defaults, specified as <group>/<version>/<kind> or <kind>")
+	createMigrationCommand.Flags().StringSliceVarP(&excludeResourceTypes, "excludeResourceTypes", "", nil, "Comma separated list of resource types that shouldn't be migrated, specified as <group>/<version>/<kind> or <kind>")
 
 	return createMigrationCommand
 }
@@ -300,12 +342,24 @@ func getUpdatedReplicaCount(annotations map[string]string, activate bool, ioStre
 }
 
 func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var watch bool
 	var clusterPair string
 	getMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
 		Aliases: migrationAliases,
 		Short:   "Get migration resources",
 		Run: func(c *cobra.Command, args []string) {
+			if watch {
+				if len(args) != 1 {
+					util.CheckErr(fmt.Errorf("exactly one name needs to be provided to watch the migration"))
+					return
+				}
+				if err := watchProgress(getMigrationProgress(args[0], cmdFactory.GetNamespace()), true, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+
 			var migrations *storkv1.MigrationList
 			var err error
 
@@ -343,7 +397,7 @@ func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOSt
 				var tempMigrations storkv1.MigrationList
 
 				for _, migration := range migrations.Items {
-					if migration.Spec.ClusterPair == clusterPair {
+					if migrationHasClusterPair(&migration.Spec, clusterPair) {
 						tempMigrations.Items = append(tempMigrations.Items, migration)
 						continue
 					}
@@ -363,6 +417,7 @@ func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOSt
 		},
 	}
 	getMigrationCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migrations")
+	getMigrationCommand.Flags().BoolVarP(&watch, "watch", "w", false, "Watch the progress of the volumes until the migration is done")
 	cmdFactory.BindGetFlags(getMigrationCommand.Flags())
 
 	return getMigrationCommand
@@ -390,7 +445,7 @@ func newDeleteMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 					return
 				}
 				for _, migration := range migrationList.Items {
-					if migration.Spec.ClusterPair == clusterPair {
+					if migrationHasClusterPair(&migration.Spec, clusterPair) {
 						migrations = append(migrations, migration.Name)
 					}
 				}
@@ -450,7 +505,11 @@ func migrationPrinter(migrationList *storkv1.MigrationList, writer io.Writer, op
 			totalResources := len(migration.Status.Resources)
 			doneResources := 0
 			for _, resource := range migration.Status.Resources {
-				if resource.Status == storkv1.MigrationStatusSuccessful {
+				// Resources that were skipped since they hadn't changed or
+				// were migrated again since they had drifted are done too
+				if resource.Status == storkv1.MigrationStatusSuccessful ||
+					resource.Status == storkv1.MigrationStatusSkipped ||
+					resource.Status == storkv1.MigrationStatusDrifted {
 					doneResources++
 				}
 			}
@@ -471,7 +530,7 @@ func migrationPrinter(migrationList *storkv1.MigrationList, writer io.Writer, op
 		creationTime := toTimeString(migration.CreationTimestamp.Time)
 		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
 			name,
-			migration.Spec.ClusterPair,
+			strings.Join(getMigrationClusterPairs(&migration.Spec), ","),
 			migration.Status.Stage,
 			migration.Status.Status,
 			volumeStatus,
@@ -484,6 +543,32 @@ func migrationPrinter(migrationList *storkv1.MigrationList, writer io.Writer, op
 	return nil
 }
 
+// getMigrationClusterPairs returns the cluster pairs for all the destinations
+// of a migration with the spec
+func getMigrationClusterPairs(spec *storkv1.MigrationSpec) []string {
+	clusterPairs := make([]string, 0)
+	if spec.ClusterPair != "" {
+		clusterPairs = append(clusterPairs, spec.ClusterPair)
+	}
+	for _, clusterPair := range spec.ClusterPairs {
+		if clusterPair != spec.ClusterPair {
+			clusterPairs = append(clusterPairs, clusterPair)
+		}
+	}
+	return clusterPairs
+}
+
+// migrationHasClusterPair returns true if a migration with the spec is to the
+// destination of the cluster pair
+func migrationHasClusterPair(spec *storkv1.MigrationSpec, clusterPair string) bool {
+	for _, pair := range getMigrationClusterPairs(spec) {
+		if pair == clusterPair {
+			return true
+		}
+	}
+	return false
+}
+
 func waitForMigration(name, namespace string, ioStreams genericclioptions.IOStreams) (string, error) {
 	var msg string
 	var err error
@@ -520,3 +605,41 @@ func waitForMigration(name, namespace string, ioStreams genericclioptions.IOStre
 
 	return msg, err
 }
+
+// getMigrationProgress returns a function to get the progress of the volumes
+// for a migration
+func getMigrationProgress(name, namespace string) progressFunc {
+	return func() (*progressSummary, []*volumeProgress, error) {
+		migration, err := k8s.Instance().GetMigration(name, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := &progressSummary{
+			stage:            string(migration.Status.Stage),
+			status:           string(migration.Status.Status),
+			totalBytes:       migration.Status.TotalBytes,
+			bytesTransferred: migration.Status.BytesTransferred,
+			etaSeconds:       migration.Status.ETASeconds,
+			done:             migration.Status.Stage == storkv1.MigrationStageFinal,
+		}
+		volumes := make([]*volumeProgress, 0, len(migration.Status.Volumes))
+		for _, vInfo := range migration.Status.Volumes {
+			clusterPair := vInfo.ClusterPair
+			if clusterPair == "" {
+				clusterPair = migration.Spec.ClusterPair
+			}
+			volumes = append(volumes, &volumeProgress{
+				namespace:        vInfo.Namespace,
+				pvc:              vInfo.PersistentVolumeClaim,
+				volume:           vInfo.Volume,
+				clusterPair:      clusterPair,
+				status:           string(vInfo.Status),
+				totalBytes:       vInfo.TotalBytes,
+				bytesTransferred: vInfo.BytesTransferred,
+				etaSeconds:       vInfo.ETASeconds,
+				inProgress:       vInfo.Status == storkv1.MigrationStatusInProgress,
+			})
+		}
+		return summary, volumes, nil
+	}
+}
@@ -136,6 +136,54 @@ func TestGetMigrationsWithStatusAndProgress(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestGetMigrationsWatch(t *testing.T) {
+	defer resetTest()
+	createMigrationAndVerify(t, "watchmigrationtest", "default", "clusterpair1", []string{"namespace1"}, "", "")
+	migration, err := k8s.Instance().GetMigration("watchmigrationtest", "default")
+	require.NoError(t, err, "Error getting migration")
+
+	migration.Status.Stage = storkv1.MigrationStageFinal
+	migration.Status.Status = storkv1.MigrationStatusFailed
+	migration.Status.TotalBytes = 3 * 1024 * 1024 * 1024
+	migration.Status.BytesTransferred = 1536 * 1024 * 1024
+	migration.Status.Volumes = []*storkv1.MigrationVolumeInfo{
+		{
+			Namespace:             "namespace1",
+			PersistentVolumeClaim: "pvc1",
+			Volume:                "volume1",
+			Status:                storkv1.MigrationStatusSuccessful,
+			TotalBytes:            1024 * 1024 * 1024,
+			BytesTransferred:      1024 * 1024 * 1024,
+		},
+		{
+			Namespace:             "namespace1",
+			PersistentVolumeClaim: "pvc2",
+			Volume:                "volume2",
+			Status:                storkv1.MigrationStatusFailed,
+			TotalBytes:            2 * 1024 * 1024 * 1024,
+			BytesTransferred:      512 * 1024 * 1024,
+			ETASeconds:            60,
+		},
+	}
+	_, err = k8s.Instance().UpdateMigration(migration)
+	require.NoError(t, err, "Error updating migration")
+
+	expected := "STAGE: Final   STATUS: Failed   PROGRESS: 1.5GiB/3.0GiB (50%)   ETA: \n" +
+		"CLUSTERPAIR    NAMESPACE    PVC       VOLUME    STATUS       PROGRESS                ETA\n" +
+		"clusterpair1   namespace1   pvc1      volume1   Successful   1.0GiB/1.0GiB (100%)    \n" +
+		"clusterpair1   namespace1   pvc2      volume2   Failed       512.0MiB/2.0GiB (25%)   \n" +
+		"\n"
+	cmdArgs := []string{"get", "migrations", "watchmigrationtest", "--watch"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestGetMigrationsWatchNoName(t *testing.T) {
+	cmdArgs := []string{"get", "migrations", "--watch"}
+
+	expected := "error: exactly one name needs to be provided to watch the migration"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
 func TestCreateMigrationsNoNamespace(t *testing.T) {
 	cmdArgs := []string{"create", "migrations", "-c", "clusterPair1", "migration1"}
 
@@ -162,6 +210,94 @@ func TestCreateMigrations(t *testing.T) {
 	createMigrationAndVerify(t, "createmigration", "default", "clusterpair1", []string{"namespace1"}, "", "")
 }
 
+func TestCreateMigrationsWithLimits(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--priority", "10", "--maxConcurrentVolumes", "2", "--maxBandwidthMBps", "100", "limitmigration"}
+
+	expected := "Migration limitmigration created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration, err := k8s.Instance().GetMigration("limitmigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	require.Equal(t, int32(10), migration.Spec.Priority, "Migration priority mismatch")
+	require.Equal(t, 2, migration.Spec.MaxConcurrentVolumes, "Migration maxConcurrentVolumes mismatch")
+	require.Equal(t, int64(100), migration.Spec.MaxBandwidthMBps, "Migration maxBandwidthMBps mismatch")
+}
+
+func TestCreateMigrationsWithVerify(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--verify", "--verifyApplications", "verifymigration"}
+
+	expected := "Migration verifymigration created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration, err := k8s.Instance().GetMigration("verifymigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	require.True(t, *migration.Spec.Verify, "Migration verify should be set")
+	require.True(t, *migration.Spec.VerifyApplications, "Migration verifyApplications should be set")
+}
+
+func TestCreateMigrationsWithResourceTypes(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--includeResourceTypes", "example.com/v1/Database,NetworkPolicy",
+		"--excludeResourceTypes", "core//Secret", "resourcetypesmigration"}
+
+	expected := "Migration resourcetypesmigration created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration, err := k8s.Instance().GetMigration("resourcetypesmigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	require.Equal(t, []storkv1.ResourceType{
+		{Group: "example.com", Version: "v1", Kind: "Database"},
+		{Kind: "NetworkPolicy"},
+	}, migration.Spec.IncludeResourceTypes, "Migration includeResourceTypes mismatch")
+	require.Equal(t, []storkv1.ResourceType{
+		{Group: "core", Kind: "Secret"},
+	}, migration.Spec.ExcludeResourceTypes, "Migration excludeResourceTypes mismatch")
+}
+
+func TestCreateMigrationsInvalidResourceType(t *testing.T) {
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--includeResourceTypes", "v1/Database", "resourcetypesmigration"}
+
+	expected := "error: invalid resource type v1/Database, should be <group>/<version>/<kind> or <kind>"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestCreateMigrationsMultipleClusterPairs(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--clusterPairs", "clusterpair2,clusterpair3",
+		"--namespaces", "namespace1", "multimigration"}
+
+	expected := "Migration multimigration created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migration, err := k8s.Instance().GetMigration("multimigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	require.Equal(t, "clusterpair1", migration.Spec.ClusterPair, "Migration clusterPair mismatch")
+	require.Equal(t, []string{"clusterpair2", "clusterpair3"}, migration.Spec.ClusterPairs, "Migration clusterPairs mismatch")
+
+	expected = "NAME             CLUSTERPAIR                              STAGE     STATUS    VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
+		"multimigration   clusterpair1,clusterpair2,clusterpair3                       0/0       0/0                   \n"
+	cmdArgs = []string{"get", "migrations", "-c", "clusterpair3"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	expected = "No resources found.\n"
+	cmdArgs = []string{"get", "migrations", "-c", "clusterpair4"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestCreateMigrationsNegativeLimits(t *testing.T) {
+	cmdArgs := []string{"create", "migrations", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--maxConcurrentVolumes", "-1", "limitmigration"}
+
+	expected := "error: maxConcurrentVolumes can't be negative"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
 func TestCreateDuplicateMigrations(t *testing.T) {
 	defer resetTest()
 	createMigrationAndVerify(t, "createmigration", "default", "clusterpair1", []string{"namespace1"}, "", "")
@@ -3,6 +3,7 @@ package storkctl
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
@@ -28,6 +29,9 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 	var postExecRule string
 	var schedulePolicyName string
 	var suspend bool
+	var startingDeadlineSeconds int64
+	var concurrencyPolicy string
+	var clusterPairs []string
 
 	createMigrationScheduleCommand := &cobra.Command{
 		Use:     migrationScheduleSubcommand,
@@ -39,7 +43,7 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 				return
 			}
 			migrationScheduleName = args[0]
-			if len(clusterPair) == 0 {
+			if len(clusterPair) == 0 && len(clusterPairs) == 0 {
 				util.CheckErr(fmt.Errorf("ClusterPair name needs to be provided for migration schedule"))
 				return
 			}
@@ -52,7 +56,13 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 				return
 			}
 
-			_, err := k8s.Instance().GetSchedulePolicy(schedulePolicyName)
+			deadline, concurrency, err := getScheduleRunOptions(c, startingDeadlineSeconds, concurrencyPolicy)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			_, err = k8s.Instance().GetSchedulePolicy(schedulePolicyName)
 			if err != nil {
 				util.CheckErr(fmt.Errorf("error getting schedulepolicy %v: %v", schedulePolicyName, err))
 				return
@@ -63,6 +73,7 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 					Template: storkv1.MigrationTemplateSpec{
 						Spec: storkv1.MigrationSpec{
 							ClusterPair:       clusterPair,
+							ClusterPairs:      clusterPairs,
 							Namespaces:        namespaceList,
 							IncludeResources:  &includeResources,
 							IncludeVolumes:    &includeVolumes,
@@ -71,8 +82,10 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 							PostExecRule:      postExecRule,
 						},
 					},
-					SchedulePolicyName: schedulePolicyName,
-					Suspend:            &suspend,
+					SchedulePolicyName:      schedulePolicyName,
+					Suspend:                 &suspend,
+					StartingDeadlineSeconds: deadline,
+					ConcurrencyPolicy:       concurrency,
 				},
 			}
 			migrationSchedule.Name = migrationScheduleName
@@ -88,6 +101,7 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 	}
 	createMigrationScheduleCommand.Flags().StringSliceVarP(&namespaceList, "namespaces", "", nil, "Comma separated list of namespaces to migrate")
 	createMigrationScheduleCommand.Flags().StringVarP(&clusterPair, "clusterPair", "c", "", "ClusterPair name for migration")
+	createMigrationScheduleCommand.Flags().StringSliceVarP(&clusterPairs, "clusterPairs", "", nil, "Comma separated list of additional ClusterPairs to migrate to in parallel")
 	createMigrationScheduleCommand.Flags().BoolVarP(&includeResources, "includeResources", "r", true, "Include resources in the migration")
 	createMigrationScheduleCommand.Flags().BoolVarP(&includeVolumes, "includeVolumes", "", true, "Include volumees in the migration")
 	createMigrationScheduleCommand.Flags().BoolVarP(&startApplications, "startApplications", "a", false, "Start applications on the destination cluster after migration")
@@ -95,6 +109,8 @@ func newCreateMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 	createMigrationScheduleCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing migration")
 	createMigrationScheduleCommand.Flags().StringVarP(&schedulePolicyName, "schedulePolicyName", "s", "default-migration-policy", "Name of the schedule policy to use")
 	createMigrationScheduleCommand.Flags().BoolVar(&suspend, "suspend", false, "Flag to denote whether schedule should be suspended on creation")
+	createMigrationScheduleCommand.Flags().Int64VarP(&startingDeadlineSeconds, "startingDeadlineSeconds", "", 0, "Deadline in seconds for starting a migration after the time it was scheduled for, after which it is recorded as missed")
+	createMigrationScheduleCommand.Flags().StringVarP(&concurrencyPolicy, "concurrencyPolicy", "", "", "How to handle a scheduled migration when a previous one is still in progress (Allow, Forbid or Replace). Defaults to Forbid")
 
 	return createMigrationScheduleCommand
 }
@@ -143,7 +159,7 @@ func newGetMigrationScheduleCommand(cmdFactory Factory, ioStreams genericcliopti
 				var tempMigrationSchedules storkv1.MigrationScheduleList
 
 				for _, migrationSchedule := range migrationSchedules.Items {
-					if migrationSchedule.Spec.Template.Spec.ClusterPair == clusterPair {
+					if migrationHasClusterPair(&migrationSchedule.Spec.Template.Spec, clusterPair) {
 						tempMigrationSchedules.Items = append(tempMigrationSchedules.Items, migrationSchedule)
 						continue
 					}
@@ -190,7 +206,7 @@ func newDeleteMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclio
 					return
 				}
 				for _, migrationSchedule := range migrationScheduleList.Items {
-					if migrationSchedule.Spec.Template.Spec.ClusterPair == clusterPair {
+					if migrationHasClusterPair(&migrationSchedule.Spec.Template.Spec, clusterPair) {
 						migrationSchedules = append(migrationSchedules, migrationSchedule.Name)
 					}
 				}
@@ -238,7 +254,7 @@ func getMigrationSchedules(clusterPair string, args []string, namespace string)
 			return nil, err
 		}
 		for _, migrationSchedule := range migrationScheduleList.Items {
-			if migrationSchedule.Spec.Template.Spec.ClusterPair == clusterPair {
+			if migrationHasClusterPair(&migrationSchedule.Spec.Template.Spec, clusterPair) {
 				migrationSchedules = append(migrationSchedules, &migrationSchedule)
 			}
 		}
@@ -352,7 +368,7 @@ func migrationSchedulePrinter(migrationScheduleList *storkv1.MigrationScheduleLi
 		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
 			name,
 			migrationSchedule.Spec.SchedulePolicyName,
-			migrationSchedule.Spec.Template.Spec.ClusterPair,
+			strings.Join(getMigrationClusterPairs(&migrationSchedule.Spec.Template.Spec), ","),
 			suspend,
 			toTimeString(lastSuccessTime),
 			lastSuccessDuration,
@@ -226,6 +226,33 @@ func TestCreateMigrationSchedules(t *testing.T) {
 	createMigrationScheduleAndVerify(t, "createmigration", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", true)
 }
 
+func TestCreateMigrationSchedulesWithRunOptions(t *testing.T) {
+	defer resetTest()
+	createMigrationScheduleAndVerify(t, "runoptionsschedule", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", false)
+
+	cmdArgs := []string{"create", "migrationschedules", "-s", "testpolicy", "-c", "clusterpair1", "--namespaces", "namespace1",
+		"--startingDeadlineSeconds", "300", "--concurrencyPolicy", "Replace", "runoptionsschedule2"}
+	expected := "MigrationSchedule runoptionsschedule2 created successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	migrationSchedule, err := k8s.Instance().GetMigrationSchedule("runoptionsschedule2", "default")
+	require.NoError(t, err, "Error getting migration schedule")
+	require.NotNil(t, migrationSchedule.Spec.StartingDeadlineSeconds, "StartingDeadlineSeconds should have been set")
+	require.Equal(t, int64(300), *migrationSchedule.Spec.StartingDeadlineSeconds, "StartingDeadlineSeconds mismatch")
+	require.Equal(t, storkv1.ConcurrencyPolicyReplace, migrationSchedule.Spec.ConcurrencyPolicy, "ConcurrencyPolicy mismatch")
+
+	migrationSchedule, err = k8s.Instance().GetMigrationSchedule("runoptionsschedule", "default")
+	require.NoError(t, err, "Error getting migration schedule")
+	require.Nil(t, migrationSchedule.Spec.StartingDeadlineSeconds, "StartingDeadlineSeconds shouldn't have been set")
+}
+
+func TestCreateMigrationSchedulesInvalidConcurrencyPolicy(t *testing.T) {
+	cmdArgs := []string{"create", "migrationschedules", "-c", "clusterpair1", "--namespaces", "namespace1", "--concurrencyPolicy", "Sometimes", "invalidpolicyschedule"}
+
+	expected := "error: invalid concurrencyPolicy Sometimes, should be Allow, Forbid or Replace"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
 func TestCreateDuplicateMigrationSchedules(t *testing.T) {
 	defer resetTest()
 	createMigrationScheduleAndVerify(t, "createmigrationschedule", "testpolicy", "default", "clusterpair1", []string{"namespace1"}, "", "", true)
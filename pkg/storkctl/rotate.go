@@ -0,0 +1,19 @@
+package storkctl
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+)
+
+func newRotateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	rotateCommands := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate keys for stork resources",
+	}
+
+	rotateCommands.AddCommand(
+		newRotateBackupLocationCommand(cmdFactory, ioStreams),
+	)
+
+	return rotateCommands
+}
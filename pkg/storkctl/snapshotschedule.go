@@ -25,6 +25,8 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 	var schedulePolicyName string
 	var reclaimPolicy string
 	var suspend bool
+	var startingDeadlineSeconds int64
+	var concurrencyPolicy string
 	var pvc string
 
 	createSnapshotScheduleCommand := &cobra.Command{
@@ -42,6 +44,12 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 				return
 			}
 
+			deadline, concurrency, err := getScheduleRunOptions(c, startingDeadlineSeconds, concurrencyPolicy)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
 			snapshotSchedule := &storkv1.VolumeSnapshotSchedule{
 				Spec: storkv1.VolumeSnapshotScheduleSpec{
 					Template: storkv1.VolumeSnapshotTemplateSpec{
@@ -49,16 +57,18 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 							PersistentVolumeClaimName: pvc,
 						},
 					},
-					PreExecRule:        preExecRule,
-					PostExecRule:       postExecRule,
-					SchedulePolicyName: schedulePolicyName,
-					Suspend:            &suspend,
-					ReclaimPolicy:      storkv1.ReclaimPolicyType(reclaimPolicy),
+					PreExecRule:             preExecRule,
+					PostExecRule:            postExecRule,
+					SchedulePolicyName:      schedulePolicyName,
+					Suspend:                 &suspend,
+					ReclaimPolicy:           storkv1.ReclaimPolicyType(reclaimPolicy),
+					StartingDeadlineSeconds: deadline,
+					ConcurrencyPolicy:       concurrency,
 				},
 			}
 			snapshotSchedule.Name = snapshotScheduleName
 			snapshotSchedule.Namespace = cmdFactory.GetNamespace()
-			_, err := k8s.Instance().CreateSnapshotSchedule(snapshotSchedule)
+			_, err = k8s.Instance().CreateSnapshotSchedule(snapshotSchedule)
 			if err != nil {
 				util.CheckErr(err)
 				return
@@ -73,6 +83,8 @@ func newCreateSnapshotScheduleCommand(cmdFactory Factory, ioStreams genericcliop
 	createSnapshotScheduleCommand.Flags().StringVarP(&schedulePolicyName, "schedulePolicyName", "s", "", "Name of the schedule policy to use")
 	createSnapshotScheduleCommand.Flags().StringVarP(&reclaimPolicy, "reclaimPolicy", "", "Retain", "Reclaim policy for the created snapshots (Retain or Delete)")
 	createSnapshotScheduleCommand.Flags().BoolVar(&suspend, "suspend", false, "Flag to denote whether schedule should be suspended on creation")
+	createSnapshotScheduleCommand.Flags().Int64VarP(&startingDeadlineSeconds, "startingDeadlineSeconds", "", 0, "Deadline in seconds for starting a snapshot after the time it was scheduled for, after which it is recorded as missed")
+	createSnapshotScheduleCommand.Flags().StringVarP(&concurrencyPolicy, "concurrencyPolicy", "", "", "How to handle a scheduled snapshot when a previous one is still in progress (Allow, Forbid or Replace). Defaults to Forbid")
 
 	return createSnapshotScheduleCommand
 }
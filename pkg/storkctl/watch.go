@@ -0,0 +1,145 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/kubernetes/pkg/printers"
+)
+
+var (
+	watchInterval = 5 * time.Second
+)
+
+var volumeProgressColumns = []string{"NAMESPACE", "PVC", "VOLUME", "STATUS", "PROGRESS", "ETA"}
+
+// progressSummary is the overall progress of an operation that is displayed
+// above the per-volume progress table while watching it
+type progressSummary struct {
+	stage            string
+	status           string
+	totalBytes       uint64
+	bytesTransferred uint64
+	etaSeconds       int64
+	done             bool
+}
+
+// volumeProgress is the progress for one of the volumes of an operation
+type volumeProgress struct {
+	namespace        string
+	pvc              string
+	volume           string
+	clusterPair      string
+	status           string
+	totalBytes       uint64
+	bytesTransferred uint64
+	etaSeconds       int64
+	inProgress       bool
+}
+
+// progressFunc returns the current progress of the operation being watched
+type progressFunc func() (*progressSummary, []*volumeProgress, error)
+
+// watchProgress prints the progress returned by getProgress at every
+// watchInterval until the operation is done
+func watchProgress(getProgress progressFunc, withClusterPair bool, out io.Writer) error {
+	for {
+		summary, volumes, err := getProgress()
+		if err != nil {
+			return err
+		}
+		if err := printProgress(summary, volumes, withClusterPair, out); err != nil {
+			return err
+		}
+		if summary.done {
+			return nil
+		}
+		time.Sleep(watchInterval)
+	}
+}
+
+func printProgress(summary *progressSummary, volumes []*volumeProgress, withClusterPair bool, out io.Writer) error {
+	writer := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintf(writer, "STAGE: %v\tSTATUS: %v\tPROGRESS: %v\tETA: %v\n",
+		summary.stage,
+		summary.status,
+		formatProgress(summary.bytesTransferred, summary.totalBytes),
+		formatETA(summary.etaSeconds, !summary.done),
+	); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	columns := volumeProgressColumns
+	if withClusterPair {
+		columns = append([]string{"CLUSTERPAIR"}, columns...)
+	}
+	for i, column := range columns {
+		separator := "\t"
+		if i == len(columns)-1 {
+			separator = "\n"
+		}
+		if _, err := fmt.Fprintf(writer, "%v%v", column, separator); err != nil {
+			return err
+		}
+	}
+	for _, volume := range volumes {
+		if withClusterPair {
+			if _, err := fmt.Fprintf(writer, "%v\t", volume.clusterPair); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			volume.namespace,
+			volume.pvc,
+			volume.volume,
+			volume.status,
+			formatProgress(volume.bytesTransferred, volume.totalBytes),
+			formatETA(volume.etaSeconds, volume.inProgress),
+		); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	printMsg("", out)
+	return nil
+}
+
+// formatProgress returns the bytes transferred out of the total along with
+// the percentage. It is empty if the driver didn't report the total.
+func formatProgress(bytesTransferred, totalBytes uint64) string {
+	if totalBytes == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v/%v (%v%%)",
+		formatBytes(bytesTransferred),
+		formatBytes(totalBytes),
+		bytesTransferred*100/totalBytes)
+}
+
+func formatETA(etaSeconds int64, inProgress bool) string {
+	if !inProgress || etaSeconds <= 0 {
+		return ""
+	}
+	return (time.Duration(etaSeconds) * time.Second).String()
+}
+
+// formatBytes returns the size in the largest binary unit that it is at
+// least one of
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%vB", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(bytes)/float64(div), "KMGTP"[exp])
+}
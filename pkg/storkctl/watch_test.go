@@ -0,0 +1,24 @@
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "0B", formatBytes(0))
+	require.Equal(t, "1023B", formatBytes(1023))
+	require.Equal(t, "1.0KiB", formatBytes(1024))
+	require.Equal(t, "1.5MiB", formatBytes(1536*1024))
+	require.Equal(t, "2.0TiB", formatBytes(2*1024*1024*1024*1024))
+}
+
+func TestFormatProgress(t *testing.T) {
+	require.Equal(t, "", formatProgress(0, 0), "Progress should be empty without total")
+	require.Equal(t, "256B/1.0KiB (25%)", formatProgress(256, 1024))
+	require.Equal(t, "", formatETA(30, false), "ETA should be empty when not in progress")
+	require.Equal(t, "1m30s", formatETA(90, true))
+}
@@ -39,7 +39,7 @@ const (
 // by stork
 type Controller struct {
 	Recorder record.EventRecorder
-	Driver   volume.Driver
+	Drivers  []volume.Driver
 	server   *http.Server
 	lock     sync.Mutex
 	started  bool
@@ -157,7 +157,7 @@ func (c *Controller) checkVolumeOwner(volumes []v1.Volume, namespace string) (bo
 		if err != nil {
 			return false, err
 		}
-		if c.Driver.OwnsPVC(pvc) {
+		if _, err := volume.GetPVCDriverFromList(c.Drivers, pvc); err == nil {
 			return true, nil
 		}
 	}